@@ -0,0 +1,96 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Claves del contexto de Gin donde el middleware deja al usuario autenticado
+const (
+	ContextUserID    = "auth_user_id"
+	ContextUsername  = "auth_username"
+	ContextRole      = "auth_role"
+	ContextExpiresAt = "auth_expires_at"
+)
+
+// Middleware exige un token de acceso válido en el header `Authorization: Bearer <token>`
+// y guarda el ID, el username y el rol del usuario en el contexto de la petición.
+func Middleware(tokens *TokenManager) gin.HandlerFunc {
+	return authenticate(tokens, TokenTypeAccess)
+}
+
+// ServiceMiddleware exige un token emitido con IssueServiceToken; protege los endpoints internos
+func ServiceMiddleware(tokens *TokenManager) gin.HandlerFunc {
+	return authenticate(tokens, TokenTypeService)
+}
+
+// OptionalMiddleware identifica al usuario o servicio que hace la petición si envía un token, para
+// las rutas públicas cuyo resultado depende de quién consulta. Sin header la petición sigue como
+// anónima (UserID devuelve 0); un token inválido o expirado se rechaza igual que en Middleware.
+func OptionalMiddleware(tokens *TokenManager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			c.Next()
+			return
+		}
+		authenticate(tokens, TokenTypeAccess, TokenTypeService)(c)
+	}
+}
+
+func authenticate(tokens *TokenManager, tokenTypes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		token, found := strings.CutPrefix(header, "Bearer ")
+		if !found || token == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Token de acceso no proporcionado"})
+			return
+		}
+
+		claims, err := tokens.Verify(token)
+		if err != nil || !slices.Contains(tokenTypes, claims.Type) {
+			message := "Token de acceso inválido"
+			if errors.Is(err, ErrExpiredToken) {
+				message = "Token de acceso expirado"
+			}
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": message})
+			return
+		}
+
+		c.Set(ContextUserID, claims.UserID)
+		c.Set(ContextUsername, claims.Username)
+		c.Set(ContextRole, claims.Role)
+		c.Set(ContextExpiresAt, time.Unix(claims.ExpiresAt, 0))
+		c.Next()
+	}
+}
+
+// UserID devuelve el ID del usuario autenticado por el middleware
+func UserID(c *gin.Context) uint {
+	return c.GetUint(ContextUserID)
+}
+
+// Username devuelve el username del usuario autenticado por el middleware
+func Username(c *gin.Context) string {
+	return c.GetString(ContextUsername)
+}
+
+// Role devuelve el rol del usuario autenticado por el middleware
+func Role(c *gin.Context) string {
+	return c.GetString(ContextRole)
+}
+
+// ExpiresAt devuelve cuándo expira el token con el que se autenticó la petición; las conexiones que
+// siguen abiertas (streaming) deben cerrarse entonces
+func ExpiresAt(c *gin.Context) time.Time {
+	return c.GetTime(ContextExpiresAt)
+}
+
+// BearerHeader arma el valor del header Authorization para un token
+func BearerHeader(token string) string {
+	return "Bearer " + token
+}
@@ -0,0 +1,119 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupTestRouter(tokens *TokenManager) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/me", Middleware(tokens), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"user_id": UserID(c), "username": Username(c), "role": Role(c)})
+	})
+	router.GET("/expires", Middleware(tokens), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"expires_in": time.Until(ExpiresAt(c)).Round(time.Minute).String()})
+	})
+	router.POST("/internal", ServiceMiddleware(tokens), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"service": Username(c)})
+	})
+	router.GET("/public", OptionalMiddleware(tokens), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"user_id": UserID(c), "role": Role(c)})
+	})
+	return router
+}
+
+func TestMiddleware(t *testing.T) {
+	tokens := NewTokenManager("secreto-de-prueba")
+	router := setupTestRouter(tokens)
+
+	accessToken, _ := tokens.IssueAccessToken(7, "user1", RoleUser)
+	serviceToken, _ := tokens.IssueServiceToken("tweet-service")
+
+	t.Run("Token válido", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/me", nil)
+		req.Header.Set("Authorization", BearerHeader(accessToken))
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"user_id": 7, "username": "user1", "role": "user"}`, w.Body.String())
+	})
+
+	t.Run("Expiración del token", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/expires", nil)
+		req.Header.Set("Authorization", BearerHeader(accessToken))
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"expires_in": "15m0s"}`, w.Body.String())
+	})
+
+	t.Run("Sin token", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/me", nil)
+		req.Header.Set("Username", "user1")
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("Token inválido", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/me", nil)
+		req.Header.Set("Authorization", BearerHeader(accessToken+"x"))
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("Los tokens de servicio no sirven como tokens de usuario", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/me", nil)
+		req.Header.Set("Authorization", BearerHeader(serviceToken))
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("Endpoint interno", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/internal", nil)
+		req.Header.Set("Authorization", BearerHeader(accessToken))
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+		w = httptest.NewRecorder()
+		req.Header.Set("Authorization", BearerHeader(serviceToken))
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"service": "tweet-service"}`, w.Body.String())
+	})
+
+	t.Run("Endpoint público con token opcional", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/public", nil)
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"user_id": 0, "role": ""}`, w.Body.String())
+
+		for _, token := range []string{accessToken, serviceToken} {
+			w = httptest.NewRecorder()
+			req.Header.Set("Authorization", BearerHeader(token))
+			router.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusOK, w.Code)
+		}
+		assert.JSONEq(t, `{"user_id": 0, "role": "service"}`, w.Body.String())
+
+		w = httptest.NewRecorder()
+		req.Header.Set("Authorization", BearerHeader(accessToken+"x"))
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
@@ -0,0 +1,145 @@
+// Package auth contiene la emisión y verificación de tokens de acceso compartida por todos los servicios.
+// Los tokens son JWT firmados con HMAC-SHA256 usando un secreto común (AUTH_SECRET), por lo que cada
+// servicio puede verificarlos localmente sin consultar a user-service.
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// Duración de los tokens de acceso emitidos en el login
+	AccessTokenTTL = 15 * time.Minute
+	// Duración de los tokens emitidos para llamadas entre servicios
+	ServiceTokenTTL = time.Minute
+
+	TokenTypeAccess  = "access"
+	TokenTypeService = "service"
+
+	RoleUser    = "user"
+	RoleAdmin   = "admin"
+	RoleService = "service"
+)
+
+var (
+	ErrInvalidToken  = errors.New("token inválido")
+	ErrExpiredToken  = errors.New("token expirado")
+	ErrMissingSecret = errors.New("AUTH_SECRET no configurado")
+)
+
+// Claims del token; UserID y Username identifican al usuario autenticado
+type Claims struct {
+	UserID    uint   `json:"sub"`
+	Username  string `json:"username"`
+	Role      string `json:"role"`
+	Type      string `json:"typ"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+type TokenManager struct {
+	secret []byte
+	now    func() time.Time
+}
+
+func NewTokenManager(secret string) *TokenManager {
+	return &TokenManager{secret: []byte(secret), now: time.Now}
+}
+
+// SecretFromEnv lee AUTH_SECRET. No hay valor por defecto: sin secreto cualquiera podría firmar tokens,
+// así que los servicios no deben arrancar.
+func SecretFromEnv() (string, error) {
+	secret := os.Getenv("AUTH_SECRET")
+	if secret == "" {
+		return "", ErrMissingSecret
+	}
+	return secret, nil
+}
+
+// IssueAccessToken emite un token de acceso para un usuario autenticado
+func (m *TokenManager) IssueAccessToken(userID uint, username, role string) (string, error) {
+	if role == "" {
+		role = RoleUser
+	}
+	return m.sign(Claims{UserID: userID, Username: username, Role: role, Type: TokenTypeAccess}, AccessTokenTTL)
+}
+
+// IssueServiceToken emite un token de corta duración para que un servicio llame a otro
+func (m *TokenManager) IssueServiceToken(service string) (string, error) {
+	return m.sign(Claims{Username: service, Role: RoleService, Type: TokenTypeService}, ServiceTokenTTL)
+}
+
+// Verify comprueba la firma y la expiración del token y devuelve sus claims
+func (m *TokenManager) Verify(token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidToken
+	}
+
+	if !hmac.Equal([]byte(parts[2]), []byte(m.signature(parts[0]+"."+parts[1]))) {
+		return nil, ErrInvalidToken
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil || header.Alg != "HS256" {
+		return nil, ErrInvalidToken
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, ErrInvalidToken
+	}
+	if m.now().Unix() >= claims.ExpiresAt {
+		return nil, ErrExpiredToken
+	}
+	return &claims, nil
+}
+
+func (m *TokenManager) sign(claims Claims, ttl time.Duration) (string, error) {
+	now := m.now()
+	claims.IssuedAt = now.Unix()
+	claims.ExpiresAt = now.Add(ttl).Unix()
+
+	header, err := encodeSegment(map[string]string{"alg": "HS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	payload, err := encodeSegment(claims)
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := header + "." + payload
+	return unsigned + "." + m.signature(unsigned), nil
+}
+
+func (m *TokenManager) signature(unsigned string) string {
+	mac := hmac.New(sha256.New, m.secret)
+	mac.Write([]byte(unsigned))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func encodeSegment(value interface{}) (string, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeSegment(segment string, value interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, value)
+}
@@ -0,0 +1,69 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIssueAndVerifyAccessToken(t *testing.T) {
+	tokens := NewTokenManager("secreto-de-prueba")
+
+	token, err := tokens.IssueAccessToken(7, "user1", "")
+	assert.NoError(t, err)
+
+	claims, err := tokens.Verify(token)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(7), claims.UserID)
+	assert.Equal(t, "user1", claims.Username)
+	assert.Equal(t, RoleUser, claims.Role)
+	assert.Equal(t, TokenTypeAccess, claims.Type)
+}
+
+func TestVerifyRejectsInvalidTokens(t *testing.T) {
+	tokens := NewTokenManager("secreto-de-prueba")
+	token, err := tokens.IssueAccessToken(7, "user1", RoleUser)
+	assert.NoError(t, err)
+
+	t.Run("Firmado con otro secreto", func(t *testing.T) {
+		_, err := NewTokenManager("otro-secreto").Verify(token)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("Payload modificado", func(t *testing.T) {
+		other, _ := tokens.IssueAccessToken(8, "user2", RoleUser)
+		tampered := token[:len(token)-43] + other[len(other)-43:]
+		_, err := tokens.Verify(tampered)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("Formato inválido", func(t *testing.T) {
+		for _, value := range []string{"", "abc", "a.b", "a.b.c"} {
+			_, err := tokens.Verify(value)
+			assert.ErrorIs(t, err, ErrInvalidToken, value)
+		}
+	})
+
+	t.Run("Token expirado", func(t *testing.T) {
+		expired := NewTokenManager("secreto-de-prueba")
+		expired.now = func() time.Time { return time.Now().Add(-AccessTokenTTL - time.Second) }
+		old, err := expired.IssueAccessToken(7, "user1", RoleUser)
+		assert.NoError(t, err)
+
+		_, err = tokens.Verify(old)
+		assert.ErrorIs(t, err, ErrExpiredToken)
+	})
+}
+
+func TestSecretFromEnv(t *testing.T) {
+	// Sin AUTH_SECRET los servicios no deben arrancar con un secreto conocido
+	t.Setenv("AUTH_SECRET", "")
+	_, err := SecretFromEnv()
+	assert.ErrorIs(t, err, ErrMissingSecret)
+
+	t.Setenv("AUTH_SECRET", "secreto-de-prueba")
+	secret, err := SecretFromEnv()
+	assert.NoError(t, err)
+	assert.Equal(t, "secreto-de-prueba", secret)
+}
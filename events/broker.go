@@ -0,0 +1,98 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// Handler procesa un evento recibido. Si devuelve error el evento se vuelve a entregar.
+type Handler func(ctx context.Context, event Event) error
+
+// Broker publica eventos y los entrega a los consumidores suscritos
+type Broker interface {
+	// Publish devuelve nil solo cuando el broker aceptó el evento; si falla, el Relay lo reintenta
+	Publish(ctx context.Context, event Event) error
+	// Subscribe registra handler para los eventos de eventType. Cada consumer recibe cada evento
+	// al menos una vez; varias instancias del mismo consumer se reparten los eventos.
+	Subscribe(consumer, eventType string, handler Handler) error
+	// SubscribeInstance entrega a handler todos los eventos de eventType publicados desde since (o
+	// desde la suscripción si since es cero), en cada instancia del proceso. Sirve para estado que
+	// cada instancia guarda en memoria. Los eventos cuyo handler falla no se reintentan.
+	SubscribeInstance(eventType string, since time.Time, handler Handler) error
+	Close() error
+}
+
+// NewBrokerFromEnv crea el broker indicado por EVENT_BROKER: `nats` (usa NATS_URL) o `memory`.
+// Falla si no está configurado: MemoryBroker no comunica procesos distintos, así que usarlo sin pedirlo
+// perdería los eventos que se publican para los otros servicios.
+func NewBrokerFromEnv(ctx context.Context) (Broker, error) {
+	switch broker := os.Getenv("EVENT_BROKER"); broker {
+	case "":
+		return nil, errors.New("EVENT_BROKER no está configurado: usar `nats`, o `memory` para un solo proceso")
+	case "memory":
+		return NewMemoryBroker(), nil
+	case "nats":
+		return NewNATSBroker(ctx, os.Getenv("NATS_URL"))
+	default:
+		return nil, fmt.Errorf("EVENT_BROKER desconocido: %q", broker)
+	}
+}
+
+type subscription struct {
+	consumer string
+	handler  Handler
+	instance bool
+}
+
+// MemoryBroker entrega los eventos dentro del mismo proceso, de forma sincrónica. Publish falla si
+// algún handler falla, y el Relay reintenta el evento completo: los consumidores que ya lo habían
+// procesado lo reciben de nuevo, como con cualquier entrega al menos una vez. No guarda los eventos,
+// así que SubscribeInstance solo recibe los que se publican después.
+type MemoryBroker struct {
+	mu            sync.RWMutex
+	subscriptions map[string][]subscription
+}
+
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{subscriptions: make(map[string][]subscription)}
+}
+
+func (b *MemoryBroker) Publish(ctx context.Context, event Event) error {
+	b.mu.RLock()
+	subscriptions := b.subscriptions[event.Type]
+	b.mu.RUnlock()
+
+	for _, sub := range subscriptions {
+		if err := sub.handler(ctx, event); err != nil {
+			if sub.instance {
+				log.Printf("La instancia no procesó el evento %s (%s): %v", event.ID, event.Type, err)
+				continue
+			}
+			return fmt.Errorf("el consumidor %s no procesó el evento %s: %w", sub.consumer, event.ID, err)
+		}
+	}
+	return nil
+}
+
+func (b *MemoryBroker) Subscribe(consumer, eventType string, handler Handler) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscriptions[eventType] = append(b.subscriptions[eventType], subscription{consumer: consumer, handler: handler})
+	return nil
+}
+
+func (b *MemoryBroker) SubscribeInstance(eventType string, _ time.Time, handler Handler) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscriptions[eventType] = append(b.subscriptions[eventType], subscription{handler: handler, instance: true})
+	return nil
+}
+
+func (b *MemoryBroker) Close() error {
+	return nil
+}
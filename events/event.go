@@ -0,0 +1,187 @@
+// Package events contiene el bus de eventos de dominio compartido por los servicios.
+// Cada servicio guarda sus eventos en una tabla outbox dentro de la misma transacción que el cambio
+// que los origina, y un Relay los publica después en un Broker. La entrega es al menos una vez:
+// un evento puede llegar repetido, y los consumidores usan Event.ID como clave de idempotencia.
+package events
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+// Tipos de evento publicados por los servicios
+const (
+	TypeTweetCreated   = "TweetCreated"
+	TypeTweetUpdated   = "TweetUpdated"
+	TypeTweetDeleted   = "TweetDeleted"
+	TypeTweetRestored  = "TweetRestored"
+	TypeTweetLiked     = "TweetLiked"
+	TypeUserFollowed   = "UserFollowed"
+	TypeUserUnfollowed = "UserUnfollowed"
+	TypeUserBlocked    = "UserBlocked"
+	TypeUserRegistered = "UserRegistered"
+)
+
+// Event es el sobre común de todos los eventos. ID se genera al crear el evento y se mantiene en
+// cada reintento, por lo que sirve como clave de idempotencia para los consumidores.
+type Event struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	Source     string          `json:"source"`
+	Payload    json.RawMessage `json:"payload"`
+	OccurredAt time.Time       `json:"occurred_at"`
+}
+
+// Payload es el contenido específico de cada tipo de evento
+type Payload interface {
+	EventType() string
+}
+
+// New arma un evento nuevo de source con un ID único
+func New(source string, payload Payload) (Event, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return Event{}, err
+	}
+	return Event{
+		ID:         newEventID(),
+		Type:       payload.EventType(),
+		Source:     source,
+		Payload:    data,
+		OccurredAt: time.Now().UTC(),
+	}, nil
+}
+
+// Decode deserializa el contenido del evento en payload
+func (e Event) Decode(payload Payload) error {
+	return json.Unmarshal(e.Payload, payload)
+}
+
+// newEventID genera un UUID v4 aleatorio
+func newEventID() string {
+	var id [16]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		panic(err)
+	}
+	id[6] = id[6]&0x0f | 0x40
+	id[8] = id[8]&0x3f | 0x80
+
+	buf := make([]byte, 36)
+	hex.Encode(buf[0:8], id[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], id[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], id[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], id[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], id[10:])
+	return string(buf)
+}
+
+// Usuario mencionado en un tweet, ya resuelto contra user-service
+type Mention struct {
+	UserID   uint   `json:"user_id"`
+	Username string `json:"username"`
+}
+
+// TweetCreated se publica al crear un tweet, una respuesta, una cita o un retweet.
+// RetweetOfUserID y QuotedUserID son los autores del tweet retuiteado o citado.
+type TweetCreated struct {
+	TweetID          uint      `json:"tweet_id"`
+	UserID           uint      `json:"user_id"`
+	Username         string    `json:"username"`
+	Content          string    `json:"content"`
+	InReplyToTweetID *uint     `json:"in_reply_to_tweet_id,omitempty"`
+	InReplyToUserID  *uint     `json:"in_reply_to_user_id,omitempty"`
+	ConversationID   uint      `json:"conversation_id"`
+	RetweetOfTweetID *uint     `json:"retweet_of_tweet_id,omitempty"`
+	RetweetOfUserID  *uint     `json:"retweet_of_user_id,omitempty"`
+	QuotedTweetID    *uint     `json:"quoted_tweet_id,omitempty"`
+	QuotedUserID     *uint     `json:"quoted_user_id,omitempty"`
+	Mentions         []Mention `json:"mentions"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+func (TweetCreated) EventType() string { return TypeTweetCreated }
+
+// TweetUpdated se publica al editar el contenido de un tweet; Revision es el número de la versión nueva
+type TweetUpdated struct {
+	TweetID  uint      `json:"tweet_id"`
+	UserID   uint      `json:"user_id"`
+	Content  string    `json:"content"`
+	Mentions []Mention `json:"mentions"`
+	Revision int       `json:"revision"`
+	EditedAt time.Time `json:"edited_at"`
+}
+
+func (TweetUpdated) EventType() string { return TypeTweetUpdated }
+
+// TweetDeleted se publica al eliminar un tweet o deshacer un retweet
+type TweetDeleted struct {
+	TweetID          uint  `json:"tweet_id"`
+	UserID           uint  `json:"user_id"`
+	RetweetOfTweetID *uint `json:"retweet_of_tweet_id,omitempty"`
+}
+
+func (TweetDeleted) EventType() string { return TypeTweetDeleted }
+
+// TweetRestored se publica al restaurar un tweet eliminado, y por cada retweet restaurado con él
+type TweetRestored struct {
+	TweetID          uint  `json:"tweet_id"`
+	UserID           uint  `json:"user_id"`
+	RetweetOfTweetID *uint `json:"retweet_of_tweet_id,omitempty"`
+}
+
+func (TweetRestored) EventType() string { return TypeTweetRestored }
+
+// TweetLiked se publica cuando UserID da like al tweet TweetID de TweetUserID. Quitar el like no genera evento.
+type TweetLiked struct {
+	TweetID     uint   `json:"tweet_id"`
+	TweetUserID uint   `json:"tweet_user_id"`
+	UserID      uint   `json:"user_id"`
+	Username    string `json:"username"`
+}
+
+func (TweetLiked) EventType() string { return TypeTweetLiked }
+
+// UserFollowed se publica cuando FollowerID empieza a seguir a FollowedID
+type UserFollowed struct {
+	FollowerID       uint   `json:"follower_id"`
+	FollowerUsername string `json:"follower_username"`
+	FollowedID       uint   `json:"followed_id"`
+	FollowedUsername string `json:"followed_username"`
+}
+
+func (UserFollowed) EventType() string { return TypeUserFollowed }
+
+// UserUnfollowed se publica cuando FollowerID deja de seguir a FollowedID
+type UserUnfollowed struct {
+	FollowerID       uint   `json:"follower_id"`
+	FollowerUsername string `json:"follower_username"`
+	FollowedID       uint   `json:"followed_id"`
+	FollowedUsername string `json:"followed_username"`
+}
+
+func (UserUnfollowed) EventType() string { return TypeUserUnfollowed }
+
+// UserBlocked se publica cuando BlockerID bloquea a BlockedID. Los seguimientos entre ambos que
+// elimina el bloqueo generan además sus propios UserUnfollowed.
+type UserBlocked struct {
+	BlockerID       uint   `json:"blocker_id"`
+	BlockerUsername string `json:"blocker_username"`
+	BlockedID       uint   `json:"blocked_id"`
+	BlockedUsername string `json:"blocked_username"`
+}
+
+func (UserBlocked) EventType() string { return TypeUserBlocked }
+
+// UserRegistered se publica al registrarse un usuario; no incluye el email
+type UserRegistered struct {
+	UserID   uint   `json:"user_id"`
+	Username string `json:"username"`
+}
+
+func (UserRegistered) EventType() string { return TypeUserRegistered }
@@ -0,0 +1,26 @@
+package events
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewEvent(t *testing.T) {
+	followed := UserFollowed{FollowerID: 1, FollowerUsername: "ana", FollowedID: 2, FollowedUsername: "bruno"}
+	event, err := New("user-service", followed)
+	assert.NoError(t, err)
+	assert.Equal(t, TypeUserFollowed, event.Type)
+	assert.Equal(t, "user-service", event.Source)
+	assert.Regexp(t, regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`), event.ID)
+	assert.JSONEq(t, `{"follower_id": 1, "follower_username": "ana", "followed_id": 2, "followed_username": "bruno"}`, string(event.Payload))
+
+	var payload UserFollowed
+	assert.NoError(t, event.Decode(&payload))
+	assert.Equal(t, followed, payload)
+
+	// Cada evento tiene su propia clave de idempotencia
+	other, _ := New("user-service", followed)
+	assert.NotEqual(t, event.ID, other.ID)
+}
@@ -0,0 +1,184 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+const (
+	// Stream de JetStream donde se guardan todos los eventos
+	natsStreamName = "MICROBLOGGING_EVENTS"
+	// Los eventos se publican en events.<Tipo>
+	natsSubjectPrefix = "events."
+	// Ventana en la que JetStream descarta eventos repetidos con el mismo ID
+	natsDuplicateWindow = 10 * time.Minute
+	// Tiempo que espera JetStream la confirmación de un handler antes de reenviar el evento
+	natsAckWait = 30 * time.Second
+	// Entregas de un evento a un consumidor antes de descartarlo como dead letter
+	natsMaxDeliver = 20
+	// Antigüedad máxima de los eventos del stream; cubre las 25 horas que reprocesan las tendencias al iniciar
+	natsMaxAge = 7 * 24 * time.Hour
+	// Tamaño máximo del stream; al superarlo se descartan los eventos más antiguos
+	natsMaxBytes = 1 << 30
+)
+
+// NATSBroker publica los eventos en un stream de JetStream. El ID del evento viaja como Nats-Msg-Id,
+// por lo que JetStream descarta las publicaciones repetidas del Relay dentro de natsDuplicateWindow.
+// Cada consumer es un consumidor durable con confirmación explícita: un evento se reenvía hasta que
+// su handler lo procesa sin error, o hasta natsMaxDeliver entregas, después de las cuales se descarta
+// y se registra como dead letter. SubscribeInstance usa consumidores ordenados efímeros, que el
+// servidor elimina cuando la instancia se desconecta.
+type NATSBroker struct {
+	conn       *nats.Conn
+	js         jetstream.JetStream
+	stream     jetstream.Stream
+	retryDelay time.Duration
+
+	mu        sync.Mutex
+	consuming []jetstream.ConsumeContext
+}
+
+// NewNATSBroker se conecta a url y crea el stream de eventos si todavía no existe
+func NewNATSBroker(ctx context.Context, url string) (*NATSBroker, error) {
+	if url == "" {
+		url = nats.DefaultURL
+	}
+
+	conn, err := nats.Connect(url, nats.Name("microblogging-events"), nats.MaxReconnects(-1))
+	if err != nil {
+		return nil, fmt.Errorf("no se pudo conectar a NATS: %w", err)
+	}
+
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	stream, err := js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:       natsStreamName,
+		Subjects:   []string{natsSubjectPrefix + ">"},
+		Storage:    jetstream.FileStorage,
+		Duplicates: natsDuplicateWindow,
+		MaxAge:     natsMaxAge,
+		MaxBytes:   natsMaxBytes,
+		Discard:    jetstream.DiscardOld,
+	})
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("no se pudo crear el stream de eventos: %w", err)
+	}
+
+	return &NATSBroker{conn: conn, js: js, stream: stream, retryDelay: time.Second}, nil
+}
+
+func (b *NATSBroker) Publish(ctx context.Context, event Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = b.js.Publish(ctx, natsSubjectPrefix+event.Type, data, jetstream.WithMsgID(event.ID))
+	return err
+}
+
+func (b *NATSBroker) Subscribe(consumer, eventType string, handler Handler) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	durable, err := b.stream.CreateOrUpdateConsumer(ctx, jetstream.ConsumerConfig{
+		Durable:       consumer + "_" + eventType,
+		FilterSubject: natsSubjectPrefix + eventType,
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		AckWait:       natsAckWait,
+		MaxDeliver:    natsMaxDeliver,
+	})
+	if err != nil {
+		return fmt.Errorf("no se pudo crear el consumidor %s: %w", consumer, err)
+	}
+
+	consuming, err := durable.Consume(func(msg jetstream.Msg) {
+		var event Event
+		if err := json.Unmarshal(msg.Data(), &event); err != nil {
+			// Un mensaje que no se puede leer no se reintenta
+			log.Printf("Evento inválido en %s: %v", msg.Subject(), err)
+			msg.Term()
+			return
+		}
+
+		if err := handler(context.Background(), event); err != nil {
+			if metadata, metaErr := msg.Metadata(); metaErr == nil && metadata.NumDelivered >= natsMaxDeliver {
+				log.Printf("El consumidor %s descartó el evento %s (%s) como dead letter después de %d entregas: %v",
+					consumer, event.ID, event.Type, metadata.NumDelivered, err)
+				msg.Term()
+				return
+			}
+			log.Printf("El consumidor %s no procesó el evento %s: %v", consumer, event.ID, err)
+			msg.NakWithDelay(b.retryDelay)
+			return
+		}
+		msg.Ack()
+	})
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	b.consuming = append(b.consuming, consuming)
+	b.mu.Unlock()
+	return nil
+}
+
+func (b *NATSBroker) SubscribeInstance(eventType string, since time.Time, handler Handler) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	config := jetstream.OrderedConsumerConfig{
+		FilterSubjects: []string{natsSubjectPrefix + eventType},
+		DeliverPolicy:  jetstream.DeliverNewPolicy,
+	}
+	if !since.IsZero() {
+		config.DeliverPolicy = jetstream.DeliverByStartTimePolicy
+		config.OptStartTime = &since
+	}
+	ordered, err := b.stream.OrderedConsumer(ctx, config)
+	if err != nil {
+		return fmt.Errorf("no se pudo crear el consumidor de %s: %w", eventType, err)
+	}
+
+	consuming, err := ordered.Consume(func(msg jetstream.Msg) {
+		var event Event
+		if err := json.Unmarshal(msg.Data(), &event); err != nil {
+			log.Printf("Evento inválido en %s: %v", msg.Subject(), err)
+			return
+		}
+		if err := handler(context.Background(), event); err != nil {
+			log.Printf("La instancia no procesó el evento %s (%s): %v", event.ID, event.Type, err)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	b.consuming = append(b.consuming, consuming)
+	b.mu.Unlock()
+	return nil
+}
+
+func (b *NATSBroker) Close() error {
+	b.mu.Lock()
+	for _, consuming := range b.consuming {
+		consuming.Stop()
+	}
+	b.consuming = nil
+	b.mu.Unlock()
+
+	return b.conn.Drain()
+}
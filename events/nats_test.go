@@ -0,0 +1,164 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/stretchr/testify/assert"
+)
+
+// startNATSServer levanta un servidor NATS local con JetStream para las pruebas
+func startNATSServer(t *testing.T) string {
+	opts := &server.Options{
+		Host:      "127.0.0.1",
+		Port:      -1,
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+		NoLog:     true,
+		NoSigs:    true,
+	}
+	srv, err := server.NewServer(opts)
+	if err != nil {
+		t.Fatalf("No se pudo crear el servidor NATS: %v", err)
+	}
+	go srv.Start()
+	if !srv.ReadyForConnections(5 * time.Second) {
+		t.Fatal("El servidor NATS no inició")
+	}
+	t.Cleanup(srv.Shutdown)
+	return srv.ClientURL()
+}
+
+// collector registra los eventos recibidos por un handler
+type collector struct {
+	mu       sync.Mutex
+	received []string
+	fail     int
+}
+
+func (c *collector) handle(ctx context.Context, event Event) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.received = append(c.received, event.ID)
+	if c.fail > 0 {
+		c.fail--
+		return errors.New("error temporal")
+	}
+	return nil
+}
+
+func (c *collector) events() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.received...)
+}
+
+func TestNATSBroker(t *testing.T) {
+	url := startNATSServer(t)
+	ctx := context.Background()
+
+	broker, err := NewNATSBroker(ctx, url)
+	assert.NoError(t, err)
+	defer broker.Close()
+	broker.retryDelay = 10 * time.Millisecond
+
+	// El stream descarta los eventos viejos en lugar de crecer sin límite
+	info, err := broker.stream.Info(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, natsMaxAge, info.Config.MaxAge)
+	assert.Equal(t, int64(natsMaxBytes), info.Config.MaxBytes)
+
+	timeline, notifications := &collector{}, &collector{fail: 1}
+	assert.NoError(t, broker.Subscribe("timeline-service", TypeTweetCreated, timeline.handle))
+	assert.NoError(t, broker.Subscribe("notification-service", TypeTweetCreated, notifications.handle))
+
+	created, _ := New("tweet-service", TweetCreated{TweetID: 1, UserID: 1})
+	deleted, _ := New("tweet-service", TweetDeleted{TweetID: 1, UserID: 1})
+
+	t.Run("Publicaciones Repetidas se Descartan", func(t *testing.T) {
+		// El Relay puede publicar dos veces el mismo evento si falla antes de marcarlo
+		assert.NoError(t, broker.Publish(ctx, created))
+		assert.NoError(t, broker.Publish(ctx, created))
+		assert.NoError(t, broker.Publish(ctx, deleted))
+
+		assert.Eventually(t, func() bool { return len(timeline.events()) == 1 }, 5*time.Second, 10*time.Millisecond)
+		// TweetDeleted no llega a los suscriptores de TweetCreated
+		time.Sleep(100 * time.Millisecond)
+		assert.Equal(t, []string{created.ID}, timeline.events())
+	})
+
+	t.Run("Reintento hasta Procesar", func(t *testing.T) {
+		// El primer intento falla y el evento se vuelve a entregar con el mismo ID
+		assert.Eventually(t, func() bool { return len(notifications.events()) == 2 }, 5*time.Second, 10*time.Millisecond)
+		assert.Equal(t, []string{created.ID, created.ID}, notifications.events())
+	})
+
+	t.Run("Consumidor Durable", func(t *testing.T) {
+		// Un consumidor que se suscribe más tarde recibe los eventos guardados en el stream
+		late := &collector{}
+		assert.NoError(t, broker.Subscribe("search-service", TypeTweetDeleted, late.handle))
+		assert.Eventually(t, func() bool { return len(late.events()) == 1 }, 5*time.Second, 10*time.Millisecond)
+		assert.Equal(t, []string{deleted.ID}, late.events())
+	})
+
+	t.Run("Dead Letter", func(t *testing.T) {
+		// Un evento que siempre falla se entrega natsMaxDeliver veces y después se descarta
+		failing := &collector{fail: 2 * natsMaxDeliver}
+		assert.NoError(t, broker.Subscribe("failing-service", TypeTweetDeleted, failing.handle))
+		assert.Eventually(t, func() bool { return len(failing.events()) == natsMaxDeliver }, 5*time.Second, 10*time.Millisecond)
+		time.Sleep(200 * time.Millisecond)
+		assert.Len(t, failing.events(), natsMaxDeliver)
+	})
+
+	t.Run("Suscripción por Instancia", func(t *testing.T) {
+		// Cada instancia recibe todos los eventos; con since también los ya guardados
+		first, second, fresh := &collector{}, &collector{fail: 1}, &collector{}
+		since := time.Now().Add(-time.Hour)
+		assert.NoError(t, broker.SubscribeInstance(TypeTweetCreated, since, first.handle))
+		assert.NoError(t, broker.SubscribeInstance(TypeTweetCreated, since, second.handle))
+		assert.NoError(t, broker.SubscribeInstance(TypeTweetCreated, time.Time{}, fresh.handle))
+		assert.Eventually(t, func() bool { return len(first.events()) == 1 && len(second.events()) == 1 }, 5*time.Second, 10*time.Millisecond)
+
+		next, _ := New("tweet-service", TweetCreated{TweetID: 2, UserID: 1})
+		assert.NoError(t, broker.Publish(ctx, next))
+		assert.Eventually(t, func() bool { return len(fresh.events()) == 1 && len(second.events()) == 2 }, 5*time.Second, 10*time.Millisecond)
+		time.Sleep(100 * time.Millisecond)
+		assert.Equal(t, []string{created.ID, next.ID}, first.events())
+		// El evento que falló no se vuelve a entregar
+		assert.Equal(t, []string{created.ID, next.ID}, second.events())
+		assert.Equal(t, []string{next.ID}, fresh.events())
+	})
+}
+
+func TestMemoryBrokerSubscribeInstance(t *testing.T) {
+	broker := NewMemoryBroker()
+	durable, instance := &collector{}, &collector{fail: 1}
+	assert.NoError(t, broker.Subscribe("timeline-service", TypeTweetCreated, durable.handle))
+	assert.NoError(t, broker.SubscribeInstance(TypeTweetCreated, time.Time{}, instance.handle))
+
+	// Un error de la instancia no hace fallar la publicación
+	event, _ := New("tweet-service", TweetCreated{TweetID: 1, UserID: 1})
+	assert.NoError(t, broker.Publish(context.Background(), event))
+	assert.Equal(t, []string{event.ID}, durable.events())
+	assert.Equal(t, []string{event.ID}, instance.events())
+}
+
+func TestNewBrokerFromEnv(t *testing.T) {
+	// Sin configurar no se usa el broker en memoria, que perdería los eventos entre servicios
+	t.Setenv("EVENT_BROKER", "")
+	_, err := NewBrokerFromEnv(context.Background())
+	assert.Error(t, err)
+
+	t.Setenv("EVENT_BROKER", "memory")
+	broker, err := NewBrokerFromEnv(context.Background())
+	assert.NoError(t, err)
+	assert.IsType(t, &MemoryBroker{}, broker)
+
+	t.Setenv("EVENT_BROKER", "kafka")
+	_, err = NewBrokerFromEnv(context.Background())
+	assert.Error(t, err)
+}
@@ -0,0 +1,157 @@
+package events
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// Largo máximo guardado del último error de publicación
+	maxLastErrorLength = 500
+	// Intentos de publicación de un evento antes de apartarlo como dead letter
+	DefaultMaxAttempts = 10
+	// Espera máxima entre dos intentos de publicar el mismo evento
+	maxRetryBackoff = 5 * time.Minute
+)
+
+// OutboxEvent es un evento pendiente de publicar. Se inserta en la misma transacción que el cambio
+// que lo origina y el Relay completa PublishedAt cuando el broker lo acepta. Un evento que falla
+// espera hasta NextAttemptAt para reintentarse; después del último intento se completa
+// DeadLetteredAt y el Relay deja de publicarlo.
+type OutboxEvent struct {
+	ID             uint      `gorm:"primaryKey;index:idx_outbox_events_deliverable,where:published_at IS NULL AND dead_lettered_at IS NULL"`
+	EventID        string    `gorm:"size:36;not null;uniqueIndex"`
+	Type           string    `gorm:"size:50;not null"`
+	Source         string    `gorm:"size:50;not null"`
+	Payload        string    `gorm:"type:jsonb;not null"`
+	OccurredAt     time.Time `gorm:"not null"`
+	Attempts       int       `gorm:"not null;default:0"`
+	LastError      string    `gorm:"size:500"`
+	NextAttemptAt  *time.Time
+	PublishedAt    *time.Time `gorm:"index"`
+	DeadLetteredAt *time.Time `gorm:"index"`
+}
+
+func (OutboxEvent) TableName() string {
+	return "outbox_events"
+}
+
+func (o OutboxEvent) Event() Event {
+	return Event{ID: o.EventID, Type: o.Type, Source: o.Source, Payload: json.RawMessage(o.Payload), OccurredAt: o.OccurredAt}
+}
+
+// failure devuelve las columnas que se actualizan cuando falla el intento número attempts de publicar
+// el evento: el error, la hora del próximo intento con espera exponencial y, si se agotaron los
+// maxAttempts intentos, la marca de dead letter
+func failure(attempts, maxAttempts int, err error, now time.Time) map[string]interface{} {
+	backoff := maxRetryBackoff
+	if attempts < 20 {
+		backoff = min(time.Second<<attempts, maxRetryBackoff)
+	}
+	updates := map[string]interface{}{
+		"attempts":        attempts,
+		"last_error":      truncate(err.Error(), maxLastErrorLength),
+		"next_attempt_at": now.Add(backoff),
+	}
+	if attempts >= maxAttempts {
+		updates["dead_lettered_at"] = now
+	}
+	return updates
+}
+
+// Enqueue guarda los eventos en la outbox usando tx, que debe ser la transacción del cambio que los
+// origina: si la transacción se revierte los eventos no se publican.
+func Enqueue(tx *gorm.DB, source string, payloads ...Payload) error {
+	if len(payloads) == 0 {
+		return nil
+	}
+
+	rows := make([]OutboxEvent, 0, len(payloads))
+	for _, payload := range payloads {
+		event, err := New(source, payload)
+		if err != nil {
+			return err
+		}
+		rows = append(rows, OutboxEvent{
+			EventID:    event.ID,
+			Type:       event.Type,
+			Source:     event.Source,
+			Payload:    string(event.Payload),
+			OccurredAt: event.OccurredAt,
+		})
+	}
+	return tx.Create(&rows).Error
+}
+
+// OutboxStore es la fuente de eventos pendientes del Relay
+type OutboxStore interface {
+	// ProcessPending pasa a publish, en el orden en que se guardaron, hasta limit eventos pendientes
+	// y marca como publicados los que publish aceptó. Se detiene en el primer error y lo devuelve;
+	// ese evento espera su próximo intento sin frenar a los siguientes, que salen en la próxima pasada.
+	ProcessPending(limit int, publish func(Event) error) (int, error)
+}
+
+// GormOutbox lee la tabla outbox_events de la base del servicio
+type GormOutbox struct {
+	db          *gorm.DB
+	maxAttempts int
+}
+
+func NewGormOutbox(db *gorm.DB) *GormOutbox {
+	return &GormOutbox{db: db, maxAttempts: DefaultMaxAttempts}
+}
+
+// ProcessPending bloquea los eventos que procesa con FOR UPDATE SKIP LOCKED, por lo que varias
+// instancias del servicio pueden correr su Relay sin publicar dos veces el mismo lote.
+// Si el proceso termina después de publicar pero antes de confirmar, el evento se publica de nuevo.
+// Los eventos en dead letter quedan en la tabla con su último error para revisarlos a mano.
+func (o *GormOutbox) ProcessPending(limit int, publish func(Event) error) (int, error) {
+	published := 0
+	var publishErr error
+	err := o.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		var pending []OutboxEvent
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("published_at IS NULL AND dead_lettered_at IS NULL").
+			Where("next_attempt_at IS NULL OR next_attempt_at <= ?", now).
+			Order("id").Limit(limit).Find(&pending).Error
+		if err != nil {
+			return err
+		}
+
+		for _, row := range pending {
+			if publishErr = publish(row.Event()); publishErr != nil {
+				updates := failure(row.Attempts+1, o.maxAttempts, publishErr, now)
+				if _, dead := updates["dead_lettered_at"]; dead {
+					log.Printf("El evento %s (%s) pasó a dead letter después de %d intentos: %v", row.EventID, row.Type, row.Attempts+1, publishErr)
+				}
+				return tx.Model(&OutboxEvent{}).Where("id = ?", row.ID).Updates(updates).Error
+			}
+			err := tx.Model(&OutboxEvent{}).Where("id = ?", row.ID).Updates(map[string]interface{}{
+				"attempts":     gorm.Expr("attempts + 1"),
+				"published_at": time.Now(),
+			}).Error
+			if err != nil {
+				return err
+			}
+			published++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return published, publishErr
+}
+
+func truncate(s string, length int) string {
+	runes := []rune(s)
+	if len(runes) <= length {
+		return s
+	}
+	return string(runes[:length])
+}
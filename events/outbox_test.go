@@ -0,0 +1,32 @@
+package events
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutboxFailure(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	err := errors.New("broker no disponible")
+
+	// La espera entre intentos crece de forma exponencial hasta maxRetryBackoff
+	updates := failure(1, DefaultMaxAttempts, err, now)
+	assert.Equal(t, 1, updates["attempts"])
+	assert.Equal(t, "broker no disponible", updates["last_error"])
+	assert.Equal(t, now.Add(2*time.Second), updates["next_attempt_at"])
+	assert.NotContains(t, updates, "dead_lettered_at")
+
+	assert.Equal(t, now.Add(maxRetryBackoff), failure(9, DefaultMaxAttempts, err, now)["next_attempt_at"])
+	assert.Equal(t, now.Add(maxRetryBackoff), failure(70, 100, err, now)["next_attempt_at"])
+
+	// El último intento aparta el evento como dead letter
+	assert.Equal(t, now, failure(DefaultMaxAttempts, DefaultMaxAttempts, err, now)["dead_lettered_at"])
+
+	// El error guardado se recorta al largo de la columna
+	long := failure(1, DefaultMaxAttempts, errors.New(strings.Repeat("x", 2*maxLastErrorLength)), now)
+	assert.Len(t, long["last_error"], maxLastErrorLength)
+}
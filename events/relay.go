@@ -0,0 +1,59 @@
+package events
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+const (
+	defaultRelayInterval  = 500 * time.Millisecond
+	defaultRelayBatchSize = 100
+)
+
+// Relay publica en el broker los eventos pendientes de la outbox. Un evento se marca como publicado
+// solo después de que el broker lo acepta, por lo que la entrega es al menos una vez.
+type Relay struct {
+	store     OutboxStore
+	broker    Broker
+	interval  time.Duration
+	batchSize int
+}
+
+func NewRelay(store OutboxStore, broker Broker) *Relay {
+	return &Relay{store: store, broker: broker, interval: defaultRelayInterval, batchSize: defaultRelayBatchSize}
+}
+
+// PublishPending publica los eventos pendientes en lotes hasta vaciar la outbox o hasta el primer error.
+// Devuelve cuántos eventos se publicaron.
+func (r *Relay) PublishPending(ctx context.Context) (int, error) {
+	total := 0
+	for {
+		published, err := r.store.ProcessPending(r.batchSize, func(event Event) error {
+			return r.broker.Publish(ctx, event)
+		})
+		total += published
+		if err != nil || published < r.batchSize {
+			return total, err
+		}
+	}
+}
+
+// Run revisa la outbox periódicamente hasta que ctx se cancela. Los errores solo se registran:
+// los eventos que no se pudieron publicar se reintentan en la siguiente pasada.
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := r.PublishPending(ctx); err != nil {
+			log.Printf("Error al publicar eventos de la outbox: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryOutbox imita a GormOutbox sobre un slice
+type memoryOutbox struct {
+	events    []Event
+	published int
+}
+
+func (o *memoryOutbox) add(t *testing.T, payload Payload) Event {
+	event, err := New("tweet-service", payload)
+	assert.NoError(t, err)
+	o.events = append(o.events, event)
+	return event
+}
+
+func (o *memoryOutbox) ProcessPending(limit int, publish func(Event) error) (int, error) {
+	count := 0
+	for o.published < len(o.events) && count < limit {
+		if err := publish(o.events[o.published]); err != nil {
+			return count, err
+		}
+		o.published++
+		count++
+	}
+	return count, nil
+}
+
+func TestRelayPublishesInOrder(t *testing.T) {
+	outbox := &memoryOutbox{}
+	broker := NewMemoryBroker()
+	relay := NewRelay(outbox, broker)
+	relay.batchSize = 2
+
+	var received []uint
+	broker.Subscribe("timeline-service", TypeTweetCreated, func(ctx context.Context, event Event) error {
+		var payload TweetCreated
+		assert.NoError(t, event.Decode(&payload))
+		received = append(received, payload.TweetID)
+		return nil
+	})
+
+	for id := uint(1); id <= 5; id++ {
+		outbox.add(t, TweetCreated{TweetID: id, UserID: 1})
+	}
+	// Los tipos sin suscriptores se publican igual
+	outbox.add(t, TweetDeleted{TweetID: 1, UserID: 1})
+
+	published, err := relay.PublishPending(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 6, published)
+	assert.Equal(t, []uint{1, 2, 3, 4, 5}, received)
+
+	// Sin eventos pendientes no se publica nada
+	published, err = relay.PublishPending(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, published)
+}
+
+func TestRelayRetriesFailedEvents(t *testing.T) {
+	outbox := &memoryOutbox{}
+	broker := NewMemoryBroker()
+	relay := NewRelay(outbox, broker)
+
+	failures := 1
+	deliveries := make(map[string]int)
+	broker.Subscribe("notification-service", TypeUserFollowed, func(ctx context.Context, event Event) error {
+		deliveries[event.ID]++
+		if failures > 0 {
+			failures--
+			return errors.New("consumidor no disponible")
+		}
+		return nil
+	})
+
+	first := outbox.add(t, UserFollowed{FollowerID: 1, FollowedID: 2})
+	second := outbox.add(t, UserFollowed{FollowerID: 3, FollowedID: 2})
+
+	// El evento que falla queda pendiente y se devuelve el error
+	published, err := relay.PublishPending(context.Background())
+	assert.Error(t, err)
+	assert.Equal(t, 0, published)
+	assert.Equal(t, map[string]int{first.ID: 1}, deliveries)
+
+	// En el reintento se entrega de nuevo con la misma clave de idempotencia
+	published, err = relay.PublishPending(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, published)
+	assert.Equal(t, map[string]int{first.ID: 2, second.ID: 1}, deliveries)
+}
+
+func TestRelayRunStopsWithContext(t *testing.T) {
+	outbox := &memoryOutbox{}
+	broker := NewMemoryBroker()
+	outbox.add(t, UserRegistered{UserID: 1, Username: "user1"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	broker.Subscribe("test", TypeUserRegistered, func(context.Context, Event) error {
+		cancel()
+		return nil
+	})
+
+	done := make(chan struct{})
+	go func() {
+		NewRelay(outbox, broker).Run(ctx)
+		close(done)
+	}()
+	<-done
+	assert.Equal(t, 1, outbox.published)
+}
@@ -1,3 +1,5 @@
 CREATE DATABASE userdb;
 CREATE DATABASE tweetdb;
+CREATE DATABASE notificationdb;
+CREATE DATABASE messagedb;
 
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/DevOpslp/microblogging-platform/auth"
+	"github.com/DevOpslp/microblogging-platform/message-service/internal/domain"
+	"github.com/DevOpslp/microblogging-platform/message-service/internal/infrastructure/api"
+	"github.com/DevOpslp/microblogging-platform/message-service/internal/infrastructure/persistence"
+	"github.com/DevOpslp/microblogging-platform/pagination"
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func main() {
+	// Secreto compartido para firmar y verificar tokens; sin él el servicio no arranca
+	authSecret, err := auth.SecretFromEnv()
+	if err != nil {
+		log.Fatalf("Error de configuración: %v", err)
+	}
+	// Secreto para firmar los cursores de paginación
+	paginationSecret, err := pagination.SecretFromEnv()
+	if err != nil {
+		log.Fatalf("Error de configuración: %v", err)
+	}
+
+	// Construir el DSN (Data Source Name) usando las variables de entorno
+	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable",
+		os.Getenv("DB_HOST"), os.Getenv("DB_USER"), os.Getenv("DB_PASSWORD"), os.Getenv("DB_NAME"), os.Getenv("DB_PORT"))
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	if err != nil {
+		log.Fatalf("Error al conectar a messagedb: %v", err)
+	}
+
+	if err := db.AutoMigrate(&domain.Conversation{}, &domain.Participant{}, &domain.Message{}); err != nil {
+		log.Fatalf("Error al migrar los modelos de message-service: %v", err)
+	}
+
+	userServiceURL := os.Getenv("USER_SERVICE_URL")
+	if userServiceURL == "" {
+		userServiceURL = "http://localhost:8080" // Valor predeterminado para desarrollo local
+	}
+
+	tokens := auth.NewTokenManager(authSecret)
+	userRepo := persistence.NewHTTPUserRepository(userServiceURL, tokens)
+	handler := api.NewMessageHandler(persistence.NewMessageRepository(db, userRepo), userRepo, pagination.NewSigner(paginationSecret))
+
+	// Configurar rutas con el secreto compartido de tokens
+	router := gin.Default()
+	api.SetupRoutes(router, handler, tokens)
+
+	// Escuchar en el puerto 8084
+	if err := router.Run(":8084"); err != nil {
+		log.Fatalf("Error al iniciar el servidor de message-service: %v", err)
+	}
+}
@@ -0,0 +1,125 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// Participantes máximos de una conversación grupal, incluido quien la crea
+	MaxGroupParticipants = 10
+	// Largo máximo de un mensaje, en caracteres
+	MaxMessageLength = 1000
+)
+
+var (
+	// ErrEmptyMessage se devuelve cuando el mensaje no tiene contenido
+	ErrEmptyMessage = errors.New("el mensaje está vacío")
+	// ErrMessageTooLong se devuelve cuando el mensaje supera MaxMessageLength
+	ErrMessageTooLong = fmt.Errorf("el mensaje no puede superar los %d caracteres", MaxMessageLength)
+)
+
+// Usuario de user-service que participa en una conversación
+type User struct {
+	ID       uint
+	Username string
+}
+
+// Conversación directa entre dos usuarios o grupal. Las directas tienen DirectKey, que impide crear dos
+// conversaciones entre los mismos usuarios; las grupales se crean siempre nuevas.
+type Conversation struct {
+	ID            uint      `gorm:"primaryKey"`
+	IsGroup       bool      `gorm:"not null;default:false"`
+	DirectKey     *string   `gorm:"size:50;uniqueIndex"`
+	CreatorID     uint      `gorm:"not null"`
+	LastMessageAt time.Time `gorm:"not null;index"`
+	CreatedAt     time.Time
+
+	// Cargados al consultar las conversaciones
+	Participants []Participant `gorm:"-"`
+	LastMessage  *Message      `gorm:"-"`
+	UnreadCount  int64         `gorm:"-"`
+}
+
+// Participante de una conversación. LastReadMessageID es el último mensaje que leyó (0 si ninguno) y
+// ReadAt cuándo lo marcó como leído: son sus recibos de lectura.
+type Participant struct {
+	ConversationID    uint   `gorm:"primaryKey"`
+	UserID            uint   `gorm:"primaryKey;index"`
+	Username          string `gorm:"not null"`
+	LastReadMessageID uint   `gorm:"not null;default:0"`
+	ReadAt            *time.Time
+	JoinedAt          time.Time `gorm:"not null"`
+}
+
+// Mensaje de una conversación. Al eliminarlo se borra para todos los participantes.
+type Message struct {
+	ID             uint      `gorm:"primaryKey"`
+	ConversationID uint      `gorm:"not null;index:idx_messages_conversation_created,priority:1"`
+	SenderID       uint      `gorm:"not null"`
+	SenderUsername string    `gorm:"not null"`
+	Content        string    `gorm:"not null"`
+	CreatedAt      time.Time `gorm:"index:idx_messages_conversation_created,priority:2"`
+}
+
+// DirectKey identifica la conversación directa entre dos usuarios, sin importar quién la inicia
+func DirectKey(userID, otherID uint) string {
+	return fmt.Sprintf("%d:%d", min(userID, otherID), max(userID, otherID))
+}
+
+// NormalizeMessage quita los espacios al inicio y al final del contenido y valida su largo
+func NormalizeMessage(content string) (string, error) {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return "", ErrEmptyMessage
+	}
+	if utf8.RuneCountInString(content) > MaxMessageLength {
+		return "", ErrMessageTooLong
+	}
+	return content, nil
+}
+
+// Cursor devuelve la posición de la conversación en el listado, ordenado por (last_message_at, id) descendente
+func (c Conversation) Cursor() Cursor {
+	return Cursor{Time: c.LastMessageAt, ID: c.ID}
+}
+
+// Participant devuelve el participante userID de la conversación, o nil si no participa
+func (c Conversation) Participant(userID uint) *Participant {
+	for i := range c.Participants {
+		if c.Participants[i].UserID == userID {
+			return &c.Participants[i]
+		}
+	}
+	return nil
+}
+
+// Others devuelve los participantes distintos de userID
+func (c Conversation) Others(userID uint) []Participant {
+	others := make([]Participant, 0, len(c.Participants))
+	for _, participant := range c.Participants {
+		if participant.UserID != userID {
+			others = append(others, participant)
+		}
+	}
+	return others
+}
+
+// Cursor devuelve la posición del mensaje en el listado, ordenado por (created_at, id) descendente
+func (m Message) Cursor() Cursor {
+	return Cursor{Time: m.CreatedAt, ID: m.ID}
+}
+
+// ReadBy devuelve los participantes, sin contar al remitente, que ya leyeron el mensaje
+func (m Message) ReadBy(participants []Participant) []Participant {
+	readers := make([]Participant, 0)
+	for _, participant := range participants {
+		if participant.UserID != m.SenderID && participant.LastReadMessageID >= m.ID {
+			readers = append(readers, participant)
+		}
+	}
+	return readers
+}
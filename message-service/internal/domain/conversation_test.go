@@ -0,0 +1,48 @@
+package domain
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDirectKey(t *testing.T) {
+	assert.Equal(t, "3:12", DirectKey(12, 3))
+	assert.Equal(t, DirectKey(3, 12), DirectKey(12, 3))
+}
+
+func TestNormalizeMessage(t *testing.T) {
+	content, err := NormalizeMessage("  hola  \n")
+	assert.NoError(t, err)
+	assert.Equal(t, "hola", content)
+
+	_, err = NormalizeMessage(" \t ")
+	assert.ErrorIs(t, err, ErrEmptyMessage)
+
+	// El largo se cuenta en caracteres, no en bytes
+	_, err = NormalizeMessage(strings.Repeat("ñ", MaxMessageLength))
+	assert.NoError(t, err)
+	_, err = NormalizeMessage(strings.Repeat("a", MaxMessageLength+1))
+	assert.ErrorIs(t, err, ErrMessageTooLong)
+}
+
+func TestReadBy(t *testing.T) {
+	participants := []Participant{
+		{UserID: 1, Username: "user1", LastReadMessageID: 5},
+		{UserID: 2, Username: "user2", LastReadMessageID: 7},
+		{UserID: 3, Username: "user3"},
+	}
+	conversation := Conversation{Participants: participants}
+
+	// El remitente no figura como lector de su propio mensaje
+	readers := Message{ID: 5, SenderID: 1}.ReadBy(participants)
+	assert.Equal(t, []Participant{participants[1]}, readers)
+
+	assert.Empty(t, Message{ID: 8, SenderID: 1}.ReadBy(participants))
+	assert.Len(t, Message{ID: 4, SenderID: 3}.ReadBy(participants), 2)
+
+	assert.Equal(t, "user2", conversation.Participant(2).Username)
+	assert.Nil(t, conversation.Participant(4))
+	assert.Equal(t, []Participant{participants[0], participants[2]}, conversation.Others(2))
+}
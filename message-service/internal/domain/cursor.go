@@ -0,0 +1,10 @@
+package domain
+
+import "time"
+
+// Posición de una conversación o un mensaje en su listado, ordenado por (Time, ID) de forma descendente.
+// Se usa como cursor para la paginación por keyset.
+type Cursor struct {
+	Time time.Time
+	ID   uint
+}
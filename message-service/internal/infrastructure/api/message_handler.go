@@ -0,0 +1,352 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DevOpslp/microblogging-platform/auth"
+	"github.com/DevOpslp/microblogging-platform/message-service/internal/domain"
+	"github.com/DevOpslp/microblogging-platform/message-service/internal/infrastructure/persistence"
+	"github.com/DevOpslp/microblogging-platform/pagination"
+	"github.com/gin-gonic/gin"
+)
+
+type MessageHandler struct {
+	repo     *persistence.MessageRepository
+	userRepo persistence.UserRepository
+	cursors  *pagination.Signer
+}
+
+func NewMessageHandler(repo *persistence.MessageRepository, userRepo persistence.UserRepository, cursors *pagination.Signer) *MessageHandler {
+	return &MessageHandler{repo: repo, userRepo: userRepo, cursors: cursors}
+}
+
+// Recibo de lectura de un participante: el último mensaje que leyó y cuándo
+type ParticipantResponse struct {
+	UserID            uint    `json:"user_id"`
+	Username          string  `json:"username"`
+	LastReadMessageID uint    `json:"last_read_message_id"`
+	ReadAt            *string `json:"read_at"`
+}
+
+type ReaderResponse struct {
+	UserID   uint   `json:"user_id"`
+	Username string `json:"username"`
+}
+
+// Mensaje con los participantes que ya lo leyeron, sin contar al remitente
+type MessageResponse struct {
+	ID             uint             `json:"id"`
+	ConversationID uint             `json:"conversation_id"`
+	SenderID       uint             `json:"sender_id"`
+	SenderUsername string           `json:"sender_username"`
+	Content        string           `json:"content"`
+	ReadBy         []ReaderResponse `json:"read_by"`
+	CreatedAt      string           `json:"created_at"`
+}
+
+type ConversationResponse struct {
+	ID            uint                  `json:"id"`
+	IsGroup       bool                  `json:"is_group"`
+	Participants  []ParticipantResponse `json:"participants"`
+	LastMessage   *MessageResponse      `json:"last_message"`
+	UnreadCount   int64                 `json:"unread_count"`
+	LastMessageAt string                `json:"last_message_at"`
+	CreatedAt     string                `json:"created_at"`
+}
+
+func formatParticipantResponse(participant domain.Participant) ParticipantResponse {
+	response := ParticipantResponse{
+		UserID:            participant.UserID,
+		Username:          participant.Username,
+		LastReadMessageID: participant.LastReadMessageID,
+	}
+	if participant.ReadAt != nil {
+		readAt := participant.ReadAt.Format(time.RFC3339)
+		response.ReadAt = &readAt
+	}
+	return response
+}
+
+func formatMessageResponse(message domain.Message, participants []domain.Participant) MessageResponse {
+	readers := make([]ReaderResponse, 0)
+	for _, reader := range message.ReadBy(participants) {
+		readers = append(readers, ReaderResponse{UserID: reader.UserID, Username: reader.Username})
+	}
+	return MessageResponse{
+		ID:             message.ID,
+		ConversationID: message.ConversationID,
+		SenderID:       message.SenderID,
+		SenderUsername: message.SenderUsername,
+		Content:        message.Content,
+		ReadBy:         readers,
+		CreatedAt:      message.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+func formatConversationResponse(conversation domain.Conversation) ConversationResponse {
+	participants := make([]ParticipantResponse, 0, len(conversation.Participants))
+	for _, participant := range conversation.Participants {
+		participants = append(participants, formatParticipantResponse(participant))
+	}
+	response := ConversationResponse{
+		ID:            conversation.ID,
+		IsGroup:       conversation.IsGroup,
+		Participants:  participants,
+		UnreadCount:   conversation.UnreadCount,
+		LastMessageAt: conversation.LastMessageAt.Format(time.RFC3339),
+		CreatedAt:     conversation.CreatedAt.Format(time.RFC3339),
+	}
+	if conversation.LastMessage != nil {
+		lastMessage := formatMessageResponse(*conversation.LastMessage, conversation.Participants)
+		response.LastMessage = &lastMessage
+	}
+	return response
+}
+
+// respondRepositoryError responde los errores de MessageRepository; los de permisos indican qué
+// destinatario no acepta mensajes
+func respondRepositoryError(c *gin.Context, err error, fallback string) {
+	var recipientErr *persistence.RecipientError
+	switch {
+	case errors.As(err, &recipientErr) && errors.Is(err, persistence.ErrRecipientFollowersOnly):
+		c.JSON(http.StatusForbidden, gin.H{"error": "El usuario solo recibe mensajes de sus seguidores", "username": recipientErr.Username})
+	case errors.As(err, &recipientErr):
+		c.JSON(http.StatusForbidden, gin.H{"error": "No puedes enviarle mensajes a este usuario", "username": recipientErr.Username})
+	case errors.Is(err, persistence.ErrConversationNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Conversación no encontrada"})
+	case errors.Is(err, persistence.ErrMessageNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Mensaje no encontrado"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+// parseID lee el parámetro de ruta indicado como ID. Si no es válido responde 400 y devuelve false.
+func parseID(c *gin.Context, param string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param(param), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// CreateConversation inicia una conversación con los usuarios {"usernames"}: directa con uno solo, o
+// grupal con hasta domain.MaxGroupParticipants participantes contando al autenticado. Si la conversación
+// directa ya existe se devuelve con 200.
+func (h *MessageHandler) CreateConversation(c *gin.Context) {
+	var body struct {
+		Usernames []string `json:"usernames" binding:"required,min=1"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "usernames es obligatorio"})
+		return
+	}
+	if len(body.Usernames) > domain.MaxGroupParticipants-1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Una conversación admite como máximo %d participantes", domain.MaxGroupParticipants)})
+		return
+	}
+
+	creator := domain.User{ID: auth.UserID(c), Username: auth.Username(c)}
+	usernames := make([]string, 0, len(body.Usernames))
+	seen := make(map[string]bool, len(body.Usernames))
+	for _, username := range body.Usernames {
+		username = strings.TrimPrefix(strings.TrimSpace(username), "@")
+		if username == "" || username == creator.Username {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Username inválido"})
+			return
+		}
+		if !seen[username] {
+			seen[username] = true
+			usernames = append(usernames, username)
+		}
+	}
+
+	users, err := h.userRepo.FindUsersByUsernames(usernames)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudieron obtener los usuarios"})
+		return
+	}
+	others := make([]domain.User, 0, len(usernames))
+	for _, username := range usernames {
+		user, ok := users[username]
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Usuario no encontrado", "username": username})
+			return
+		}
+		others = append(others, user)
+	}
+
+	conversation, created, err := h.repo.CreateConversation(creator, others)
+	if err != nil {
+		respondRepositoryError(c, err, "No se pudo crear la conversación")
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	c.JSON(status, formatConversationResponse(*conversation))
+}
+
+// GetConversations devuelve una página de las conversaciones del usuario autenticado, de la que tuvo
+// el mensaje más reciente a la más antigua
+func (h *MessageHandler) GetConversations(c *gin.Context) {
+	cursor, limit, err := parsePageParams(c, h.cursors)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	conversations, next, err := h.repo.GetConversations(auth.UserID(c), cursor, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudieron obtener las conversaciones"})
+		return
+	}
+
+	response := make([]ConversationResponse, 0, len(conversations))
+	for _, conversation := range conversations {
+		response = append(response, formatConversationResponse(conversation))
+	}
+	c.JSON(http.StatusOK, gin.H{"conversations": response, "next_cursor": encodeCursor(h.cursors, next)})
+}
+
+// GetConversation devuelve una conversación del usuario autenticado con los recibos de lectura de
+// sus participantes
+func (h *MessageHandler) GetConversation(c *gin.Context) {
+	conversationID, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	conversation, err := h.repo.GetConversation(conversationID, auth.UserID(c))
+	if err != nil {
+		respondRepositoryError(c, err, "No se pudo obtener la conversación")
+		return
+	}
+	c.JSON(http.StatusOK, formatConversationResponse(*conversation))
+}
+
+// SendMessage envía el mensaje {"content"} a una conversación del usuario autenticado
+func (h *MessageHandler) SendMessage(c *gin.Context) {
+	conversationID, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	var body struct {
+		Content string `json:"content"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Contenido del mensaje inválido"})
+		return
+	}
+	content, err := domain.NormalizeMessage(body.Content)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sender := domain.User{ID: auth.UserID(c), Username: auth.Username(c)}
+	conversation, err := h.repo.GetConversation(conversationID, sender.ID)
+	if err != nil {
+		respondRepositoryError(c, err, "No se pudo enviar el mensaje")
+		return
+	}
+
+	message, err := h.repo.CreateMessage(conversation, sender, content)
+	if err != nil {
+		respondRepositoryError(c, err, "No se pudo enviar el mensaje")
+		return
+	}
+	c.JSON(http.StatusCreated, formatMessageResponse(*message, conversation.Participants))
+}
+
+// GetMessages devuelve una página de los mensajes de una conversación del usuario autenticado, del más
+// reciente al más antiguo, cada uno con los participantes que ya lo leyeron
+func (h *MessageHandler) GetMessages(c *gin.Context) {
+	conversationID, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+	cursor, limit, err := parsePageParams(c, h.cursors)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	conversation, err := h.repo.GetConversation(conversationID, auth.UserID(c))
+	if err != nil {
+		respondRepositoryError(c, err, "No se pudieron obtener los mensajes")
+		return
+	}
+
+	messages, next, err := h.repo.GetMessages(conversationID, cursor, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudieron obtener los mensajes"})
+		return
+	}
+
+	response := make([]MessageResponse, 0, len(messages))
+	for _, message := range messages {
+		response = append(response, formatMessageResponse(message, conversation.Participants))
+	}
+	c.JSON(http.StatusOK, gin.H{"messages": response, "next_cursor": encodeCursor(h.cursors, next)})
+}
+
+// DeleteMessage elimina para todos un mensaje enviado por el usuario autenticado
+func (h *MessageHandler) DeleteMessage(c *gin.Context) {
+	conversationID, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+	messageID, ok := parseID(c, "message_id")
+	if !ok {
+		return
+	}
+
+	if err := h.repo.DeleteMessage(conversationID, messageID, auth.UserID(c)); err != nil {
+		respondRepositoryError(c, err, "No se pudo eliminar el mensaje")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Mensaje eliminado exitosamente"})
+}
+
+// MarkRead marca como leídos los mensajes de una conversación hasta {"message_id"}, o todos si no se
+// envía, y devuelve el recibo de lectura del usuario autenticado
+func (h *MessageHandler) MarkRead(c *gin.Context) {
+	conversationID, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	var body struct {
+		MessageID uint `json:"message_id"`
+	}
+	// El cuerpo es opcional
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "message_id inválido"})
+			return
+		}
+	}
+
+	userID := auth.UserID(c)
+	if _, err := h.repo.GetConversation(conversationID, userID); err != nil {
+		respondRepositoryError(c, err, "No se pudo marcar la conversación como leída")
+		return
+	}
+
+	participant, err := h.repo.MarkRead(conversationID, userID, body.MessageID)
+	if err != nil {
+		respondRepositoryError(c, err, "No se pudo marcar la conversación como leída")
+		return
+	}
+	c.JSON(http.StatusOK, formatParticipantResponse(*participant))
+}
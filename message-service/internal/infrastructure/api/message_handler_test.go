@@ -0,0 +1,336 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DevOpslp/microblogging-platform/auth"
+	"github.com/DevOpslp/microblogging-platform/message-service/internal/domain"
+	"github.com/DevOpslp/microblogging-platform/message-service/internal/infrastructure/persistence"
+	"github.com/DevOpslp/microblogging-platform/pagination"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+var testDB *gorm.DB
+
+var (
+	tokens  = auth.NewTokenManager("secreto-de-prueba")
+	cursors = pagination.NewSigner("secreto-de-prueba")
+)
+
+func setupTestDB() {
+	dsn := "host=localhost user=devuser password=devpassword dbname=messagedb port=5432 sslmode=disable"
+	var err error
+	testDB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	if err != nil {
+		log.Fatalf("Error al conectar a la base de datos de pruebas: %v", err)
+	}
+
+	testDB.AutoMigrate(&domain.Conversation{}, &domain.Participant{}, &domain.Message{})
+}
+
+// fakeUserService simula los endpoints de user-service que usa message-service. Los usuarios se llaman
+// "user<ID>" y restrictions guarda, por remitente, la restricción de cada destinatario.
+type fakeUserService struct {
+	mu           sync.Mutex
+	restrictions map[uint]map[uint]string
+}
+
+func (f *fakeUserService) restrict(senderID, recipientID uint, restriction string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.restrictions[senderID] == nil {
+		f.restrictions[senderID] = make(map[uint]string)
+	}
+	f.restrictions[senderID][recipientID] = restriction
+}
+
+func newFakeUserService(f *fakeUserService) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/users/batch", func(w http.ResponseWriter, r *http.Request) {
+		users := make([]gin.H, 0)
+		for _, username := range strings.Split(r.URL.Query().Get("usernames"), ",") {
+			if id, err := strconv.ParseUint(strings.TrimPrefix(username, "user"), 10, 64); err == nil {
+				users = append(users, gin.H{"user_id": id, "username": username})
+			}
+		}
+		json.NewEncoder(w).Encode(gin.H{"users": users})
+	})
+	mux.HandleFunc("/users/message-restrictions", func(w http.ResponseWriter, r *http.Request) {
+		// La consulta de restricciones exige un token de servicio
+		if r.Header.Get("Authorization") == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		senderID, _ := strconv.ParseUint(r.URL.Query().Get("sender_id"), 10, 64)
+
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		blocked, followersOnly := make([]uint64, 0), make([]uint64, 0)
+		for _, value := range strings.Split(r.URL.Query().Get("ids"), ",") {
+			id, _ := strconv.ParseUint(value, 10, 64)
+			switch f.restrictions[uint(senderID)][uint(id)] {
+			case "blocked":
+				blocked = append(blocked, id)
+			case "followers_only":
+				followersOnly = append(followersOnly, id)
+			}
+		}
+		json.NewEncoder(w).Encode(gin.H{"blocked_ids": blocked, "followers_only_ids": followersOnly})
+	})
+	return httptest.NewServer(mux)
+}
+
+func setupTestRouter(userServiceURL string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	userRepo := persistence.NewHTTPUserRepository(userServiceURL, tokens)
+	handler := NewMessageHandler(persistence.NewMessageRepository(testDB, userRepo), userRepo, cursors)
+	router := gin.Default()
+	SetupRoutes(router, handler, tokens)
+	return router
+}
+
+// bearerFor genera el header Authorization de un usuario para las pruebas
+func bearerFor(userID uint) string {
+	token, _ := tokens.IssueAccessToken(userID, fmt.Sprintf("user%d", userID), auth.RoleUser)
+	return auth.BearerHeader(token)
+}
+
+func TestMessagesFlow(t *testing.T) {
+	setupTestDB()
+	users := &fakeUserService{restrictions: make(map[uint]map[uint]string)}
+	userService := newFakeUserService(users)
+	defer userService.Close()
+	router := setupTestRouter(userService.URL)
+
+	// IDs únicos por ejecución para no mezclar conversaciones de pruebas anteriores
+	base := uint(time.Now().UnixNano() % 1_000_000_000)
+	alice, bob, carol, dave, eve := base, base+1, base+2, base+3, base+4
+	username := func(userID uint) string { return fmt.Sprintf("user%d", userID) }
+
+	send := func(method, path, body string, userID uint) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", bearerFor(userID))
+		router.ServeHTTP(w, req)
+		return w
+	}
+	createConversation := func(t *testing.T, userID uint, expectedCode int, others ...uint) ConversationResponse {
+		usernames := make([]string, len(others))
+		for i, other := range others {
+			usernames[i] = fmt.Sprintf("%q", username(other))
+		}
+		w := send("POST", "/conversations", `{"usernames": [`+strings.Join(usernames, ",")+`]}`, userID)
+		assert.Equal(t, expectedCode, w.Code, w.Body.String())
+
+		var conversation ConversationResponse
+		json.Unmarshal(w.Body.Bytes(), &conversation)
+		return conversation
+	}
+	sendMessage := func(t *testing.T, conversationID, userID uint, content string) MessageResponse {
+		w := send("POST", fmt.Sprintf("/conversations/%d/messages", conversationID), fmt.Sprintf(`{"content": %q}`, content), userID)
+		assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		var message MessageResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &message))
+		return message
+	}
+
+	type messagesPage struct {
+		Messages   []MessageResponse `json:"messages"`
+		NextCursor string            `json:"next_cursor"`
+	}
+	getMessages := func(t *testing.T, conversationID, userID uint, query string) messagesPage {
+		w := send("GET", fmt.Sprintf("/conversations/%d/messages%s", conversationID, query), "", userID)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var page messagesPage
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+		return page
+	}
+
+	type conversationsPage struct {
+		Conversations []ConversationResponse `json:"conversations"`
+		NextCursor    string                 `json:"next_cursor"`
+	}
+	getConversations := func(t *testing.T, userID uint, query string) conversationsPage {
+		w := send("GET", "/conversations"+query, "", userID)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var page conversationsPage
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+		return page
+	}
+
+	var direct ConversationResponse
+	var sent []MessageResponse
+
+	t.Run("Conversación Directa", func(t *testing.T) {
+		direct = createConversation(t, alice, http.StatusCreated, bob)
+		assert.False(t, direct.IsGroup)
+		if assert.Len(t, direct.Participants, 2) {
+			assert.Equal(t, username(alice), direct.Participants[0].Username)
+		}
+
+		// Entre los mismos usuarios hay una sola conversación directa, la inicie quien la inicie
+		again := createConversation(t, bob, http.StatusOK, alice)
+		assert.Equal(t, direct.ID, again.ID)
+	})
+
+	t.Run("Enviar y Listar Mensajes", func(t *testing.T) {
+		for _, content := range []string{"hola", "¿cómo estás?", "  ¿nos vemos mañana?  "} {
+			sent = append(sent, sendMessage(t, direct.ID, alice, content))
+		}
+		assert.Equal(t, "¿nos vemos mañana?", sent[2].Content)
+
+		page := getMessages(t, direct.ID, bob, "?limit=2")
+		if assert.Len(t, page.Messages, 2) {
+			assert.Equal(t, sent[2].ID, page.Messages[0].ID)
+			assert.Equal(t, sent[1].ID, page.Messages[1].ID)
+		}
+		assert.NotEmpty(t, page.NextCursor)
+
+		page = getMessages(t, direct.ID, bob, "?limit=2&cursor="+page.NextCursor)
+		if assert.Len(t, page.Messages, 1) {
+			assert.Equal(t, sent[0].ID, page.Messages[0].ID)
+		}
+		assert.Empty(t, page.NextCursor)
+	})
+
+	t.Run("Recibos de Lectura", func(t *testing.T) {
+		conversations := getConversations(t, bob, "")
+		if assert.NotEmpty(t, conversations.Conversations) {
+			assert.Equal(t, direct.ID, conversations.Conversations[0].ID)
+			assert.Equal(t, int64(3), conversations.Conversations[0].UnreadCount)
+			assert.Equal(t, sent[2].ID, conversations.Conversations[0].LastMessage.ID)
+		}
+		// Los mensajes propios no cuentan como no leídos
+		assert.Equal(t, int64(0), getConversations(t, alice, "").Conversations[0].UnreadCount)
+
+		w := send("POST", fmt.Sprintf("/conversations/%d/read", direct.ID), fmt.Sprintf(`{"message_id": %d}`, sent[1].ID), bob)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var receipt ParticipantResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &receipt))
+		assert.Equal(t, sent[1].ID, receipt.LastReadMessageID)
+		assert.NotNil(t, receipt.ReadAt)
+
+		page := getMessages(t, direct.ID, alice, "")
+		assert.Empty(t, page.Messages[0].ReadBy)
+		assert.Equal(t, []ReaderResponse{{UserID: bob, Username: username(bob)}}, page.Messages[1].ReadBy)
+		assert.Equal(t, int64(1), getConversations(t, bob, "").Conversations[0].UnreadCount)
+
+		// Sin message_id se marcan todos; el recibo nunca retrocede
+		assert.Equal(t, http.StatusOK, send("POST", fmt.Sprintf("/conversations/%d/read", direct.ID), "", bob).Code)
+		w = send("POST", fmt.Sprintf("/conversations/%d/read", direct.ID), fmt.Sprintf(`{"message_id": %d}`, sent[0].ID), bob)
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &receipt))
+		assert.Equal(t, sent[2].ID, receipt.LastReadMessageID)
+		assert.Equal(t, int64(0), getConversations(t, bob, "").Conversations[0].UnreadCount)
+
+		// Responder marca como leído todo lo anterior
+		reply := sendMessage(t, direct.ID, bob, "¡sí!")
+		w = send("GET", fmt.Sprintf("/conversations/%d", direct.ID), "", bob)
+		assert.Contains(t, w.Body.String(), fmt.Sprintf(`"last_read_message_id":%d`, reply.ID))
+	})
+
+	t.Run("Eliminar Mensaje", func(t *testing.T) {
+		path := fmt.Sprintf("/conversations/%d/messages/%d", direct.ID, sent[2].ID)
+
+		// Solo el remitente puede eliminar su mensaje
+		assert.Equal(t, http.StatusNotFound, send("DELETE", path, "", bob).Code)
+		assert.Equal(t, http.StatusOK, send("DELETE", path, "", alice).Code)
+		assert.Equal(t, http.StatusNotFound, send("DELETE", path, "", alice).Code)
+
+		for _, message := range getMessages(t, direct.ID, bob, "").Messages {
+			assert.NotEqual(t, sent[2].ID, message.ID)
+		}
+	})
+
+	t.Run("Grupos y Paginación de Conversaciones", func(t *testing.T) {
+		group := createConversation(t, alice, http.StatusCreated, bob, carol)
+		assert.True(t, group.IsGroup)
+		assert.Len(t, group.Participants, 3)
+
+		// Cada grupo es una conversación nueva, aunque tenga los mismos participantes
+		newest := createConversation(t, alice, http.StatusCreated, carol, bob)
+		assert.NotEqual(t, group.ID, newest.ID)
+		sendMessage(t, group.ID, carol, "hola a todos")
+
+		var ids []uint
+		cursor := ""
+		for page := 0; page < 5; page++ {
+			response := getConversations(t, alice, "?limit=1&cursor="+cursor)
+			for _, conversation := range response.Conversations {
+				ids = append(ids, conversation.ID)
+			}
+			if response.NextCursor == "" {
+				break
+			}
+			cursor = response.NextCursor
+		}
+		// Primero la del mensaje más reciente
+		assert.Equal(t, []uint{group.ID, newest.ID, direct.ID}, ids)
+	})
+
+	t.Run("Bloqueos y Privacidad", func(t *testing.T) {
+		users.restrict(alice, dave, "followers_only")
+		w := send("POST", "/conversations", fmt.Sprintf(`{"usernames": ["%s"]}`, username(dave)), alice)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.JSONEq(t, fmt.Sprintf(`{"error": "El usuario solo recibe mensajes de sus seguidores", "username": "%s"}`, username(dave)), w.Body.String())
+
+		// Un destinatario que solo acepta seguidores impide crear el grupo completo
+		createConversation(t, alice, http.StatusForbidden, bob, dave)
+
+		// Pero no impide volver a abrir una conversación directa que ya existe
+		users.restrict(alice, bob, "followers_only")
+		existing := createConversation(t, alice, http.StatusOK, bob)
+		assert.Equal(t, direct.ID, existing.ID)
+
+		// Un bloqueo impide escribir también en las conversaciones existentes
+		users.restrict(alice, bob, "blocked")
+		w = send("POST", fmt.Sprintf("/conversations/%d/messages", direct.ID), `{"content": "hola"}`, alice)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.JSONEq(t, fmt.Sprintf(`{"error": "No puedes enviarle mensajes a este usuario", "username": "%s"}`, username(bob)), w.Body.String())
+		createConversation(t, alice, http.StatusForbidden, bob)
+	})
+
+	t.Run("Validación", func(t *testing.T) {
+		// Quien no participa no ve la conversación
+		assert.Equal(t, http.StatusNotFound, send("GET", fmt.Sprintf("/conversations/%d/messages", direct.ID), "", eve).Code)
+		assert.Equal(t, http.StatusNotFound, send("POST", fmt.Sprintf("/conversations/%d/messages", direct.ID), `{"content": "hola"}`, eve).Code)
+		assert.Equal(t, http.StatusNotFound, send("POST", fmt.Sprintf("/conversations/%d/read", direct.ID), "", eve).Code)
+
+		tooMany := make([]string, domain.MaxGroupParticipants)
+		for i := range tooMany {
+			tooMany[i] = fmt.Sprintf("%q", username(base+100+uint(i)))
+		}
+		for _, body := range []string{`{}`, `{"usernames": []}`, `{"usernames": [""]}`, fmt.Sprintf(`{"usernames": ["%s"]}`, username(eve)),
+			`{"usernames": [` + strings.Join(tooMany, ",") + `]}`} {
+			assert.Equal(t, http.StatusBadRequest, send("POST", "/conversations", body, eve).Code, body)
+		}
+		w := send("POST", "/conversations", `{"usernames": ["no_existe"]}`, eve)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		for _, body := range []string{`{"content": "   "}`, fmt.Sprintf(`{"content": %q}`, strings.Repeat("a", domain.MaxMessageLength+1))} {
+			assert.Equal(t, http.StatusBadRequest, send("POST", fmt.Sprintf("/conversations/%d/messages", direct.ID), body, bob).Code)
+		}
+		assert.Equal(t, http.StatusBadRequest, send("GET", "/conversations?cursor=invalido", "", alice).Code)
+		assert.Equal(t, http.StatusBadRequest, send("GET", "/conversations/abc/messages", "", alice).Code)
+
+		w = httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/conversations", nil)
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
@@ -0,0 +1,33 @@
+package api
+
+import (
+	"github.com/DevOpslp/microblogging-platform/message-service/internal/domain"
+	"github.com/DevOpslp/microblogging-platform/pagination"
+	"github.com/gin-gonic/gin"
+)
+
+// parsePageParams lee los parámetros `cursor` y `limit` de la query
+func parsePageParams(c *gin.Context, cursors *pagination.Signer) (*domain.Cursor, int, error) {
+	limit, err := pagination.ParseLimit(c.Query("limit"))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	value := c.Query("cursor")
+	if value == "" {
+		return nil, limit, nil
+	}
+	at, id, err := cursors.DecodeTimeID(value)
+	if err != nil {
+		return nil, 0, err
+	}
+	return &domain.Cursor{Time: at, ID: id}, limit, nil
+}
+
+// encodeCursor firma el cursor de la página siguiente; vacío si no hay más resultados
+func encodeCursor(cursors *pagination.Signer, cursor *domain.Cursor) string {
+	if cursor == nil {
+		return ""
+	}
+	return cursors.EncodeTimeID(cursor.Time, cursor.ID)
+}
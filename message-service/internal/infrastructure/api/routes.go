@@ -0,0 +1,18 @@
+package api
+
+import (
+	"github.com/DevOpslp/microblogging-platform/auth"
+	"github.com/gin-gonic/gin"
+)
+
+func SetupRoutes(router *gin.Engine, handler *MessageHandler, tokens *auth.TokenManager) {
+	// Conversaciones y mensajes del usuario autenticado
+	conversations := router.Group("/conversations", auth.Middleware(tokens))
+	conversations.POST("", handler.CreateConversation)
+	conversations.GET("", handler.GetConversations)
+	conversations.GET("/:id", handler.GetConversation)
+	conversations.GET("/:id/messages", handler.GetMessages)
+	conversations.POST("/:id/messages", handler.SendMessage)
+	conversations.DELETE("/:id/messages/:message_id", handler.DeleteMessage)
+	conversations.POST("/:id/read", handler.MarkRead)
+}
@@ -0,0 +1,118 @@
+package persistence
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/DevOpslp/microblogging-platform/auth"
+	"github.com/DevOpslp/microblogging-platform/message-service/internal/domain"
+)
+
+// Restricciones de user-service para escribirle a un grupo de usuarios: Blocked tiene los que tienen un
+// bloqueo con el remitente en cualquier dirección y FollowersOnly los que solo aceptan mensajes de sus
+// seguidores y no lo son
+type MessageRestrictions struct {
+	Blocked       map[uint]bool
+	FollowersOnly map[uint]bool
+}
+
+type UserRepository interface {
+	// Buscar varios usuarios por username exacto; los inexistentes no aparecen en el resultado
+	FindUsersByUsernames(usernames []string) (map[string]domain.User, error)
+	// Indicar a cuáles de recipientIDs no puede escribirles senderID
+	FindMessageRestrictions(senderID uint, recipientIDs []uint) (*MessageRestrictions, error)
+}
+
+// Las peticiones a user-service llevan un token de servicio, que exige la consulta de restricciones
+type HTTPUserRepository struct {
+	baseURL string
+	tokens  *auth.TokenManager
+}
+
+func NewHTTPUserRepository(baseURL string, tokens *auth.TokenManager) *HTTPUserRepository {
+	return &HTTPUserRepository{baseURL: baseURL, tokens: tokens}
+}
+
+// get hace una petición GET a user-service autenticada con un token de servicio y decodifica la respuesta
+func (repo *HTTPUserRepository) get(endpoint string, result interface{}) error {
+	token, err := repo.tokens.IssueServiceToken("message-service")
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodGet, repo.baseURL+endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", auth.BearerHeader(token))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("error: user-service devolvió estado %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+// FindUsersByUsernames consulta GET /users/batch de user-service
+func (repo *HTTPUserRepository) FindUsersByUsernames(usernames []string) (map[string]domain.User, error) {
+	users := make(map[string]domain.User, len(usernames))
+	if len(usernames) == 0 {
+		return users, nil
+	}
+
+	escaped := make([]string, len(usernames))
+	for i, username := range usernames {
+		escaped[i] = url.QueryEscape(username)
+	}
+
+	var result struct {
+		Users []struct {
+			UserID   uint   `json:"user_id"`
+			Username string `json:"username"`
+		} `json:"users"`
+	}
+	if err := repo.get("/users/batch?usernames="+strings.Join(escaped, ","), &result); err != nil {
+		return nil, err
+	}
+	for _, user := range result.Users {
+		users[user.Username] = domain.User{ID: user.UserID, Username: user.Username}
+	}
+	return users, nil
+}
+
+// FindMessageRestrictions consulta GET /users/message-restrictions de user-service
+func (repo *HTTPUserRepository) FindMessageRestrictions(senderID uint, recipientIDs []uint) (*MessageRestrictions, error) {
+	restrictions := &MessageRestrictions{Blocked: make(map[uint]bool), FollowersOnly: make(map[uint]bool)}
+	if len(recipientIDs) == 0 {
+		return restrictions, nil
+	}
+
+	ids := make([]string, len(recipientIDs))
+	for i, userID := range recipientIDs {
+		ids[i] = strconv.FormatUint(uint64(userID), 10)
+	}
+
+	var result struct {
+		BlockedIDs       []uint `json:"blocked_ids"`
+		FollowersOnlyIDs []uint `json:"followers_only_ids"`
+	}
+	endpoint := fmt.Sprintf("/users/message-restrictions?sender_id=%d&ids=%s", senderID, strings.Join(ids, ","))
+	if err := repo.get(endpoint, &result); err != nil {
+		return nil, err
+	}
+	for _, userID := range result.BlockedIDs {
+		restrictions.Blocked[userID] = true
+	}
+	for _, userID := range result.FollowersOnlyIDs {
+		restrictions.FollowersOnly[userID] = true
+	}
+	return restrictions, nil
+}
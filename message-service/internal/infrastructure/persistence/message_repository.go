@@ -0,0 +1,355 @@
+package persistence
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/DevOpslp/microblogging-platform/message-service/internal/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrConversationNotFound se devuelve cuando la conversación no existe o el usuario no participa en ella
+	ErrConversationNotFound = errors.New("conversación no encontrada")
+	// ErrMessageNotFound se devuelve cuando el mensaje no existe en la conversación o no es del usuario
+	ErrMessageNotFound = errors.New("mensaje no encontrado")
+	// ErrRecipientBlocked se devuelve cuando hay un bloqueo entre el remitente y un destinatario
+	ErrRecipientBlocked = errors.New("hay un bloqueo con el usuario")
+	// ErrRecipientFollowersOnly se devuelve cuando un destinatario solo acepta mensajes de sus seguidores
+	ErrRecipientFollowersOnly = errors.New("el usuario solo recibe mensajes de sus seguidores")
+)
+
+// RecipientError indica qué destinatario no acepta mensajes del remitente. Err es ErrRecipientBlocked
+// o ErrRecipientFollowersOnly.
+type RecipientError struct {
+	Username string
+	Err      error
+}
+
+func (e *RecipientError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Err, e.Username)
+}
+
+func (e *RecipientError) Unwrap() error {
+	return e.Err
+}
+
+type MessageRepository struct {
+	db       *gorm.DB
+	userRepo UserRepository
+}
+
+func NewMessageRepository(db *gorm.DB, userRepo UserRepository) *MessageRepository {
+	return &MessageRepository{db: db, userRepo: userRepo}
+}
+
+// checkRecipients verifica con una sola petición a user-service que senderID pueda escribirles a los
+// destinatarios. La configuración de solo seguidores decide quién puede iniciar una conversación
+// (newConversation); en las conversaciones existentes solo cuentan los bloqueos.
+func (repo *MessageRepository) checkRecipients(senderID uint, recipients []domain.User, newConversation bool) error {
+	ids := make([]uint, 0, len(recipients))
+	for _, recipient := range recipients {
+		ids = append(ids, recipient.ID)
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	restrictions, err := repo.userRepo.FindMessageRestrictions(senderID, ids)
+	if err != nil {
+		return fmt.Errorf("error al consultar las restricciones de mensajes: %w", err)
+	}
+	for _, recipient := range recipients {
+		if restrictions.Blocked[recipient.ID] {
+			return &RecipientError{Username: recipient.Username, Err: ErrRecipientBlocked}
+		}
+		if newConversation && restrictions.FollowersOnly[recipient.ID] {
+			return &RecipientError{Username: recipient.Username, Err: ErrRecipientFollowersOnly}
+		}
+	}
+	return nil
+}
+
+// CreateConversation crea una conversación de creator con los demás participantes, después de verificar
+// que pueda escribirles. Con un solo participante más la conversación es directa y, si ya existía, se
+// devuelve la existente con created en false; en ese caso solo cuentan los bloqueos, porque la
+// configuración de solo seguidores decide quién puede iniciar una conversación.
+func (repo *MessageRepository) CreateConversation(creator domain.User, others []domain.User) (*domain.Conversation, bool, error) {
+	now := time.Now()
+	conversation := domain.Conversation{IsGroup: len(others) > 1, CreatorID: creator.ID, LastMessageAt: now, CreatedAt: now}
+	if !conversation.IsGroup {
+		key := domain.DirectKey(creator.ID, others[0].ID)
+		conversation.DirectKey = &key
+
+		var existing domain.Conversation
+		err := repo.db.Where("direct_key = ?", key).First(&existing).Error
+		if err == nil {
+			if err := repo.checkRecipients(creator.ID, others, false); err != nil {
+				return nil, false, err
+			}
+			conversations := []domain.Conversation{existing}
+			if err := repo.loadDetails(conversations, creator.ID); err != nil {
+				return nil, false, err
+			}
+			return &conversations[0], false, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, false, err
+		}
+	}
+
+	if err := repo.checkRecipients(creator.ID, others, true); err != nil {
+		return nil, false, err
+	}
+
+	created := false
+	err := repo.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "direct_key"}}, DoNothing: true}).Create(&conversation)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			// Otra petición creó la conversación directa al mismo tiempo
+			key := *conversation.DirectKey
+			conversation = domain.Conversation{}
+			return tx.Where("direct_key = ?", key).First(&conversation).Error
+		}
+		created = true
+
+		participants := make([]domain.Participant, 0, 1+len(others))
+		for _, user := range append([]domain.User{creator}, others...) {
+			participants = append(participants, domain.Participant{
+				ConversationID: conversation.ID,
+				UserID:         user.ID,
+				Username:       user.Username,
+				JoinedAt:       now,
+			})
+		}
+		return tx.Create(&participants).Error
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	conversations := []domain.Conversation{conversation}
+	if err := repo.loadDetails(conversations, creator.ID); err != nil {
+		return nil, false, err
+	}
+	return &conversations[0], created, nil
+}
+
+// participantOf filtra las conversaciones en las que participa el usuario
+const participantOf = "EXISTS (SELECT 1 FROM participants WHERE participants.conversation_id = conversations.id AND participants.user_id = ?)"
+
+// GetConversation devuelve la conversación con sus participantes si userID participa en ella
+func (repo *MessageRepository) GetConversation(conversationID, userID uint) (*domain.Conversation, error) {
+	var conversation domain.Conversation
+	err := repo.db.Where("id = ?", conversationID).Where(participantOf, userID).First(&conversation).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrConversationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	conversations := []domain.Conversation{conversation}
+	if err := repo.loadDetails(conversations, userID); err != nil {
+		return nil, err
+	}
+	return &conversations[0], nil
+}
+
+// GetConversations devuelve una página de las conversaciones de userID, de la que tuvo el mensaje más
+// reciente a la más antigua. Devuelve el cursor de la página siguiente, o nil si no hay más.
+func (repo *MessageRepository) GetConversations(userID uint, cursor *domain.Cursor, limit int) ([]domain.Conversation, *domain.Cursor, error) {
+	query := repo.db.Where(participantOf, userID)
+	if cursor != nil {
+		query = query.Where("(last_message_at, id) < (?, ?)", cursor.Time, cursor.ID)
+	}
+
+	var conversations []domain.Conversation
+	if err := query.Order("last_message_at DESC, id DESC").Limit(limit + 1).Find(&conversations).Error; err != nil {
+		return nil, nil, err
+	}
+
+	var next *domain.Cursor
+	if len(conversations) > limit {
+		conversations = conversations[:limit]
+		position := conversations[limit-1].Cursor()
+		next = &position
+	}
+
+	if err := repo.loadDetails(conversations, userID); err != nil {
+		return nil, nil, err
+	}
+	return conversations, next, nil
+}
+
+// loadDetails carga, con una consulta para cada dato, los participantes, el último mensaje y la cantidad
+// de mensajes sin leer por userID de las conversaciones
+func (repo *MessageRepository) loadDetails(conversations []domain.Conversation, userID uint) error {
+	if len(conversations) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, len(conversations))
+	for i, conversation := range conversations {
+		ids[i] = conversation.ID
+	}
+
+	var participants []domain.Participant
+	if err := repo.db.Where("conversation_id IN ?", ids).Order("joined_at, user_id").Find(&participants).Error; err != nil {
+		return err
+	}
+
+	var lastMessages []domain.Message
+	err := repo.db.Raw(`SELECT DISTINCT ON (conversation_id) * FROM messages
+		WHERE conversation_id IN ? ORDER BY conversation_id, created_at DESC, id DESC`, ids).
+		Scan(&lastMessages).Error
+	if err != nil {
+		return err
+	}
+
+	var unread []struct {
+		ConversationID uint
+		Count          int64
+	}
+	err = repo.db.Table("messages").Select("messages.conversation_id, COUNT(*) AS count").
+		Joins("JOIN participants ON participants.conversation_id = messages.conversation_id AND participants.user_id = ?", userID).
+		Where("messages.conversation_id IN ? AND messages.sender_id <> ? AND messages.id > participants.last_read_message_id", ids, userID).
+		Group("messages.conversation_id").Scan(&unread).Error
+	if err != nil {
+		return err
+	}
+
+	byConversation := make(map[uint][]domain.Participant, len(conversations))
+	for _, participant := range participants {
+		byConversation[participant.ConversationID] = append(byConversation[participant.ConversationID], participant)
+	}
+	lastByConversation := make(map[uint]domain.Message, len(lastMessages))
+	for _, message := range lastMessages {
+		lastByConversation[message.ConversationID] = message
+	}
+	unreadByConversation := make(map[uint]int64, len(unread))
+	for _, count := range unread {
+		unreadByConversation[count.ConversationID] = count.Count
+	}
+
+	for i := range conversations {
+		id := conversations[i].ID
+		conversations[i].Participants = byConversation[id]
+		if message, ok := lastByConversation[id]; ok {
+			conversations[i].LastMessage = &message
+		}
+		conversations[i].UnreadCount = unreadByConversation[id]
+	}
+	return nil
+}
+
+// CreateMessage guarda un mensaje de sender en la conversación, después de verificar que no haya bloqueos
+// con los demás participantes. El remitente queda como lector de su propio mensaje.
+func (repo *MessageRepository) CreateMessage(conversation *domain.Conversation, sender domain.User, content string) (*domain.Message, error) {
+	others := conversation.Others(sender.ID)
+	recipients := make([]domain.User, 0, len(others))
+	for _, participant := range others {
+		recipients = append(recipients, domain.User{ID: participant.UserID, Username: participant.Username})
+	}
+	if err := repo.checkRecipients(sender.ID, recipients, false); err != nil {
+		return nil, err
+	}
+
+	message := domain.Message{
+		ConversationID: conversation.ID,
+		SenderID:       sender.ID,
+		SenderUsername: sender.Username,
+		Content:        content,
+	}
+	err := repo.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&message).Error; err != nil {
+			return err
+		}
+		err := tx.Model(&domain.Conversation{}).Where("id = ?", conversation.ID).
+			UpdateColumn("last_message_at", message.CreatedAt).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&domain.Participant{}).Where("conversation_id = ? AND user_id = ?", conversation.ID, sender.ID).
+			Updates(map[string]interface{}{"last_read_message_id": message.ID, "read_at": message.CreatedAt}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &message, nil
+}
+
+// GetMessages devuelve una página de los mensajes de la conversación, del más reciente al más antiguo.
+// Devuelve el cursor de la página siguiente, o nil si no hay más.
+func (repo *MessageRepository) GetMessages(conversationID uint, cursor *domain.Cursor, limit int) ([]domain.Message, *domain.Cursor, error) {
+	query := repo.db.Where("conversation_id = ?", conversationID)
+	if cursor != nil {
+		query = query.Where("(created_at, id) < (?, ?)", cursor.Time, cursor.ID)
+	}
+
+	var messages []domain.Message
+	if err := query.Order("created_at DESC, id DESC").Limit(limit + 1).Find(&messages).Error; err != nil {
+		return nil, nil, err
+	}
+
+	var next *domain.Cursor
+	if len(messages) > limit {
+		messages = messages[:limit]
+		position := messages[limit-1].Cursor()
+		next = &position
+	}
+	return messages, next, nil
+}
+
+// DeleteMessage elimina para todos los participantes un mensaje enviado por senderID
+func (repo *MessageRepository) DeleteMessage(conversationID, messageID, senderID uint) error {
+	result := repo.db.Where("id = ? AND conversation_id = ? AND sender_id = ?", messageID, conversationID, senderID).
+		Delete(&domain.Message{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrMessageNotFound
+	}
+	return nil
+}
+
+// MarkRead marca como leídos por userID los mensajes de la conversación hasta messageID, o hasta el más
+// reciente si messageID es 0, y devuelve su recibo de lectura. El recibo nunca retrocede: marcar un
+// mensaje anterior al último leído no tiene efecto.
+func (repo *MessageRepository) MarkRead(conversationID, userID, messageID uint) (*domain.Participant, error) {
+	if messageID != 0 {
+		var count int64
+		err := repo.db.Model(&domain.Message{}).Where("id = ? AND conversation_id = ?", messageID, conversationID).Count(&count).Error
+		if err != nil {
+			return nil, err
+		}
+		if count == 0 {
+			return nil, ErrMessageNotFound
+		}
+	} else {
+		err := repo.db.Model(&domain.Message{}).Select("COALESCE(MAX(id), 0)").
+			Where("conversation_id = ?", conversationID).Scan(&messageID).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err := repo.db.Model(&domain.Participant{}).
+		Where("conversation_id = ? AND user_id = ? AND last_read_message_id < ?", conversationID, userID, messageID).
+		Updates(map[string]interface{}{"last_read_message_id": messageID, "read_at": time.Now()}).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var participant domain.Participant
+	if err := repo.db.Where("conversation_id = ? AND user_id = ?", conversationID, userID).First(&participant).Error; err != nil {
+		return nil, err
+	}
+	return &participant, nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/DevOpslp/microblogging-platform/auth"
+	"github.com/DevOpslp/microblogging-platform/events"
+	"github.com/DevOpslp/microblogging-platform/notification-service/internal/domain"
+	"github.com/DevOpslp/microblogging-platform/notification-service/internal/infrastructure/api"
+	"github.com/DevOpslp/microblogging-platform/notification-service/internal/infrastructure/consumer"
+	"github.com/DevOpslp/microblogging-platform/notification-service/internal/infrastructure/persistence"
+	"github.com/DevOpslp/microblogging-platform/pagination"
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func main() {
+	// Secreto compartido para firmar y verificar tokens; sin él el servicio no arranca
+	authSecret, err := auth.SecretFromEnv()
+	if err != nil {
+		log.Fatalf("Error de configuración: %v", err)
+	}
+	// Secreto para firmar los cursores de paginación
+	paginationSecret, err := pagination.SecretFromEnv()
+	if err != nil {
+		log.Fatalf("Error de configuración: %v", err)
+	}
+
+	// Construir el DSN (Data Source Name) usando las variables de entorno
+	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable",
+		os.Getenv("DB_HOST"), os.Getenv("DB_USER"), os.Getenv("DB_PASSWORD"), os.Getenv("DB_NAME"), os.Getenv("DB_PORT"))
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	if err != nil {
+		log.Fatalf("Error al conectar a notificationdb: %v", err)
+	}
+
+	if err := db.AutoMigrate(&domain.Notification{}, &domain.NotificationActor{}, &domain.ProcessedEvent{}); err != nil {
+		log.Fatalf("Error al migrar los modelos de notification-service: %v", err)
+	}
+
+	repo := persistence.NewNotificationRepository(db)
+
+	// Consumir los eventos de user-service y tweet-service del broker configurado por EVENT_BROKER
+	broker, err := events.NewBrokerFromEnv(context.Background())
+	if err != nil {
+		log.Fatalf("Error al conectar con el broker de eventos: %v", err)
+	}
+	defer broker.Close()
+	eventConsumer := consumer.NewEventConsumer(repo)
+	if err := eventConsumer.Subscribe(broker); err != nil {
+		log.Fatalf("Error al suscribirse a los eventos: %v", err)
+	}
+	go eventConsumer.PurgeProcessed(context.Background(), time.Hour)
+
+	handler := api.NewNotificationHandler(repo, pagination.NewSigner(paginationSecret))
+
+	// Configurar rutas con el secreto compartido de tokens
+	router := gin.Default()
+	api.SetupRoutes(router, handler, auth.NewTokenManager(authSecret))
+
+	// Escuchar en el puerto 8083
+	if err := router.Run(":8083"); err != nil {
+		log.Fatalf("Error al iniciar el servidor de notification-service: %v", err)
+	}
+}
@@ -0,0 +1,10 @@
+package domain
+
+import "time"
+
+// Posición de una notificación en el listado ordenado por (updated_at, id) de forma descendente.
+// Se usa como cursor para la paginación por keyset.
+type Cursor struct {
+	UpdatedAt time.Time
+	ID        uint
+}
@@ -0,0 +1,55 @@
+package domain
+
+import "github.com/DevOpslp/microblogging-platform/events"
+
+// TweetCreatedNotifications devuelve las notificaciones que genera un tweet nuevo. Cada usuario recibe como
+// máximo una por tweet: quien fue respondido o citado no recibe además la de mención. Nadie se notifica a sí mismo.
+func TweetCreatedNotifications(event events.TweetCreated) []NotificationEvent {
+	notifications := make([]NotificationEvent, 0)
+	notified := map[uint]bool{event.UserID: true}
+	add := func(recipientID *uint, notificationType string, tweetID uint) {
+		if recipientID == nil || *recipientID == 0 || notified[*recipientID] {
+			return
+		}
+		notified[*recipientID] = true
+		notifications = append(notifications, NotificationEvent{
+			RecipientID:   *recipientID,
+			Type:          notificationType,
+			TweetID:       &tweetID,
+			ActorID:       event.UserID,
+			ActorUsername: event.Username,
+		})
+	}
+
+	// Los retweets se agrupan sobre el tweet original
+	if event.RetweetOfTweetID != nil {
+		add(event.RetweetOfUserID, TypeRetweet, *event.RetweetOfTweetID)
+		return notifications
+	}
+	add(event.InReplyToUserID, TypeReply, event.TweetID)
+	add(event.QuotedUserID, TypeQuote, event.TweetID)
+	for _, mention := range event.Mentions {
+		add(&mention.UserID, TypeMention, event.TweetID)
+	}
+	return notifications
+}
+
+func TweetLikedNotification(event events.TweetLiked) NotificationEvent {
+	tweetID := event.TweetID
+	return NotificationEvent{
+		RecipientID:   event.TweetUserID,
+		Type:          TypeLike,
+		TweetID:       &tweetID,
+		ActorID:       event.UserID,
+		ActorUsername: event.Username,
+	}
+}
+
+func UserFollowedNotification(event events.UserFollowed) NotificationEvent {
+	return NotificationEvent{
+		RecipientID:   event.FollowedID,
+		Type:          TypeFollow,
+		ActorID:       event.FollowerID,
+		ActorUsername: event.FollowerUsername,
+	}
+}
@@ -0,0 +1,119 @@
+package domain
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Tipos de notificación
+const (
+	TypeFollow  = "follow"
+	TypeLike    = "like"
+	TypeRetweet = "retweet"
+	TypeReply   = "reply"
+	TypeQuote   = "quote"
+	TypeMention = "mention"
+)
+
+// Notificación de un usuario. Los eventos repetidos se agrupan: mientras la notificación no se lea,
+// cada actor nuevo con la misma GroupKey se suma a ella en lugar de crear otra
+// (ej. "user2 y 12 personas más dieron like a tu tweet").
+type Notification struct {
+	ID         uint   `gorm:"primaryKey"`
+	UserID     uint   `gorm:"not null;index:idx_notifications_user_updated,priority:1;uniqueIndex:idx_notifications_unread_group,priority:1,where:read_at IS NULL"`
+	Type       string `gorm:"size:20;not null"`
+	GroupKey   string `gorm:"size:50;not null;uniqueIndex:idx_notifications_unread_group,priority:2,where:read_at IS NULL"`
+	TweetID    *uint  `gorm:"index"`
+	ActorCount int    `gorm:"not null;default:0"`
+	ReadAt     *time.Time
+	CreatedAt  time.Time
+	UpdatedAt  time.Time `gorm:"index:idx_notifications_user_updated,priority:2"`
+
+	// Actores más recientes, cargados al listar las notificaciones
+	Actors []NotificationActor `gorm:"-"`
+}
+
+// Usuario que generó una notificación; la clave primaria evita contar dos veces al mismo actor
+type NotificationActor struct {
+	NotificationID uint      `gorm:"primaryKey"`
+	ActorID        uint      `gorm:"primaryKey"`
+	ActorUsername  string    `gorm:"not null"`
+	CreatedAt      time.Time `gorm:"not null"`
+}
+
+// Evento que genera una notificación para RecipientID
+type NotificationEvent struct {
+	RecipientID   uint
+	Type          string
+	TweetID       *uint
+	ActorID       uint
+	ActorUsername string
+}
+
+// GroupKey identifica las notificaciones que se agrupan: los likes y retweets de un mismo tweet
+// y todos los seguidores nuevos. Las respuestas, citas y menciones apuntan a su propio tweet,
+// por lo que cada una queda en una notificación distinta.
+func (e NotificationEvent) GroupKey() string {
+	if e.TweetID == nil {
+		return e.Type
+	}
+	return e.Type + ":" + strconv.FormatUint(uint64(*e.TweetID), 10)
+}
+
+// IsSelf indica si el actor es el propio destinatario (ej. un like a un tweet propio)
+func (e NotificationEvent) IsSelf() bool {
+	return e.ActorID == e.RecipientID
+}
+
+func (n Notification) IsRead() bool {
+	return n.ReadAt != nil
+}
+
+// Cursor devuelve la posición de la notificación en el listado, ordenado por (updated_at, id) descendente
+func (n Notification) Cursor() Cursor {
+	return Cursor{UpdatedAt: n.UpdatedAt, ID: n.ID}
+}
+
+// Message resume la notificación en una frase, usando los actores más recientes cargados en Actors
+func (n Notification) Message() string {
+	var action string
+	switch n.Type {
+	case TypeFollow:
+		action = "te siguió"
+		if n.ActorCount > 1 {
+			action = "te siguieron"
+		}
+	case TypeLike:
+		action = "le dio like a tu tweet"
+		if n.ActorCount > 1 {
+			action = "le dieron like a tu tweet"
+		}
+	case TypeRetweet:
+		action = "retuiteó tu tweet"
+		if n.ActorCount > 1 {
+			action = "retuitearon tu tweet"
+		}
+	case TypeReply:
+		action = "respondió a tu tweet"
+	case TypeQuote:
+		action = "citó tu tweet"
+	case TypeMention:
+		action = "te mencionó"
+	default:
+		action = "interactuó contigo"
+	}
+
+	if len(n.Actors) == 0 {
+		return action
+	}
+	actor := n.Actors[0].ActorUsername
+	switch others := n.ActorCount - 1; {
+	case others <= 0:
+		return fmt.Sprintf("%s %s", actor, action)
+	case others == 1:
+		return fmt.Sprintf("%s y 1 persona más %s", actor, action)
+	default:
+		return fmt.Sprintf("%s y %d personas más %s", actor, others, action)
+	}
+}
@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/DevOpslp/microblogging-platform/events"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroupKey(t *testing.T) {
+	tweetID := uint(7)
+	assert.Equal(t, "like:7", NotificationEvent{Type: TypeLike, TweetID: &tweetID}.GroupKey())
+	assert.Equal(t, "follow", NotificationEvent{Type: TypeFollow}.GroupKey())
+}
+
+func TestNotificationMessage(t *testing.T) {
+	actors := []NotificationActor{{ActorUsername: "user2"}, {ActorUsername: "user3"}}
+
+	cases := []struct {
+		notification Notification
+		expected     string
+	}{
+		{Notification{Type: TypeLike, ActorCount: 1, Actors: actors[:1]}, "user2 le dio like a tu tweet"},
+		{Notification{Type: TypeLike, ActorCount: 2, Actors: actors}, "user2 y 1 persona más le dieron like a tu tweet"},
+		{Notification{Type: TypeLike, ActorCount: 13, Actors: actors}, "user2 y 12 personas más le dieron like a tu tweet"},
+		{Notification{Type: TypeFollow, ActorCount: 3, Actors: actors}, "user2 y 2 personas más te siguieron"},
+		{Notification{Type: TypeMention, ActorCount: 1, Actors: actors[:1]}, "user2 te mencionó"},
+	}
+	for _, tc := range cases {
+		assert.Equal(t, tc.expected, tc.notification.Message())
+	}
+}
+
+func TestTweetCreatedNotifications(t *testing.T) {
+	parentAuthor, quotedTweet, quotedAuthor := uint(2), uint(5), uint(3)
+	event := events.TweetCreated{
+		TweetID:         10,
+		UserID:          1,
+		Username:        "user1",
+		InReplyToUserID: &parentAuthor,
+		QuotedTweetID:   &quotedTweet,
+		QuotedUserID:    &quotedAuthor,
+		// Mencionados: el autor respondido, el propio autor y un tercero
+		Mentions: []events.Mention{{UserID: 2}, {UserID: 1}, {UserID: 4}},
+	}
+
+	notifications := TweetCreatedNotifications(event)
+	types := make(map[uint]string)
+	for _, notification := range notifications {
+		types[notification.RecipientID] = notification.Type
+		assert.Equal(t, uint(10), *notification.TweetID)
+		assert.Equal(t, "user1", notification.ActorUsername)
+	}
+	assert.Equal(t, map[uint]string{2: TypeReply, 3: TypeQuote, 4: TypeMention}, types)
+
+	// Un retweet notifica al autor original, agrupado sobre el tweet original
+	retweetAuthor := uint(3)
+	retweet := events.TweetCreated{TweetID: 11, UserID: 1, Username: "user1", RetweetOfTweetID: &quotedTweet, RetweetOfUserID: &retweetAuthor}
+	notifications = TweetCreatedNotifications(retweet)
+	assert.Len(t, notifications, 1)
+	assert.Equal(t, "retweet:5", notifications[0].GroupKey())
+
+	// Retuitear un tweet propio no notifica
+	retweetAuthor = 1
+	assert.Empty(t, TweetCreatedNotifications(retweet))
+}
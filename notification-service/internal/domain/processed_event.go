@@ -0,0 +1,13 @@
+package domain
+
+import "time"
+
+// Evento del broker ya aplicado. Se guarda en la misma transacción que sus notificaciones, por lo que
+// una entrega repetida del mismo evento se descarta.
+type ProcessedEvent struct {
+	EventID     string    `gorm:"primaryKey;size:36"`
+	ProcessedAt time.Time `gorm:"not null;index"`
+}
+
+// Tiempo que se recuerdan los eventos aplicados; cubre de sobra los reenvíos del broker
+const ProcessedEventRetention = 7 * 24 * time.Hour
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/DevOpslp/microblogging-platform/auth"
+	"github.com/DevOpslp/microblogging-platform/notification-service/internal/domain"
+	"github.com/DevOpslp/microblogging-platform/notification-service/internal/infrastructure/persistence"
+	"github.com/DevOpslp/microblogging-platform/pagination"
+	"github.com/gin-gonic/gin"
+)
+
+type NotificationHandler struct {
+	repo    *persistence.NotificationRepository
+	cursors *pagination.Signer
+}
+
+func NewNotificationHandler(repo *persistence.NotificationRepository, cursors *pagination.Signer) *NotificationHandler {
+	return &NotificationHandler{repo: repo, cursors: cursors}
+}
+
+type ActorResponse struct {
+	UserID   uint   `json:"user_id"`
+	Username string `json:"username"`
+}
+
+// Notificación agrupada; Actors tiene solo los más recientes y ActorCount el total
+type NotificationResponse struct {
+	ID         uint            `json:"id"`
+	Type       string          `json:"type"`
+	TweetID    *uint           `json:"tweet_id"`
+	Actors     []ActorResponse `json:"actors"`
+	ActorCount int             `json:"actor_count"`
+	Message    string          `json:"message"`
+	Read       bool            `json:"read"`
+	CreatedAt  string          `json:"created_at"`
+	UpdatedAt  string          `json:"updated_at"`
+}
+
+func formatNotificationResponse(notification domain.Notification) NotificationResponse {
+	actors := make([]ActorResponse, 0, len(notification.Actors))
+	for _, actor := range notification.Actors {
+		actors = append(actors, ActorResponse{UserID: actor.ActorID, Username: actor.ActorUsername})
+	}
+	return NotificationResponse{
+		ID:         notification.ID,
+		Type:       notification.Type,
+		TweetID:    notification.TweetID,
+		Actors:     actors,
+		ActorCount: notification.ActorCount,
+		Message:    notification.Message(),
+		Read:       notification.IsRead(),
+		CreatedAt:  notification.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:  notification.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+// GetNotifications devuelve una página de las notificaciones del usuario autenticado, de la más reciente
+// a la más antigua, junto con la cantidad sin leer. Con ?unread=true solo devuelve las no leídas.
+func (h *NotificationHandler) GetNotifications(c *gin.Context) {
+	cursor, limit, err := parsePageParams(c, h.cursors)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Usuario autenticado por auth.Middleware
+	userID := auth.UserID(c)
+
+	notifications, next, err := h.repo.GetNotifications(userID, c.Query("unread") == "true", cursor, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudieron obtener las notificaciones"})
+		return
+	}
+	unread, err := h.repo.CountUnread(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudieron obtener las notificaciones"})
+		return
+	}
+
+	response := make([]NotificationResponse, 0, len(notifications))
+	for _, notification := range notifications {
+		response = append(response, formatNotificationResponse(notification))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"notifications": response, "unread_count": unread, "next_cursor": encodeCursor(h.cursors, next)})
+}
+
+// GetUnreadCount devuelve la cantidad de notificaciones sin leer del usuario autenticado
+func (h *NotificationHandler) GetUnreadCount(c *gin.Context) {
+	unread, err := h.repo.CountUnread(auth.UserID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo obtener la cantidad de notificaciones sin leer"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"unread_count": unread})
+}
+
+// MarkRead marca como leídas las notificaciones indicadas en `ids`, o todas si no se envía ninguna
+func (h *NotificationHandler) MarkRead(c *gin.Context) {
+	var body struct {
+		IDs []uint `json:"ids"`
+	}
+	// El cuerpo es opcional
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "IDs de notificaciones inválidos"})
+			return
+		}
+	}
+	for _, id := range body.IDs {
+		if id == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "IDs de notificaciones inválidos"})
+			return
+		}
+	}
+
+	userID := auth.UserID(c)
+	marked, err := h.repo.MarkRead(userID, body.IDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudieron marcar las notificaciones como leídas"})
+		return
+	}
+	unread, err := h.repo.CountUnread(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo obtener la cantidad de notificaciones sin leer"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"marked_read": marked, "unread_count": unread})
+}
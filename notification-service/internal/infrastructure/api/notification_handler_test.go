@@ -0,0 +1,205 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DevOpslp/microblogging-platform/auth"
+	"github.com/DevOpslp/microblogging-platform/events"
+	"github.com/DevOpslp/microblogging-platform/notification-service/internal/domain"
+	"github.com/DevOpslp/microblogging-platform/notification-service/internal/infrastructure/consumer"
+	"github.com/DevOpslp/microblogging-platform/notification-service/internal/infrastructure/persistence"
+	"github.com/DevOpslp/microblogging-platform/pagination"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+var (
+	testDB *gorm.DB
+	// Broker en memoria con el consumidor de notification-service suscrito
+	broker *events.MemoryBroker
+)
+
+var (
+	tokens  = auth.NewTokenManager("secreto-de-prueba")
+	cursors = pagination.NewSigner("secreto-de-prueba")
+)
+
+func setupTestDB() {
+	dsn := "host=localhost user=devuser password=devpassword dbname=notificationdb port=5432 sslmode=disable"
+	var err error
+	testDB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	if err != nil {
+		log.Fatalf("Error al conectar a la base de datos de pruebas: %v", err)
+	}
+
+	testDB.AutoMigrate(&domain.Notification{}, &domain.NotificationActor{}, &domain.ProcessedEvent{})
+}
+
+func setupTestRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	repo := persistence.NewNotificationRepository(testDB)
+	broker = events.NewMemoryBroker()
+	if err := consumer.NewEventConsumer(repo).Subscribe(broker); err != nil {
+		log.Fatalf("Error al suscribir el consumidor de eventos: %v", err)
+	}
+	handler := NewNotificationHandler(repo, cursors)
+	router := gin.Default()
+	SetupRoutes(router, handler, tokens)
+	return router
+}
+
+// bearerFor genera el header Authorization de un usuario para las pruebas
+func bearerFor(userID uint) string {
+	token, _ := tokens.IssueAccessToken(userID, fmt.Sprintf("user%d", userID), auth.RoleUser)
+	return auth.BearerHeader(token)
+}
+
+func TestNotificationsFlow(t *testing.T) {
+	setupTestDB()
+	router := setupTestRouter()
+
+	// IDs únicos por ejecución para no mezclar notificaciones de pruebas anteriores
+	base := uint(time.Now().UnixNano() % 1_000_000_000)
+	recipient, actor1, actor2 := base, base+1, base+2
+	tweetID := base + 100
+
+	deliver := func(t *testing.T, event events.Event) {
+		assert.NoError(t, broker.Publish(context.Background(), event))
+	}
+	publish := func(t *testing.T, payload events.Payload) events.Event {
+		event, err := events.New("test", payload)
+		assert.NoError(t, err)
+		deliver(t, event)
+		return event
+	}
+
+	like := func(t *testing.T, actorID uint) events.Event {
+		return publish(t, events.TweetLiked{TweetID: tweetID, TweetUserID: recipient, UserID: actorID, Username: fmt.Sprintf("user%d", actorID)})
+	}
+
+	type page struct {
+		Notifications []NotificationResponse `json:"notifications"`
+		UnreadCount   int64                  `json:"unread_count"`
+		NextCursor    string                 `json:"next_cursor"`
+	}
+	getNotifications := func(t *testing.T, query string) page {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/notifications"+query, nil)
+		req.Header.Set("Authorization", bearerFor(recipient))
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response page
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response
+	}
+
+	var firstLike events.Event
+	t.Run("Likes Agrupados", func(t *testing.T) {
+		firstLike = like(t, actor1)
+		like(t, actor2)
+		// El mismo actor no cuenta dos veces, aunque llegue en otro evento
+		like(t, actor2)
+		// Un like al tweet propio no notifica
+		like(t, recipient)
+
+		response := getNotifications(t, "")
+		assert.Equal(t, int64(1), response.UnreadCount)
+		assert.Len(t, response.Notifications, 1)
+
+		notification := response.Notifications[0]
+		assert.Equal(t, domain.TypeLike, notification.Type)
+		assert.Equal(t, tweetID, *notification.TweetID)
+		assert.Equal(t, 2, notification.ActorCount)
+		assert.Equal(t, fmt.Sprintf("user%d", actor2), notification.Actors[0].Username)
+		assert.Equal(t, fmt.Sprintf("user%d y 1 persona más le dieron like a tu tweet", actor2), notification.Message)
+		assert.False(t, notification.Read)
+	})
+
+	t.Run("Seguidores, Respuestas y Menciones", func(t *testing.T) {
+		publish(t, events.UserFollowed{FollowerID: actor1, FollowerUsername: fmt.Sprintf("user%d", actor1), FollowedID: recipient})
+		publish(t, events.TweetCreated{
+			TweetID:          tweetID + 1,
+			UserID:           actor2,
+			Username:         fmt.Sprintf("user%d", actor2),
+			InReplyToTweetID: &tweetID,
+			InReplyToUserID:  &recipient,
+			Mentions:         []events.Mention{{UserID: recipient, Username: fmt.Sprintf("user%d", recipient)}},
+		})
+
+		response := getNotifications(t, "?limit=2")
+		assert.Equal(t, int64(3), response.UnreadCount)
+		assert.Len(t, response.Notifications, 2)
+		// La respuesta que también lo menciona genera una sola notificación
+		assert.Equal(t, domain.TypeReply, response.Notifications[0].Type)
+		assert.Equal(t, domain.TypeFollow, response.Notifications[1].Type)
+		assert.NotEmpty(t, response.NextCursor)
+
+		response = getNotifications(t, "?limit=2&cursor="+response.NextCursor)
+		assert.Len(t, response.Notifications, 1)
+		assert.Equal(t, domain.TypeLike, response.Notifications[0].Type)
+		assert.Empty(t, response.NextCursor)
+	})
+
+	t.Run("Marcar como Leídas", func(t *testing.T) {
+		response := getNotifications(t, "")
+		likeID := response.Notifications[2].ID
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/notifications/read", strings.NewReader(fmt.Sprintf(`{"ids":[%d]}`, likeID)))
+		req.Header.Set("Authorization", bearerFor(recipient))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"marked_read": 1, "unread_count": 2}`, w.Body.String())
+
+		// Un like nuevo después de leer inicia otro grupo
+		like(t, base+3)
+		response = getNotifications(t, "?unread=true")
+		assert.Len(t, response.Notifications, 3)
+		assert.Equal(t, 1, response.Notifications[0].ActorCount)
+
+		// Sin cuerpo se marcan todas
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("POST", "/notifications/read", nil)
+		req.Header.Set("Authorization", bearerFor(recipient))
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"marked_read": 3, "unread_count": 0}`, w.Body.String())
+
+		// Un evento que el broker entrega de nuevo no vuelve a notificar, aunque su grupo ya se leyó
+		deliver(t, firstLike)
+
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("GET", "/notifications/unread-count", nil)
+		req.Header.Set("Authorization", bearerFor(recipient))
+		router.ServeHTTP(w, req)
+		assert.JSONEq(t, `{"unread_count": 0}`, w.Body.String())
+	})
+
+	t.Run("Tweet Eliminado", func(t *testing.T) {
+		publish(t, events.TweetDeleted{TweetID: tweetID, UserID: recipient})
+
+		response := getNotifications(t, "")
+		for _, notification := range response.Notifications {
+			assert.NotEqual(t, domain.TypeLike, notification.Type)
+		}
+	})
+
+	t.Run("Autenticación", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/notifications", nil)
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
@@ -0,0 +1,14 @@
+package api
+
+import (
+	"github.com/DevOpslp/microblogging-platform/auth"
+	"github.com/gin-gonic/gin"
+)
+
+func SetupRoutes(router *gin.Engine, handler *NotificationHandler, tokens *auth.TokenManager) {
+	// Notificaciones del usuario autenticado
+	notifications := router.Group("/notifications", auth.Middleware(tokens))
+	notifications.GET("", handler.GetNotifications)
+	notifications.GET("/unread-count", handler.GetUnreadCount)
+	notifications.POST("/read", handler.MarkRead)
+}
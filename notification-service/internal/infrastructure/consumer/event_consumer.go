@@ -0,0 +1,117 @@
+package consumer
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/DevOpslp/microblogging-platform/events"
+	"github.com/DevOpslp/microblogging-platform/notification-service/internal/domain"
+	"github.com/DevOpslp/microblogging-platform/notification-service/internal/infrastructure/persistence"
+)
+
+// Nombre del consumidor durable; las instancias de notification-service se reparten los eventos
+const Name = "notification-service"
+
+// EventConsumer genera las notificaciones a partir de los eventos que user-service y tweet-service
+// publican desde su outbox. Cada evento se aplica una sola vez aunque el broker lo entregue de nuevo.
+type EventConsumer struct {
+	repo *persistence.NotificationRepository
+}
+
+func NewEventConsumer(repo *persistence.NotificationRepository) *EventConsumer {
+	return &EventConsumer{repo: repo}
+}
+
+// Subscribe registra el consumidor en broker para cada tipo de evento que genera notificaciones
+func (c *EventConsumer) Subscribe(broker events.Broker) error {
+	handlers := []struct {
+		eventType string
+		handler   events.Handler
+	}{
+		{events.TypeUserFollowed, c.userFollowed},
+		{events.TypeTweetCreated, c.tweetCreated},
+		{events.TypeTweetLiked, c.tweetLiked},
+		{events.TypeTweetDeleted, c.tweetDeleted},
+	}
+	for _, h := range handlers {
+		if err := broker.Subscribe(Name, h.eventType, h.handler); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *EventConsumer) userFollowed(_ context.Context, event events.Event) error {
+	var payload events.UserFollowed
+	if !decode(event, &payload) {
+		return nil
+	}
+	return c.addNotifications(event, domain.UserFollowedNotification(payload))
+}
+
+// tweetCreated notifica a los usuarios respondidos, citados, retuiteados o mencionados
+func (c *EventConsumer) tweetCreated(_ context.Context, event events.Event) error {
+	var payload events.TweetCreated
+	if !decode(event, &payload) {
+		return nil
+	}
+	return c.addNotifications(event, domain.TweetCreatedNotifications(payload)...)
+}
+
+func (c *EventConsumer) tweetLiked(_ context.Context, event events.Event) error {
+	var payload events.TweetLiked
+	if !decode(event, &payload) {
+		return nil
+	}
+	return c.addNotifications(event, domain.TweetLikedNotification(payload))
+}
+
+// tweetDeleted borra las notificaciones que referencian el tweet eliminado
+func (c *EventConsumer) tweetDeleted(_ context.Context, event events.Event) error {
+	var payload events.TweetDeleted
+	if !decode(event, &payload) {
+		return nil
+	}
+	return c.repo.Consume(event.ID, func(repo *persistence.NotificationRepository) error {
+		return repo.DeleteTweetNotifications(payload.TweetID)
+	})
+}
+
+func (c *EventConsumer) addNotifications(event events.Event, notifications ...domain.NotificationEvent) error {
+	return c.repo.Consume(event.ID, func(repo *persistence.NotificationRepository) error {
+		for _, notification := range notifications {
+			if err := repo.AddNotification(notification); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// decode lee el contenido del evento. Un evento que no se puede leer no se reintenta: solo se registra.
+func decode(event events.Event, payload events.Payload) bool {
+	if err := event.Decode(payload); err != nil {
+		log.Printf("Evento %s inválido (%s): %v", event.ID, event.Type, err)
+		return false
+	}
+	return true
+}
+
+// PurgeProcessed borra periódicamente los eventos aplicados más antiguos que
+// domain.ProcessedEventRetention, hasta que ctx se cancela
+func (c *EventConsumer) PurgeProcessed(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if _, err := c.repo.PurgeProcessedEvents(now.Add(-domain.ProcessedEventRetention)); err != nil {
+				log.Printf("Error al purgar los eventos procesados: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package persistence
+
+import (
+	"errors"
+	"time"
+
+	"github.com/DevOpslp/microblogging-platform/notification-service/internal/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Cantidad de actores más recientes que se cargan por notificación al listarlas
+const listedActors = 3
+
+type NotificationRepository struct {
+	db *gorm.DB
+}
+
+func NewNotificationRepository(db *gorm.DB) *NotificationRepository {
+	return &NotificationRepository{db: db}
+}
+
+// AddNotification registra un evento para su destinatario. Si hay una notificación sin leer con la misma
+// GroupKey el actor se suma a ella; si no, se crea una nueva. Repetir el mismo evento no tiene efecto,
+// por lo que los eventos pueden reenviarse sin duplicar notificaciones.
+func (repo *NotificationRepository) AddNotification(event domain.NotificationEvent) error {
+	if event.RecipientID == 0 || event.ActorID == 0 || event.IsSelf() {
+		return nil
+	}
+
+	return repo.db.Transaction(func(tx *gorm.DB) error {
+		notification, err := findOrCreateGroup(tx, event)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		actor := domain.NotificationActor{
+			NotificationID: notification.ID,
+			ActorID:        event.ActorID,
+			ActorUsername:  event.ActorUsername,
+			CreatedAt:      now,
+		}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&actor)
+		if result.Error != nil || result.RowsAffected == 0 {
+			// El actor ya estaba en la notificación
+			return result.Error
+		}
+
+		return tx.Model(&domain.Notification{}).Where("id = ?", notification.ID).Updates(map[string]interface{}{
+			"actor_count": gorm.Expr("actor_count + 1"),
+			"updated_at":  now,
+		}).Error
+	})
+}
+
+// findOrCreateGroup bloquea la notificación sin leer del grupo del evento, creándola si no existe.
+// El índice único parcial sobre (user_id, group_key) evita que dos eventos simultáneos creen dos grupos.
+func findOrCreateGroup(tx *gorm.DB, event domain.NotificationEvent) (*domain.Notification, error) {
+	var notification domain.Notification
+	find := func() error {
+		return tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ? AND group_key = ? AND read_at IS NULL", event.RecipientID, event.GroupKey()).
+			First(&notification).Error
+	}
+
+	err := find()
+	if err == nil {
+		return &notification, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	notification = domain.Notification{
+		UserID:   event.RecipientID,
+		Type:     event.Type,
+		GroupKey: event.GroupKey(),
+		TweetID:  event.TweetID,
+	}
+	result := tx.Clauses(clause.OnConflict{
+		Columns:     []clause.Column{{Name: "user_id"}, {Name: "group_key"}},
+		TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "read_at IS NULL"}}},
+		DoNothing:   true,
+	}).Create(&notification)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 1 {
+		return &notification, nil
+	}
+
+	// Otro evento creó el grupo en paralelo
+	notification = domain.Notification{}
+	if err := find(); err != nil {
+		return nil, err
+	}
+	return &notification, nil
+}
+
+// Obtener una página de las notificaciones de un usuario, de la actualizada más recientemente a la más antigua.
+// Devuelve el cursor de la página siguiente, o nil si no hay más notificaciones.
+func (repo *NotificationRepository) GetNotifications(userID uint, unreadOnly bool, cursor *domain.Cursor, limit int) ([]domain.Notification, *domain.Cursor, error) {
+	query := repo.db.Where("user_id = ?", userID)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+	if cursor != nil {
+		query = query.Where("(updated_at, id) < (?, ?)", cursor.UpdatedAt, cursor.ID)
+	}
+
+	var notifications []domain.Notification
+	if err := query.Order("updated_at DESC, id DESC").Limit(limit + 1).Find(&notifications).Error; err != nil {
+		return nil, nil, err
+	}
+
+	var next *domain.Cursor
+	if len(notifications) > limit {
+		notifications = notifications[:limit]
+		position := notifications[limit-1].Cursor()
+		next = &position
+	}
+
+	if err := repo.loadActors(notifications); err != nil {
+		return nil, nil, err
+	}
+	return notifications, next, nil
+}
+
+// loadActors carga en una sola consulta los actores más recientes de cada notificación
+func (repo *NotificationRepository) loadActors(notifications []domain.Notification) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, len(notifications))
+	for i, notification := range notifications {
+		ids[i] = notification.ID
+	}
+
+	var actors []domain.NotificationActor
+	err := repo.db.Raw(`
+		SELECT notification_id, actor_id, actor_username, created_at FROM (
+			SELECT *, ROW_NUMBER() OVER (PARTITION BY notification_id ORDER BY created_at DESC, actor_id DESC) AS position
+			FROM notification_actors WHERE notification_id IN ?
+		) ranked
+		WHERE position <= ?
+		ORDER BY notification_id, position`, ids, listedActors).
+		Scan(&actors).Error
+	if err != nil {
+		return err
+	}
+
+	byNotification := make(map[uint][]domain.NotificationActor, len(notifications))
+	for _, actor := range actors {
+		byNotification[actor.NotificationID] = append(byNotification[actor.NotificationID], actor)
+	}
+	for i := range notifications {
+		notifications[i].Actors = byNotification[notifications[i].ID]
+	}
+	return nil
+}
+
+// Cantidad de notificaciones sin leer de un usuario
+func (repo *NotificationRepository) CountUnread(userID uint) (int64, error) {
+	var count int64
+	err := repo.db.Model(&domain.Notification{}).Where("user_id = ? AND read_at IS NULL", userID).Count(&count).Error
+	return count, err
+}
+
+// MarkRead marca como leídas las notificaciones indicadas del usuario, o todas si ids está vacío.
+// Devuelve cuántas notificaciones cambiaron; las ajenas o ya leídas se ignoran.
+func (repo *NotificationRepository) MarkRead(userID uint, ids []uint) (int64, error) {
+	query := repo.db.Model(&domain.Notification{}).Where("user_id = ? AND read_at IS NULL", userID)
+	if len(ids) > 0 {
+		query = query.Where("id IN ?", ids)
+	}
+	// UpdateColumn no modifica updated_at, para no reordenar el listado al leer
+	result := query.UpdateColumn("read_at", time.Now())
+	return result.RowsAffected, result.Error
+}
+
+// DeleteTweetNotifications elimina las notificaciones sobre un tweet eliminado
+func (repo *NotificationRepository) DeleteTweetNotifications(tweetID uint) error {
+	return repo.db.Transaction(func(tx *gorm.DB) error {
+		ids := tx.Model(&domain.Notification{}).Select("id").Where("tweet_id = ?", tweetID)
+		if err := tx.Where("notification_id IN (?)", ids).Delete(&domain.NotificationActor{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("tweet_id = ?", tweetID).Delete(&domain.Notification{}).Error
+	})
+}
+
+// Consume ejecuta apply con un repositorio ligado a una transacción que también registra eventID.
+// Si el evento ya se había aplicado no hace nada, por lo que un evento reenviado por el broker no
+// vuelve a generar notificaciones aunque la anterior ya se haya leído.
+func (repo *NotificationRepository) Consume(eventID string, apply func(repo *NotificationRepository) error) error {
+	return repo.db.Transaction(func(tx *gorm.DB) error {
+		processed := domain.ProcessedEvent{EventID: eventID, ProcessedAt: time.Now()}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&processed)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return apply(&NotificationRepository{db: tx})
+	})
+}
+
+// PurgeProcessedEvents olvida los eventos aplicados antes de before y devuelve cuántos se borraron
+func (repo *NotificationRepository) PurgeProcessedEvents(before time.Time) (int64, error) {
+	result := repo.db.Where("processed_at < ?", before).Delete(&domain.ProcessedEvent{})
+	return result.RowsAffected, result.Error
+}
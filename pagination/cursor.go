@@ -0,0 +1,141 @@
+// Package pagination contiene la paginación por cursor compartida por todos los servicios.
+// Los cursores son opacos y van firmados con HMAC-SHA256 usando PAGINATION_SECRET, para que un cliente
+// no pueda fabricarlos ni modificarlos.
+package pagination
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+var (
+	ErrInvalidCursor = errors.New("cursor inválido")
+	ErrInvalidLimit  = fmt.Errorf("limit debe ser un número entre 1 y %d", MaxLimit)
+	ErrMissingSecret = errors.New("PAGINATION_SECRET no configurado")
+)
+
+// SecretFromEnv lee PAGINATION_SECRET. No hay valor por defecto: con un secreto conocido cualquiera
+// podría fabricar cursores, así que los servicios no deben arrancar sin él.
+func SecretFromEnv() (string, error) {
+	secret := os.Getenv("PAGINATION_SECRET")
+	if secret == "" {
+		return "", ErrMissingSecret
+	}
+	return secret, nil
+}
+
+// Signer firma y verifica cursores con un secreto
+type Signer struct {
+	secret []byte
+}
+
+func NewSigner(secret string) *Signer {
+	return &Signer{secret: []byte(secret)}
+}
+
+// Encode serializa los campos como "<payload>.<firma>" en base64 URL-safe, con los campos separados por ":"
+func (s *Signer) Encode(fields ...string) string {
+	payload := strings.Join(fields, ":")
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." + s.sign(payload)
+}
+
+// Decode comprueba la firma del cursor y devuelve sus campos, que deben ser fieldCount
+func (s *Signer) Decode(value string, fieldCount int) ([]string, error) {
+	encoded, signature, found := strings.Cut(value, ".")
+	if !found {
+		return nil, ErrInvalidCursor
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	payload := string(raw)
+	if !hmac.Equal([]byte(signature), []byte(s.sign(payload))) {
+		return nil, ErrInvalidCursor
+	}
+
+	fields := strings.Split(payload, ":")
+	if len(fields) != fieldCount {
+		return nil, ErrInvalidCursor
+	}
+	return fields, nil
+}
+
+// EncodeTimeID serializa el cursor más común: la fecha y el ID del último elemento de la página
+func (s *Signer) EncodeTimeID(at time.Time, id uint) string {
+	return s.Encode(FormatTime(at), FormatID(id))
+}
+
+// DecodeTimeID es el inverso de EncodeTimeID
+func (s *Signer) DecodeTimeID(value string) (time.Time, uint, error) {
+	fields, err := s.Decode(value, 2)
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+	at, err := ParseTime(fields[0])
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+	id, err := ParseID(fields[1])
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+	return at, id, nil
+}
+
+func (s *Signer) sign(payload string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:12])
+}
+
+// ParseLimit valida el parámetro limit; vacío usa DefaultLimit
+func ParseLimit(value string) (int, error) {
+	if value == "" {
+		return DefaultLimit, nil
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 1 || limit > MaxLimit {
+		return 0, ErrInvalidLimit
+	}
+	return limit, nil
+}
+
+// FormatTime serializa una fecha de un cursor en microsegundos, la precisión de PostgreSQL
+func FormatTime(at time.Time) string {
+	return strconv.FormatInt(at.UnixMicro(), 10)
+}
+
+// ParseTime lee una fecha serializada con FormatTime
+func ParseTime(field string) (time.Time, error) {
+	micros, err := strconv.ParseInt(field, 10, 64)
+	if err != nil {
+		return time.Time{}, ErrInvalidCursor
+	}
+	return time.UnixMicro(micros).UTC(), nil
+}
+
+func FormatID(id uint) string {
+	return strconv.FormatUint(uint64(id), 10)
+}
+
+// ParseID lee un ID de un cursor; los IDs empiezan en 1
+func ParseID(field string) (uint, error) {
+	id, err := strconv.ParseUint(field, 10, 64)
+	if err != nil || id == 0 {
+		return 0, ErrInvalidCursor
+	}
+	return uint(id), nil
+}
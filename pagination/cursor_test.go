@@ -0,0 +1,61 @@
+package pagination
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecretFromEnv(t *testing.T) {
+	// Sin PAGINATION_SECRET los servicios no deben arrancar con un secreto conocido
+	t.Setenv("PAGINATION_SECRET", "")
+	_, err := SecretFromEnv()
+	assert.ErrorIs(t, err, ErrMissingSecret)
+
+	t.Setenv("PAGINATION_SECRET", "secreto-de-prueba")
+	secret, err := SecretFromEnv()
+	assert.NoError(t, err)
+	assert.Equal(t, "secreto-de-prueba", secret)
+}
+
+func TestTimeIDRoundTrip(t *testing.T) {
+	signer := NewSigner("secreto-de-prueba")
+	at := time.Date(2024, 5, 1, 12, 30, 0, 123456000, time.UTC)
+
+	decodedAt, id, err := signer.DecodeTimeID(signer.EncodeTimeID(at, 42))
+	assert.NoError(t, err)
+	assert.True(t, at.Equal(decodedAt))
+	assert.Equal(t, uint(42), id)
+}
+
+func TestDecodeRejectsInvalidCursors(t *testing.T) {
+	signer := NewSigner("secreto-de-prueba")
+	valid := signer.EncodeTimeID(time.Now(), 7)
+	forged := signer.EncodeTimeID(time.Now(), 8)
+	tampered := forged[:len(forged)-16] + valid[len(valid)-16:]
+
+	for _, value := range []string{"", "abc", "abc.def", tampered, valid + "x", signer.Encode("1", "0"), signer.Encode("1", "2", "3")} {
+		_, _, err := signer.DecodeTimeID(value)
+		assert.ErrorIs(t, err, ErrInvalidCursor, value)
+	}
+
+	// Un cursor firmado con otro secreto no es válido
+	_, _, err := NewSigner("otro-secreto").DecodeTimeID(valid)
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+}
+
+func TestParseLimit(t *testing.T) {
+	limit, err := ParseLimit("")
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultLimit, limit)
+
+	limit, err = ParseLimit("5")
+	assert.NoError(t, err)
+	assert.Equal(t, 5, limit)
+
+	for _, value := range []string{"0", "-1", "101", "abc"} {
+		_, err := ParseLimit(value)
+		assert.ErrorIs(t, err, ErrInvalidLimit, value)
+	}
+}
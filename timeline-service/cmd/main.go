@@ -1,20 +1,154 @@
 package main
 
 import (
+	"context"
 	"log"
+	"os"
+	"strconv"
+	"time"
 
+	"github.com/DevOpslp/microblogging-platform/auth"
+	"github.com/DevOpslp/microblogging-platform/events"
+	"github.com/DevOpslp/microblogging-platform/pagination"
 	"github.com/DevOpslp/microblogging-platform/timeline-service/internal/infrastructure/api"
+	"github.com/DevOpslp/microblogging-platform/timeline-service/internal/infrastructure/consumer"
+	"github.com/DevOpslp/microblogging-platform/timeline-service/internal/infrastructure/persistence"
+	"github.com/DevOpslp/microblogging-platform/timeline-service/internal/stream"
+	"github.com/DevOpslp/microblogging-platform/timeline-service/internal/trends"
 	"github.com/gin-gonic/gin"
 )
 
 func main() {
-	router := gin.Default()
+	// Secreto compartido para firmar y verificar tokens; sin él el servicio no arranca
+	authSecret, err := auth.SecretFromEnv()
+	if err != nil {
+		log.Fatalf("Error de configuración: %v", err)
+	}
+	// Secreto para firmar los cursores de paginación
+	paginationSecret, err := pagination.SecretFromEnv()
+	if err != nil {
+		log.Fatalf("Error de configuración: %v", err)
+	}
 
-	// Crear instancia de TimelineHandler
-	timelineHandler := api.NewTimelineHandler()
+	// Obtiene las URLs de los servicios desde las variables de entorno
+	tweetServiceURL := os.Getenv("TWEET_SERVICE_URL")
+	if tweetServiceURL == "" {
+		tweetServiceURL = "http://localhost:8081/tweets" // Valor predeterminado para desarrollo local
+	}
+	userServiceURL := os.Getenv("USER_SERVICE_URL")
+	if userServiceURL == "" {
+		userServiceURL = "http://localhost:8080" // Valor predeterminado para desarrollo local
+	}
 
-	// Configurar rutas con la instancia de handler
-	api.SetupRoutes(router, timelineHandler)
+	// Umbral de seguidores a partir del cual un autor se resuelve con fan-out-on-read (0 lo desactiva)
+	highFanoutThreshold := 0
+	if value := os.Getenv("FANOUT_THRESHOLD"); value != "" {
+		threshold, err := strconv.Atoi(value)
+		if err != nil || threshold < 0 {
+			log.Fatalf("FANOUT_THRESHOLD inválido: %q", value)
+		}
+		highFanoutThreshold = threshold
+	}
+
+	// Tendencias: ventana reciente, ventana de referencia y cada cuántos segundos se recalculan
+	trendsConfig := trends.DefaultConfig()
+	if value := os.Getenv("TRENDS_REFRESH_SECONDS"); value != "" {
+		seconds, err := strconv.Atoi(value)
+		if err != nil || seconds < 1 {
+			log.Fatalf("TRENDS_REFRESH_SECONDS inválido: %q", value)
+		}
+		trendsConfig.RefreshInterval = time.Duration(seconds) * time.Second
+	}
+	if value := os.Getenv("TRENDS_WINDOW"); value != "" {
+		window, err := time.ParseDuration(value)
+		if err != nil || window < trendsConfig.Bucket {
+			log.Fatalf("TRENDS_WINDOW inválido: %q", value)
+		}
+		trendsConfig.Window = window
+	}
+	if value := os.Getenv("TRENDS_BASELINE"); value != "" {
+		baseline, err := time.ParseDuration(value)
+		if err != nil || baseline < trendsConfig.Window {
+			log.Fatalf("TRENDS_BASELINE inválido: %q", value)
+		}
+		trendsConfig.Baseline = baseline
+	}
+
+	// Streaming del timeline: heartbeat, tweets pendientes por conexión y conexiones por instancia
+	streamConfig := stream.DefaultConfig()
+	if value := os.Getenv("STREAM_HEARTBEAT_SECONDS"); value != "" {
+		seconds, err := strconv.Atoi(value)
+		if err != nil || seconds < 1 {
+			log.Fatalf("STREAM_HEARTBEAT_SECONDS inválido: %q", value)
+		}
+		streamConfig.Heartbeat = time.Duration(seconds) * time.Second
+	}
+	if value := os.Getenv("STREAM_BUFFER"); value != "" {
+		buffer, err := strconv.Atoi(value)
+		if err != nil || buffer < 1 {
+			log.Fatalf("STREAM_BUFFER inválido: %q", value)
+		}
+		streamConfig.Buffer = buffer
+	}
+	if value := os.Getenv("STREAM_MAX_CONNECTIONS"); value != "" {
+		connections, err := strconv.Atoi(value)
+		if err != nil || connections < 0 {
+			log.Fatalf("STREAM_MAX_CONNECTIONS inválido: %q", value)
+		}
+		streamConfig.MaxConnections = connections
+	}
+
+	// El logger por defecto de Gin escribiría el token de acceso que los streams reciben en la query
+	router := gin.New()
+	router.Use(api.Logger(), gin.Recovery())
+	tokens := auth.NewTokenManager(authSecret)
+
+	// Crear los repositorios HTTP hacia user-service y tweet-service
+	userRepo := persistence.NewHTTPUserRepository(userServiceURL, tokens)
+	tweetRepo := persistence.NewHTTPTweetRepository(tweetServiceURL, tokens)
+
+	// Store de timelines precalculados: en memoria por defecto o Redis si TIMELINE_STORE=redis
+	var store persistence.TimelineStore
+	switch os.Getenv("TIMELINE_STORE") {
+	case "", "memory":
+		store = persistence.NewMemoryTimelineStore()
+	case "redis":
+		redisStore := persistence.NewRedisTimelineStore(os.Getenv("REDIS_ADDR"), os.Getenv("REDIS_PASSWORD"))
+		if err := redisStore.Ping(); err != nil {
+			log.Fatalf("No se pudo conectar a Redis: %v", err)
+		}
+		store = redisStore
+	default:
+		log.Fatalf("TIMELINE_STORE desconocido: %q", os.Getenv("TIMELINE_STORE"))
+	}
+
+	timelineRepo := persistence.NewTimelineRepository(store, userRepo, tweetRepo, highFanoutThreshold)
+
+	// Calcular las tendencias en segundo plano con los tweets que llegan por eventos a cada instancia
+	trendEngine := trends.NewEngine(trendsConfig, trends.SystemClock)
+	go trendEngine.Run(context.Background())
+
+	hub := stream.NewHub(streamConfig)
+
+	// Mantener los timelines con los eventos de tweet-service del broker configurado por EVENT_BROKER
+	broker, err := events.NewBrokerFromEnv(context.Background())
+	if err != nil {
+		log.Fatalf("Error al conectar con el broker de eventos: %v", err)
+	}
+	defer broker.Close()
+	eventConsumer := consumer.NewEventConsumer(timelineRepo, tweetRepo, trendEngine, hub)
+	if err := eventConsumer.Subscribe(broker); err != nil {
+		log.Fatalf("Error al suscribirse a los eventos: %v", err)
+	}
+	if err := eventConsumer.SubscribeInstance(broker); err != nil {
+		log.Fatalf("Error al suscribirse a los eventos: %v", err)
+	}
+
+	// Crear instancia de TimelineHandler con el hub que reparte los tweets nuevos a las conexiones abiertas
+	timelineHandler := api.NewTimelineHandler(timelineRepo, trendEngine, hub, pagination.NewSigner(paginationSecret))
+
+	// Configurar rutas con la instancia de handler y el secreto compartido de tokens
+	api.SetupRoutes(router, timelineHandler, tokens)
 
 	// Iniciar el servidor en el puerto 8082
 	log.Fatal(router.Run(":8082"))
@@ -0,0 +1,174 @@
+package domain
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Máximo de tweets de un mismo autor en una página del timeline ordenado por relevancia
+const MaxTweetsPerAuthor = 3
+
+// AuthorAffinity son las interacciones recientes del lector con un autor: likes a sus tweets y
+// respuestas dirigidas a él
+type AuthorAffinity struct {
+	Likes   int `json:"likes"`
+	Replies int `json:"replies"`
+}
+
+// Weight resume la afinidad en un número: cada respuesta vale como dos likes
+func (a AuthorAffinity) Weight() int {
+	return a.Likes + 2*a.Replies
+}
+
+// Engagement son los contadores actuales de un tweet en tweet-service
+type Engagement struct {
+	ReplyCount   int `json:"reply_count"`
+	LikeCount    int `json:"like_count"`
+	RetweetCount int `json:"retweet_count"`
+}
+
+// Candidate es un tweet que puede aparecer en el timeline ordenado, con las señales para puntuarlo.
+// SecondDegree indica que el autor no lo sigue el lector sino alguno de sus seguidos.
+type Candidate struct {
+	Tweet        Tweet
+	SecondDegree bool
+	Affinity     AuthorAffinity
+	Engagement   Engagement
+}
+
+// Scorer puntúa un candidato a la hora now; un puntaje mayor lo ubica antes en el timeline
+type Scorer interface {
+	Score(candidate Candidate, now time.Time) float64
+}
+
+// DefaultScorer combina la antigüedad del tweet, la afinidad con el autor y las interacciones:
+//
+//	puntaje = 2^(-edad/HalfLife) * (1 + AffinityWeight*ln(1+likes+2*respuestas) + EngagementWeight*ln(1+likes+2*(retweets+respuestas)))
+//
+// multiplicado por SecondDegreeFactor si el autor no lo sigue el lector. Solo depende del candidato y
+// de now, por lo que el resultado es determinista.
+type DefaultScorer struct {
+	HalfLife           time.Duration
+	AffinityWeight     float64
+	EngagementWeight   float64
+	SecondDegreeFactor float64
+}
+
+func NewDefaultScorer() DefaultScorer {
+	return DefaultScorer{
+		HalfLife:           6 * time.Hour,
+		AffinityWeight:     1,
+		EngagementWeight:   0.5,
+		SecondDegreeFactor: 0.5,
+	}
+}
+
+func (s DefaultScorer) Score(candidate Candidate, now time.Time) float64 {
+	age := max(now.Sub(candidate.Tweet.CreatedAt), 0)
+	recency := math.Exp2(-float64(age) / float64(s.HalfLife))
+
+	affinity := math.Log1p(float64(candidate.Affinity.Weight()))
+	engagement := candidate.Engagement
+	interactions := math.Log1p(float64(engagement.LikeCount + 2*(engagement.RetweetCount+engagement.ReplyCount)))
+
+	score := recency * (1 + s.AffinityWeight*affinity + s.EngagementWeight*interactions)
+	if candidate.SecondDegree {
+		score *= s.SecondDegreeFactor
+	}
+	return score
+}
+
+// Author devuelve el autor del contenido que muestra el tweet: el del original en un retweet
+func (t Tweet) Author() string {
+	if t.RetweetedTweet != nil {
+		return t.RetweetedTweet.Username
+	}
+	return t.Username
+}
+
+// Rank ordena los candidatos por puntaje (a igual puntaje, del más nuevo al más antiguo) y arma una
+// página de hasta limit tweets con reglas de diversidad: como máximo MaxTweetsPerAuthor por autor, y
+// dos tweets seguidos del mismo autor solo si no queda otro autor para intercalar
+func Rank(candidates []Candidate, scorer Scorer, now time.Time, limit int) []Tweet {
+	type scored struct {
+		tweet Tweet
+		score float64
+	}
+	remaining := make([]scored, 0, len(candidates))
+	for _, candidate := range candidates {
+		remaining = append(remaining, scored{tweet: candidate.Tweet, score: scorer.Score(candidate, now)})
+	}
+	sort.SliceStable(remaining, func(i, j int) bool {
+		if remaining[i].score != remaining[j].score {
+			return remaining[i].score > remaining[j].score
+		}
+		return remaining[i].tweet.Entry().Before(remaining[j].tweet.Entry())
+	})
+
+	page := make([]Tweet, 0, limit)
+	perAuthor := make(map[string]int)
+	previous := ""
+	for len(page) < limit {
+		pick, fallback := -1, -1
+		for i, candidate := range remaining {
+			author := candidate.tweet.Author()
+			if perAuthor[author] >= MaxTweetsPerAuthor {
+				continue
+			}
+			if author != previous {
+				pick = i
+				break
+			}
+			if fallback < 0 {
+				fallback = i
+			}
+		}
+		if pick < 0 {
+			pick = fallback
+		}
+		if pick < 0 {
+			break
+		}
+
+		tweet := remaining[pick].tweet
+		remaining = append(remaining[:pick], remaining[pick+1:]...)
+		page = append(page, tweet)
+		perAuthor[tweet.Author()]++
+		previous = tweet.Author()
+	}
+	return page
+}
+
+// RankPage devuelve la página del timeline ordenado que empieza en la posición offset e indica si
+// quedan candidatos para más páginas. Las páginas se arman una tras otra con Rank sobre los candidatos
+// que no salieron en las anteriores, así cada una cumple las reglas de diversidad; offset es la suma
+// de los tamaños de las páginas anteriores pedidas con el mismo limit.
+func RankPage(candidates []Candidate, scorer Scorer, now time.Time, offset, limit int) ([]Tweet, bool) {
+	start := 0
+	remaining := candidates
+	for len(remaining) > 0 {
+		page := Rank(remaining, scorer, now, limit)
+		if len(page) == 0 {
+			break
+		}
+
+		picked := make(map[uint]bool, len(page))
+		for _, tweet := range page {
+			picked[tweet.ID] = true
+		}
+		next := make([]Candidate, 0, len(remaining)-len(page))
+		for _, candidate := range remaining {
+			if !picked[candidate.Tweet.ID] {
+				next = append(next, candidate)
+			}
+		}
+		remaining = next
+
+		if start+len(page) > offset {
+			return page[max(offset-start, 0):], len(remaining) > 0
+		}
+		start += len(page)
+	}
+	return []Tweet{}, false
+}
@@ -0,0 +1,126 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var rankingNow = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func candidate(id uint, author string, age time.Duration) Candidate {
+	return Candidate{Tweet: Tweet{ID: id, Username: author, CreatedAt: rankingNow.Add(-age)}}
+}
+
+func tweetIDs(tweets []Tweet) []uint {
+	ids := make([]uint, 0, len(tweets))
+	for _, tweet := range tweets {
+		ids = append(ids, tweet.ID)
+	}
+	return ids
+}
+
+func TestDefaultScorer(t *testing.T) {
+	scorer := NewDefaultScorer()
+
+	fresh := candidate(1, "ana", 0)
+	assert.Equal(t, 1.0, scorer.Score(fresh, rankingNow))
+
+	// Cada HalfLife el puntaje se reduce a la mitad
+	assert.InDelta(t, 0.5, scorer.Score(candidate(2, "ana", 6*time.Hour), rankingNow), 1e-9)
+	assert.InDelta(t, 0.25, scorer.Score(candidate(3, "ana", 12*time.Hour), rankingNow), 1e-9)
+
+	// Un tweet con fecha futura cuenta como recién publicado
+	assert.Equal(t, 1.0, scorer.Score(candidate(4, "ana", -time.Hour), rankingNow))
+
+	liked := fresh
+	liked.Affinity = AuthorAffinity{Likes: 1, Replies: 1}
+	popular := fresh
+	popular.Engagement = Engagement{LikeCount: 10, RetweetCount: 5, ReplyCount: 5}
+	assert.Greater(t, scorer.Score(liked, rankingNow), scorer.Score(fresh, rankingNow))
+	assert.Greater(t, scorer.Score(popular, rankingNow), scorer.Score(fresh, rankingNow))
+
+	secondDegree := fresh
+	secondDegree.SecondDegree = true
+	assert.Equal(t, 0.5, scorer.Score(secondDegree, rankingNow))
+
+	// El mismo candidato siempre tiene el mismo puntaje
+	assert.Equal(t, scorer.Score(popular, rankingNow), scorer.Score(popular, rankingNow))
+}
+
+func TestRank(t *testing.T) {
+	scorer := NewDefaultScorer()
+
+	t.Run("Afinidad e interacciones compiten con la antigüedad", func(t *testing.T) {
+		recent := candidate(1, "ana", time.Hour)
+		friend := candidate(2, "bea", 3*time.Hour)
+		friend.Affinity = AuthorAffinity{Likes: 5, Replies: 2}
+		old := candidate(3, "carla", 48*time.Hour)
+		old.Engagement = Engagement{LikeCount: 1000}
+
+		ranked := Rank([]Candidate{recent, friend, old}, scorer, rankingNow, 10)
+		assert.Equal(t, []uint{2, 1, 3}, tweetIDs(ranked))
+	})
+
+	t.Run("Empates del más nuevo al más antiguo", func(t *testing.T) {
+		a := candidate(1, "ana", time.Hour)
+		b := candidate(2, "bea", time.Hour)
+		ranked := Rank([]Candidate{a, b}, scorer, rankingNow, 10)
+		assert.Equal(t, []uint{2, 1}, tweetIDs(ranked))
+	})
+
+	t.Run("Un autor no llena la página", func(t *testing.T) {
+		candidates := make([]Candidate, 0)
+		for i := uint(1); i <= 6; i++ {
+			candidates = append(candidates, candidate(i, "ana", time.Duration(i)*time.Minute))
+		}
+		candidates = append(candidates, candidate(10, "bea", 2*time.Hour), candidate(11, "bea", 3*time.Hour))
+
+		ranked := Rank(candidates, scorer, rankingNow, 10)
+		// Se intercalan los autores mientras haya otro disponible y ana no pasa de MaxTweetsPerAuthor
+		assert.Equal(t, []uint{1, 10, 2, 11, 3}, tweetIDs(ranked))
+	})
+
+	t.Run("Los retweets cuentan para el autor original", func(t *testing.T) {
+		original := candidate(1, "ana", time.Hour)
+		retweet := candidate(2, "bea", 0)
+		retweet.Tweet.RetweetedTweet = &Tweet{ID: 5, Username: "ana"}
+		other := candidate(3, "carla", 5*time.Hour)
+
+		ranked := Rank([]Candidate{original, retweet, other}, scorer, rankingNow, 10)
+		assert.Equal(t, []uint{2, 3, 1}, tweetIDs(ranked))
+	})
+
+	t.Run("Respeta el límite", func(t *testing.T) {
+		ranked := Rank([]Candidate{candidate(1, "ana", 0), candidate(2, "bea", 0), candidate(3, "carla", 0)}, scorer, rankingNow, 2)
+		assert.Len(t, ranked, 2)
+		assert.Empty(t, Rank(nil, scorer, rankingNow, 10))
+	})
+}
+
+func TestRankPage(t *testing.T) {
+	scorer := NewDefaultScorer()
+	candidates := make([]Candidate, 0)
+	for i := uint(1); i <= 6; i++ {
+		candidates = append(candidates, candidate(i, "ana", time.Duration(i)*time.Minute))
+	}
+	candidates = append(candidates, candidate(10, "bea", 2*time.Hour), candidate(11, "bea", 3*time.Hour))
+
+	// La primera página es la de Rank; las siguientes siguen con los candidatos que quedaron afuera
+	first, more := RankPage(candidates, scorer, rankingNow, 0, 4)
+	assert.Equal(t, []uint{1, 10, 2, 11}, tweetIDs(first))
+	assert.True(t, more)
+
+	second, more := RankPage(candidates, scorer, rankingNow, len(first), 4)
+	assert.Equal(t, []uint{3, 4, 5}, tweetIDs(second), "Cada página tiene su propio máximo por autor")
+	assert.True(t, more)
+
+	third, more := RankPage(candidates, scorer, rankingNow, len(first)+len(second), 4)
+	assert.Equal(t, []uint{6}, tweetIDs(third))
+	assert.False(t, more)
+
+	last, more := RankPage(candidates, scorer, rankingNow, 100, 4)
+	assert.Empty(t, last)
+	assert.False(t, more)
+}
@@ -2,10 +2,147 @@ package domain
 
 import "time"
 
+// Tweet tal como lo publica tweet-service. En un retweet Username es quien retuiteó y RetweetedTweet
+// embebe el original; en una cita QuotedTweet embebe el tweet citado. RevisionCount es la cantidad de
+// versiones del contenido (1 si nunca se editó). Deleted marca el tombstone que reemplaza a un tweet
+// eliminado para mostrarlo así en las citas.
 type Tweet struct {
-	ID        uint      `json:"id"`
-	Username  string    `json:"username"`
-	Content   string    `json:"content"`
+	ID               uint       `json:"id"`
+	Username         string     `json:"username"`
+	Content          string     `json:"content"`
+	Entities         *Entities  `json:"entities,omitempty"`
+	Media            []Media    `json:"media,omitempty"`
+	RetweetOfTweetID *uint      `json:"retweet_of_tweet_id,omitempty"`
+	RetweetedTweet   *Tweet     `json:"retweeted_tweet,omitempty"`
+	QuotedTweetID    *uint      `json:"quoted_tweet_id,omitempty"`
+	QuotedTweet      *Tweet     `json:"quoted_tweet,omitempty"`
+	Edited           bool       `json:"edited"`
+	RevisionCount    int        `json:"revision_count"`
+	EditedAt         *time.Time `json:"edited_at,omitempty"`
+	Deleted          bool       `json:"deleted,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+// Entidades reconocidas por tweet-service en el contenido de un tweet
+type Entities struct {
+	Hashtags []HashtagEntity `json:"hashtags"`
+	Mentions []MentionEntity `json:"mentions"`
+}
+
+type HashtagEntity struct {
+	Tag   string `json:"tag"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+}
+
+// Imagen adjunta; URL y ThumbnailURL son rutas de tweet-service
+type Media struct {
+	ID           uint   `json:"media_id"`
+	Type         string `json:"type"`
+	Width        int    `json:"width"`
+	Height       int    `json:"height"`
+	AltText      string `json:"alt_text"`
+	URL          string `json:"url"`
+	ThumbnailURL string `json:"thumbnail_url"`
+}
+
+// Usuario mencionado, ya resuelto por tweet-service al crear el tweet
+type MentionEntity struct {
+	UserID   uint   `json:"user_id"`
+	Username string `json:"username"`
+	Start    int    `json:"start"`
+	End      int    `json:"end"`
+}
+
+// MentionedUsernames devuelve los usuarios mencionados por el tweet, sin repetir
+func (t Tweet) MentionedUsernames() []string {
+	usernames := make([]string, 0)
+	if t.Entities == nil {
+		return usernames
+	}
+	seen := make(map[string]bool, len(t.Entities.Mentions))
+	for _, mention := range t.Entities.Mentions {
+		if !seen[mention.Username] {
+			seen[mention.Username] = true
+			usernames = append(usernames, mention.Username)
+		}
+	}
+	return usernames
+}
+
+// NewerThan indica si el tweet es una versión editada posterior a other
+func (t Tweet) NewerThan(other Tweet) bool {
+	return t.RevisionCount > other.RevisionCount
+}
+
+// TombstoneText es el contenido que muestra tweet-service en lugar de un tweet eliminado
+const TombstoneText = "Este tweet fue eliminado"
+
+// Tombstone devuelve el tweet eliminado sin autor, contenido, entidades ni imágenes
+func (t Tweet) Tombstone() Tweet {
+	return Tweet{ID: t.ID, Content: TombstoneText, Deleted: true, CreatedAt: t.CreatedAt, UpdatedAt: t.UpdatedAt}
+}
+
+// OriginalID devuelve el ID del contenido que muestra el tweet: el original en un retweet, o el propio ID
+func (t Tweet) OriginalID() uint {
+	if t.RetweetOfTweetID != nil {
+		return *t.RetweetOfTweetID
+	}
+	return t.ID
+}
+
+// Entrada de un timeline precalculado: solo se guarda la referencia al tweet y su fecha para ordenar
+type TimelineEntry struct {
+	TweetID   uint      `json:"tweet_id"`
 	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (t Tweet) Entry() TimelineEntry {
+	return TimelineEntry{TweetID: t.ID, CreatedAt: t.CreatedAt}
+}
+
+// Indica si la entrada va antes que otra en un timeline ordenado del más nuevo al más antiguo
+func (e TimelineEntry) Before(other TimelineEntry) bool {
+	if e.CreatedAt.Equal(other.CreatedAt) {
+		return e.TweetID > other.TweetID
+	}
+	return e.CreatedAt.After(other.CreatedAt)
+}
+
+// Authors devuelve, sin repetir, los autores de los tweets y de los tweets que embeben
+func Authors(tweets []Tweet) []string {
+	authors := make([]string, 0)
+	seen := make(map[string]bool)
+	add := func(tweet *Tweet) {
+		if tweet != nil && !seen[tweet.Username] {
+			seen[tweet.Username] = true
+			authors = append(authors, tweet.Username)
+		}
+	}
+	for i := range tweets {
+		add(&tweets[i])
+		add(tweets[i].RetweetedTweet)
+		add(tweets[i].QuotedTweet)
+	}
+	return authors
+}
+
+// HideAuthors quita los tweets de los autores ocultos y los retweets de sus tweets; una cita de un
+// tweet oculto se mantiene sin embeber el tweet citado
+func HideAuthors(tweets []Tweet, hidden map[string]bool) []Tweet {
+	visible := make([]Tweet, 0, len(tweets))
+	for _, tweet := range tweets {
+		if hidden[tweet.Username] {
+			continue
+		}
+		if tweet.RetweetedTweet != nil && hidden[tweet.RetweetedTweet.Username] {
+			continue
+		}
+		if tweet.QuotedTweet != nil && hidden[tweet.QuotedTweet.Username] {
+			tweet.QuotedTweet = nil
+		}
+		visible = append(visible, tweet)
+	}
+	return visible
 }
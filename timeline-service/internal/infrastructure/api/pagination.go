@@ -0,0 +1,78 @@
+package api
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/DevOpslp/microblogging-platform/pagination"
+	"github.com/DevOpslp/microblogging-platform/timeline-service/internal/domain"
+	"github.com/gin-gonic/gin"
+)
+
+// parsePageParams lee los parámetros `cursor` y `limit` de la query
+func parsePageParams(c *gin.Context, cursors *pagination.Signer) (*domain.TimelineEntry, int, error) {
+	limit, err := pagination.ParseLimit(c.Query("limit"))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	value := c.Query("cursor")
+	if value == "" {
+		return nil, limit, nil
+	}
+	createdAt, tweetID, err := cursors.DecodeTimeID(value)
+	if err != nil {
+		return nil, 0, err
+	}
+	return &domain.TimelineEntry{CreatedAt: createdAt, TweetID: tweetID}, limit, nil
+}
+
+// encodeCursor firma el cursor de la página siguiente; vacío si no hay más resultados
+func encodeCursor(cursors *pagination.Signer, cursor *domain.TimelineEntry) string {
+	if cursor == nil {
+		return ""
+	}
+	return cursors.EncodeTimeID(cursor.CreatedAt, cursor.TweetID)
+}
+
+// rankedCursor es la posición en el timeline ordenado por relevancia: la hora con que se ordenó la
+// primera página y cuántos tweets se devolvieron hasta ahora
+type rankedCursor struct {
+	AsOf   time.Time
+	Offset int
+}
+
+// Primer campo de los cursores del timeline ordenado, para no confundirlos con los cronológicos
+const rankedCursorKind = "ranked"
+
+// parseRankedPageParams lee `cursor` y `limit` del timeline ordenado; sin cursor es la primera página
+// ordenada a la hora now
+func parseRankedPageParams(c *gin.Context, cursors *pagination.Signer, now time.Time) (rankedCursor, int, error) {
+	limit, err := pagination.ParseLimit(c.Query("limit"))
+	if err != nil {
+		return rankedCursor{}, 0, err
+	}
+
+	value := c.Query("cursor")
+	if value == "" {
+		return rankedCursor{AsOf: now}, limit, nil
+	}
+	fields, err := cursors.Decode(value, 3)
+	if err != nil || fields[0] != rankedCursorKind {
+		return rankedCursor{}, 0, pagination.ErrInvalidCursor
+	}
+	asOf, err := pagination.ParseTime(fields[1])
+	if err != nil {
+		return rankedCursor{}, 0, err
+	}
+	offset, err := strconv.Atoi(fields[2])
+	if err != nil || offset < 1 {
+		return rankedCursor{}, 0, pagination.ErrInvalidCursor
+	}
+	return rankedCursor{AsOf: asOf, Offset: offset}, limit, nil
+}
+
+// encodeRankedCursor firma el cursor de la página siguiente del timeline ordenado
+func encodeRankedCursor(cursors *pagination.Signer, cursor rankedCursor) string {
+	return cursors.Encode(rankedCursorKind, pagination.FormatTime(cursor.AsOf), strconv.Itoa(cursor.Offset))
+}
@@ -1,9 +1,69 @@
 package api
 
 import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/DevOpslp/microblogging-platform/auth"
 	"github.com/gin-gonic/gin"
 )
 
-func SetupRoutes(router *gin.Engine, handler *TimelineHandler) {
-	router.GET("/timeline", handler.GetTimeline)
+func SetupRoutes(router *gin.Engine, handler *TimelineHandler, tokens *auth.TokenManager) {
+	router.GET("/timeline", auth.Middleware(tokens), handler.GetTimeline)
+	router.GET("/timeline/mentions", auth.Middleware(tokens), handler.GetMentions)
+	router.GET("/timeline/stream", tokenFromQuery, auth.Middleware(tokens), handler.StreamTimeline)
+	router.GET("/timeline/ws", tokenFromQuery, auth.Middleware(tokens), handler.StreamTimelineWebSocket)
+	router.GET("/trends", handler.GetTrends)
+}
+
+// tokenFromQuery acepta el token de acceso en ?access_token=. EventSource y WebSocket en el navegador
+// no permiten enviar el header Authorization.
+func tokenFromQuery(c *gin.Context) {
+	if token := c.Query("access_token"); token != "" && c.GetHeader("Authorization") == "" {
+		c.Request.Header.Set("Authorization", auth.BearerHeader(token))
+	}
+	c.Next()
+}
+
+// Logger es el logger de Gin con el mismo formato, pero sin el token de acceso que los streams reciben
+// en ?access_token=
+func Logger() gin.HandlerFunc {
+	return gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
+		var statusColor, methodColor, resetColor string
+		if param.IsOutputColor() {
+			statusColor, methodColor, resetColor = param.StatusCodeColor(), param.MethodColor(), param.ResetColor()
+		}
+		if param.Latency > time.Minute {
+			param.Latency = param.Latency.Truncate(time.Second)
+		}
+		return fmt.Sprintf("[GIN] %v |%s %3d %s| %13v | %15s |%s %-7s %s %#v\n%s",
+			param.TimeStamp.Format("2006/01/02 - 15:04:05"),
+			statusColor, param.StatusCode, resetColor,
+			param.Latency,
+			param.ClientIP,
+			methodColor, param.Method, resetColor,
+			redactQuery(param.Path),
+			param.ErrorMessage,
+		)
+	})
+}
+
+// redactQuery reemplaza el valor de access_token en la query de path. Una query que no se puede leer
+// se omite completa.
+func redactQuery(path string) string {
+	base, rawQuery, found := strings.Cut(path, "?")
+	if !found {
+		return path
+	}
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return base
+	}
+	if !query.Has("access_token") {
+		return path
+	}
+	query.Set("access_token", "REDACTED")
+	return base + "?" + query.Encode()
 }
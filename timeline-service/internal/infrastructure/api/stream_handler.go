@@ -0,0 +1,296 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/DevOpslp/microblogging-platform/auth"
+	"github.com/DevOpslp/microblogging-platform/timeline-service/internal/domain"
+	"github.com/DevOpslp/microblogging-platform/timeline-service/internal/infrastructure/persistence"
+	"github.com/DevOpslp/microblogging-platform/timeline-service/internal/stream"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+var ErrInvalidLastEventID = errors.New("Last-Event-ID inválido")
+
+// Los buffers de escritura se comparten entre conexiones: con miles de conexiones abiertas la mayoría
+// está inactiva y no necesita uno propio
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  512,
+	WriteBufferSize: 4096,
+	WriteBufferPool: &sync.Pool{},
+	// La autenticación es por token y no por cookies, así que se aceptan conexiones de cualquier origen
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// streamWriter escribe los mensajes de una conexión de streaming (SSE o WebSocket)
+type streamWriter interface {
+	WriteTweet(tweet domain.Tweet) error
+	WriteResync() error
+	WriteHeartbeat() error
+}
+
+// StreamTimeline envía por Server-Sent Events los tweets nuevos de los usuarios que sigue el usuario
+// autenticado. Cada evento lleva el ID del tweet; al reconectar con el header Last-Event-ID (o
+// ?last_event_id=) se reenvían los tweets del timeline posteriores a ese ID.
+func (h *TimelineHandler) StreamTimeline(c *gin.Context) {
+	sub, missed, resync, ok := h.openStream(c, c.GetHeader("Last-Event-ID"))
+	if !ok {
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // Evita que un proxy nginx acumule los eventos
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	writer := &sseWriter{c: c, timeout: h.hub.Config().WriteTimeout}
+	h.serveStream(c.Request.Context(), sub, writer, missed, resync, auth.ExpiresAt(c))
+}
+
+// StreamTimelineWebSocket es el equivalente de StreamTimeline sobre WebSocket. Los mensajes son JSON con
+// un campo type (tweet, resync o heartbeat) y se reanuda con ?last_event_id=.
+func (h *TimelineHandler) StreamTimelineWebSocket(c *gin.Context) {
+	sub, missed, resync, ok := h.openStream(c, "")
+	if !ok {
+		return
+	}
+
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// Upgrade ya respondió con el error
+		h.hub.Unsubscribe(sub)
+		return
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+	go readWebSocket(conn, h.hub.Config().Heartbeat, cancel)
+
+	writer := &webSocketWriter{conn: conn, timeout: h.hub.Config().WriteTimeout}
+	h.serveStream(ctx, sub, writer, missed, resync, auth.ExpiresAt(c))
+	conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
+		time.Now().Add(time.Second))
+}
+
+// openStream registra la conexión del usuario autenticado en el hub y busca los tweets que se perdió
+// desde lastEventID. Si algo falla responde el error y devuelve ok en false.
+func (h *TimelineHandler) openStream(c *gin.Context, lastEventID string) (*stream.Subscriber, []domain.Tweet, bool, bool) {
+	// Usuario autenticado por auth.Middleware
+	username := auth.Username(c)
+
+	if lastEventID == "" {
+		lastEventID = c.Query("last_event_id")
+	}
+	var lastID uint64
+	if lastEventID != "" {
+		parsed, err := strconv.ParseUint(lastEventID, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": ErrInvalidLastEventID.Error()})
+			return nil, nil, false, false
+		}
+		lastID = parsed
+	}
+
+	following, hidden, err := h.timelineRepo.GetStreamSources(username)
+	if err != nil {
+		if errors.Is(err, persistence.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Usuario no encontrado"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al abrir el stream del timeline"})
+		}
+		return nil, nil, false, false
+	}
+
+	// La conexión se registra antes de buscar los tweets perdidos para no perder los que lleguen mientras tanto
+	sub, err := h.hub.Subscribe(username, following, hidden)
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Demasiadas conexiones, intente más tarde"})
+		return nil, nil, false, false
+	}
+	if lastID == 0 {
+		return sub, nil, false, true
+	}
+
+	missed, resync, err := h.missedTweets(username, uint(lastID))
+	if err != nil {
+		h.hub.Unsubscribe(sub)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener el timeline"})
+		return nil, nil, false, false
+	}
+	return sub, missed, resync, true
+}
+
+// missedTweets devuelve, del más antiguo al más nuevo, los tweets del timeline posteriores a lastID.
+// Se reenvían como máximo ReplayLimit; si se perdieron más, resync indica que el cliente debe volver a
+// cargar /timeline.
+func (h *TimelineHandler) missedTweets(username string, lastID uint) ([]domain.Tweet, bool, error) {
+	limit := h.hub.Config().ReplayLimit
+	tweets, _, err := h.timelineRepo.GetHomeTimeline(username, nil, limit+1)
+	if err != nil {
+		return nil, false, err
+	}
+
+	missed := make([]domain.Tweet, 0)
+	for i := len(tweets) - 1; i >= 0; i-- {
+		if tweets[i].ID > lastID {
+			missed = append(missed, tweets[i])
+		}
+	}
+	if len(missed) > limit {
+		return missed[len(missed)-limit:], true, nil
+	}
+	return missed, false, nil
+}
+
+// serveStream envía los tweets perdidos y luego los que publique el hub, con heartbeats periódicos, hasta
+// que el cliente se desconecta, el hub descarta la conexión por lenta o expira el token de acceso
+// (expiresAt). En ese caso el cliente se reconecta con un token nuevo y Last-Event-ID.
+func (h *TimelineHandler) serveStream(ctx context.Context, sub *stream.Subscriber, writer streamWriter, missed []domain.Tweet, resync bool, expiresAt time.Time) {
+	defer h.hub.Unsubscribe(sub)
+	config := h.hub.Config()
+
+	if resync {
+		if err := writer.WriteResync(); err != nil {
+			return
+		}
+	}
+	replayed := make(map[uint]bool, len(missed))
+	for _, tweet := range missed {
+		if err := writer.WriteTweet(tweet); err != nil {
+			return
+		}
+		replayed[tweet.ID] = true
+	}
+
+	heartbeat := time.NewTicker(config.Heartbeat)
+	defer heartbeat.Stop()
+	refresh := time.NewTicker(config.RefreshInterval)
+	defer refresh.Stop()
+	expired := time.NewTimer(time.Until(expiresAt))
+	defer expired.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-expired.C:
+			return
+		case tweet, ok := <-sub.Events():
+			if !ok {
+				// El hub descartó la conexión por lenta; el cliente se reconecta con Last-Event-ID
+				return
+			}
+			if replayed[tweet.ID] {
+				continue
+			}
+			if err := writer.WriteTweet(tweet); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if err := writer.WriteHeartbeat(); err != nil {
+				return
+			}
+		case <-refresh.C:
+			// Los seguimientos, bloqueos y silencios pueden cambiar mientras la conexión está abierta
+			following, hidden, err := h.timelineRepo.GetStreamSources(sub.Username)
+			if err != nil {
+				log.Printf("Error al actualizar el stream de %s: %v", sub.Username, err)
+				continue
+			}
+			h.hub.Update(sub, following, hidden)
+		}
+	}
+}
+
+// sseWriter escribe eventos con el formato de Server-Sent Events
+type sseWriter struct {
+	c       *gin.Context
+	timeout time.Duration
+}
+
+func (w *sseWriter) WriteTweet(tweet domain.Tweet) error {
+	data, err := json.Marshal(tweet)
+	if err != nil {
+		return err
+	}
+	return w.write(fmt.Sprintf("id: %d\nevent: tweet\ndata: %s\n\n", tweet.ID, data))
+}
+
+func (w *sseWriter) WriteResync() error {
+	return w.write("event: resync\ndata: {}\n\n")
+}
+
+func (w *sseWriter) WriteHeartbeat() error {
+	// Las líneas que empiezan con ':' son comentarios que el cliente ignora
+	return w.write(": heartbeat\n\n")
+}
+
+func (w *sseWriter) write(message string) error {
+	// Un cliente que no lee no debe dejar la conexión bloqueada indefinidamente
+	err := http.NewResponseController(w.c.Writer).SetWriteDeadline(time.Now().Add(w.timeout))
+	if err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	if _, err := w.c.Writer.WriteString(message); err != nil {
+		return err
+	}
+	w.c.Writer.Flush()
+	return nil
+}
+
+// webSocketWriter escribe mensajes JSON en una conexión WebSocket
+type webSocketWriter struct {
+	conn    *websocket.Conn
+	timeout time.Duration
+}
+
+func (w *webSocketWriter) WriteTweet(tweet domain.Tweet) error {
+	return w.write(gin.H{"type": "tweet", "id": tweet.ID, "tweet": tweet})
+}
+
+func (w *webSocketWriter) WriteResync() error {
+	return w.write(gin.H{"type": "resync"})
+}
+
+// WriteHeartbeat envía un ping, cuyo pong mantiene viva la lectura, y un mensaje heartbeat para los
+// clientes de navegador, que no ven los pings
+func (w *webSocketWriter) WriteHeartbeat() error {
+	if err := w.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(w.timeout)); err != nil {
+		return err
+	}
+	return w.write(gin.H{"type": "heartbeat"})
+}
+
+func (w *webSocketWriter) write(message gin.H) error {
+	w.conn.SetWriteDeadline(time.Now().Add(w.timeout))
+	return w.conn.WriteJSON(message)
+}
+
+// readWebSocket descarta los mensajes del cliente y cancela la conexión cuando se cierra o deja de
+// responder los pings durante dos heartbeats
+func readWebSocket(conn *websocket.Conn, heartbeat time.Duration, cancel context.CancelFunc) {
+	defer cancel()
+
+	conn.SetReadLimit(512)
+	conn.SetReadDeadline(time.Now().Add(2 * heartbeat))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(2 * heartbeat))
+	})
+	for {
+		if _, _, err := conn.NextReader(); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,314 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DevOpslp/microblogging-platform/auth"
+	"github.com/DevOpslp/microblogging-platform/events"
+	"github.com/DevOpslp/microblogging-platform/timeline-service/internal/domain"
+	"github.com/DevOpslp/microblogging-platform/timeline-service/internal/stream"
+	"github.com/DevOpslp/microblogging-platform/timeline-service/internal/trends"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+// sseEvent es un evento leído de un stream SSE; Comment guarda las líneas de comentario (heartbeats)
+type sseEvent struct {
+	ID      string
+	Event   string
+	Data    string
+	Comment string
+}
+
+// readSSEEvent lee líneas hasta el fin del próximo evento
+func readSSEEvent(t *testing.T, reader *bufio.Reader) sseEvent {
+	var event sseEvent
+	for {
+		line, err := reader.ReadString('\n')
+		if !assert.NoError(t, err) {
+			return event
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "":
+			return event
+		case strings.HasPrefix(line, ":"):
+			event.Comment = strings.TrimSpace(strings.TrimPrefix(line, ":"))
+		case strings.HasPrefix(line, "id: "):
+			event.ID = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			event.Event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			event.Data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+// newStreamServer levanta timeline-service en un servidor real, necesario para las conexiones que no terminan
+func newStreamServer(userServiceURL, tweetServiceURL string, config stream.Config) (*httptest.Server, *gin.Engine, *events.MemoryBroker, *stream.Hub) {
+	hub := stream.NewHub(config)
+	router, broker := setupTestService(userServiceURL, tweetServiceURL, hub, trends.NewEngine(trends.DefaultConfig(), trends.SystemClock))
+	return httptest.NewServer(router), router, broker, hub
+}
+
+func openSSE(t *testing.T, url string, header http.Header) (*http.Response, *bufio.Reader) {
+	req, _ := http.NewRequest("GET", url, nil)
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Do(req)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return resp, bufio.NewReader(resp.Body)
+}
+
+func TestStreamTimelineSSE(t *testing.T) {
+	userService := newFakeUserService(map[string][]string{
+		"user1": {"user2"},
+		"user2": {},
+		"user3": {},
+	})
+	defer userService.Close()
+	tweetService := newFakeTweetService(map[string]string{"user2": `[]`})
+	defer tweetService.Close()
+
+	config := stream.DefaultConfig()
+	config.Heartbeat = 50 * time.Millisecond
+	server, _, broker, hub := newStreamServer(userService.URL, tweetService.URL, config)
+	defer server.Close()
+
+	resp, reader := openSSE(t, server.URL+"/timeline/stream", http.Header{"Authorization": {bearerFor("user1")}})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	assert.Equal(t, 1, hub.Connections())
+
+	// Sin tweets nuevos solo llegan heartbeats
+	assert.Equal(t, sseEvent{Comment: "heartbeat"}, readSSEEvent(t, reader))
+
+	// Los tweets de autores no seguidos no se envían
+	publishTweetCreated(t, broker, tweetService, `{"id":1,"username":"user3","content":"no seguido","created_at":"2024-01-01T10:00:00Z"}`)
+	publishTweetCreated(t, broker, tweetService, `{"id":2,"username":"user2","content":"nuevo","created_at":"2024-01-01T11:00:00Z"}`)
+
+	event := readSSEEvent(t, reader)
+	for event.Comment == "heartbeat" {
+		event = readSSEEvent(t, reader)
+	}
+	assert.Equal(t, "2", event.ID)
+	assert.Equal(t, "tweet", event.Event)
+	var tweet domain.Tweet
+	assert.NoError(t, json.Unmarshal([]byte(event.Data), &tweet))
+	assert.Equal(t, "nuevo", tweet.Content)
+
+	// Al desconectarse el cliente la conexión sale del hub
+	resp.Body.Close()
+	assert.Eventually(t, func() bool { return hub.Connections() == 0 }, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestStreamTimelineResume(t *testing.T) {
+	userService := newFakeUserService(map[string][]string{"user1": {"user2"}})
+	defer userService.Close()
+	tweetService := newFakeTweetService(map[string]string{
+		"user2": `[{"id":1,"username":"user2","content":"uno","created_at":"2024-01-01T10:00:00Z"},
+		           {"id":2,"username":"user2","content":"dos","created_at":"2024-01-01T11:00:00Z"},
+		           {"id":3,"username":"user2","content":"tres","created_at":"2024-01-01T12:00:00Z"},
+		           {"id":4,"username":"user2","content":"cuatro","created_at":"2024-01-01T13:00:00Z"}]`,
+	})
+	defer tweetService.Close()
+
+	config := stream.DefaultConfig()
+	config.ReplayLimit = 2
+	server, _, _, _ := newStreamServer(userService.URL, tweetService.URL, config)
+	defer server.Close()
+
+	// EventSource reenvía el último ID recibido en el header Last-Event-ID; los perdidos llegan en orden
+	resp, reader := openSSE(t, server.URL+"/timeline/stream", http.Header{
+		"Authorization": {bearerFor("user1")},
+		"Last-Event-ID": {"2"},
+	})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	event := readSSEEvent(t, reader)
+	assert.Equal(t, "3", event.ID)
+	assert.Equal(t, "tweet", event.Event)
+	assert.Equal(t, "4", readSSEEvent(t, reader).ID)
+	resp.Body.Close()
+
+	// Si se perdió más de lo que se reenvía se pide recargar el timeline y se envían los más nuevos.
+	// Los navegadores pasan el token y el último ID por query.
+	token, _ := tokens.IssueAccessToken(1, "user1", auth.RoleUser)
+	resp, reader = openSSE(t, server.URL+"/timeline/stream?last_event_id=1&access_token="+token, nil)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "resync", readSSEEvent(t, reader).Event)
+	assert.Equal(t, "3", readSSEEvent(t, reader).ID)
+	assert.Equal(t, "4", readSSEEvent(t, reader).ID)
+}
+
+func TestStreamTimelineWebSocket(t *testing.T) {
+	userService := newFakeUserService(map[string][]string{"user1": {"user2"}, "user2": {}})
+	defer userService.Close()
+	tweetService := newFakeTweetService(map[string]string{
+		"user2": `[{"id":1,"username":"user2","content":"uno","created_at":"2024-01-01T10:00:00Z"},
+		           {"id":2,"username":"user2","content":"dos","created_at":"2024-01-01T11:00:00Z"}]`,
+	})
+	defer tweetService.Close()
+
+	config := stream.DefaultConfig()
+	config.Heartbeat = 50 * time.Millisecond
+	server, _, broker, hub := newStreamServer(userService.URL, tweetService.URL, config)
+	defer server.Close()
+
+	token, _ := tokens.IssueAccessToken(1, "user1", auth.RoleUser)
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/timeline/ws?last_event_id=1&access_token=" + token
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer conn.Close()
+
+	type message struct {
+		Type  string       `json:"type"`
+		ID    uint         `json:"id"`
+		Tweet domain.Tweet `json:"tweet"`
+	}
+	readMessage := func(t *testing.T) message {
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		for {
+			var msg message
+			if !assert.NoError(t, conn.ReadJSON(&msg)) || msg.Type != "heartbeat" {
+				return msg
+			}
+		}
+	}
+
+	// Primero llegan los tweets perdidos desde last_event_id y luego los nuevos
+	msg := readMessage(t)
+	assert.Equal(t, "tweet", msg.Type)
+	assert.Equal(t, uint(2), msg.ID)
+	assert.Equal(t, "dos", msg.Tweet.Content)
+
+	publishTweetCreated(t, broker, tweetService, `{"id":3,"username":"user2","content":"tres","created_at":"2024-01-01T12:00:00Z"}`)
+	msg = readMessage(t)
+	assert.Equal(t, uint(3), msg.ID)
+	assert.Equal(t, "tres", msg.Tweet.Content)
+
+	// Mientras el cliente lee responde los pings y la conexión sigue abierta pasados varios heartbeats
+	for i := 0; i < 3; i++ {
+		var heartbeat message
+		assert.NoError(t, conn.ReadJSON(&heartbeat))
+		assert.Equal(t, "heartbeat", heartbeat.Type)
+	}
+	assert.Equal(t, 1, hub.Connections())
+
+	conn.Close()
+	assert.Eventually(t, func() bool { return hub.Connections() == 0 }, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestStreamTimelineErrors(t *testing.T) {
+	userService := newFakeUserService(map[string][]string{"user1": {}})
+	defer userService.Close()
+	tweetService := newFakeTweetService(map[string]string{})
+	defer tweetService.Close()
+
+	config := stream.DefaultConfig()
+	config.MaxConnections = 1
+	server, router, _, hub := newStreamServer(userService.URL, tweetService.URL, config)
+	defer server.Close()
+
+	tests := []struct {
+		name        string
+		path        string
+		username    string
+		lastEventID string
+		status      int
+	}{
+		{"sin token", "/timeline/stream", "", "", http.StatusUnauthorized},
+		{"websocket sin token", "/timeline/ws", "", "", http.StatusUnauthorized},
+		{"Last-Event-ID inválido", "/timeline/stream", "user1", "abc", http.StatusBadRequest},
+		{"last_event_id inválido", "/timeline/ws?last_event_id=-1", "user1", "", http.StatusBadRequest},
+		{"usuario inexistente", "/timeline/stream", "nobody", "", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", tt.path, nil)
+			if tt.username != "" {
+				req.Header.Set("Authorization", bearerFor(tt.username))
+			}
+			if tt.lastEventID != "" {
+				req.Header.Set("Last-Event-ID", tt.lastEventID)
+			}
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tt.status, w.Code)
+		})
+	}
+	assert.Equal(t, 0, hub.Connections())
+
+	// Con el máximo de conexiones alcanzado se rechazan las nuevas
+	resp, _ := openSSE(t, server.URL+"/timeline/stream", http.Header{"Authorization": {bearerFor("user1")}})
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/timeline/stream", nil)
+	req.Header.Set("Authorization", bearerFor("user1"))
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
+
+func TestLoggerRedactsAccessToken(t *testing.T) {
+	var logs strings.Builder
+	defaultWriter := gin.DefaultWriter
+	gin.DefaultWriter = &logs
+	defer func() { gin.DefaultWriter = defaultWriter }()
+
+	router := gin.New()
+	router.Use(Logger())
+	router.GET("/timeline/stream", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/timeline/stream?access_token=secreto&last_event_id=3", nil)
+	router.ServeHTTP(w, req)
+
+	assert.NotContains(t, logs.String(), "secreto")
+	assert.Contains(t, logs.String(), "/timeline/stream?access_token=REDACTED&last_event_id=3")
+	assert.Equal(t, "/timeline?limit=5", redactQuery("/timeline?limit=5"))
+	// Una query que no se puede leer podría esconder el token, así que se omite
+	assert.Equal(t, "/timeline/stream", redactQuery("/timeline/stream?access_token=abc&x=%ZZ"))
+}
+
+// discardWriter descarta los mensajes de un stream
+type discardWriter struct{}
+
+func (discardWriter) WriteTweet(domain.Tweet) error { return nil }
+func (discardWriter) WriteResync() error            { return nil }
+func (discardWriter) WriteHeartbeat() error         { return nil }
+
+func TestStreamClosesWhenTokenExpires(t *testing.T) {
+	hub := stream.NewHub(stream.DefaultConfig())
+	handler := NewTimelineHandler(nil, nil, hub, cursors)
+	sub, err := hub.Subscribe("user1", []string{"user2"}, nil)
+	assert.NoError(t, err)
+
+	done := make(chan struct{})
+	go func() {
+		handler.serveStream(context.Background(), sub, discardWriter{}, nil, false, time.Now().Add(50*time.Millisecond))
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("El stream sigue abierto después de expirar el token")
+	}
+	assert.Equal(t, 0, hub.Connections())
+}
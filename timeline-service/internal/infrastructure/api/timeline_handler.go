@@ -2,42 +2,122 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
-	"os"
+	"time"
 
+	"github.com/DevOpslp/microblogging-platform/auth"
+	"github.com/DevOpslp/microblogging-platform/pagination"
 	"github.com/DevOpslp/microblogging-platform/timeline-service/internal/domain"
+	"github.com/DevOpslp/microblogging-platform/timeline-service/internal/infrastructure/persistence"
+	"github.com/DevOpslp/microblogging-platform/timeline-service/internal/stream"
+	"github.com/DevOpslp/microblogging-platform/timeline-service/internal/trends"
 	"github.com/gin-gonic/gin"
 )
 
 type TimelineHandler struct {
-	tweetServiceURL string
+	timelineRepo *persistence.TimelineRepository
+	trends       *trends.Engine
+	hub          *stream.Hub
+	cursors      *pagination.Signer
 }
 
-func NewTimelineHandler() *TimelineHandler {
-	// Obtiene la URL de tweet-service desde las variables de entorno
-	tweetServiceURL := os.Getenv("TWEET_SERVICE_URL")
-	if tweetServiceURL == "" {
-		tweetServiceURL = "http://localhost:8081/tweets" // Valor predeterminado para desarrollo local
-	}
-	return &TimelineHandler{tweetServiceURL: tweetServiceURL}
+func NewTimelineHandler(timelineRepo *persistence.TimelineRepository, trendEngine *trends.Engine, hub *stream.Hub, cursors *pagination.Signer) *TimelineHandler {
+	return &TimelineHandler{timelineRepo: timelineRepo, trends: trendEngine, hub: hub, cursors: cursors}
 }
 
+// Modos del timeline: cronológico (por defecto) u ordenado por relevancia
+const (
+	TimelineModeChronological = "chronological"
+	TimelineModeRanked        = "ranked"
+)
+
+// GetTimeline devuelve el timeline del usuario autenticado. Con ?mode=ranked los tweets se ordenan
+// por relevancia; su cursor conserva la hora de la primera página para que el orden no cambie al paginar.
 func (h *TimelineHandler) GetTimeline(c *gin.Context) {
-	resp, err := http.Get(h.tweetServiceURL)
-	if err != nil || resp.StatusCode != http.StatusOK {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener el timeline"})
+	// Usuario autenticado por auth.Middleware
+	username := auth.Username(c)
+
+	switch c.DefaultQuery("mode", TimelineModeChronological) {
+	case TimelineModeChronological:
+	case TimelineModeRanked:
+		h.getRankedTimeline(c, username)
+		return
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "mode debe ser chronological o ranked"})
+		return
+	}
+
+	cursor, limit, err := parsePageParams(c, h.cursors)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tweets, next, err := h.timelineRepo.GetHomeTimeline(username, cursor, limit)
+	if err != nil {
+		if errors.Is(err, persistence.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Usuario no encontrado"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener el timeline"})
+		}
+		return
+	}
+
+	respondTimeline(c, tweets, encodeCursor(h.cursors, next))
+}
+
+func (h *TimelineHandler) getRankedTimeline(c *gin.Context, username string) {
+	cursor, limit, err := parseRankedPageParams(c, h.cursors, time.Now())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	defer resp.Body.Close()
 
-	var tweets []domain.Tweet
-	if err := json.NewDecoder(resp.Body).Decode(&tweets); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al decodificar tweets"})
+	tweets, more, err := h.timelineRepo.GetRankedTimeline(username, cursor.AsOf, cursor.Offset, limit)
+	if err != nil {
+		if errors.Is(err, persistence.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Usuario no encontrado"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener el timeline"})
+		}
 		return
 	}
 
+	next := ""
+	if more && len(tweets) > 0 {
+		next = encodeRankedCursor(h.cursors, rankedCursor{AsOf: cursor.AsOf, Offset: cursor.Offset + len(tweets)})
+	}
+	respondTimeline(c, tweets, next)
+}
+
+// GetMentions devuelve una página de los tweets que mencionan al usuario autenticado, del más nuevo al más antiguo
+func (h *TimelineHandler) GetMentions(c *gin.Context) {
+	// Usuario autenticado por auth.Middleware
+	username := auth.Username(c)
+
+	cursor, limit, err := parsePageParams(c, h.cursors)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tweets, next, err := h.timelineRepo.GetMentionsTimeline(username, cursor, limit)
+	if err != nil {
+		if errors.Is(err, persistence.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Usuario no encontrado"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener las menciones"})
+		}
+		return
+	}
+
+	respondTimeline(c, tweets, encodeCursor(h.cursors, next))
+}
+
+func respondTimeline(c *gin.Context, tweets []domain.Tweet, nextCursor string) {
 	// Usamos MarshalIndent para embellecer el JSON
-	prettyJSON, err := json.MarshalIndent(gin.H{"timeline": tweets}, "", "    ")
+	prettyJSON, err := json.MarshalIndent(gin.H{"timeline": tweets, "next_cursor": nextCursor}, "", "    ")
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al formatear el JSON"})
 		return
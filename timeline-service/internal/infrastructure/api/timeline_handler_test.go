@@ -0,0 +1,589 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DevOpslp/microblogging-platform/auth"
+	"github.com/DevOpslp/microblogging-platform/events"
+	"github.com/DevOpslp/microblogging-platform/pagination"
+	"github.com/DevOpslp/microblogging-platform/timeline-service/internal/domain"
+	"github.com/DevOpslp/microblogging-platform/timeline-service/internal/infrastructure/consumer"
+	"github.com/DevOpslp/microblogging-platform/timeline-service/internal/infrastructure/persistence"
+	"github.com/DevOpslp/microblogging-platform/timeline-service/internal/stream"
+	"github.com/DevOpslp/microblogging-platform/timeline-service/internal/trends"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	tokens  = auth.NewTokenManager("secreto-de-prueba")
+	cursors = pagination.NewSigner("secreto-de-prueba")
+)
+
+// bearerFor genera el header Authorization de un usuario para las pruebas
+func bearerFor(username string) string {
+	token, _ := tokens.IssueAccessToken(1, username, auth.RoleUser)
+	return auth.BearerHeader(token)
+}
+
+// newFakeUserService simula los endpoints /users/:username/following y /users/:username/followers de user-service,
+// y /users/batch con todas las cuentas públicas. /users/:username/hidden responde vacío.
+func newFakeUserService(following map[string][]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// timeline-service consulta con un token de servicio
+		if r.Header.Get("Authorization") == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path == "/users/batch" {
+			json.NewEncoder(w).Encode(gin.H{"users": []gin.H{}})
+			return
+		}
+		if r.URL.Path == "/users/following" {
+			batch := make(map[string][]string)
+			for _, username := range strings.Split(r.URL.Query().Get("usernames"), ",") {
+				if followed, ok := following[username]; ok {
+					batch[username] = followed
+				}
+			}
+			json.NewEncoder(w).Encode(gin.H{"following": batch})
+			return
+		}
+
+		parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/users/"), "/")
+		if len(parts) != 2 {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		username, field := parts[0], parts[1]
+		if _, ok := following[username]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		users := make([]gin.H, 0)
+		switch field {
+		case "following":
+			for _, followed := range following[username] {
+				users = append(users, gin.H{"username": followed})
+			}
+		case "followers":
+			for follower, followedUsers := range following {
+				for _, followed := range followedUsers {
+					if followed == username {
+						users = append(users, gin.H{"username": follower})
+					}
+				}
+			}
+		}
+		json.NewEncoder(w).Encode(gin.H{field: users})
+	}))
+}
+
+// fakeTweetService simula tweet-service. Los tweets registrados con add responden en /tweets/:id,
+// como los tweets nuevos que timeline-service pide al recibir sus eventos.
+type fakeTweetService struct {
+	*httptest.Server
+	mu   sync.Mutex
+	byID map[uint]string
+}
+
+// add registra el tweet serializado en body y devuelve su contenido
+func (f *fakeTweetService) add(t *testing.T, body string) domain.Tweet {
+	var tweet domain.Tweet
+	assert.NoError(t, json.Unmarshal([]byte(body), &tweet))
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.byID[tweet.ID] = body
+	return tweet
+}
+
+// newFakeTweetService simula el endpoint /tweets/user/:username de tweet-service. /tweets/affinity/:username
+// y /tweets/engagement responden sin interacciones.
+func newFakeTweetService(tweets map[string]string) *fakeTweetService {
+	fake := &fakeTweetService{byID: make(map[uint]string)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/tweets/affinity/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"affinity": []}`))
+	})
+	mux.HandleFunc("/tweets/engagement", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"engagement": []}`))
+	})
+	mux.HandleFunc("/tweets/", fake.getTweet)
+	for username, body := range tweets {
+		body := body
+		mux.HandleFunc("/tweets/user/"+username, func(w http.ResponseWriter, r *http.Request) {
+			// timeline-service consulta con un token de servicio para recibir también los tweets privados
+			if r.Header.Get("Authorization") == "" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte(`{"tweets": ` + body + `}`))
+		})
+	}
+	fake.Server = httptest.NewServer(mux)
+	return fake
+}
+
+func (f *fakeTweetService) getTweet(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") == "" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	var id uint
+	if _, err := fmt.Sscanf(strings.TrimPrefix(r.URL.Path, "/tweets/"), "%d", &id); err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	f.mu.Lock()
+	body, ok := f.byID[id]
+	f.mu.Unlock()
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	w.Write([]byte(body))
+}
+
+// publishEvent publica payload en broker como lo hace el Relay de tweet-service
+func publishEvent(t *testing.T, broker *events.MemoryBroker, payload events.Payload) events.Event {
+	event, err := events.New("tweet-service", payload)
+	assert.NoError(t, err)
+	assert.NoError(t, broker.Publish(context.Background(), event))
+	return event
+}
+
+// publishTweetCreated registra el tweet en tweet-service y publica su evento TweetCreated
+func publishTweetCreated(t *testing.T, broker *events.MemoryBroker, tweetService *fakeTweetService, body string) events.Event {
+	tweet := tweetService.add(t, body)
+	return publishEvent(t, broker, events.TweetCreated{TweetID: tweet.ID, Username: tweet.Username, CreatedAt: tweet.CreatedAt})
+}
+
+func setupTestRouter(userServiceURL, tweetServiceURL string) *gin.Engine {
+	router, _ := setupTestService(userServiceURL, tweetServiceURL, stream.NewHub(stream.DefaultConfig()), trends.NewEngine(trends.DefaultConfig(), trends.SystemClock))
+	return router
+}
+
+// setupTestService arma timeline-service con un broker en memoria por el que llegan los eventos de tweet-service
+func setupTestService(userServiceURL, tweetServiceURL string, hub *stream.Hub, engine *trends.Engine) (*gin.Engine, *events.MemoryBroker) {
+	gin.SetMode(gin.TestMode)
+	tweetRepo := persistence.NewHTTPTweetRepository(tweetServiceURL+"/tweets", tokens)
+	timelineRepo := persistence.NewTimelineRepository(
+		persistence.NewMemoryTimelineStore(),
+		persistence.NewHTTPUserRepository(userServiceURL, tokens),
+		tweetRepo,
+		0,
+	)
+	broker := events.NewMemoryBroker()
+	eventConsumer := consumer.NewEventConsumer(timelineRepo, tweetRepo, engine, hub)
+	if err := eventConsumer.Subscribe(broker); err != nil {
+		panic(err)
+	}
+	if err := eventConsumer.SubscribeInstance(broker); err != nil {
+		panic(err)
+	}
+
+	router := gin.Default()
+	SetupRoutes(router, NewTimelineHandler(timelineRepo, engine, hub, cursors), tokens)
+	return router, broker
+}
+
+func TestGetTimelineOnlyFollowedUsers(t *testing.T) {
+	userService := newFakeUserService(map[string][]string{
+		"user1": {"user2", "user3"},
+	})
+	defer userService.Close()
+
+	tweetService := newFakeTweetService(map[string]string{
+		"user2": `[{"id":1,"username":"user2","content":"viejo de user2","created_at":"2024-01-01T10:00:00Z"},
+		           {"id":4,"username":"user2","content":"nuevo de user2","created_at":"2024-01-01T13:00:00Z"}]`,
+		"user3": `[{"id":2,"username":"user3","content":"de user3","created_at":"2024-01-01T12:00:00Z"}]`,
+		"user4": `[{"id":3,"username":"user4","content":"de user4","created_at":"2024-01-01T14:00:00Z"}]`,
+	})
+	defer tweetService.Close()
+
+	router := setupTestRouter(userService.URL, tweetService.URL)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/timeline", nil)
+	req.Header.Set("Authorization", bearerFor("user1"))
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Timeline []domain.Tweet `json:"timeline"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+
+	// Solo tweets de usuarios seguidos, del más nuevo al más antiguo
+	ids := make([]uint, 0)
+	for _, tweet := range response.Timeline {
+		ids = append(ids, tweet.ID)
+	}
+	assert.Equal(t, []uint{4, 2, 1}, ids)
+}
+
+func TestGetTimelineWithoutFollowing(t *testing.T) {
+	userService := newFakeUserService(map[string][]string{"user1": {}})
+	defer userService.Close()
+	tweetService := newFakeTweetService(map[string]string{})
+	defer tweetService.Close()
+
+	router := setupTestRouter(userService.URL, tweetService.URL)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/timeline", nil)
+	req.Header.Set("Authorization", bearerFor("user1"))
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"timeline": [], "next_cursor": ""}`, w.Body.String())
+}
+
+func TestGetTimelineErrors(t *testing.T) {
+	userService := newFakeUserService(map[string][]string{})
+	defer userService.Close()
+	tweetService := newFakeTweetService(map[string]string{})
+	defer tweetService.Close()
+
+	router := setupTestRouter(userService.URL, tweetService.URL)
+
+	t.Run("Sin token", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/timeline", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("Usuario inexistente", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/timeline", nil)
+		req.Header.Set("Authorization", bearerFor("desconocido"))
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestTimelineFanoutOnWrite(t *testing.T) {
+	userService := newFakeUserService(map[string][]string{
+		"user1": {"user2"},
+		"user2": {},
+		"user3": {},
+	})
+	defer userService.Close()
+
+	tweetService := newFakeTweetService(map[string]string{
+		"user2": `[{"id":1,"username":"user2","content":"primero","created_at":"2024-01-01T10:00:00Z"}]`,
+	})
+	defer tweetService.Close()
+
+	router, broker := setupTestService(userService.URL, tweetService.URL, stream.NewHub(stream.DefaultConfig()), trends.NewEngine(trends.DefaultConfig(), trends.SystemClock))
+
+	getTimelineIDs := func(t *testing.T) []uint {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/timeline", nil)
+		req.Header.Set("Authorization", bearerFor("user1"))
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response struct {
+			Timeline []domain.Tweet `json:"timeline"`
+		}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		ids := make([]uint, 0)
+		for _, tweet := range response.Timeline {
+			ids = append(ids, tweet.ID)
+		}
+		return ids
+	}
+
+	// La primera lectura materializa el timeline desde tweet-service
+	assert.Equal(t, []uint{1}, getTimelineIDs(t))
+
+	// Los tweets nuevos de autores seguidos se agregan sin volver a pedir el timeline a tweet-service
+	created := publishTweetCreated(t, broker, tweetService, `{"id":2,"username":"user2","content":"segundo","created_at":"2024-01-01T11:00:00Z"}`)
+	publishTweetCreated(t, broker, tweetService, `{"id":3,"username":"user3","content":"no seguido","created_at":"2024-01-01T12:00:00Z"}`)
+	assert.Equal(t, []uint{2, 1}, getTimelineIDs(t))
+
+	// Los tweets eliminados se quitan del timeline
+	publishEvent(t, broker, events.TweetDeleted{TweetID: 2})
+	assert.Equal(t, []uint{1}, getTimelineIDs(t))
+
+	// Una entrega repetida del evento de creación no vuelve a agregar el tweet
+	assert.NoError(t, broker.Publish(context.Background(), created))
+	assert.Equal(t, []uint{1}, getTimelineIDs(t))
+
+	// Restaurado, el tweet vuelve al timeline
+	publishEvent(t, broker, events.TweetRestored{TweetID: 2})
+	assert.Equal(t, []uint{2, 1}, getTimelineIDs(t))
+
+	// Un tweet que tweet-service ya no tiene se ignora
+	publishEvent(t, broker, events.TweetCreated{TweetID: 99, Username: "user2"})
+	assert.Equal(t, []uint{2, 1}, getTimelineIDs(t))
+}
+
+func TestTimelineFollowEvents(t *testing.T) {
+	following := map[string][]string{"user1": {"user2"}, "user2": {}, "user3": {}}
+	userService := newFakeUserService(following)
+	defer userService.Close()
+
+	tweetService := newFakeTweetService(map[string]string{
+		"user2": `[{"id":1,"username":"user2","content":"de user2","created_at":"2024-01-01T10:00:00Z"}]`,
+		"user3": `[{"id":2,"username":"user3","content":"de user3","created_at":"2024-01-01T11:00:00Z"}]`,
+	})
+	defer tweetService.Close()
+
+	router, broker := setupTestService(userService.URL, tweetService.URL, stream.NewHub(stream.DefaultConfig()), trends.NewEngine(trends.DefaultConfig(), trends.SystemClock))
+
+	getTimelineIDs := func(t *testing.T) []uint {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/timeline", nil)
+		req.Header.Set("Authorization", bearerFor("user1"))
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response struct {
+			Timeline []domain.Tweet `json:"timeline"`
+		}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		ids := make([]uint, 0)
+		for _, tweet := range response.Timeline {
+			ids = append(ids, tweet.ID)
+		}
+		return ids
+	}
+
+	assert.Equal(t, []uint{1}, getTimelineIDs(t))
+
+	// Al seguir a un autor aparecen sus tweets anteriores
+	following["user1"] = []string{"user2", "user3"}
+	publishEvent(t, broker, events.UserFollowed{FollowerUsername: "user1", FollowedUsername: "user3"})
+	assert.Equal(t, []uint{2, 1}, getTimelineIDs(t))
+
+	// Al dejar de seguirlo desaparecen
+	following["user1"] = []string{"user2"}
+	publishEvent(t, broker, events.UserUnfollowed{FollowerUsername: "user1", FollowedUsername: "user3"})
+	assert.Equal(t, []uint{1}, getTimelineIDs(t))
+
+	// Un bloqueo descarta el timeline, que se reconstruye con los seguidos que quedan
+	following["user1"] = []string{}
+	publishEvent(t, broker, events.UserBlocked{BlockerUsername: "user1", BlockedUsername: "user2"})
+	assert.Empty(t, getTimelineIDs(t))
+}
+
+func TestGetTimelinePagination(t *testing.T) {
+	userService := newFakeUserService(map[string][]string{"user1": {"user2"}})
+	defer userService.Close()
+
+	tweetService := newFakeTweetService(map[string]string{
+		"user2": `[{"id":1,"username":"user2","content":"1","created_at":"2024-01-01T10:00:00Z"},
+		           {"id":2,"username":"user2","content":"2","created_at":"2024-01-01T11:00:00Z"},
+		           {"id":3,"username":"user2","content":"3","created_at":"2024-01-01T11:00:00Z"}]`,
+	})
+	defer tweetService.Close()
+
+	router := setupTestRouter(userService.URL, tweetService.URL)
+
+	getPage := func(t *testing.T, query string) (int, []uint, string) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/timeline?"+query, nil)
+		req.Header.Set("Authorization", bearerFor("user1"))
+		router.ServeHTTP(w, req)
+
+		var response struct {
+			Timeline   []domain.Tweet `json:"timeline"`
+			NextCursor string         `json:"next_cursor"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		ids := make([]uint, 0)
+		for _, tweet := range response.Timeline {
+			ids = append(ids, tweet.ID)
+		}
+		return w.Code, ids, response.NextCursor
+	}
+
+	code, ids, next := getPage(t, "limit=2")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []uint{3, 2}, ids)
+	assert.NotEmpty(t, next)
+
+	code, ids, next = getPage(t, "limit=2&cursor="+next)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []uint{1}, ids)
+	assert.Empty(t, next)
+
+	for _, query := range []string{"limit=0", "limit=101", "cursor=adulterado", "cursor=" + next + "x"} {
+		code, _, _ = getPage(t, query)
+		assert.Equal(t, http.StatusBadRequest, code, query)
+	}
+}
+
+func TestGetTimelineRetweets(t *testing.T) {
+	userService := newFakeUserService(map[string][]string{
+		"user1": {"user2", "user3"},
+		"user2": {},
+		"user3": {},
+	})
+	defer userService.Close()
+
+	tweetService := newFakeTweetService(map[string]string{
+		"user2": `[{"id":1,"username":"user2","content":"original","created_at":"2024-01-01T10:00:00Z"}]`,
+		"user3": `[{"id":2,"username":"user3","content":"","retweet_of_tweet_id":1,"created_at":"2024-01-01T11:00:00Z",
+		            "retweeted_tweet":{"id":1,"username":"user2","content":"original","created_at":"2024-01-01T10:00:00Z"}}]`,
+	})
+	defer tweetService.Close()
+
+	router, broker := setupTestService(userService.URL, tweetService.URL, stream.NewHub(stream.DefaultConfig()), trends.NewEngine(trends.DefaultConfig(), trends.SystemClock))
+
+	getTimeline := func(t *testing.T) []domain.Tweet {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/timeline", nil)
+		req.Header.Set("Authorization", bearerFor("user1"))
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response struct {
+			Timeline []domain.Tweet `json:"timeline"`
+		}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response.Timeline
+	}
+
+	// El retweet se atribuye a quien retuiteó y el original no se repite
+	timeline := getTimeline(t)
+	assert.Len(t, timeline, 1)
+	assert.Equal(t, "user3", timeline[0].Username)
+	assert.Equal(t, "original", timeline[0].RetweetedTweet.Content)
+
+	// Un segundo retweet del mismo tweet tampoco lo duplica
+	publishTweetCreated(t, broker, tweetService,
+		`{"id":3,"username":"user2","retweet_of_tweet_id":1,"created_at":"2024-01-01T12:00:00Z",
+		  "retweeted_tweet":{"id":1,"username":"user2","content":"original","created_at":"2024-01-01T10:00:00Z"}}`)
+
+	timeline = getTimeline(t)
+	assert.Len(t, timeline, 1)
+	assert.Equal(t, uint(3), timeline[0].ID)
+}
+
+func TestGetMentionsTimeline(t *testing.T) {
+	userService := newFakeUserService(map[string][]string{"user1": {}, "user2": {}})
+	defer userService.Close()
+
+	// Simula el endpoint /tweets/mentions/:username de tweet-service
+	mux := http.NewServeMux()
+	mux.HandleFunc("/tweets/mentions/user1", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"tweets": [{"id":1,"username":"user2","content":"hola @user1","created_at":"2024-01-01T10:00:00Z",
+		  "entities":{"hashtags":[],"mentions":[{"user_id":1,"username":"user1","start":5,"end":11}]}}]}`))
+	})
+	mux.HandleFunc("/tweets/2", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id":2,"username":"user2","content":"@user1 otra vez","created_at":"2024-01-01T11:00:00Z",
+		  "entities":{"hashtags":[],"mentions":[{"user_id":1,"username":"user1","start":0,"end":6}]}}`))
+	})
+	tweetService := httptest.NewServer(mux)
+	defer tweetService.Close()
+
+	router, broker := setupTestService(userService.URL, tweetService.URL, stream.NewHub(stream.DefaultConfig()), trends.NewEngine(trends.DefaultConfig(), trends.SystemClock))
+
+	getMentions := func(t *testing.T, username string) (int, []domain.Tweet) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/timeline/mentions", nil)
+		req.Header.Set("Authorization", bearerFor(username))
+		router.ServeHTTP(w, req)
+
+		var response struct {
+			Timeline []domain.Tweet `json:"timeline"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return w.Code, response.Timeline
+	}
+
+	code, mentions := getMentions(t, "user1")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Len(t, mentions, 1)
+	assert.Equal(t, uint(1), mentions[0].Entities.Mentions[0].UserID)
+
+	// Un tweet nuevo que menciona al usuario se agrega aunque no siga al autor
+	publishEvent(t, broker, events.TweetCreated{TweetID: 2, Username: "user2"})
+
+	code, mentions = getMentions(t, "user1")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Len(t, mentions, 2)
+	assert.Equal(t, uint(2), mentions[0].ID)
+
+	// Sin token no se puede consultar
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/timeline/mentions", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// tweet-service responde 404 para usuarios inexistentes
+	code, _ = getMentions(t, "desconocido")
+	assert.Equal(t, http.StatusNotFound, code)
+}
+
+func TestGetRankedTimeline(t *testing.T) {
+	userService := newFakeUserService(map[string][]string{"user1": {"user2", "user3"}, "user2": {}, "user3": {}})
+	defer userService.Close()
+
+	now := time.Now().UTC()
+	tweetService := newFakeTweetService(map[string]string{
+		"user2": fmt.Sprintf(`[{"id":1,"username":"user2","content":"de user2","created_at":%q}]`, now.Add(-3*time.Hour).Format(time.RFC3339)),
+		"user3": fmt.Sprintf(`[{"id":2,"username":"user3","content":"de user3","created_at":%q}]`, now.Add(-time.Hour).Format(time.RFC3339)),
+	})
+	defer tweetService.Close()
+
+	router := setupTestRouter(userService.URL, tweetService.URL)
+
+	getTimeline := func(t *testing.T, query string) (int, []uint, string) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/timeline?"+query, nil)
+		req.Header.Set("Authorization", bearerFor("user1"))
+		router.ServeHTTP(w, req)
+
+		var response struct {
+			Timeline   []domain.Tweet `json:"timeline"`
+			NextCursor string         `json:"next_cursor"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		ids := make([]uint, 0)
+		for _, tweet := range response.Timeline {
+			ids = append(ids, tweet.ID)
+		}
+		return w.Code, ids, response.NextCursor
+	}
+
+	// Sin interacciones el más reciente tiene mayor puntaje; el cursor lleva a la página siguiente
+	code, ids, rankedNext := getTimeline(t, "mode=ranked&limit=1")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []uint{2}, ids)
+	assert.NotEmpty(t, rankedNext)
+
+	code, ids, last := getTimeline(t, "mode=ranked&limit=1&cursor="+rankedNext)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []uint{1}, ids)
+	assert.Empty(t, last)
+
+	code, ids, next := getTimeline(t, "mode=chronological&limit=1")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []uint{2}, ids)
+	assert.NotEmpty(t, next)
+
+	// Los cursores de un modo no sirven para el otro
+	for _, query := range []string{"mode=popular", "mode=ranked&limit=0", "mode=ranked&cursor=" + next, "cursor=" + rankedNext} {
+		code, _, _ = getTimeline(t, query)
+		assert.Equal(t, http.StatusBadRequest, code, query)
+	}
+}
@@ -0,0 +1,40 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	DefaultTrendsLimit = 10
+	MaxTrendsLimit     = 50
+)
+
+// Idiomas por los que se pueden filtrar las tendencias
+var trendLanguages = map[string]bool{"es": true, "en": true, "pt": true}
+
+// GetTrends devuelve los hashtags y términos en tendencia del último cálculo, opcionalmente solo
+// de los tweets en el idioma `lang`
+func (h *TimelineHandler) GetTrends(c *gin.Context) {
+	lang := c.Query("lang")
+	if lang != "" && !trendLanguages[lang] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "lang debe ser es, en o pt"})
+		return
+	}
+
+	limit := DefaultTrendsLimit
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > MaxTrendsLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limit debe ser un número entre 1 y %d", MaxTrendsLimit)})
+			return
+		}
+		limit = parsed
+	}
+
+	trends, asOf := h.trends.Trends(lang, limit)
+	c.JSON(http.StatusOK, gin.H{"trends": trends, "lang": lang, "as_of": asOf})
+}
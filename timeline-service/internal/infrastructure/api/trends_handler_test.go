@@ -0,0 +1,65 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DevOpslp/microblogging-platform/timeline-service/internal/stream"
+	"github.com/DevOpslp/microblogging-platform/timeline-service/internal/trends"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetTrends(t *testing.T) {
+	userService := newFakeUserService(map[string][]string{"user1": {"user2"}, "user2": {}})
+	defer userService.Close()
+	tweetService := newFakeTweetService(map[string]string{})
+	defer tweetService.Close()
+
+	engine := trends.NewEngine(trends.DefaultConfig(), trends.SystemClock)
+	router, broker := setupTestService(userService.URL, tweetService.URL, stream.NewHub(stream.DefaultConfig()), engine)
+
+	recent := time.Now().Add(-10 * time.Minute).UTC().Format(time.RFC3339)
+	for id := 1; id <= 5; id++ {
+		publishTweetCreated(t, broker, tweetService, fmt.Sprintf(
+			`{"id":%d,"username":"user2","content":"el partido es hoy #Final","created_at":%q,
+			  "entities":{"hashtags":[{"tag":"Final","start":19,"end":25}],"mentions":[]}}`, id, recent))
+	}
+	// Un evento de un tweet anterior a la ventana de referencia no cuenta
+	publishTweetCreated(t, broker, tweetService,
+		`{"id":6,"username":"user2","content":"el partido es hoy #Final","created_at":"2024-01-01T10:00:00Z",
+		  "entities":{"hashtags":[{"tag":"Final","start":19,"end":25}],"mentions":[]}}`)
+	engine.Refresh()
+
+	getTrends := func(query string) (int, []trends.Trend) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/trends?"+query, nil)
+		router.ServeHTTP(w, req)
+
+		var response struct {
+			Trends []trends.Trend `json:"trends"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return w.Code, response.Trends
+	}
+
+	code, all := getTrends("limit=1")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []trends.Trend{{Name: "#final", Type: trends.TypeHashtag, Count: 5, Score: 5}}, all)
+
+	code, spanish := getTrends("lang=es")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Len(t, spanish, 3)
+
+	code, english := getTrends("lang=en")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Empty(t, english)
+
+	for _, query := range []string{"lang=fr", "limit=0", "limit=51", "limit=diez"} {
+		code, _ := getTrends(query)
+		assert.Equal(t, http.StatusBadRequest, code, query)
+	}
+}
@@ -0,0 +1,218 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/DevOpslp/microblogging-platform/events"
+	"github.com/DevOpslp/microblogging-platform/timeline-service/internal/domain"
+	"github.com/DevOpslp/microblogging-platform/timeline-service/internal/infrastructure/persistence"
+	"github.com/DevOpslp/microblogging-platform/timeline-service/internal/stream"
+	"github.com/DevOpslp/microblogging-platform/timeline-service/internal/trends"
+)
+
+// Nombre del consumidor durable; las instancias de timeline-service se reparten los eventos
+const Name = "timeline-service"
+
+// EventConsumer mantiene los timelines precalculados con los eventos que tweet-service y user-service
+// publican desde su outbox. Los eventos de tweets solo llevan IDs: el tweet se pide a tweet-service, con
+// el tweet retuiteado o citado embebido. Cada evento se aplica una sola vez aunque el broker lo entregue
+// de nuevo.
+type EventConsumer struct {
+	timelineRepo *persistence.TimelineRepository
+	tweetRepo    persistence.TweetRepository
+	trends       *trends.Engine
+	hub          *stream.Hub
+}
+
+func NewEventConsumer(timelineRepo *persistence.TimelineRepository, tweetRepo persistence.TweetRepository, trendEngine *trends.Engine, hub *stream.Hub) *EventConsumer {
+	return &EventConsumer{timelineRepo: timelineRepo, tweetRepo: tweetRepo, trends: trendEngine, hub: hub}
+}
+
+// Subscribe registra el consumidor en broker para cada tipo de evento que cambia los timelines
+func (c *EventConsumer) Subscribe(broker events.Broker) error {
+	handlers := []struct {
+		eventType string
+		handler   events.Handler
+	}{
+		{events.TypeTweetCreated, c.tweetCreated},
+		{events.TypeTweetUpdated, c.tweetUpdated},
+		{events.TypeTweetDeleted, c.tweetDeleted},
+		{events.TypeTweetRestored, c.tweetRestored},
+		{events.TypeUserFollowed, c.userFollowed},
+		{events.TypeUserUnfollowed, c.userUnfollowed},
+		{events.TypeUserBlocked, c.userBlocked},
+	}
+	for _, h := range handlers {
+		if err := broker.Subscribe(Name, h.eventType, h.handler); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SubscribeInstance registra los handlers del estado que cada instancia guarda en memoria, que necesitan
+// todos los eventos y no solo los que le tocan a la instancia: las tendencias reciben los tweets desde
+// Since para empezar con los mismos conteos que las demás instancias, y el hub los tweets nuevos para
+// las conexiones de streaming abiertas en la instancia.
+func (c *EventConsumer) SubscribeInstance(broker events.Broker) error {
+	if err := broker.SubscribeInstance(events.TypeTweetCreated, c.trends.Since(), c.recordTrends); err != nil {
+		return err
+	}
+	if err := broker.SubscribeInstance(events.TypeTweetCreated, time.Time{}, c.streamCreated); err != nil {
+		return err
+	}
+	return broker.SubscribeInstance(events.TypeTweetRestored, time.Time{}, c.streamRestored)
+}
+
+// tweetCreated distribuye el tweet nuevo a los seguidores
+func (c *EventConsumer) tweetCreated(_ context.Context, event events.Event) error {
+	var payload events.TweetCreated
+	if !decode(event, &payload) {
+		return nil
+	}
+	return c.timelineRepo.ApplyEvent(event.ID, func() error {
+		tweet, err := c.fetchTweet(payload.TweetID)
+		if err != nil || tweet == nil {
+			return err
+		}
+		return c.timelineRepo.FanoutTweet(*tweet)
+	})
+}
+
+// streamCreated envía el tweet nuevo a las conexiones de streaming de la instancia
+func (c *EventConsumer) streamCreated(_ context.Context, event events.Event) error {
+	var payload events.TweetCreated
+	if !decode(event, &payload) {
+		return nil
+	}
+	return c.publishToStreams(payload.TweetID)
+}
+
+// streamRestored envía el tweet restaurado a las conexiones de streaming de la instancia
+func (c *EventConsumer) streamRestored(_ context.Context, event events.Event) error {
+	var payload events.TweetRestored
+	if !decode(event, &payload) {
+		return nil
+	}
+	return c.publishToStreams(payload.TweetID)
+}
+
+func (c *EventConsumer) publishToStreams(tweetID uint) error {
+	tweet, err := c.fetchTweet(tweetID)
+	if err != nil || tweet == nil {
+		return err
+	}
+	c.hub.Publish(*tweet)
+	return nil
+}
+
+// recordTrends cuenta el tweet nuevo en las tendencias. Los retweets y los tweets que ya salieron de la
+// ventana no cuentan, así que no se piden a tweet-service.
+func (c *EventConsumer) recordTrends(_ context.Context, event events.Event) error {
+	var payload events.TweetCreated
+	if !decode(event, &payload) || payload.RetweetOfTweetID != nil || payload.CreatedAt.Before(c.trends.Since()) {
+		return nil
+	}
+	tweet, err := c.fetchTweet(payload.TweetID)
+	if err != nil || tweet == nil {
+		return err
+	}
+	c.trends.Record(*tweet)
+	return nil
+}
+
+// tweetUpdated reemplaza el contenido guardado del tweet editado
+func (c *EventConsumer) tweetUpdated(_ context.Context, event events.Event) error {
+	var payload events.TweetUpdated
+	if !decode(event, &payload) {
+		return nil
+	}
+	return c.timelineRepo.ApplyEvent(event.ID, func() error {
+		tweet, err := c.fetchTweet(payload.TweetID)
+		if err != nil || tweet == nil {
+			return err
+		}
+		return c.timelineRepo.UpdateTweet(*tweet)
+	})
+}
+
+// tweetDeleted quita el tweet de los timelines; el autor se resuelve desde el store
+func (c *EventConsumer) tweetDeleted(_ context.Context, event events.Event) error {
+	var payload events.TweetDeleted
+	if !decode(event, &payload) {
+		return nil
+	}
+	return c.timelineRepo.ApplyEvent(event.ID, func() error {
+		return c.timelineRepo.RemoveTweet(payload.TweetID, "")
+	})
+}
+
+// tweetRestored devuelve el tweet restaurado a los timelines
+func (c *EventConsumer) tweetRestored(_ context.Context, event events.Event) error {
+	var payload events.TweetRestored
+	if !decode(event, &payload) {
+		return nil
+	}
+	return c.timelineRepo.ApplyEvent(event.ID, func() error {
+		tweet, err := c.fetchTweet(payload.TweetID)
+		if err != nil || tweet == nil {
+			return err
+		}
+		return c.timelineRepo.FanoutTweet(*tweet)
+	})
+}
+
+// userFollowed agrega los tweets del autor seguido al timeline de quien lo sigue
+func (c *EventConsumer) userFollowed(_ context.Context, event events.Event) error {
+	var payload events.UserFollowed
+	if !decode(event, &payload) {
+		return nil
+	}
+	return c.timelineRepo.ApplyEvent(event.ID, func() error {
+		return c.timelineRepo.FollowAuthor(payload.FollowerUsername, payload.FollowedUsername)
+	})
+}
+
+// userUnfollowed quita los tweets del autor del timeline de quien dejó de seguirlo
+func (c *EventConsumer) userUnfollowed(_ context.Context, event events.Event) error {
+	var payload events.UserUnfollowed
+	if !decode(event, &payload) {
+		return nil
+	}
+	return c.timelineRepo.ApplyEvent(event.ID, func() error {
+		return c.timelineRepo.UnfollowAuthor(payload.FollowerUsername, payload.FollowedUsername)
+	})
+}
+
+// userBlocked descarta los timelines de ambos usuarios, que se reconstruyen sin el otro al leerlos
+func (c *EventConsumer) userBlocked(_ context.Context, event events.Event) error {
+	var payload events.UserBlocked
+	if !decode(event, &payload) {
+		return nil
+	}
+	return c.timelineRepo.ApplyEvent(event.ID, func() error {
+		return c.timelineRepo.DropHomeTimelines(payload.BlockerUsername, payload.BlockedUsername)
+	})
+}
+
+// fetchTweet pide el tweet a tweet-service. Devuelve nil sin error si el tweet ya no existe: se
+// eliminó después del evento y su TweetDeleted lo quita de los timelines.
+func (c *EventConsumer) fetchTweet(id uint) (*domain.Tweet, error) {
+	tweet, err := c.tweetRepo.GetTweet(id)
+	if errors.Is(err, persistence.ErrTweetNotFound) {
+		return nil, nil
+	}
+	return tweet, err
+}
+
+// decode lee el contenido del evento. Un evento que no se puede leer no se reintenta: solo se registra.
+func decode(event events.Event, payload events.Payload) bool {
+	if err := event.Decode(payload); err != nil {
+		log.Printf("Evento %s inválido (%s): %v", event.ID, event.Type, err)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,150 @@
+package persistence
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/DevOpslp/microblogging-platform/auth"
+	"github.com/DevOpslp/microblogging-platform/timeline-service/internal/domain"
+)
+
+// ErrTweetNotFound se devuelve cuando tweet-service no tiene el tweet o ya fue eliminado
+var ErrTweetNotFound = errors.New("tweet no encontrado")
+
+type TweetRepository interface {
+	// GetTweet devuelve el tweet con el tweet retuiteado o citado embebido
+	GetTweet(id uint) (*domain.Tweet, error)
+	GetTweetsByUsername(username string) ([]domain.Tweet, error)
+	GetTweetsMentioning(username string) ([]domain.Tweet, error)
+	// Interacciones recientes de username con cada autor, por username del autor
+	GetAuthorAffinity(username string) (map[string]domain.AuthorAffinity, error)
+	// Contadores actuales de los tweets; los que no existen no aparecen en el resultado
+	GetEngagement(tweetIDs []uint) (map[uint]domain.Engagement, error)
+}
+
+// Las peticiones usan un token de servicio para que tweet-service incluya los tweets de cuentas
+// privadas; TimelineRepository decide qué puede ver cada lector.
+type HTTPTweetRepository struct {
+	baseURL string
+	tokens  *auth.TokenManager
+}
+
+// baseURL apunta al recurso /tweets de tweet-service (ej. http://tweet-service:8081/tweets)
+func NewHTTPTweetRepository(baseURL string, tokens *auth.TokenManager) *HTTPTweetRepository {
+	return &HTTPTweetRepository{baseURL: baseURL, tokens: tokens}
+}
+
+// Cantidad de tweets recientes que se piden por autor al materializar un timeline (máximo de tweet-service)
+const tweetsPerAuthor = 100
+
+// Obtener un tweet por su ID usando el endpoint /tweets/:id
+func (repo *HTTPTweetRepository) GetTweet(id uint) (*domain.Tweet, error) {
+	var tweet domain.Tweet
+	if err := repo.get(fmt.Sprintf("%s/%d", repo.baseURL, id), &tweet, ErrTweetNotFound); err != nil {
+		return nil, err
+	}
+	return &tweet, nil
+}
+
+// Obtener los tweets más recientes publicados por un usuario
+func (repo *HTTPTweetRepository) GetTweetsByUsername(username string) ([]domain.Tweet, error) {
+	return repo.getTweets(fmt.Sprintf("%s/user/%s?limit=%d", repo.baseURL, url.PathEscape(username), tweetsPerAuthor))
+}
+
+// Obtener los tweets más recientes que mencionan a un usuario
+func (repo *HTTPTweetRepository) GetTweetsMentioning(username string) ([]domain.Tweet, error) {
+	return repo.getTweets(fmt.Sprintf("%s/mentions/%s?limit=%d", repo.baseURL, url.PathEscape(username), tweetsPerAuthor))
+}
+
+// Obtener los likes y respuestas de un usuario a cada autor usando el endpoint /tweets/affinity/:username
+func (repo *HTTPTweetRepository) GetAuthorAffinity(username string) (map[string]domain.AuthorAffinity, error) {
+	var result struct {
+		Affinity []struct {
+			Username string `json:"username"`
+			domain.AuthorAffinity
+		} `json:"affinity"`
+	}
+	if err := repo.get(fmt.Sprintf("%s/affinity/%s", repo.baseURL, url.PathEscape(username)), &result, ErrUserNotFound); err != nil {
+		return nil, err
+	}
+
+	affinity := make(map[string]domain.AuthorAffinity, len(result.Affinity))
+	for _, author := range result.Affinity {
+		affinity[author.Username] = author.AuthorAffinity
+	}
+	return affinity, nil
+}
+
+// Máximo de tweets por petición a /tweets/engagement de tweet-service
+const maxEngagementTweets = 100
+
+// Obtener los contadores actuales de los tweets usando el endpoint /tweets/engagement, en lotes de
+// maxEngagementTweets
+func (repo *HTTPTweetRepository) GetEngagement(tweetIDs []uint) (map[uint]domain.Engagement, error) {
+	engagement := make(map[uint]domain.Engagement, len(tweetIDs))
+	for start := 0; start < len(tweetIDs); start += maxEngagementTweets {
+		batch := tweetIDs[start:min(start+maxEngagementTweets, len(tweetIDs))]
+		ids := make([]string, len(batch))
+		for i, id := range batch {
+			ids[i] = strconv.FormatUint(uint64(id), 10)
+		}
+
+		var result struct {
+			Engagement []struct {
+				TweetID uint `json:"tweet_id"`
+				domain.Engagement
+			} `json:"engagement"`
+		}
+		if err := repo.get(fmt.Sprintf("%s/engagement?ids=%s", repo.baseURL, strings.Join(ids, ",")), &result, ErrUserNotFound); err != nil {
+			return nil, err
+		}
+		for _, tweet := range result.Engagement {
+			engagement[tweet.TweetID] = tweet.Engagement
+		}
+	}
+	return engagement, nil
+}
+
+func (repo *HTTPTweetRepository) getTweets(endpoint string) ([]domain.Tweet, error) {
+	var result struct {
+		Tweets []domain.Tweet `json:"tweets"`
+	}
+	if err := repo.get(endpoint, &result, ErrUserNotFound); err != nil {
+		return nil, err
+	}
+	return result.Tweets, nil
+}
+
+// get hace una petición GET a tweet-service con un token de servicio y decodifica la respuesta en result.
+// Un 404 se devuelve como notFound.
+func (repo *HTTPTweetRepository) get(endpoint string, result interface{}, notFound error) error {
+	token, err := repo.tokens.IssueServiceToken("timeline-service")
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", auth.BearerHeader(token))
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return notFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("error: tweet-service devolvió estado %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
@@ -0,0 +1,187 @@
+package persistence
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/DevOpslp/microblogging-platform/auth"
+)
+
+// Máximo de usernames por petición a /users/batch de user-service
+const maxBatchUsers = 100
+
+// ErrUserNotFound se devuelve cuando user-service no reconoce al usuario solicitado
+var ErrUserNotFound = errors.New("usuario no encontrado")
+
+type UserRepository interface {
+	GetFollowing(username string) ([]string, error)
+	// Seguidos de varios usuarios a la vez; los usernames inexistentes se omiten
+	GetFollowingBatch(usernames []string) (map[string][]string, error)
+	GetFollowers(username string) ([]string, error)
+	// Indicar cuáles de los usernames tienen la cuenta privada
+	GetPrivateUsernames(usernames []string) (map[string]bool, error)
+	// Usuarios cuyos tweets no se le muestran a username: bloqueados en cualquier dirección o silenciados
+	GetHiddenUsernames(username string) ([]string, error)
+}
+
+// Las peticiones llevan un token de servicio, que user-service exige para consultar los bloqueos
+type HTTPUserRepository struct {
+	baseURL string
+	tokens  *auth.TokenManager
+}
+
+func NewHTTPUserRepository(baseURL string, tokens *auth.TokenManager) *HTTPUserRepository {
+	return &HTTPUserRepository{baseURL: baseURL, tokens: tokens}
+}
+
+// Obtener los usernames que sigue un usuario usando el endpoint /users/:username/following de user-service
+func (repo *HTTPUserRepository) GetFollowing(username string) ([]string, error) {
+	return repo.getUsernames("following", username)
+}
+
+// Obtener los seguidos de varios usuarios usando el endpoint /users/following de user-service, en lotes
+// de maxBatchUsers
+func (repo *HTTPUserRepository) GetFollowingBatch(usernames []string) (map[string][]string, error) {
+	following := make(map[string][]string, len(usernames))
+	for start := 0; start < len(usernames); start += maxBatchUsers {
+		batch := usernames[start:min(start+maxBatchUsers, len(usernames))]
+		if err := repo.addFollowing(batch, following); err != nil {
+			return nil, err
+		}
+	}
+	return following, nil
+}
+
+func (repo *HTTPUserRepository) addFollowing(usernames []string, following map[string][]string) error {
+	escaped := make([]string, len(usernames))
+	for i, username := range usernames {
+		escaped[i] = url.QueryEscape(username)
+	}
+
+	resp, err := repo.get(fmt.Sprintf("%s/users/following?usernames=%s", repo.baseURL, strings.Join(escaped, ",")))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("error: user-service devolvió estado %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Following map[string][]string `json:"following"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return err
+	}
+	for username, followed := range result.Following {
+		following[username] = followed
+	}
+	return nil
+}
+
+// Obtener los usernames que siguen a un usuario usando el endpoint /users/:username/followers de user-service
+func (repo *HTTPUserRepository) GetFollowers(username string) ([]string, error) {
+	return repo.getUsernames("followers", username)
+}
+
+// Obtener los usuarios bloqueados o silenciados por un usuario, y los que lo bloquearon, usando el
+// endpoint /users/:username/hidden de user-service
+func (repo *HTTPUserRepository) GetHiddenUsernames(username string) ([]string, error) {
+	return repo.getUsernames("hidden", username)
+}
+
+// get hace una petición GET a user-service autenticada con un token de servicio
+func (repo *HTTPUserRepository) get(endpoint string) (*http.Response, error) {
+	token, err := repo.tokens.IssueServiceToken("timeline-service")
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", auth.BearerHeader(token))
+	return http.DefaultClient.Do(req)
+}
+
+func (repo *HTTPUserRepository) getUsernames(field, username string) ([]string, error) {
+	resp, err := repo.get(fmt.Sprintf("%s/users/%s/%s", repo.baseURL, url.PathEscape(username), field))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// user-service responde 404 cuando el username no existe
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrUserNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error: user-service devolvió estado %d", resp.StatusCode)
+	}
+
+	var result map[string][]struct {
+		Username string `json:"username"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	usernames := make([]string, 0, len(result[field]))
+	for _, user := range result[field] {
+		usernames = append(usernames, user.Username)
+	}
+	return usernames, nil
+}
+
+// Obtener qué usuarios tienen la cuenta privada usando el endpoint /users/batch de user-service,
+// en lotes de maxBatchUsers. Los usernames inexistentes se omiten.
+func (repo *HTTPUserRepository) GetPrivateUsernames(usernames []string) (map[string]bool, error) {
+	private := make(map[string]bool)
+	for start := 0; start < len(usernames); start += maxBatchUsers {
+		batch := usernames[start:min(start+maxBatchUsers, len(usernames))]
+		if err := repo.addPrivateUsernames(batch, private); err != nil {
+			return nil, err
+		}
+	}
+	return private, nil
+}
+
+func (repo *HTTPUserRepository) addPrivateUsernames(usernames []string, private map[string]bool) error {
+	escaped := make([]string, len(usernames))
+	for i, username := range usernames {
+		escaped[i] = url.QueryEscape(username)
+	}
+
+	resp, err := repo.get(fmt.Sprintf("%s/users/batch?usernames=%s", repo.baseURL, strings.Join(escaped, ",")))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("error: user-service devolvió estado %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Users []struct {
+			Username  string `json:"username"`
+			IsPrivate bool   `json:"is_private"`
+		} `json:"users"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return err
+	}
+
+	for _, user := range result.Users {
+		if user.IsPrivate {
+			private[user.Username] = true
+		}
+	}
+	return nil
+}
@@ -0,0 +1,376 @@
+package persistence
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+
+	"github.com/DevOpslp/microblogging-platform/timeline-service/internal/domain"
+)
+
+// Cantidad de tweets a partir de la cual MemoryTimelineStore descarta los que ningún timeline guardado
+// incluye, empezando por los más antiguos. Los tweets que algún timeline incluye nunca se descartan; como
+// cada timeline se recorta a MaxTimelineLength, ocupan como máximo MaxTimelineLength por timeline.
+const MaxStoredTweets = 100000
+
+// MemoryTimelineStore implementa TimelineStore en memoria; pensado para desarrollo y pruebas
+type MemoryTimelineStore struct {
+	mu        sync.RWMutex
+	tweets    map[uint]domain.Tweet
+	maxTweets int
+	// Cantidad de entradas de timelines (inicio, menciones y tweets de cada autor) que usan cada tweet
+	refs map[uint]int
+	// Tweets que ningún timeline usa, el más antiguo primero. Puede tener entradas que volvieron a usarse
+	// o que ya se borraron; se ignoran al descartar.
+	unreferenced entryHeap
+	home         map[string][]domain.TimelineEntry
+	mentions     map[string][]domain.TimelineEntry
+	userTweets   map[string][]domain.TimelineEntry
+	highFanout   map[string]bool
+	// Vencimiento de cada evento aplicado; processedOrder los guarda en el orden en que vencen
+	processed      map[string]time.Time
+	processedOrder []string
+}
+
+func NewMemoryTimelineStore() *MemoryTimelineStore {
+	return &MemoryTimelineStore{
+		tweets:     make(map[uint]domain.Tweet),
+		maxTweets:  MaxStoredTweets,
+		refs:       make(map[uint]int),
+		home:       make(map[string][]domain.TimelineEntry),
+		mentions:   make(map[string][]domain.TimelineEntry),
+		userTweets: make(map[string][]domain.TimelineEntry),
+		highFanout: make(map[string]bool),
+		processed:  make(map[string]time.Time),
+	}
+}
+
+func (s *MemoryTimelineStore) SaveTweets(tweets ...domain.Tweet) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, tweet := range tweets {
+		if _, ok := s.tweets[tweet.ID]; !ok && s.refs[tweet.ID] == 0 {
+			heap.Push(&s.unreferenced, tweet.Entry())
+		}
+		s.tweets[tweet.ID] = tweet
+	}
+	// No se descarta nada todavía: los tweets recién guardados se agregan a los timelines a continuación
+	return nil
+}
+
+// updateTimeline reemplaza el timeline de username por el resultado de change y actualiza las referencias
+// a los tweets. Con remove el timeline se elimina. Después descarta los tweets que sobran.
+func (s *MemoryTimelineStore) updateTimeline(timelines map[string][]domain.TimelineEntry, username string, remove bool, change func([]domain.TimelineEntry) []domain.TimelineEntry) {
+	// change puede reutilizar el slice anterior, así que las referencias se liberan antes
+	old := timelines[username]
+	released := make([]domain.TimelineEntry, 0, len(old))
+	for _, entry := range old {
+		s.refs[entry.TweetID]--
+		released = append(released, entry)
+	}
+
+	if remove {
+		delete(timelines, username)
+	} else {
+		updated := change(old)
+		for _, entry := range updated {
+			s.refs[entry.TweetID]++
+		}
+		timelines[username] = updated
+	}
+
+	for _, entry := range released {
+		if s.refs[entry.TweetID] > 0 {
+			continue
+		}
+		delete(s.refs, entry.TweetID)
+		if _, ok := s.tweets[entry.TweetID]; ok {
+			heap.Push(&s.unreferenced, entry)
+		}
+	}
+	s.trimTweets()
+}
+
+// trimTweets descarta los tweets más antiguos que ningún timeline usa hasta quedar en maxTweets. Si el
+// heap acumuló muchas entradas que ya no sirven se reconstruye.
+func (s *MemoryTimelineStore) trimTweets() {
+	for len(s.tweets) > s.maxTweets && s.unreferenced.Len() > 0 {
+		entry := heap.Pop(&s.unreferenced).(domain.TimelineEntry)
+		if s.refs[entry.TweetID] == 0 {
+			delete(s.tweets, entry.TweetID)
+		}
+	}
+	if s.unreferenced.Len() > 2*len(s.tweets) {
+		s.unreferenced = make(entryHeap, 0)
+		for id, tweet := range s.tweets {
+			if s.refs[id] == 0 {
+				s.unreferenced = append(s.unreferenced, tweet.Entry())
+			}
+		}
+		heap.Init(&s.unreferenced)
+	}
+}
+
+func (s *MemoryTimelineStore) GetTweets(ids []uint) (map[uint]domain.Tweet, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tweets := make(map[uint]domain.Tweet, len(ids))
+	for _, id := range ids {
+		if tweet, ok := s.tweets[id]; ok {
+			tweets[id] = tweet
+		}
+	}
+	return tweets, nil
+}
+
+func (s *MemoryTimelineStore) DeleteTweet(tweetID uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.tweets, tweetID)
+	return nil
+}
+
+func (s *MemoryTimelineStore) SetHomeTimeline(username string, entries []domain.TimelineEntry) error {
+	return s.setTimeline(s.home, username, entries)
+}
+
+func (s *MemoryTimelineStore) AddToHomeTimelines(usernames []string, entry domain.TimelineEntry) error {
+	return s.addToTimelines(s.home, usernames, entry)
+}
+
+func (s *MemoryTimelineStore) RemoveFromHomeTimelines(usernames []string, tweetID uint) error {
+	return s.removeFromTimelines(s.home, usernames, tweetID)
+}
+
+func (s *MemoryTimelineStore) GetHomeTimeline(username string, before *domain.TimelineEntry, limit int) ([]domain.TimelineEntry, bool, error) {
+	return s.getTimeline(s.home, username, before, limit)
+}
+
+func (s *MemoryTimelineStore) AddToHomeTimeline(username string, entries []domain.TimelineEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.home[username]; !ok {
+		return nil
+	}
+	s.updateTimeline(s.home, username, false, func(current []domain.TimelineEntry) []domain.TimelineEntry {
+		merged := append(append([]domain.TimelineEntry{}, current...), entries...)
+		return truncateEntries(sortEntries(merged), MaxTimelineLength)
+	})
+	return nil
+}
+
+func (s *MemoryTimelineStore) RemoveFromHomeTimeline(username string, tweetIDs []uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.home[username]; !ok {
+		return nil
+	}
+	s.updateTimeline(s.home, username, false, func(entries []domain.TimelineEntry) []domain.TimelineEntry {
+		for _, id := range tweetIDs {
+			entries = removeEntry(entries, id)
+		}
+		return entries
+	})
+	return nil
+}
+
+func (s *MemoryTimelineStore) DeleteHomeTimeline(username string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.updateTimeline(s.home, username, true, nil)
+	return nil
+}
+
+func (s *MemoryTimelineStore) SetMentionsTimeline(username string, entries []domain.TimelineEntry) error {
+	return s.setTimeline(s.mentions, username, entries)
+}
+
+func (s *MemoryTimelineStore) AddToMentionsTimelines(usernames []string, entry domain.TimelineEntry) error {
+	return s.addToTimelines(s.mentions, usernames, entry)
+}
+
+func (s *MemoryTimelineStore) RemoveFromMentionsTimelines(usernames []string, tweetID uint) error {
+	return s.removeFromTimelines(s.mentions, usernames, tweetID)
+}
+
+func (s *MemoryTimelineStore) GetMentionsTimeline(username string, before *domain.TimelineEntry, limit int) ([]domain.TimelineEntry, bool, error) {
+	return s.getTimeline(s.mentions, username, before, limit)
+}
+
+func (s *MemoryTimelineStore) setTimeline(timelines map[string][]domain.TimelineEntry, username string, entries []domain.TimelineEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.updateTimeline(timelines, username, false, func([]domain.TimelineEntry) []domain.TimelineEntry {
+		copied := append([]domain.TimelineEntry{}, entries...)
+		return truncateEntries(sortEntries(copied), MaxTimelineLength)
+	})
+	return nil
+}
+
+// addToTimelines agrega la entrada solo a los timelines que ya fueron materializados
+func (s *MemoryTimelineStore) addToTimelines(timelines map[string][]domain.TimelineEntry, usernames []string, entry domain.TimelineEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, username := range usernames {
+		if _, ok := timelines[username]; !ok {
+			continue
+		}
+		s.updateTimeline(timelines, username, false, func(entries []domain.TimelineEntry) []domain.TimelineEntry {
+			return insertEntry(entries, entry)
+		})
+	}
+	return nil
+}
+
+func (s *MemoryTimelineStore) removeFromTimelines(timelines map[string][]domain.TimelineEntry, usernames []string, tweetID uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, username := range usernames {
+		if _, ok := timelines[username]; ok {
+			s.updateTimeline(timelines, username, false, func(entries []domain.TimelineEntry) []domain.TimelineEntry {
+				return removeEntry(entries, tweetID)
+			})
+		}
+	}
+	return nil
+}
+
+func (s *MemoryTimelineStore) getTimeline(timelines map[string][]domain.TimelineEntry, username string, before *domain.TimelineEntry, limit int) ([]domain.TimelineEntry, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entries, ok := timelines[username]
+	if !ok {
+		return nil, false, nil
+	}
+	return append([]domain.TimelineEntry{}, pageEntries(entries, before, limit)...), true, nil
+}
+
+func (s *MemoryTimelineStore) AddToUserTweets(username string, entry domain.TimelineEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.updateTimeline(s.userTweets, username, false, func(entries []domain.TimelineEntry) []domain.TimelineEntry {
+		return insertEntry(entries, entry)
+	})
+	return nil
+}
+
+func (s *MemoryTimelineStore) RemoveFromUserTweets(username string, tweetID uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.updateTimeline(s.userTweets, username, false, func(entries []domain.TimelineEntry) []domain.TimelineEntry {
+		return removeEntry(entries, tweetID)
+	})
+	return nil
+}
+
+func (s *MemoryTimelineStore) GetUserTweets(username string, before *domain.TimelineEntry, limit int) ([]domain.TimelineEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return append([]domain.TimelineEntry{}, pageEntries(s.userTweets[username], before, limit)...), nil
+}
+
+func (s *MemoryTimelineStore) MarkHighFanout(username string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.highFanout[username] = true
+	return nil
+}
+
+func (s *MemoryTimelineStore) UnmarkHighFanout(username string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	marked := s.highFanout[username]
+	delete(s.highFanout, username)
+	return marked, nil
+}
+
+func (s *MemoryTimelineStore) GetHighFanoutAuthors() ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	authors := make([]string, 0, len(s.highFanout))
+	for username := range s.highFanout {
+		authors = append(authors, username)
+	}
+	return authors, nil
+}
+
+func (s *MemoryTimelineStore) EventProcessed(eventID string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	expiresAt, ok := s.processed[eventID]
+	return ok && time.Now().Before(expiresAt), nil
+}
+
+// MarkEventProcessed descarta de paso las marcas vencidas
+func (s *MemoryTimelineStore) MarkEventProcessed(eventID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for len(s.processedOrder) > 0 && !now.Before(s.processed[s.processedOrder[0]]) {
+		delete(s.processed, s.processedOrder[0])
+		s.processedOrder = s.processedOrder[1:]
+	}
+	if _, ok := s.processed[eventID]; !ok {
+		s.processed[eventID] = now.Add(ProcessedEventRetention)
+		s.processedOrder = append(s.processedOrder, eventID)
+	}
+	return nil
+}
+
+// insertEntry agrega la entrada manteniendo el orden y el largo máximo del timeline
+func insertEntry(entries []domain.TimelineEntry, entry domain.TimelineEntry) []domain.TimelineEntry {
+	entries = removeEntry(entries, entry.TweetID)
+	i := 0
+	for i < len(entries) && entries[i].Before(entry) {
+		i++
+	}
+	entries = append(entries, domain.TimelineEntry{})
+	copy(entries[i+1:], entries[i:])
+	entries[i] = entry
+	return truncateEntries(entries, MaxTimelineLength)
+}
+
+func removeEntry(entries []domain.TimelineEntry, tweetID uint) []domain.TimelineEntry {
+	for i, entry := range entries {
+		if entry.TweetID == tweetID {
+			return append(entries[:i], entries[i+1:]...)
+		}
+	}
+	return entries
+}
+
+// entryHeap implementa heap.Interface con la entrada más antigua primero
+type entryHeap []domain.TimelineEntry
+
+func (h entryHeap) Len() int           { return len(h) }
+func (h entryHeap) Less(i, j int) bool { return h[j].Before(h[i]) }
+func (h entryHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *entryHeap) Push(x interface{}) {
+	*h = append(*h, x.(domain.TimelineEntry))
+}
+
+func (h *entryHeap) Pop() interface{} {
+	old := *h
+	entry := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return entry
+}
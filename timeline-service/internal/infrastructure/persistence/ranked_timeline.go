@@ -0,0 +1,230 @@
+package persistence
+
+import (
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/DevOpslp/microblogging-platform/timeline-service/internal/domain"
+)
+
+const (
+	// Tweets más recientes del timeline cronológico que se consideran para el timeline ordenado
+	rankedFollowedCandidates = 200
+	// Usuarios seguidos cuyos seguidos se consultan para buscar autores de segundo grado
+	rankedSecondDegreeSources = 20
+	// Tiempo y cantidad de usuarios por los que se guardan los seguidos de las fuentes de segundo grado
+	followingCacheTTL        = 5 * time.Minute
+	followingCacheMaxEntries = 10000
+	// Autores de segundo grado, los más seguidos por los seguidos del lector, y tweets de cada uno
+	rankedSecondDegreeAuthors = 10
+	rankedTweetsPerAuthor     = 5
+)
+
+// SetScorer cambia el criterio con que se ordena el timeline por relevancia
+func (repo *TimelineRepository) SetScorer(scorer domain.Scorer) {
+	repo.scorer = scorer
+}
+
+// GetRankedTimeline devuelve la página de hasta limit tweets que empieza en offset del timeline ordenado
+// por relevancia a la hora asOf, e indica si hay más páginas. Los candidatos son los tweets recientes
+// del timeline cronológico y los de autores de segundo grado (seguidos por los usuarios que sigue el
+// lector), publicados hasta asOf y puntuados con el Scorer del repositorio a partir de la afinidad del
+// lector con cada autor y de las interacciones de cada tweet (ver domain.RankPage). Las páginas
+// siguientes se piden con el mismo asOf para que los tweets nuevos no corran el orden.
+func (repo *TimelineRepository) GetRankedTimeline(username string, asOf time.Time, offset, limit int) ([]domain.Tweet, bool, error) {
+	followed, _, err := repo.GetHomeTimeline(username, nil, rankedFollowedCandidates)
+	if err != nil {
+		return nil, false, err
+	}
+	affinity, err := repo.tweetRepo.GetAuthorAffinity(username)
+	if err != nil {
+		return nil, false, err
+	}
+	secondDegree, err := repo.secondDegreeTweets(username, affinity)
+	if err != nil {
+		return nil, false, err
+	}
+
+	candidates := make([]domain.Candidate, 0, len(followed)+len(secondDegree))
+	seen := make(map[uint]bool)
+	for _, tweet := range followed {
+		if !tweet.CreatedAt.After(asOf) {
+			seen[tweet.OriginalID()] = true
+			candidates = append(candidates, domain.Candidate{Tweet: tweet})
+		}
+	}
+	for _, tweet := range secondDegree {
+		if !seen[tweet.OriginalID()] && !tweet.CreatedAt.After(asOf) {
+			seen[tweet.OriginalID()] = true
+			candidates = append(candidates, domain.Candidate{Tweet: tweet, SecondDegree: true})
+		}
+	}
+	if len(candidates) == 0 {
+		return []domain.Tweet{}, false, nil
+	}
+
+	ids := make([]uint, 0, len(candidates))
+	for _, candidate := range candidates {
+		ids = append(ids, candidate.Tweet.OriginalID())
+	}
+	engagement, err := repo.tweetRepo.GetEngagement(ids)
+	if err != nil {
+		return nil, false, err
+	}
+	for i := range candidates {
+		candidates[i].Affinity = affinity[candidates[i].Tweet.Author()]
+		candidates[i].Engagement = engagement[candidates[i].Tweet.OriginalID()]
+	}
+
+	tweets, more := domain.RankPage(candidates, repo.scorer, asOf, offset, limit)
+	return tweets, more, nil
+}
+
+// secondDegreeTweets devuelve los tweets recientes, sin retweets, de los autores más seguidos por los
+// usuarios que sigue el lector y que él no sigue. Las fuentes son los seguidos con los que el lector
+// más interactúa según affinity; entre los que tienen la misma afinidad se eligen al azar, para no
+// favorecer siempre a los mismos. Los seguidos de las fuentes se piden en lote y se guardan en
+// followingCache. Solo se consideran los tweets que timeline-service ya recibió de cada autor, sin
+// consultar a tweet-service.
+func (repo *TimelineRepository) secondDegreeTweets(username string, affinity map[string]domain.AuthorAffinity) ([]domain.Tweet, error) {
+	following, err := repo.userRepo.GetFollowing(username)
+	if err != nil {
+		return nil, err
+	}
+	excluded := map[string]bool{username: true}
+	for _, followed := range following {
+		excluded[followed] = true
+	}
+
+	sources := append([]string{}, following...)
+	rand.Shuffle(len(sources), func(i, j int) { sources[i], sources[j] = sources[j], sources[i] })
+	sort.SliceStable(sources, func(i, j int) bool {
+		return affinity[sources[i]].Weight() > affinity[sources[j]].Weight()
+	})
+	sources = sources[:min(len(sources), rankedSecondDegreeSources)]
+
+	sourceFollowing, err := repo.followingOf(sources)
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int)
+	for _, source := range sources {
+		for _, author := range sourceFollowing[source] {
+			if !excluded[author] {
+				counts[author]++
+			}
+		}
+	}
+	authors := make([]string, 0, len(counts))
+	for author := range counts {
+		authors = append(authors, author)
+	}
+	sort.Slice(authors, func(i, j int) bool {
+		if counts[authors[i]] != counts[authors[j]] {
+			return counts[authors[i]] > counts[authors[j]]
+		}
+		return authors[i] < authors[j]
+	})
+	authors = authors[:min(len(authors), rankedSecondDegreeAuthors)]
+
+	entries := make([]domain.TimelineEntry, 0)
+	for _, author := range authors {
+		authorEntries, err := repo.store.GetUserTweets(author, nil, rankedTweetsPerAuthor*2)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, authorEntries...)
+	}
+	tweets, err := repo.hydrate(entries)
+	if err != nil {
+		return nil, err
+	}
+
+	perAuthor := make(map[string]int)
+	original := make([]domain.Tweet, 0, len(tweets))
+	for _, tweet := range tweets {
+		if tweet.RetweetOfTweetID == nil && perAuthor[tweet.Username] < rankedTweetsPerAuthor {
+			perAuthor[tweet.Username]++
+			original = append(original, tweet)
+		}
+	}
+	return repo.hideAuthors(username, original)
+}
+
+// followingOf devuelve los seguidos de cada uno de los usernames: los que están en followingCache y
+// los demás con una sola consulta a user-service. Los usernames inexistentes no aparecen.
+func (repo *TimelineRepository) followingOf(usernames []string) (map[string][]string, error) {
+	following, missing := repo.followingCache.get(usernames, time.Now())
+	if len(missing) == 0 {
+		return following, nil
+	}
+
+	fetched, err := repo.userRepo.GetFollowingBatch(missing)
+	if err != nil {
+		return nil, err
+	}
+	repo.followingCache.put(fetched, time.Now())
+	for username, followed := range fetched {
+		following[username] = followed
+	}
+	return following, nil
+}
+
+// followingCache guarda por ttl los seguidos de los usuarios consultados como fuentes de segundo
+// grado, que se repiten entre lectores con seguidos en común. Guarda como máximo maxEntries usuarios.
+type followingCache struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	entries    map[string]cachedFollowing
+}
+
+type cachedFollowing struct {
+	following []string
+	expiresAt time.Time
+}
+
+func newFollowingCache(ttl time.Duration, maxEntries int) *followingCache {
+	return &followingCache{ttl: ttl, maxEntries: maxEntries, entries: make(map[string]cachedFollowing)}
+}
+
+// get devuelve los seguidos guardados y vigentes a la hora now, y los usernames que faltan
+func (c *followingCache) get(usernames []string, now time.Time) (map[string][]string, []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	found := make(map[string][]string, len(usernames))
+	missing := make([]string, 0)
+	for _, username := range usernames {
+		entry, ok := c.entries[username]
+		if ok && now.Before(entry.expiresAt) {
+			found[username] = entry.following
+		} else {
+			missing = append(missing, username)
+		}
+	}
+	return found, missing
+}
+
+// put guarda los seguidos consultados a la hora now. Si se llega al máximo se descartan los vencidos,
+// y si no alcanza, todos.
+func (c *followingCache) put(following map[string][]string, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.entries)+len(following) > c.maxEntries {
+		for username, entry := range c.entries {
+			if !now.Before(entry.expiresAt) {
+				delete(c.entries, username)
+			}
+		}
+		if len(c.entries)+len(following) > c.maxEntries {
+			c.entries = make(map[string]cachedFollowing)
+		}
+	}
+	for username, followed := range following {
+		c.entries[username] = cachedFollowing{following: followed, expiresAt: now.Add(c.ttl)}
+	}
+}
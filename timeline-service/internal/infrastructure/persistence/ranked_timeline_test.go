@@ -0,0 +1,145 @@
+package persistence
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DevOpslp/microblogging-platform/timeline-service/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+// signalTweetRepository agrega afinidad e interacciones a fakeTweetRepository
+type signalTweetRepository struct {
+	fakeTweetRepository
+	affinity   map[string]map[string]domain.AuthorAffinity
+	engagement map[uint]domain.Engagement
+}
+
+func (f signalTweetRepository) GetAuthorAffinity(username string) (map[string]domain.AuthorAffinity, error) {
+	return f.affinity[username], nil
+}
+
+func (f signalTweetRepository) GetEngagement(tweetIDs []uint) (map[uint]domain.Engagement, error) {
+	return f.engagement, nil
+}
+
+// batchCountingUserRepository cuenta las consultas en lote de seguidos
+type batchCountingUserRepository struct {
+	privateUserRepository
+	batchCalls *int
+}
+
+func (f batchCountingUserRepository) GetFollowingBatch(usernames []string) (map[string][]string, error) {
+	*f.batchCalls++
+	return f.privateUserRepository.GetFollowingBatch(usernames)
+}
+
+// idScorer ordena por ID, para probar que el criterio se puede reemplazar
+type idScorer struct{}
+
+func (idScorer) Score(candidate domain.Candidate, now time.Time) float64 {
+	return float64(candidate.Tweet.ID)
+}
+
+func TestTimelineRepositoryRankedTimeline(t *testing.T) {
+	batchCalls := 0
+	users := batchCountingUserRepository{
+		privateUserRepository: privateUserRepository{
+			fakeUserRepository: fakeUserRepository{
+				"reader": {"ana", "bea"},
+				"ana":    {"carla", "eva"},
+				"bea":    {"carla", "dani", "reader"},
+				"carla":  {}, "dani": {}, "eva": {},
+			},
+			private: map[string]bool{"eva": true},
+		},
+		batchCalls: &batchCalls,
+	}
+	tweets := signalTweetRepository{
+		affinity:   map[string]map[string]domain.AuthorAffinity{"reader": {"bea": {Likes: 10}}},
+		engagement: map[uint]domain.Engagement{7: {LikeCount: 100}},
+	}
+	store := NewMemoryTimelineStore()
+	repo := NewTimelineRepository(store, users, tweets, 0)
+
+	// Materializar el timeline vacío del lector
+	_, _, err := repo.GetHomeTimeline("reader", nil, 10)
+	assert.NoError(t, err)
+
+	for id := uint(1); id <= 5; id++ {
+		assert.NoError(t, repo.FanoutTweet(newTestTweet(id, "ana", 49+int(id))))
+	}
+	assert.NoError(t, repo.FanoutTweet(newTestTweet(6, "bea", 10)))
+	assert.NoError(t, repo.FanoutTweet(newTestTweet(7, "carla", 55)))
+	assert.NoError(t, repo.FanoutTweet(newTestTweet(8, "dani", 56)))
+	assert.NoError(t, repo.FanoutTweet(newTestTweet(9, "eva", 57)))
+	assert.NoError(t, repo.FanoutTweet(newTestTweet(10, "reader", 58)))
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	// bea sube por la afinidad y carla, de segundo grado, por sus likes. ana no llena la página: se
+	// intercala con dani y no pasa de domain.MaxTweetsPerAuthor. La cuenta privada de eva y los tweets
+	// propios no aparecen.
+	ranked, more, err := repo.GetRankedTimeline("reader", now, 0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint{6, 7, 5, 8, 4, 3}, entryIDs(tweetEntries(ranked)))
+	assert.True(t, more, "Los tweets de ana que no entraron quedan para la página siguiente")
+
+	// Los seguidos de las fuentes de segundo grado se piden en una sola consulta y se reutilizan
+	assert.Equal(t, 1, batchCalls)
+
+	// El resultado solo depende de los datos y de la hora
+	again, _, err := repo.GetRankedTimeline("reader", now, 0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, ranked, again)
+	assert.Equal(t, 1, batchCalls)
+
+	next, more, err := repo.GetRankedTimeline("reader", now, len(ranked), 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint{2, 1}, entryIDs(tweetEntries(next)))
+	assert.False(t, more)
+
+	ranked, _, err = repo.GetRankedTimeline("reader", now, 0, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint{6, 7}, entryIDs(tweetEntries(ranked)))
+
+	// Los tweets publicados después de la hora de la primera página no corren el orden
+	ranked, _, err = repo.GetRankedTimeline("reader", newTestTweet(0, "", 52).CreatedAt, 0, 10)
+	assert.NoError(t, err)
+	assert.NotContains(t, entryIDs(tweetEntries(ranked)), uint(4))
+	assert.NotContains(t, entryIDs(tweetEntries(ranked)), uint(7))
+
+	repo.SetScorer(idScorer{})
+	ranked, _, err = repo.GetRankedTimeline("reader", now, 0, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint{8, 7, 6}, entryIDs(tweetEntries(ranked)))
+
+	_, _, err = repo.GetRankedTimeline("desconocido", now, 0, 10)
+	assert.ErrorIs(t, err, ErrUserNotFound)
+}
+
+func TestFollowingCache(t *testing.T) {
+	cache := newFollowingCache(time.Minute, 2)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	cache.put(map[string][]string{"ana": {"bea"}}, now)
+	found, missing := cache.get([]string{"ana", "carla"}, now.Add(30*time.Second))
+	assert.Equal(t, map[string][]string{"ana": {"bea"}}, found)
+	assert.Equal(t, []string{"carla"}, missing)
+
+	// Vencido el ttl se vuelve a consultar
+	_, missing = cache.get([]string{"ana"}, now.Add(time.Minute))
+	assert.Equal(t, []string{"ana"}, missing)
+
+	// Al llegar al máximo se descartan los vencidos
+	cache.put(map[string][]string{"carla": {}, "dani": {}}, now.Add(2*time.Minute))
+	assert.Len(t, cache.entries, 2)
+}
+
+func tweetEntries(tweets []domain.Tweet) []domain.TimelineEntry {
+	entries := make([]domain.TimelineEntry, 0, len(tweets))
+	for _, tweet := range tweets {
+		entries = append(entries, tweet.Entry())
+	}
+	return entries
+}
@@ -0,0 +1,321 @@
+package persistence
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/DevOpslp/microblogging-platform/timeline-service/internal/domain"
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisTimelineStore implementa TimelineStore sobre cualquier servidor compatible con el protocolo de Redis.
+// Cada timeline es un sorted set con score = fecha de creación en microsegundos y miembro = ID del tweet;
+// el contenido de cada tweet se guarda como JSON en su propia clave, que vence a las TweetCacheTTL. Los
+// timelines de inicio y de menciones incluyen además el miembro redisEmptyMember, para que un timeline
+// materializado sin tweets siga existiendo (Redis elimina los sorted sets vacíos).
+type RedisTimelineStore struct {
+	client *redis.Client
+}
+
+const (
+	redisHomePrefix       = "timeline:home:"
+	redisMentionsPrefix   = "timeline:mentions:"
+	redisUserTweetsPrefix = "timeline:user:"
+	redisTweetPrefix      = "timeline:tweet:"
+	redisHighFanoutKey    = "timeline:high_fanout"
+	redisEventPrefix      = "timeline:event:"
+
+	// Miembro con score -inf que marca un timeline materializado; ningún tweet tiene ID 0
+	redisEmptyMember = "0"
+)
+
+// Agrega el miembro solo si el sorted set existe, recortándolo al largo máximo. El rango 0 es la marca
+// redisEmptyMember, que no se recorta.
+var addIfExistsScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 1 then
+	redis.call("ZADD", KEYS[1], ARGV[1], ARGV[2])
+	redis.call("ZREMRANGEBYRANK", KEYS[1], 1, -(tonumber(ARGV[3]) + 1))
+end
+return 0
+`)
+
+func NewRedisTimelineStore(addr, password string) *RedisTimelineStore {
+	return &RedisTimelineStore{client: redis.NewClient(&redis.Options{Addr: addr, Password: password})}
+}
+
+func (s *RedisTimelineStore) Ping() error {
+	return s.client.Ping(context.Background()).Err()
+}
+
+func (s *RedisTimelineStore) SaveTweets(tweets ...domain.Tweet) error {
+	if len(tweets) == 0 {
+		return nil
+	}
+	ctx := context.Background()
+
+	pipe := s.client.Pipeline()
+	for _, tweet := range tweets {
+		data, err := json.Marshal(tweet)
+		if err != nil {
+			return err
+		}
+		pipe.Set(ctx, tweetKey(tweet.ID), data, TweetCacheTTL)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (s *RedisTimelineStore) GetTweets(ids []uint) (map[uint]domain.Tweet, error) {
+	tweets := make(map[uint]domain.Tweet, len(ids))
+	if len(ids) == 0 {
+		return tweets, nil
+	}
+
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = tweetKey(id)
+	}
+
+	values, err := s.client.MGet(context.Background(), keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+	for _, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			continue
+		}
+		var tweet domain.Tweet
+		if err := json.Unmarshal([]byte(data), &tweet); err != nil {
+			return nil, fmt.Errorf("tweet inválido en redis: %w", err)
+		}
+		tweets[tweet.ID] = tweet
+	}
+	return tweets, nil
+}
+
+func (s *RedisTimelineStore) DeleteTweet(tweetID uint) error {
+	return s.client.Del(context.Background(), tweetKey(tweetID)).Err()
+}
+
+func (s *RedisTimelineStore) SetHomeTimeline(username string, entries []domain.TimelineEntry) error {
+	return s.setTimeline(redisHomePrefix+username, entries)
+}
+
+func (s *RedisTimelineStore) AddToHomeTimelines(usernames []string, entry domain.TimelineEntry) error {
+	return s.addToTimelines(redisHomePrefix, usernames, entry)
+}
+
+func (s *RedisTimelineStore) RemoveFromHomeTimelines(usernames []string, tweetID uint) error {
+	return s.removeFromTimelines(redisHomePrefix, usernames, tweetID)
+}
+
+func (s *RedisTimelineStore) GetHomeTimeline(username string, before *domain.TimelineEntry, limit int) ([]domain.TimelineEntry, bool, error) {
+	return s.getTimeline(redisHomePrefix+username, before, limit)
+}
+
+func (s *RedisTimelineStore) AddToHomeTimeline(username string, entries []domain.TimelineEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	ctx := context.Background()
+
+	pipe := s.client.Pipeline()
+	for _, entry := range entries {
+		addIfExistsScript.Eval(ctx, pipe, []string{redisHomePrefix + username}, entryScore(entry), entry.TweetID, MaxTimelineLength)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (s *RedisTimelineStore) RemoveFromHomeTimeline(username string, tweetIDs []uint) error {
+	if len(tweetIDs) == 0 {
+		return nil
+	}
+	members := make([]interface{}, len(tweetIDs))
+	for i, id := range tweetIDs {
+		members[i] = strconv.FormatUint(uint64(id), 10)
+	}
+	return s.client.ZRem(context.Background(), redisHomePrefix+username, members...).Err()
+}
+
+func (s *RedisTimelineStore) DeleteHomeTimeline(username string) error {
+	return s.client.Del(context.Background(), redisHomePrefix+username).Err()
+}
+
+func (s *RedisTimelineStore) SetMentionsTimeline(username string, entries []domain.TimelineEntry) error {
+	return s.setTimeline(redisMentionsPrefix+username, entries)
+}
+
+func (s *RedisTimelineStore) AddToMentionsTimelines(usernames []string, entry domain.TimelineEntry) error {
+	return s.addToTimelines(redisMentionsPrefix, usernames, entry)
+}
+
+func (s *RedisTimelineStore) RemoveFromMentionsTimelines(usernames []string, tweetID uint) error {
+	return s.removeFromTimelines(redisMentionsPrefix, usernames, tweetID)
+}
+
+func (s *RedisTimelineStore) GetMentionsTimeline(username string, before *domain.TimelineEntry, limit int) ([]domain.TimelineEntry, bool, error) {
+	return s.getTimeline(redisMentionsPrefix+username, before, limit)
+}
+
+func (s *RedisTimelineStore) setTimeline(key string, entries []domain.TimelineEntry) error {
+	ctx := context.Background()
+
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, key)
+		members := append(toMembers(entries), redis.Z{Score: math.Inf(-1), Member: redisEmptyMember})
+		pipe.ZAdd(ctx, key, members...)
+		pipe.ZRemRangeByRank(ctx, key, 1, -(MaxTimelineLength + 1))
+		return nil
+	})
+	return err
+}
+
+// addToTimelines agrega la entrada solo a los timelines que ya fueron materializados
+func (s *RedisTimelineStore) addToTimelines(prefix string, usernames []string, entry domain.TimelineEntry) error {
+	ctx := context.Background()
+
+	pipe := s.client.Pipeline()
+	for _, username := range usernames {
+		addIfExistsScript.Eval(ctx, pipe, []string{prefix + username}, entryScore(entry), entry.TweetID, MaxTimelineLength)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (s *RedisTimelineStore) removeFromTimelines(prefix string, usernames []string, tweetID uint) error {
+	ctx := context.Background()
+
+	pipe := s.client.Pipeline()
+	for _, username := range usernames {
+		pipe.ZRem(ctx, prefix+username, strconv.FormatUint(uint64(tweetID), 10))
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (s *RedisTimelineStore) getTimeline(key string, before *domain.TimelineEntry, limit int) ([]domain.TimelineEntry, bool, error) {
+	exists, err := s.client.Exists(context.Background(), key).Result()
+	if err != nil {
+		return nil, false, err
+	}
+	if exists == 0 {
+		return nil, false, nil
+	}
+
+	entries, err := s.rangeEntries(key, before, limit)
+	return entries, true, err
+}
+
+func (s *RedisTimelineStore) AddToUserTweets(username string, entry domain.TimelineEntry) error {
+	ctx := context.Background()
+	key := redisUserTweetsPrefix + username
+
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZAdd(ctx, key, toMembers([]domain.TimelineEntry{entry})...)
+		pipe.ZRemRangeByRank(ctx, key, 0, -(MaxTimelineLength + 1))
+		return nil
+	})
+	return err
+}
+
+func (s *RedisTimelineStore) RemoveFromUserTweets(username string, tweetID uint) error {
+	return s.client.ZRem(context.Background(), redisUserTweetsPrefix+username, strconv.FormatUint(uint64(tweetID), 10)).Err()
+}
+
+func (s *RedisTimelineStore) GetUserTweets(username string, before *domain.TimelineEntry, limit int) ([]domain.TimelineEntry, error) {
+	return s.rangeEntries(redisUserTweetsPrefix+username, before, limit)
+}
+
+func (s *RedisTimelineStore) MarkHighFanout(username string) error {
+	return s.client.SAdd(context.Background(), redisHighFanoutKey, username).Err()
+}
+
+func (s *RedisTimelineStore) UnmarkHighFanout(username string) (bool, error) {
+	removed, err := s.client.SRem(context.Background(), redisHighFanoutKey, username).Result()
+	return removed > 0, err
+}
+
+func (s *RedisTimelineStore) GetHighFanoutAuthors() ([]string, error) {
+	return s.client.SMembers(context.Background(), redisHighFanoutKey).Result()
+}
+
+func (s *RedisTimelineStore) EventProcessed(eventID string) (bool, error) {
+	count, err := s.client.Exists(context.Background(), redisEventPrefix+eventID).Result()
+	return count > 0, err
+}
+
+func (s *RedisTimelineStore) MarkEventProcessed(eventID string) error {
+	return s.client.Set(context.Background(), redisEventPrefix+eventID, 1, ProcessedEventRetention).Err()
+}
+
+func (s *RedisTimelineStore) rangeEntries(key string, before *domain.TimelineEntry, limit int) ([]domain.TimelineEntry, error) {
+	ctx := context.Background()
+
+	var members []redis.Z
+	var err error
+	if before == nil {
+		stop := int64(limit) - 1
+		if limit < 0 {
+			stop = -1
+		}
+		members, err = s.client.ZRevRangeWithScores(ctx, key, 0, stop).Result()
+	} else {
+		// Se incluyen las entradas con el mismo score que el cursor y luego se descartan por ID
+		score := strconv.FormatFloat(entryScore(*before), 'f', -1, 64)
+		ties, countErr := s.client.ZCount(ctx, key, score, score).Result()
+		if countErr != nil {
+			return nil, countErr
+		}
+		count := int64(-1)
+		if limit >= 0 {
+			count = int64(limit) + ties
+		}
+		members, err = s.client.ZRevRangeByScoreWithScores(ctx, key, &redis.ZRangeBy{
+			Max:   score,
+			Min:   "-inf",
+			Count: count,
+		}).Result()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]domain.TimelineEntry, 0, len(members))
+	for _, member := range members {
+		if member.Member == redisEmptyMember {
+			continue
+		}
+		id, err := strconv.ParseUint(fmt.Sprint(member.Member), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("entrada de timeline inválida en redis: %w", err)
+		}
+		entries = append(entries, domain.TimelineEntry{
+			TweetID:   uint(id),
+			CreatedAt: time.UnixMicro(int64(member.Score)).UTC(),
+		})
+	}
+	// Redis desempata por orden lexicográfico del miembro; se reordena por ID numérico
+	return pageEntries(sortEntries(entries), before, limit), nil
+}
+
+func tweetKey(tweetID uint) string {
+	return redisTweetPrefix + strconv.FormatUint(uint64(tweetID), 10)
+}
+
+func entryScore(entry domain.TimelineEntry) float64 {
+	return float64(entry.CreatedAt.UnixMicro())
+}
+
+func toMembers(entries []domain.TimelineEntry) []redis.Z {
+	members := make([]redis.Z, len(entries))
+	for i, entry := range entries {
+		members[i] = redis.Z{Score: entryScore(entry), Member: strconv.FormatUint(uint64(entry.TweetID), 10)}
+	}
+	return members
+}
@@ -0,0 +1,589 @@
+package persistence
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/DevOpslp/microblogging-platform/timeline-service/internal/domain"
+)
+
+// Cantidad de entradas que se leen por vez al recorrer el timeline hasta el cursor de una página
+const homeScanBatch = 100
+
+// TimelineRepository arma los timelines combinando el store precalculado con user-service y tweet-service.
+//
+// Cada tweet nuevo se empuja (fan-out-on-write) a los timelines ya materializados de los seguidores del autor.
+// Un timeline se materializa la primera vez que se lee, consultando a tweet-service (fan-out-on-read), y
+// se actualiza al seguir, dejar de seguir o bloquear a otros usuarios.
+// En modo híbrido (highFanoutThreshold > 0) los autores con más seguidores que el umbral no se distribuyen:
+// sus tweets se mezclan al leer el timeline de cada seguidor. Si vuelven a quedar por debajo del umbral se
+// distribuyen de nuevo.
+type TimelineRepository struct {
+	store               TimelineStore
+	userRepo            UserRepository
+	tweetRepo           TweetRepository
+	highFanoutThreshold int
+	scorer              domain.Scorer
+	followingCache      *followingCache
+}
+
+func NewTimelineRepository(store TimelineStore, userRepo UserRepository, tweetRepo TweetRepository, highFanoutThreshold int) *TimelineRepository {
+	return &TimelineRepository{
+		store:               store,
+		userRepo:            userRepo,
+		tweetRepo:           tweetRepo,
+		highFanoutThreshold: highFanoutThreshold,
+		scorer:              domain.NewDefaultScorer(),
+		followingCache:      newFollowingCache(followingCacheTTL, followingCacheMaxEntries),
+	}
+}
+
+// ApplyEvent aplica un evento del broker una sola vez por eventID. La marca se guarda después de apply:
+// si apply falla el broker reentrega el evento y se aplica completo de nuevo, lo que no cambia el
+// resultado porque las operaciones sobre los timelines son idempotentes.
+func (repo *TimelineRepository) ApplyEvent(eventID string, apply func() error) error {
+	processed, err := repo.store.EventProcessed(eventID)
+	if err != nil || processed {
+		return err
+	}
+	if err := apply(); err != nil {
+		return err
+	}
+	return repo.store.MarkEventProcessed(eventID)
+}
+
+// Distribuir un tweet recién creado a los timelines de los seguidores de su autor
+// y a los timelines de menciones de los usuarios mencionados
+func (repo *TimelineRepository) FanoutTweet(tweet domain.Tweet) error {
+	if err := repo.store.SaveTweets(tweet); err != nil {
+		return err
+	}
+	if err := repo.store.AddToUserTweets(tweet.Username, tweet.Entry()); err != nil {
+		return err
+	}
+	if err := repo.store.AddToMentionsTimelines(tweet.MentionedUsernames(), tweet.Entry()); err != nil {
+		return err
+	}
+
+	followers, err := repo.userRepo.GetFollowers(tweet.Username)
+	if err != nil {
+		return err
+	}
+
+	if repo.highFanoutThreshold > 0 && len(followers) > repo.highFanoutThreshold {
+		return repo.store.MarkHighFanout(tweet.Username)
+	}
+	if err := repo.resetHighFanout(tweet.Username, followers); err != nil {
+		return err
+	}
+	return repo.store.AddToHomeTimelines(followers, tweet.Entry())
+}
+
+// resetHighFanout quita la marca de un autor que volvió a quedar por debajo del umbral. Sus tweets
+// recientes se agregan a los timelines de los seguidores, donde hasta ahora se mezclaban al leer.
+func (repo *TimelineRepository) resetHighFanout(author string, followers []string) error {
+	unmarked, err := repo.store.UnmarkHighFanout(author)
+	if err != nil || !unmarked {
+		return err
+	}
+	entries, err := repo.store.GetUserTweets(author, nil, MaxTimelineLength)
+	if err != nil {
+		return err
+	}
+	for _, follower := range followers {
+		if err := repo.store.AddToHomeTimeline(follower, entries); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FollowAuthor agrega los tweets recientes de author al timeline de follower, si ya está materializado.
+// Los tweets de un autor con muchos seguidores no se agregan porque se mezclan al leer.
+func (repo *TimelineRepository) FollowAuthor(follower, author string) error {
+	_, materialized, err := repo.store.GetHomeTimeline(follower, nil, 1)
+	if err != nil || !materialized {
+		return err
+	}
+	if repo.highFanoutThreshold > 0 {
+		authors, err := repo.store.GetHighFanoutAuthors()
+		if err != nil {
+			return err
+		}
+		for _, highFanout := range authors {
+			if highFanout == author {
+				return nil
+			}
+		}
+	}
+
+	tweets, err := repo.tweetRepo.GetTweetsByUsername(author)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			return nil
+		}
+		return err
+	}
+	entries := make([]domain.TimelineEntry, 0, len(tweets))
+	for _, tweet := range tweets {
+		entries = append(entries, tweet.Entry())
+	}
+	if err := repo.store.SaveTweets(tweets...); err != nil {
+		return err
+	}
+	return repo.store.AddToHomeTimeline(follower, entries)
+}
+
+// UnfollowAuthor quita del timeline de follower los tweets y retweets publicados por author. Las
+// entradas cuyo contenido ya no está guardado se descartan igualmente al leer.
+func (repo *TimelineRepository) UnfollowAuthor(follower, author string) error {
+	entries, materialized, err := repo.store.GetHomeTimeline(follower, nil, MaxTimelineLength)
+	if err != nil || !materialized {
+		return err
+	}
+
+	ids := make([]uint, len(entries))
+	for i, entry := range entries {
+		ids[i] = entry.TweetID
+	}
+	tweets, err := repo.store.GetTweets(ids)
+	if err != nil {
+		return err
+	}
+
+	removed := make([]uint, 0)
+	for _, id := range ids {
+		if tweet, ok := tweets[id]; ok && tweet.Username == author {
+			removed = append(removed, id)
+		}
+	}
+	return repo.store.RemoveFromHomeTimeline(follower, removed)
+}
+
+// DropHomeTimelines descarta los timelines de los usuarios para que se vuelvan a materializar al leerlos.
+// Se usa al bloquear, cuando cambia lo que cada uno puede ver del otro.
+func (repo *TimelineRepository) DropHomeTimelines(usernames ...string) error {
+	for _, username := range usernames {
+		if err := repo.store.DeleteHomeTimeline(username); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UpdateTweet reemplaza el contenido guardado de un tweet editado. Las entradas de los timelines no
+// cambian de lugar; solo se actualizan las menciones que se agregaron o quitaron con la edición.
+// Los retweets y citas que embeben el tweet muestran la versión nueva al leer (ver hydrate).
+func (repo *TimelineRepository) UpdateTweet(tweet domain.Tweet) error {
+	tweets, err := repo.store.GetTweets([]uint{tweet.ID})
+	if err != nil {
+		return err
+	}
+	previous, stored := tweets[tweet.ID]
+	if stored && (previous.Deleted || previous.NewerThan(tweet)) {
+		// Un evento atrasado no pisa una versión más reciente ni un tweet eliminado
+		return nil
+	}
+	if err := repo.store.SaveTweets(tweet); err != nil {
+		return err
+	}
+
+	current := make(map[string]bool)
+	for _, username := range tweet.MentionedUsernames() {
+		current[username] = true
+	}
+	removed := make([]string, 0)
+	for _, username := range previous.MentionedUsernames() {
+		if !current[username] {
+			removed = append(removed, username)
+		}
+	}
+	if err := repo.store.RemoveFromMentionsTimelines(removed, tweet.ID); err != nil {
+		return err
+	}
+	return repo.store.AddToMentionsTimelines(tweet.MentionedUsernames(), tweet.Entry())
+}
+
+// Quitar un tweet eliminado de los timelines de los seguidores de su autor y de los mencionados.
+// Si no se indica el autor se busca en el store; las entradas huérfanas se descartan igualmente al leer.
+// El tweet guardado se reemplaza por su tombstone para que las citas lo muestren eliminado; si se
+// restaura, la nueva publicación lo vuelve a guardar completo.
+func (repo *TimelineRepository) RemoveTweet(tweetID uint, author string) error {
+	tweets, err := repo.store.GetTweets([]uint{tweetID})
+	if err != nil {
+		return err
+	}
+	if stored, ok := tweets[tweetID]; ok {
+		if author == "" {
+			author = stored.Username
+		}
+		if err := repo.store.RemoveFromMentionsTimelines(stored.MentionedUsernames(), tweetID); err != nil {
+			return err
+		}
+	}
+
+	if author != "" {
+		followers, err := repo.userRepo.GetFollowers(author)
+		if err != nil && !errors.Is(err, ErrUserNotFound) {
+			return err
+		}
+		if err := repo.store.RemoveFromHomeTimelines(followers, tweetID); err != nil {
+			return err
+		}
+		if err := repo.store.RemoveFromUserTweets(author, tweetID); err != nil {
+			return err
+		}
+	}
+
+	if stored, ok := tweets[tweetID]; ok && stored.RetweetOfTweetID == nil {
+		return repo.store.SaveTweets(stored.Tombstone())
+	}
+	return repo.store.DeleteTweet(tweetID)
+}
+
+// Obtener una página del timeline de un usuario, del tweet más nuevo al más antiguo.
+// Un tweet y sus retweets se muestran una sola vez, en su aparición más reciente, aunque caigan en páginas
+// distintas: el timeline se recorre desde el principio para saber qué contenidos ya se mostraron antes del
+// cursor, y se siguen leyendo entradas hasta completar la página.
+// Devuelve el cursor de la página siguiente, o nil si no hay más tweets.
+func (repo *TimelineRepository) GetHomeTimeline(username string, cursor *domain.TimelineEntry, limit int) ([]domain.Tweet, *domain.TimelineEntry, error) {
+	batch := limit + 1
+	if cursor != nil && batch < homeScanBatch {
+		batch = homeScanBatch
+	}
+
+	seen := make(map[uint]bool)
+	page := make([]domain.Tweet, 0, limit)
+	var last, pageEnd *domain.TimelineEntry
+	for {
+		entries, err := repo.homeEntries(username, last, batch)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		tweets, err := repo.hydrate(entries)
+		if err != nil {
+			return nil, nil, err
+		}
+		tweets, err = repo.hideAuthors(username, tweets)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		positions := make(map[uint]domain.TimelineEntry, len(entries))
+		for _, entry := range entries {
+			positions[entry.TweetID] = entry
+		}
+		for _, tweet := range tweets {
+			if seen[tweet.OriginalID()] {
+				continue
+			}
+			seen[tweet.OriginalID()] = true
+
+			entry := positions[tweet.ID]
+			if cursor != nil && !cursor.Before(entry) {
+				continue
+			}
+			// Un tweet más después de completar la página indica que existe una página siguiente
+			if len(page) == limit {
+				return page, pageEnd, nil
+			}
+			page = append(page, tweet)
+			pageEnd = &entry
+		}
+
+		if len(entries) < batch {
+			return page, nil, nil
+		}
+		last = &entries[len(entries)-1]
+	}
+}
+
+// homeEntries devuelve hasta limit entradas del timeline de un usuario que van después del cursor,
+// materializándolo si hace falta y mezclando los tweets de los autores que no se distribuyen al escribir
+func (repo *TimelineRepository) homeEntries(username string, cursor *domain.TimelineEntry, limit int) ([]domain.TimelineEntry, error) {
+	entries, materialized, err := repo.store.GetHomeTimeline(username, cursor, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	if !materialized {
+		entries, err = repo.materialize(username)
+		if err != nil {
+			return nil, err
+		}
+		entries = pageEntries(entries, cursor, limit)
+	}
+
+	if repo.highFanoutThreshold > 0 {
+		return repo.mergeHighFanout(username, entries, cursor, limit)
+	}
+	return entries, nil
+}
+
+// Obtener una página de los tweets que mencionan a un usuario, del más nuevo al más antiguo.
+// Devuelve el cursor de la página siguiente, o nil si no hay más tweets.
+func (repo *TimelineRepository) GetMentionsTimeline(username string, cursor *domain.TimelineEntry, limit int) ([]domain.Tweet, *domain.TimelineEntry, error) {
+	// Se pide una entrada extra para saber si existe una página siguiente
+	entries, materialized, err := repo.store.GetMentionsTimeline(username, cursor, limit+1)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !materialized {
+		entries, err = repo.materializeMentions(username)
+		if err != nil {
+			return nil, nil, err
+		}
+		entries = pageEntries(entries, cursor, limit+1)
+	}
+
+	var next *domain.TimelineEntry
+	if len(entries) > limit {
+		entries = entries[:limit]
+		next = &entries[limit-1]
+	}
+
+	tweets, err := repo.hydrate(entries)
+	if err != nil {
+		return nil, nil, err
+	}
+	tweets, err = repo.hideAuthors(username, tweets)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tweets, next, nil
+}
+
+// GetStreamSources devuelve los autores que sigue username y los usuarios que tiene bloqueados o
+// silenciados, con los que se filtran los tweets que recibe por streaming
+func (repo *TimelineRepository) GetStreamSources(username string) ([]string, []string, error) {
+	following, err := repo.userRepo.GetFollowing(username)
+	if err != nil {
+		return nil, nil, err
+	}
+	hidden, err := repo.userRepo.GetHiddenUsernames(username)
+	if err != nil {
+		return nil, nil, err
+	}
+	return following, hidden, nil
+}
+
+// hideAuthors quita de una página los tweets de cuentas privadas que el lector no sigue y los de usuarios
+// bloqueados o silenciados. Los timelines guardan referencias que pueden quedar desactualizadas (menciones
+// de cuentas que no sigue, seguimientos que ya terminaron o bloqueos nuevos), por lo que la visibilidad
+// se resuelve al leer.
+func (repo *TimelineRepository) hideAuthors(username string, tweets []domain.Tweet) ([]domain.Tweet, error) {
+	if len(tweets) == 0 {
+		return tweets, nil
+	}
+
+	hidden, err := repo.userRepo.GetPrivateUsernames(domain.Authors(tweets))
+	if err != nil {
+		return nil, err
+	}
+	if len(hidden) > 0 {
+		following, err := repo.userRepo.GetFollowing(username)
+		if err != nil {
+			return nil, err
+		}
+		delete(hidden, username)
+		for _, followed := range following {
+			delete(hidden, followed)
+		}
+	}
+
+	blocked, err := repo.userRepo.GetHiddenUsernames(username)
+	if err != nil {
+		return nil, err
+	}
+	for _, user := range blocked {
+		hidden[user] = true
+	}
+	if len(hidden) == 0 {
+		return tweets, nil
+	}
+	return domain.HideAuthors(tweets, hidden), nil
+}
+
+// materializeMentions construye el timeline de menciones consultando a tweet-service y lo guarda en el store
+func (repo *TimelineRepository) materializeMentions(username string) ([]domain.TimelineEntry, error) {
+	tweets, err := repo.tweetRepo.GetTweetsMentioning(username)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]domain.TimelineEntry, 0, len(tweets))
+	for _, tweet := range tweets {
+		entries = append(entries, tweet.Entry())
+	}
+	entries = truncateEntries(sortEntries(entries), MaxTimelineLength)
+
+	if err := repo.store.SaveTweets(tweets...); err != nil {
+		return nil, err
+	}
+	if err := repo.store.SetMentionsTimeline(username, entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// materialize construye el timeline consultando a tweet-service por cada usuario seguido y lo guarda en el store
+func (repo *TimelineRepository) materialize(username string) ([]domain.TimelineEntry, error) {
+	following, err := repo.userRepo.GetFollowing(username)
+	if err != nil {
+		return nil, err
+	}
+
+	tweets, err := repo.fetchTweets(following)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]domain.TimelineEntry, 0, len(tweets))
+	for _, tweet := range tweets {
+		entries = append(entries, tweet.Entry())
+	}
+	entries = truncateEntries(sortEntries(entries), MaxTimelineLength)
+
+	if err := repo.store.SaveTweets(tweets...); err != nil {
+		return nil, err
+	}
+	if err := repo.store.SetHomeTimeline(username, entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// fetchTweets obtiene en paralelo los tweets de cada autor seguido
+func (repo *TimelineRepository) fetchTweets(authors []string) ([]domain.Tweet, error) {
+	results := make([][]domain.Tweet, len(authors))
+	errs := make([]error, len(authors))
+
+	var wg sync.WaitGroup
+	for i, author := range authors {
+		wg.Add(1)
+		go func(i int, author string) {
+			defer wg.Done()
+			results[i], errs[i] = repo.tweetRepo.GetTweetsByUsername(author)
+		}(i, author)
+	}
+	wg.Wait()
+
+	tweets := make([]domain.Tweet, 0)
+	for i := range authors {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		tweets = append(tweets, results[i]...)
+	}
+	return tweets, nil
+}
+
+// mergeHighFanout agrega al timeline los tweets de los autores seguidos que no se distribuyen al escribir
+func (repo *TimelineRepository) mergeHighFanout(username string, entries []domain.TimelineEntry, cursor *domain.TimelineEntry, limit int) ([]domain.TimelineEntry, error) {
+	authors, err := repo.store.GetHighFanoutAuthors()
+	if err != nil || len(authors) == 0 {
+		return entries, err
+	}
+
+	following, err := repo.userRepo.GetFollowing(username)
+	if err != nil {
+		return nil, err
+	}
+	followed := make(map[string]bool, len(following))
+	for _, author := range following {
+		followed[author] = true
+	}
+
+	merged := append([]domain.TimelineEntry{}, entries...)
+	for _, author := range authors {
+		if !followed[author] {
+			continue
+		}
+		authorEntries, err := repo.store.GetUserTweets(author, cursor, limit)
+		if err != nil {
+			return nil, err
+		}
+		merged = append(merged, authorEntries...)
+	}
+	return truncateEntries(sortEntries(merged), limit), nil
+}
+
+// hydrate resuelve el contenido de cada entrada, descartando las de tweets que ya no existen o se eliminaron.
+// Los tweets cuyo contenido venció en el store (TweetCacheTTL) se piden de nuevo a tweet-service; los que
+// ya no existen se guardan como tombstone para no volver a pedirlos.
+func (repo *TimelineRepository) hydrate(entries []domain.TimelineEntry) ([]domain.Tweet, error) {
+	ids := make([]uint, len(entries))
+	for i, entry := range entries {
+		ids[i] = entry.TweetID
+	}
+
+	found, err := repo.store.GetTweets(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	refetched := make([]domain.Tweet, 0)
+	for _, entry := range entries {
+		if _, ok := found[entry.TweetID]; ok {
+			continue
+		}
+		tweet, err := repo.tweetRepo.GetTweet(entry.TweetID)
+		if errors.Is(err, ErrTweetNotFound) {
+			tweet = &domain.Tweet{ID: entry.TweetID, CreatedAt: entry.CreatedAt}
+			*tweet = tweet.Tombstone()
+		} else if err != nil {
+			return nil, err
+		}
+		found[entry.TweetID] = *tweet
+		refetched = append(refetched, *tweet)
+	}
+	if err := repo.store.SaveTweets(refetched...); err != nil {
+		return nil, err
+	}
+
+	tweets := make([]domain.Tweet, 0, len(entries))
+	for _, id := range ids {
+		if tweet, ok := found[id]; ok && !tweet.Deleted {
+			tweets = append(tweets, tweet)
+		}
+	}
+	return repo.refreshEmbedded(tweets)
+}
+
+// refreshEmbedded reemplaza los tweets retuiteados o citados por su versión guardada si se editaron
+// o eliminaron después de que se publicó el retweet o la cita
+func (repo *TimelineRepository) refreshEmbedded(tweets []domain.Tweet) ([]domain.Tweet, error) {
+	ids := make([]uint, 0)
+	for _, tweet := range tweets {
+		if tweet.RetweetedTweet != nil {
+			ids = append(ids, tweet.RetweetedTweet.ID)
+		}
+		if tweet.QuotedTweet != nil {
+			ids = append(ids, tweet.QuotedTweet.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return tweets, nil
+	}
+
+	stored, err := repo.store.GetTweets(ids)
+	if err != nil {
+		return nil, err
+	}
+	refresh := func(embedded *domain.Tweet) *domain.Tweet {
+		if embedded == nil {
+			return nil
+		}
+		if latest, ok := stored[embedded.ID]; ok && (latest.Deleted || latest.NewerThan(*embedded)) {
+			return &latest
+		}
+		return embedded
+	}
+	for i := range tweets {
+		tweets[i].RetweetedTweet = refresh(tweets[i].RetweetedTweet)
+		tweets[i].QuotedTweet = refresh(tweets[i].QuotedTweet)
+	}
+	return tweets, nil
+}
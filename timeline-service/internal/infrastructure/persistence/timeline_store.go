@@ -0,0 +1,97 @@
+package persistence
+
+import (
+	"sort"
+	"time"
+
+	"github.com/DevOpslp/microblogging-platform/timeline-service/internal/domain"
+)
+
+// Cantidad máxima de entradas que se conservan en cada timeline precalculado
+const MaxTimelineLength = 800
+
+// Tiempo que se recuerda un evento aplicado; el broker deja de reentregar un evento mucho antes
+const ProcessedEventRetention = 7 * 24 * time.Hour
+
+// Tiempo que RedisTimelineStore guarda el contenido de cada tweet. Los que vencen mientras un timeline
+// todavía los incluye se vuelven a pedir a tweet-service al leerlo.
+const TweetCacheTTL = 7 * 24 * time.Hour
+
+// TimelineStore guarda los timelines precalculados (fan-out-on-write) y el contenido de los tweets
+// referenciados por ellos. Los timelines solo guardan IDs; el contenido se resuelve con GetTweets.
+type TimelineStore interface {
+	SaveTweets(tweets ...domain.Tweet) error
+	GetTweets(ids []uint) (map[uint]domain.Tweet, error)
+	DeleteTweet(tweetID uint) error
+
+	// SetHomeTimeline materializa el timeline de un usuario, reemplazando el existente
+	SetHomeTimeline(username string, entries []domain.TimelineEntry) error
+	// AddToHomeTimelines agrega la entrada solo a los timelines que ya fueron materializados
+	AddToHomeTimelines(usernames []string, entry domain.TimelineEntry) error
+	RemoveFromHomeTimelines(usernames []string, tweetID uint) error
+	// GetHomeTimeline devuelve hasta limit entradas posteriores al cursor before (nil = desde el principio)
+	// e indica si el timeline está materializado
+	GetHomeTimeline(username string, before *domain.TimelineEntry, limit int) ([]domain.TimelineEntry, bool, error)
+	// AddToHomeTimeline y RemoveFromHomeTimeline cambian varias entradas del timeline de un usuario, si
+	// ya fue materializado; se usan al seguir o dejar de seguir a un autor
+	AddToHomeTimeline(username string, entries []domain.TimelineEntry) error
+	RemoveFromHomeTimeline(username string, tweetIDs []uint) error
+	// DeleteHomeTimeline descarta el timeline; se vuelve a materializar la próxima vez que se lee
+	DeleteHomeTimeline(username string) error
+
+	// Tweets que mencionan a cada usuario; se materializan y actualizan igual que el timeline principal
+	SetMentionsTimeline(username string, entries []domain.TimelineEntry) error
+	AddToMentionsTimelines(usernames []string, entry domain.TimelineEntry) error
+	RemoveFromMentionsTimelines(usernames []string, tweetID uint) error
+	GetMentionsTimeline(username string, before *domain.TimelineEntry, limit int) ([]domain.TimelineEntry, bool, error)
+
+	// Tweets propios de cada autor, usados para el fan-out-on-read de autores con muchos seguidores
+	AddToUserTweets(username string, entry domain.TimelineEntry) error
+	RemoveFromUserTweets(username string, tweetID uint) error
+	GetUserTweets(username string, before *domain.TimelineEntry, limit int) ([]domain.TimelineEntry, error)
+
+	MarkHighFanout(username string) error
+	// UnmarkHighFanout indica si el autor estaba marcado
+	UnmarkHighFanout(username string) (bool, error)
+	GetHighFanoutAuthors() ([]string, error)
+
+	// Eventos del broker ya aplicados, para ignorar sus entregas repetidas durante ProcessedEventRetention
+	EventProcessed(eventID string) (bool, error)
+	MarkEventProcessed(eventID string) error
+}
+
+// sortEntries ordena las entradas del más nuevo al más antiguo y descarta duplicados
+func sortEntries(entries []domain.TimelineEntry) []domain.TimelineEntry {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Before(entries[j])
+	})
+
+	seen := make(map[uint]bool, len(entries))
+	unique := entries[:0]
+	for _, entry := range entries {
+		if seen[entry.TweetID] {
+			continue
+		}
+		seen[entry.TweetID] = true
+		unique = append(unique, entry)
+	}
+	return unique
+}
+
+func truncateEntries(entries []domain.TimelineEntry, limit int) []domain.TimelineEntry {
+	if limit >= 0 && len(entries) > limit {
+		return entries[:limit]
+	}
+	return entries
+}
+
+// pageEntries devuelve hasta limit entradas que van después del cursor before en un timeline ya ordenado
+func pageEntries(entries []domain.TimelineEntry, before *domain.TimelineEntry, limit int) []domain.TimelineEntry {
+	start := 0
+	if before != nil {
+		start = sort.Search(len(entries), func(i int) bool {
+			return before.Before(entries[i])
+		})
+	}
+	return truncateEntries(entries[start:], limit)
+}
@@ -0,0 +1,769 @@
+package persistence
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DevOpslp/microblogging-platform/timeline-service/internal/domain"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestTweet(id uint, username string, minute int) domain.Tweet {
+	return domain.Tweet{
+		ID:        id,
+		Username:  username,
+		Content:   "tweet de prueba",
+		CreatedAt: time.Date(2024, 1, 1, 10, minute, 0, 0, time.UTC),
+	}
+}
+
+func entryIDs(entries []domain.TimelineEntry) []uint {
+	ids := make([]uint, 0, len(entries))
+	for _, entry := range entries {
+		ids = append(ids, entry.TweetID)
+	}
+	return ids
+}
+
+// testTimelineStore verifica el contrato de TimelineStore para cualquier implementación
+func testTimelineStore(t *testing.T, store TimelineStore) {
+	t1 := newTestTweet(1, "user2", 1)
+	t2 := newTestTweet(2, "user2", 2)
+	t3 := newTestTweet(3, "user3", 3)
+
+	t.Run("Guardar y obtener tweets", func(t *testing.T) {
+		assert.NoError(t, store.SaveTweets(t1, t2, t3))
+
+		tweets, err := store.GetTweets([]uint{1, 3, 99})
+		assert.NoError(t, err)
+		assert.Len(t, tweets, 2)
+		assert.Equal(t, "user3", tweets[3].Username)
+		assert.True(t, t3.CreatedAt.Equal(tweets[3].CreatedAt))
+	})
+
+	t.Run("Solo se agregan entradas a timelines materializados", func(t *testing.T) {
+		assert.NoError(t, store.AddToHomeTimelines([]string{"user1"}, t1.Entry()))
+
+		_, materialized, err := store.GetHomeTimeline("user1", nil, 10)
+		assert.NoError(t, err)
+		assert.False(t, materialized)
+	})
+
+	t.Run("Timeline ordenado del más nuevo al más antiguo", func(t *testing.T) {
+		assert.NoError(t, store.SetHomeTimeline("user1", []domain.TimelineEntry{t1.Entry()}))
+		assert.NoError(t, store.AddToHomeTimelines([]string{"user1", "user4"}, t3.Entry()))
+		assert.NoError(t, store.AddToHomeTimelines([]string{"user1"}, t2.Entry()))
+
+		entries, materialized, err := store.GetHomeTimeline("user1", nil, 10)
+		assert.NoError(t, err)
+		assert.True(t, materialized)
+		assert.Equal(t, []uint{3, 2, 1}, entryIDs(entries))
+
+		entries, _, err = store.GetHomeTimeline("user1", nil, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, []uint{3, 2}, entryIDs(entries))
+
+		// Paginación por cursor, incluso con entradas de la misma fecha
+		tie := domain.TimelineEntry{TweetID: 4, CreatedAt: t2.CreatedAt}
+		assert.NoError(t, store.AddToHomeTimelines([]string{"user1"}, tie))
+
+		entries, _, err = store.GetHomeTimeline("user1", &entries[0], 2)
+		assert.NoError(t, err)
+		assert.Equal(t, []uint{4, 2}, entryIDs(entries))
+
+		entries, _, err = store.GetHomeTimeline("user1", &entries[0], 10)
+		assert.NoError(t, err)
+		assert.Equal(t, []uint{2, 1}, entryIDs(entries))
+
+		assert.NoError(t, store.RemoveFromHomeTimelines([]string{"user1"}, 4))
+	})
+
+	t.Run("Quitar entradas del timeline", func(t *testing.T) {
+		assert.NoError(t, store.RemoveFromHomeTimelines([]string{"user1"}, 2))
+		assert.NoError(t, store.DeleteTweet(2))
+
+		entries, _, err := store.GetHomeTimeline("user1", nil, 10)
+		assert.NoError(t, err)
+		assert.Equal(t, []uint{3, 1}, entryIDs(entries))
+
+		tweets, err := store.GetTweets([]uint{2})
+		assert.NoError(t, err)
+		assert.Empty(t, tweets)
+	})
+
+	t.Run("Cambiar varias entradas de un timeline", func(t *testing.T) {
+		assert.NoError(t, store.AddToHomeTimeline("user6", []domain.TimelineEntry{t1.Entry()}))
+		_, materialized, err := store.GetHomeTimeline("user6", nil, 10)
+		assert.NoError(t, err)
+		assert.False(t, materialized)
+
+		assert.NoError(t, store.SetHomeTimeline("user6", []domain.TimelineEntry{t1.Entry()}))
+		assert.NoError(t, store.AddToHomeTimeline("user6", []domain.TimelineEntry{t3.Entry(), t1.Entry()}))
+		entries, _, err := store.GetHomeTimeline("user6", nil, 10)
+		assert.NoError(t, err)
+		assert.Equal(t, []uint{3, 1}, entryIDs(entries))
+
+		assert.NoError(t, store.RemoveFromHomeTimeline("user6", []uint{1, 99}))
+		entries, _, err = store.GetHomeTimeline("user6", nil, 10)
+		assert.NoError(t, err)
+		assert.Equal(t, []uint{3}, entryIDs(entries))
+
+		// Un timeline que queda vacío sigue materializado
+		assert.NoError(t, store.RemoveFromHomeTimeline("user6", []uint{3}))
+		entries, materialized, err = store.GetHomeTimeline("user6", nil, 10)
+		assert.NoError(t, err)
+		assert.True(t, materialized)
+		assert.Empty(t, entries)
+
+		assert.NoError(t, store.DeleteHomeTimeline("user6"))
+		_, materialized, err = store.GetHomeTimeline("user6", nil, 10)
+		assert.NoError(t, err)
+		assert.False(t, materialized)
+	})
+
+	t.Run("Timeline materializado sin tweets", func(t *testing.T) {
+		assert.NoError(t, store.SetHomeTimeline("user7", nil))
+		entries, materialized, err := store.GetHomeTimeline("user7", nil, 10)
+		assert.NoError(t, err)
+		assert.True(t, materialized)
+		assert.Empty(t, entries)
+
+		assert.NoError(t, store.AddToHomeTimelines([]string{"user7"}, t1.Entry()))
+		entries, _, err = store.GetHomeTimeline("user7", &domain.TimelineEntry{TweetID: 2, CreatedAt: t2.CreatedAt}, 10)
+		assert.NoError(t, err)
+		assert.Equal(t, []uint{1}, entryIDs(entries))
+	})
+
+	t.Run("Timeline de menciones", func(t *testing.T) {
+		assert.NoError(t, store.AddToMentionsTimelines([]string{"user5"}, t1.Entry()))
+		_, materialized, err := store.GetMentionsTimeline("user5", nil, 10)
+		assert.NoError(t, err)
+		assert.False(t, materialized)
+
+		assert.NoError(t, store.SetMentionsTimeline("user5", []domain.TimelineEntry{t1.Entry()}))
+		assert.NoError(t, store.AddToMentionsTimelines([]string{"user5"}, t3.Entry()))
+
+		entries, materialized, err := store.GetMentionsTimeline("user5", nil, 10)
+		assert.NoError(t, err)
+		assert.True(t, materialized)
+		assert.Equal(t, []uint{3, 1}, entryIDs(entries))
+
+		// Es independiente del timeline principal
+		assert.NoError(t, store.RemoveFromMentionsTimelines([]string{"user5"}, 3))
+		entries, _, err = store.GetMentionsTimeline("user5", nil, 10)
+		assert.NoError(t, err)
+		assert.Equal(t, []uint{1}, entryIDs(entries))
+
+		_, materialized, err = store.GetHomeTimeline("user5", nil, 10)
+		assert.NoError(t, err)
+		assert.False(t, materialized)
+	})
+
+	t.Run("Tweets propios de cada autor", func(t *testing.T) {
+		assert.NoError(t, store.AddToUserTweets("user2", t1.Entry()))
+		assert.NoError(t, store.AddToUserTweets("user2", t2.Entry()))
+		assert.NoError(t, store.RemoveFromUserTweets("user2", 1))
+
+		entries, err := store.GetUserTweets("user2", nil, 10)
+		assert.NoError(t, err)
+		assert.Equal(t, []uint{2}, entryIDs(entries))
+	})
+
+	t.Run("Autores con muchos seguidores", func(t *testing.T) {
+		assert.NoError(t, store.MarkHighFanout("user2"))
+		assert.NoError(t, store.MarkHighFanout("user2"))
+
+		authors, err := store.GetHighFanoutAuthors()
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"user2"}, authors)
+
+		unmarked, err := store.UnmarkHighFanout("user2")
+		assert.NoError(t, err)
+		assert.True(t, unmarked)
+		unmarked, err = store.UnmarkHighFanout("user2")
+		assert.NoError(t, err)
+		assert.False(t, unmarked)
+
+		authors, err = store.GetHighFanoutAuthors()
+		assert.NoError(t, err)
+		assert.Empty(t, authors)
+	})
+
+	t.Run("Eventos aplicados", func(t *testing.T) {
+		processed, err := store.EventProcessed("evento-1")
+		assert.NoError(t, err)
+		assert.False(t, processed)
+
+		assert.NoError(t, store.MarkEventProcessed("evento-1"))
+		assert.NoError(t, store.MarkEventProcessed("evento-1"))
+
+		processed, err = store.EventProcessed("evento-1")
+		assert.NoError(t, err)
+		assert.True(t, processed)
+
+		processed, err = store.EventProcessed("evento-2")
+		assert.NoError(t, err)
+		assert.False(t, processed)
+	})
+}
+
+func TestMemoryTimelineStore(t *testing.T) {
+	testTimelineStore(t, NewMemoryTimelineStore())
+}
+
+func TestMemoryTimelineStoreTrimsTweets(t *testing.T) {
+	store := NewMemoryTimelineStore()
+	store.maxTweets = 3
+	stored := func() []uint {
+		tweets, err := store.GetTweets([]uint{1, 2, 3, 4, 5})
+		assert.NoError(t, err)
+		ids := make([]uint, 0)
+		for id := uint(1); id <= 5; id++ {
+			if _, ok := tweets[id]; ok {
+				ids = append(ids, id)
+			}
+		}
+		return ids
+	}
+
+	entry := func(id uint) domain.TimelineEntry { return newTestTweet(id, "user2", int(id)).Entry() }
+	save := func(ids ...uint) {
+		for _, id := range ids {
+			assert.NoError(t, store.SaveTweets(newTestTweet(id, "user2", int(id))))
+		}
+	}
+
+	// Un timeline recién construido conserva sus tweets aunque sean los más antiguos: se descartan
+	// primero los más antiguos de los que ningún timeline usa
+	save(4, 5, 1, 2, 3)
+	assert.NoError(t, store.SetHomeTimeline("user1", []domain.TimelineEntry{entry(1), entry(2)}))
+	assert.Equal(t, []uint{1, 2, 5}, stored())
+
+	// Un tweet agregado a un timeline deja de poder descartarse
+	save(4)
+	assert.NoError(t, store.AddToHomeTimeline("user1", []domain.TimelineEntry{entry(5)}))
+	assert.Equal(t, []uint{1, 2, 5}, stored())
+
+	// Al quitarlo del timeline vuelve a poder descartarse
+	assert.NoError(t, store.RemoveFromHomeTimeline("user1", []uint{1}))
+	save(3)
+	assert.NoError(t, store.AddToUserTweets("user2", entry(5)))
+	assert.Equal(t, []uint{2, 3, 5}, stored())
+
+	// Un tweet borrado y guardado de nuevo mientras un timeline lo usa no se descarta
+	assert.NoError(t, store.DeleteTweet(2))
+	save(2, 4)
+	assert.NoError(t, store.AddToUserTweets("user2", entry(4)))
+	assert.Equal(t, []uint{2, 4, 5}, stored())
+
+	// Al descartar el timeline sus tweets dejan de usarse, salvo los de la lista del autor
+	assert.NoError(t, store.DeleteHomeTimeline("user1"))
+	save(3)
+	assert.NoError(t, store.AddToUserTweets("user3", entry(3)))
+	assert.Equal(t, []uint{3, 4, 5}, stored())
+}
+
+func TestRedisTimelineStore(t *testing.T) {
+	server := miniredis.RunT(t)
+	store := NewRedisTimelineStore(server.Addr(), "")
+	assert.NoError(t, store.Ping())
+
+	testTimelineStore(t, store)
+
+	// El contenido de los tweets vence
+	assert.Equal(t, TweetCacheTTL, server.TTL(tweetKey(1)))
+
+	// Al recortar un timeline se conserva la marca de timeline materializado
+	entries := make([]domain.TimelineEntry, 0, MaxTimelineLength+1)
+	for id := uint(1); id <= MaxTimelineLength+1; id++ {
+		entries = append(entries, domain.TimelineEntry{TweetID: id, CreatedAt: time.Unix(int64(id), 0)})
+	}
+	assert.NoError(t, store.SetHomeTimeline("user8", entries))
+	assert.NoError(t, store.AddToHomeTimelines([]string{"user8"}, domain.TimelineEntry{TweetID: 1000, CreatedAt: time.Unix(1000, 0)}))
+	members, err := server.ZMembers(redisHomePrefix + "user8")
+	assert.NoError(t, err)
+	assert.Len(t, members, MaxTimelineLength+1)
+	assert.Contains(t, members, redisEmptyMember)
+	assert.NotContains(t, members, "2")
+}
+
+// fakeUserRepository resuelve seguidores y seguidos a partir de un mapa en memoria
+type fakeUserRepository map[string][]string
+
+func (f fakeUserRepository) GetFollowing(username string) ([]string, error) {
+	following, ok := f[username]
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+	return following, nil
+}
+
+func (f fakeUserRepository) GetFollowingBatch(usernames []string) (map[string][]string, error) {
+	following := make(map[string][]string)
+	for _, username := range usernames {
+		if followed, ok := f[username]; ok {
+			following[username] = followed
+		}
+	}
+	return following, nil
+}
+
+func (f fakeUserRepository) GetFollowers(username string) ([]string, error) {
+	followers := make([]string, 0)
+	for follower, following := range f {
+		for _, followed := range following {
+			if followed == username {
+				followers = append(followers, follower)
+			}
+		}
+	}
+	return followers, nil
+}
+
+// Las cuentas del mapa son todas públicas; ver privateUserRepository
+func (f fakeUserRepository) GetPrivateUsernames(usernames []string) (map[string]bool, error) {
+	return map[string]bool{}, nil
+}
+
+// Nadie bloquea ni silencia a nadie; ver privateUserRepository
+func (f fakeUserRepository) GetHiddenUsernames(username string) ([]string, error) {
+	return []string{}, nil
+}
+
+// privateUserRepository agrega cuentas privadas y usuarios ocultos a fakeUserRepository
+type privateUserRepository struct {
+	fakeUserRepository
+	private map[string]bool
+	hidden  map[string][]string
+}
+
+func (f privateUserRepository) GetHiddenUsernames(username string) ([]string, error) {
+	return f.hidden[username], nil
+}
+
+func (f privateUserRepository) GetPrivateUsernames(usernames []string) (map[string]bool, error) {
+	private := make(map[string]bool)
+	for _, username := range usernames {
+		if f.private[username] {
+			private[username] = true
+		}
+	}
+	return private, nil
+}
+
+type fakeTweetRepository map[string][]domain.Tweet
+
+func (f fakeTweetRepository) GetTweet(id uint) (*domain.Tweet, error) {
+	for _, authorTweets := range f {
+		for _, tweet := range authorTweets {
+			if tweet.ID == id {
+				return &tweet, nil
+			}
+		}
+	}
+	return nil, ErrTweetNotFound
+}
+
+func (f fakeTweetRepository) GetTweetsByUsername(username string) ([]domain.Tweet, error) {
+	return f[username], nil
+}
+
+func (f fakeTweetRepository) GetTweetsMentioning(username string) ([]domain.Tweet, error) {
+	tweets := make([]domain.Tweet, 0)
+	for _, authorTweets := range f {
+		for _, tweet := range authorTweets {
+			for _, mentioned := range tweet.MentionedUsernames() {
+				if mentioned == username {
+					tweets = append(tweets, tweet)
+				}
+			}
+		}
+	}
+	return tweets, nil
+}
+
+// Sin interacciones registradas; ver signalTweetRepository
+func (f fakeTweetRepository) GetAuthorAffinity(username string) (map[string]domain.AuthorAffinity, error) {
+	return map[string]domain.AuthorAffinity{}, nil
+}
+
+func (f fakeTweetRepository) GetEngagement(tweetIDs []uint) (map[uint]domain.Engagement, error) {
+	return map[uint]domain.Engagement{}, nil
+}
+
+func TestTimelineRepositoryHybridFanout(t *testing.T) {
+	users := fakeUserRepository{
+		"user1":     {"celebrity", "user2"},
+		"user2":     {"celebrity"},
+		"user3":     {"celebrity"},
+		"celebrity": {},
+	}
+	store := NewMemoryTimelineStore()
+	repo := NewTimelineRepository(store, users, fakeTweetRepository{}, 2)
+
+	// Materializar los timelines vacíos de los lectores
+	for _, username := range []string{"user1", "user2"} {
+		tweets, _, err := repo.GetHomeTimeline(username, nil, 10)
+		assert.NoError(t, err)
+		assert.Empty(t, tweets)
+	}
+
+	assert.NoError(t, repo.FanoutTweet(newTestTweet(1, "celebrity", 1)))
+	assert.NoError(t, repo.FanoutTweet(newTestTweet(2, "user2", 2)))
+
+	// El tweet de la celebridad no se distribuye a los timelines al escribir
+	entries, _, err := store.GetHomeTimeline("user1", nil, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint{2}, entryIDs(entries))
+
+	// Pero se mezcla al leer el timeline de sus seguidores
+	tweets, _, err := repo.GetHomeTimeline("user1", nil, 10)
+	assert.NoError(t, err)
+	assert.Len(t, tweets, 2)
+	assert.Equal(t, uint(2), tweets[0].ID)
+	assert.Equal(t, uint(1), tweets[1].ID)
+
+	tweets, _, err = repo.GetHomeTimeline("user2", nil, 10)
+	assert.NoError(t, err)
+	assert.Len(t, tweets, 1)
+	assert.Equal(t, uint(1), tweets[0].ID)
+
+	// Al eliminarlo desaparece de todos los timelines
+	assert.NoError(t, repo.RemoveTweet(1, ""))
+	tweets, _, err = repo.GetHomeTimeline("user2", nil, 10)
+	assert.NoError(t, err)
+	assert.Empty(t, tweets)
+
+	// Si vuelve a quedar por debajo del umbral se distribuye otra vez, con sus tweets anteriores
+	assert.NoError(t, repo.FanoutTweet(newTestTweet(3, "celebrity", 3)))
+	users["user3"] = []string{}
+	assert.NoError(t, repo.FanoutTweet(newTestTweet(4, "celebrity", 4)))
+
+	authors, err := store.GetHighFanoutAuthors()
+	assert.NoError(t, err)
+	assert.Empty(t, authors)
+	entries, _, err = store.GetHomeTimeline("user1", nil, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint{4, 3, 2}, entryIDs(entries))
+}
+
+func TestTimelineRepositoryFollowChanges(t *testing.T) {
+	users := fakeUserRepository{"user1": {"user2"}, "user2": {}, "user3": {}}
+	tweets := fakeTweetRepository{
+		"user2": {newTestTweet(1, "user2", 1)},
+		"user3": {newTestTweet(2, "user3", 2), newTestTweet(3, "user3", 3)},
+	}
+	store := NewMemoryTimelineStore()
+	repo := NewTimelineRepository(store, users, tweets, 0)
+
+	// Sin timeline materializado no hay nada que actualizar
+	assert.NoError(t, repo.FollowAuthor("user1", "user3"))
+	_, materialized, err := store.GetHomeTimeline("user1", nil, 10)
+	assert.NoError(t, err)
+	assert.False(t, materialized)
+
+	timeline, _, err := repo.GetHomeTimeline("user1", nil, 10)
+	assert.NoError(t, err)
+	assert.Len(t, timeline, 1)
+
+	// Al seguir a un autor se agregan sus tweets anteriores
+	users["user1"] = []string{"user2", "user3"}
+	assert.NoError(t, repo.FollowAuthor("user1", "user3"))
+	entries, _, err := store.GetHomeTimeline("user1", nil, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint{3, 2, 1}, entryIDs(entries))
+
+	// Al dejar de seguirlo se quitan sus tweets y retweets
+	retweet := newTestTweet(4, "user3", 4)
+	retweet.RetweetOfTweetID = &[]uint{1}[0]
+	assert.NoError(t, repo.FanoutTweet(retweet))
+	users["user1"] = []string{"user2"}
+	assert.NoError(t, repo.UnfollowAuthor("user1", "user3"))
+	entries, _, err = store.GetHomeTimeline("user1", nil, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint{1}, entryIDs(entries))
+
+	// Un bloqueo descarta el timeline para reconstruirlo al leer
+	assert.NoError(t, repo.DropHomeTimelines("user1", "user2"))
+	_, materialized, err = store.GetHomeTimeline("user1", nil, 10)
+	assert.NoError(t, err)
+	assert.False(t, materialized)
+}
+
+func TestTimelineRepositoryDedupesRetweetsAcrossPages(t *testing.T) {
+	retweet := func(id, original uint, minute int) domain.Tweet {
+		tweet := newTestTweet(id, "user3", minute)
+		tweet.RetweetOfTweetID = &original
+		return tweet
+	}
+	users := fakeUserRepository{"user1": {"user2", "user3"}, "user2": {}, "user3": {}}
+	tweets := fakeTweetRepository{
+		"user2": {newTestTweet(1, "user2", 1), newTestTweet(3, "user2", 3)},
+		"user3": {newTestTweet(2, "user3", 2), retweet(4, 3, 4), retweet(5, 1, 5)},
+	}
+	repo := NewTimelineRepository(NewMemoryTimelineStore(), users, tweets, 0)
+
+	// Cada contenido aparece una sola vez, en su aparición más reciente, y las páginas se completan
+	for _, limit := range []int{1, 2, 10} {
+		ids := make([]uint, 0)
+		var cursor *domain.TimelineEntry
+		for {
+			page, next, err := repo.GetHomeTimeline("user1", cursor, limit)
+			assert.NoError(t, err)
+			if next != nil {
+				assert.Len(t, page, limit)
+			}
+			for _, tweet := range page {
+				ids = append(ids, tweet.ID)
+			}
+			if next == nil {
+				break
+			}
+			cursor = next
+		}
+		assert.Equal(t, []uint{5, 4, 2}, ids, "limit %d", limit)
+	}
+}
+
+func TestTimelineRepositoryRefetchesExpiredTweets(t *testing.T) {
+	users := fakeUserRepository{"user1": {"user2"}, "user2": {}}
+	tweets := fakeTweetRepository{"user2": {newTestTweet(1, "user2", 1), newTestTweet(2, "user2", 2)}}
+	store := NewMemoryTimelineStore()
+	repo := NewTimelineRepository(store, users, tweets, 0)
+
+	_, _, err := repo.GetHomeTimeline("user1", nil, 10)
+	assert.NoError(t, err)
+
+	// El contenido venció en el store; el tweet 2 además se eliminó en tweet-service
+	assert.NoError(t, store.DeleteTweet(1))
+	assert.NoError(t, store.DeleteTweet(2))
+	tweets["user2"] = tweets["user2"][:1]
+
+	timeline, _, err := repo.GetHomeTimeline("user1", nil, 10)
+	assert.NoError(t, err)
+	if assert.Len(t, timeline, 1) {
+		assert.Equal(t, uint(1), timeline[0].ID)
+	}
+
+	// Ambos quedan guardados: el eliminado como tombstone, para no volver a pedirlo
+	stored, err := store.GetTweets([]uint{1, 2})
+	assert.NoError(t, err)
+	assert.False(t, stored[1].Deleted)
+	assert.True(t, stored[2].Deleted)
+}
+
+func TestTimelineRepositoryMentions(t *testing.T) {
+	mention := func(tweet domain.Tweet, usernames ...string) domain.Tweet {
+		tweet.Entities = &domain.Entities{}
+		for _, username := range usernames {
+			tweet.Entities.Mentions = append(tweet.Entities.Mentions, domain.MentionEntity{Username: username})
+		}
+		return tweet
+	}
+
+	users := fakeUserRepository{"user1": {}, "user2": {}, "user3": {}}
+	tweets := fakeTweetRepository{
+		"user2": {mention(newTestTweet(1, "user2", 1), "user1"), newTestTweet(2, "user2", 2)},
+	}
+	store := NewMemoryTimelineStore()
+	repo := NewTimelineRepository(store, users, tweets, 0)
+
+	// La primera lectura materializa las menciones desde tweet-service
+	found, _, err := repo.GetMentionsTimeline("user1", nil, 10)
+	assert.NoError(t, err)
+	assert.Len(t, found, 1)
+	assert.Equal(t, uint(1), found[0].ID)
+
+	// Los tweets nuevos llegan a los mencionados aunque no sigan al autor
+	assert.NoError(t, repo.FanoutTweet(mention(newTestTweet(3, "user3", 3), "user1", "user1")))
+	assert.NoError(t, repo.FanoutTweet(mention(newTestTweet(4, "user3", 4), "user2")))
+
+	found, next, err := repo.GetMentionsTimeline("user1", nil, 1)
+	assert.NoError(t, err)
+	assert.Len(t, found, 1)
+	assert.Equal(t, uint(3), found[0].ID)
+	assert.NotNil(t, next)
+
+	found, next, err = repo.GetMentionsTimeline("user1", next, 1)
+	assert.NoError(t, err)
+	assert.Len(t, found, 1)
+	assert.Equal(t, uint(1), found[0].ID)
+	assert.Nil(t, next)
+
+	// Al eliminar el tweet desaparece de las menciones
+	assert.NoError(t, repo.RemoveTweet(3, "user3"))
+	entries, _, err := store.GetMentionsTimeline("user1", nil, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint{1}, entryIDs(entries))
+}
+
+func TestTimelineRepositoryHidesPrivateAccounts(t *testing.T) {
+	mention := func(tweet domain.Tweet, username string) domain.Tweet {
+		tweet.Entities = &domain.Entities{Mentions: []domain.MentionEntity{{Username: username}}}
+		return tweet
+	}
+
+	users := privateUserRepository{
+		fakeUserRepository: fakeUserRepository{"user1": {"user2"}, "user2": {}, "user3": {}},
+		private:            map[string]bool{"user2": true, "user3": true},
+	}
+	store := NewMemoryTimelineStore()
+	repo := NewTimelineRepository(store, users, fakeTweetRepository{}, 0)
+
+	// Materializar los timelines vacíos de user1
+	_, _, err := repo.GetHomeTimeline("user1", nil, 10)
+	assert.NoError(t, err)
+	_, _, err = repo.GetMentionsTimeline("user1", nil, 10)
+	assert.NoError(t, err)
+
+	quoted := newTestTweet(1, "user3", 1)
+	quote := newTestTweet(4, "user2", 4)
+	quote.QuotedTweetID, quote.QuotedTweet = &quoted.ID, &quoted
+	assert.NoError(t, repo.FanoutTweet(mention(newTestTweet(2, "user2", 2), "user1")))
+	assert.NoError(t, repo.FanoutTweet(mention(newTestTweet(3, "user3", 3), "user1")))
+	assert.NoError(t, repo.FanoutTweet(quote))
+
+	// La mención de una cuenta privada que user1 no sigue no aparece
+	found, _, err := repo.GetMentionsTimeline("user1", nil, 10)
+	assert.NoError(t, err)
+	assert.Len(t, found, 1)
+	assert.Equal(t, uint(2), found[0].ID)
+
+	// La cita de user2 se muestra sin el tweet citado de user3
+	found, _, err = repo.GetHomeTimeline("user1", nil, 10)
+	assert.NoError(t, err)
+	assert.Len(t, found, 2)
+	assert.Equal(t, uint(4), found[0].ID)
+	assert.Nil(t, found[0].QuotedTweet)
+	assert.Equal(t, uint(2), found[1].ID)
+}
+
+func TestTimelineRepositoryHidesBlockedAndMutedUsers(t *testing.T) {
+	users := privateUserRepository{
+		fakeUserRepository: fakeUserRepository{"user1": {"user2", "user3"}, "user2": {}, "user3": {}},
+		hidden:             map[string][]string{"user1": {"user3"}},
+	}
+	store := NewMemoryTimelineStore()
+	repo := NewTimelineRepository(store, users, fakeTweetRepository{}, 0)
+
+	_, _, err := repo.GetHomeTimeline("user1", nil, 10)
+	assert.NoError(t, err)
+	_, _, err = repo.GetMentionsTimeline("user1", nil, 10)
+	assert.NoError(t, err)
+
+	muted := newTestTweet(1, "user3", 1)
+	retweet := newTestTweet(3, "user2", 3)
+	retweet.RetweetOfTweetID, retweet.RetweetedTweet = &muted.ID, &muted
+	mention := newTestTweet(4, "user3", 4)
+	mention.Entities = &domain.Entities{Mentions: []domain.MentionEntity{{Username: "user1"}}}
+	assert.NoError(t, repo.FanoutTweet(muted))
+	assert.NoError(t, repo.FanoutTweet(newTestTweet(2, "user2", 2)))
+	assert.NoError(t, repo.FanoutTweet(retweet))
+	assert.NoError(t, repo.FanoutTweet(mention))
+
+	// Ni los tweets de user3 ni los retweets de sus tweets llegan al timeline de user1
+	found, _, err := repo.GetHomeTimeline("user1", nil, 10)
+	assert.NoError(t, err)
+	assert.Len(t, found, 1)
+	assert.Equal(t, uint(2), found[0].ID)
+
+	found, _, err = repo.GetMentionsTimeline("user1", nil, 10)
+	assert.NoError(t, err)
+	assert.Empty(t, found)
+}
+
+func TestTimelineRepositoryUpdateTweet(t *testing.T) {
+	users := fakeUserRepository{"user1": {"user2"}, "user2": {}, "user3": {"user2"}}
+	store := NewMemoryTimelineStore()
+	repo := NewTimelineRepository(store, users, fakeTweetRepository{}, 0)
+
+	for _, username := range []string{"user1", "user3"} {
+		_, _, err := repo.GetMentionsTimeline(username, nil, 10)
+		assert.NoError(t, err)
+	}
+	_, _, err := repo.GetHomeTimeline("user3", nil, 10)
+	assert.NoError(t, err)
+
+	original := newTestTweet(1, "user2", 1)
+	original.RevisionCount = 1
+	original.Entities = &domain.Entities{Mentions: []domain.MentionEntity{{Username: "user1"}}}
+	retweet := newTestTweet(2, "user2", 2)
+	copied := original
+	retweet.RetweetOfTweetID, retweet.RetweetedTweet = &original.ID, &copied
+	assert.NoError(t, repo.FanoutTweet(original))
+	assert.NoError(t, repo.FanoutTweet(retweet))
+
+	// La edición cambia la mención de user1 a user3
+	edited := original
+	edited.Content, edited.Edited, edited.RevisionCount = "tweet corregido", true, 2
+	edited.Entities = &domain.Entities{Mentions: []domain.MentionEntity{{Username: "user3"}}}
+	assert.NoError(t, repo.UpdateTweet(edited))
+
+	found, _, err := repo.GetMentionsTimeline("user1", nil, 10)
+	assert.NoError(t, err)
+	assert.Empty(t, found)
+	found, _, err = repo.GetMentionsTimeline("user3", nil, 10)
+	assert.NoError(t, err)
+	if assert.Len(t, found, 1) {
+		assert.Equal(t, "tweet corregido", found[0].Content)
+	}
+
+	// El retweet guardado antes de la edición muestra la versión nueva
+	found, _, err = repo.GetHomeTimeline("user3", nil, 10)
+	assert.NoError(t, err)
+	if assert.Len(t, found, 1) {
+		assert.Equal(t, uint(2), found[0].ID)
+		assert.Equal(t, "tweet corregido", found[0].RetweetedTweet.Content)
+		assert.True(t, found[0].RetweetedTweet.Edited)
+	}
+
+	// Un evento atrasado con una versión anterior no pisa la edición
+	assert.NoError(t, repo.UpdateTweet(original))
+	stored, err := store.GetTweets([]uint{1})
+	assert.NoError(t, err)
+	assert.Equal(t, "tweet corregido", stored[1].Content)
+}
+
+func TestTimelineRepositoryRemoveTweetLeavesTombstone(t *testing.T) {
+	users := fakeUserRepository{"user1": {"user2"}, "user2": {}}
+	store := NewMemoryTimelineStore()
+	repo := NewTimelineRepository(store, users, fakeTweetRepository{}, 0)
+	_, _, err := repo.GetHomeTimeline("user1", nil, 10)
+	assert.NoError(t, err)
+
+	quoted := newTestTweet(1, "user2", 1)
+	quote := newTestTweet(2, "user2", 2)
+	copied := quoted
+	quote.QuotedTweetID, quote.QuotedTweet = &quoted.ID, &copied
+	assert.NoError(t, repo.FanoutTweet(quoted))
+	assert.NoError(t, repo.FanoutTweet(quote))
+
+	// El tweet eliminado sale del timeline y la cita lo muestra como tombstone
+	assert.NoError(t, repo.RemoveTweet(1, "user2"))
+	found, _, err := repo.GetHomeTimeline("user1", nil, 10)
+	assert.NoError(t, err)
+	if assert.Len(t, found, 1) {
+		assert.Equal(t, uint(2), found[0].ID)
+		assert.True(t, found[0].QuotedTweet.Deleted)
+		assert.Equal(t, domain.TombstoneText, found[0].QuotedTweet.Content)
+		assert.Empty(t, found[0].QuotedTweet.Username)
+	}
+
+	// Una edición atrasada no revive el contenido
+	edited := quoted
+	edited.RevisionCount = 2
+	assert.NoError(t, repo.UpdateTweet(edited))
+	stored, err := store.GetTweets([]uint{1})
+	assert.NoError(t, err)
+	assert.True(t, stored[1].Deleted)
+
+	// Al restaurarlo vuelve al timeline con su contenido
+	assert.NoError(t, repo.FanoutTweet(quoted))
+	found, _, err = repo.GetHomeTimeline("user1", nil, 10)
+	assert.NoError(t, err)
+	if assert.Len(t, found, 2) {
+		assert.False(t, found[0].QuotedTweet.Deleted)
+		assert.Equal(t, uint(1), found[1].ID)
+	}
+}
@@ -0,0 +1,189 @@
+package stream
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/DevOpslp/microblogging-platform/timeline-service/internal/domain"
+)
+
+// ErrTooManyConnections se devuelve al suscribirse cuando la instancia ya tiene MaxConnections conexiones
+var ErrTooManyConnections = errors.New("demasiadas conexiones de streaming")
+
+// Config define el comportamiento de las conexiones de streaming del timeline
+type Config struct {
+	Heartbeat       time.Duration // cada cuánto se envía un heartbeat a los clientes
+	Buffer          int           // tweets pendientes por conexión antes de considerarla lenta
+	MaxConnections  int           // conexiones simultáneas por instancia (0 sin límite)
+	RefreshInterval time.Duration // cada cuánto se vuelven a consultar los seguidos y ocultos de cada conexión
+	ReplayLimit     int           // tweets que se reenvían como máximo al reconectar con Last-Event-ID
+	WriteTimeout    time.Duration // tiempo máximo para escribir un mensaje en la conexión
+}
+
+// DefaultConfig envía heartbeats cada 25 segundos y admite 10000 conexiones por instancia
+func DefaultConfig() Config {
+	return Config{
+		Heartbeat:       25 * time.Second,
+		Buffer:          64,
+		MaxConnections:  10000,
+		RefreshInterval: 5 * time.Minute,
+		ReplayLimit:     100,
+		WriteTimeout:    10 * time.Second,
+	}
+}
+
+// Subscriber es una conexión abierta de un usuario. Recibe los tweets nuevos de los autores que sigue
+// por Events; el canal se cierra si el hub la descarta por lenta.
+type Subscriber struct {
+	Username string
+	events   chan domain.Tweet
+	authors  map[string]bool
+	hidden   map[string]bool
+	closed   bool
+}
+
+// Events devuelve el canal con los tweets a enviar a la conexión
+func (s *Subscriber) Events() <-chan domain.Tweet {
+	return s.events
+}
+
+// Hub reparte los tweets nuevos entre las conexiones abiertas de los seguidores de cada autor.
+//
+// Las conexiones se indexan por autor seguido, de modo que publicar un tweet solo recorre las conexiones
+// interesadas. El envío nunca bloquea: cada conexión tiene un buffer propio y, si está lleno, la conexión
+// se descarta y su canal se cierra. El cliente vuelve a conectarse con Last-Event-ID y recupera lo perdido
+// desde el timeline guardado.
+type Hub struct {
+	config Config
+
+	mu          sync.RWMutex
+	byAuthor    map[string]map[*Subscriber]struct{}
+	subscribers map[*Subscriber]struct{}
+}
+
+func NewHub(config Config) *Hub {
+	return &Hub{
+		config:      config,
+		byAuthor:    make(map[string]map[*Subscriber]struct{}),
+		subscribers: make(map[*Subscriber]struct{}),
+	}
+}
+
+// Config devuelve la configuración del hub
+func (h *Hub) Config() Config {
+	return h.config
+}
+
+// Connections devuelve la cantidad de conexiones abiertas
+func (h *Hub) Connections() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.subscribers)
+}
+
+// Subscribe registra una conexión de username que recibirá los tweets de following, salvo los de
+// autores ocultos (bloqueados o silenciados)
+func (h *Hub) Subscribe(username string, following, hidden []string) (*Subscriber, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.config.MaxConnections > 0 && len(h.subscribers) >= h.config.MaxConnections {
+		return nil, ErrTooManyConnections
+	}
+	sub := &Subscriber{Username: username, events: make(chan domain.Tweet, max(h.config.Buffer, 1))}
+	h.subscribers[sub] = struct{}{}
+	h.setSources(sub, following, hidden)
+	return sub, nil
+}
+
+// Update reemplaza los autores seguidos y ocultos de una conexión
+func (h *Hub) Update(sub *Subscriber, following, hidden []string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if sub.closed {
+		return
+	}
+	h.removeAuthors(sub)
+	h.setSources(sub, following, hidden)
+}
+
+// Unsubscribe quita una conexión del hub y cierra su canal
+func (h *Hub) Unsubscribe(sub *Subscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.remove(sub)
+}
+
+// Publish envía un tweet nuevo a las conexiones de los seguidores de su autor. Las conexiones con el
+// buffer lleno se descartan. Devuelve a cuántas conexiones se entregó.
+func (h *Hub) Publish(tweet domain.Tweet) int {
+	delivered := 0
+	slow := make([]*Subscriber, 0)
+
+	h.mu.RLock()
+	for sub := range h.byAuthor[tweet.Username] {
+		if len(domain.HideAuthors([]domain.Tweet{tweet}, sub.hidden)) == 0 {
+			continue
+		}
+		select {
+		case sub.events <- tweet:
+			delivered++
+		default:
+			slow = append(slow, sub)
+		}
+	}
+	h.mu.RUnlock()
+
+	if len(slow) > 0 {
+		h.mu.Lock()
+		for _, sub := range slow {
+			h.remove(sub)
+		}
+		h.mu.Unlock()
+	}
+	return delivered
+}
+
+// setSources indexa la conexión por cada autor seguido. Requiere h.mu tomado para escritura.
+func (h *Hub) setSources(sub *Subscriber, following, hidden []string) {
+	sub.hidden = make(map[string]bool, len(hidden))
+	for _, username := range hidden {
+		sub.hidden[username] = true
+	}
+	sub.authors = make(map[string]bool, len(following))
+	for _, author := range following {
+		if sub.hidden[author] {
+			continue
+		}
+		sub.authors[author] = true
+		if h.byAuthor[author] == nil {
+			h.byAuthor[author] = make(map[*Subscriber]struct{})
+		}
+		h.byAuthor[author][sub] = struct{}{}
+	}
+}
+
+// removeAuthors quita la conexión del índice por autor. Requiere h.mu tomado para escritura.
+func (h *Hub) removeAuthors(sub *Subscriber) {
+	for author := range sub.authors {
+		delete(h.byAuthor[author], sub)
+		if len(h.byAuthor[author]) == 0 {
+			delete(h.byAuthor, author)
+		}
+	}
+	sub.authors = nil
+}
+
+// remove quita la conexión y cierra su canal una sola vez. Requiere h.mu tomado para escritura, así
+// ningún Publish envía al canal mientras se cierra.
+func (h *Hub) remove(sub *Subscriber) {
+	if sub.closed {
+		return
+	}
+	h.removeAuthors(sub)
+	delete(h.subscribers, sub)
+	sub.closed = true
+	close(sub.events)
+}
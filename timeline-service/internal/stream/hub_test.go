@@ -0,0 +1,118 @@
+package stream
+
+import (
+	"testing"
+
+	"github.com/DevOpslp/microblogging-platform/timeline-service/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestTweet(id uint, username string) domain.Tweet {
+	return domain.Tweet{ID: id, Username: username, Content: "hola"}
+}
+
+// received devuelve los IDs de los tweets pendientes en la conexión sin bloquear
+func received(sub *Subscriber) []uint {
+	ids := make([]uint, 0)
+	for {
+		select {
+		case tweet, ok := <-sub.Events():
+			if !ok {
+				return ids
+			}
+			ids = append(ids, tweet.ID)
+		default:
+			return ids
+		}
+	}
+}
+
+func TestHubPublishToFollowers(t *testing.T) {
+	hub := NewHub(DefaultConfig())
+	reader, _ := hub.Subscribe("reader", []string{"ana", "bea"}, nil)
+	other, _ := hub.Subscribe("other", []string{"bea"}, nil)
+	assert.Equal(t, 2, hub.Connections())
+
+	assert.Equal(t, 1, hub.Publish(newTestTweet(1, "ana")))
+	assert.Equal(t, 2, hub.Publish(newTestTweet(2, "bea")))
+	assert.Equal(t, 0, hub.Publish(newTestTweet(3, "carla")))
+
+	assert.Equal(t, []uint{1, 2}, received(reader))
+	assert.Equal(t, []uint{2}, received(other))
+}
+
+func TestHubHidesBlockedAndMutedAuthors(t *testing.T) {
+	hub := NewHub(DefaultConfig())
+	sub, _ := hub.Subscribe("reader", []string{"ana", "muted"}, []string{"muted", "blocked"})
+
+	retweet := newTestTweet(2, "ana")
+	original := newTestTweet(1, "blocked")
+	retweet.RetweetedTweet = &original
+
+	assert.Equal(t, 0, hub.Publish(newTestTweet(3, "muted")))
+	assert.Equal(t, 0, hub.Publish(retweet))
+	assert.Equal(t, 1, hub.Publish(newTestTweet(4, "ana")))
+	assert.Equal(t, []uint{4}, received(sub))
+}
+
+func TestHubDropsSlowSubscribers(t *testing.T) {
+	config := DefaultConfig()
+	config.Buffer = 2
+	hub := NewHub(config)
+	slow, _ := hub.Subscribe("slow", []string{"ana"}, nil)
+	fast, _ := hub.Subscribe("fast", []string{"ana"}, nil)
+
+	assert.Equal(t, 2, hub.Publish(newTestTweet(1, "ana")))
+	assert.Equal(t, []uint{1}, received(fast))
+	assert.Equal(t, 2, hub.Publish(newTestTweet(2, "ana")))
+	assert.Equal(t, []uint{2}, received(fast))
+
+	// El buffer de la conexión lenta está lleno: se descarta sin bloquear a las demás
+	assert.Equal(t, 1, hub.Publish(newTestTweet(3, "ana")))
+	assert.Equal(t, []uint{3}, received(fast))
+	assert.Equal(t, 1, hub.Connections())
+
+	// La conexión descartada conserva lo que ya tenía en el buffer y luego ve el canal cerrado
+	assert.Equal(t, []uint{1, 2}, received(slow))
+	_, open := <-slow.Events()
+	assert.False(t, open)
+
+	// Cerrar una conexión ya descartada no falla
+	hub.Unsubscribe(slow)
+	hub.Update(slow, []string{"ana"}, nil)
+	assert.Equal(t, 1, hub.Connections())
+}
+
+func TestHubUpdateAndUnsubscribe(t *testing.T) {
+	hub := NewHub(DefaultConfig())
+	sub, _ := hub.Subscribe("reader", []string{"ana"}, nil)
+
+	// Un seguimiento nuevo empieza a recibirse y uno terminado deja de recibirse
+	hub.Update(sub, []string{"bea"}, nil)
+	hub.Publish(newTestTweet(1, "ana"))
+	hub.Publish(newTestTweet(2, "bea"))
+	assert.Equal(t, []uint{2}, received(sub))
+
+	hub.Unsubscribe(sub)
+	assert.Equal(t, 0, hub.Connections())
+	assert.Equal(t, 0, hub.Publish(newTestTweet(3, "bea")))
+	assert.Empty(t, hub.byAuthor)
+	_, open := <-sub.Events()
+	assert.False(t, open)
+}
+
+func TestHubMaxConnections(t *testing.T) {
+	config := DefaultConfig()
+	config.MaxConnections = 1
+	hub := NewHub(config)
+
+	first, err := hub.Subscribe("ana", nil, nil)
+	assert.NoError(t, err)
+	_, err = hub.Subscribe("bea", nil, nil)
+	assert.ErrorIs(t, err, ErrTooManyConnections)
+
+	// Al cerrarse una conexión queda lugar para otra
+	hub.Unsubscribe(first)
+	_, err = hub.Subscribe("bea", nil, nil)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,279 @@
+package trends
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DevOpslp/microblogging-platform/timeline-service/internal/domain"
+)
+
+// Tipos de tendencia
+const (
+	TypeHashtag = "hashtag"
+	TypeTerm    = "term"
+)
+
+// Config define las ventanas de las tendencias. Window es la ventana reciente y Baseline la ventana
+// inmediatamente anterior con la que se compara; ambas se dividen en intervalos de Bucket.
+type Config struct {
+	Window          time.Duration
+	Baseline        time.Duration
+	Bucket          time.Duration
+	MinCount        int // tweets mínimos en Window para que un término sea tendencia
+	MaxTrends       int // tendencias guardadas por idioma
+	RefreshInterval time.Duration
+}
+
+// DefaultConfig compara la última hora con las 24 horas anteriores y recalcula cada 30 segundos
+func DefaultConfig() Config {
+	return Config{
+		Window:          time.Hour,
+		Baseline:        24 * time.Hour,
+		Bucket:          time.Minute,
+		MinCount:        5,
+		MaxTrends:       50,
+		RefreshInterval: 30 * time.Second,
+	}
+}
+
+// Clock da la hora actual; las pruebas usan un reloj manual para que los resultados sean deterministas
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+// SystemClock es el reloj del sistema
+var SystemClock Clock = systemClock{}
+
+// Trend es un hashtag (con '#') o término en tendencia. Count es la cantidad de tweets que lo usaron
+// en la ventana reciente y Score cuánto supera lo esperado según la ventana de referencia.
+type Trend struct {
+	Name  string  `json:"name"`
+	Type  string  `json:"type"`
+	Count int     `json:"tweet_count"`
+	Score float64 `json:"score"`
+}
+
+type termKey struct {
+	lang string
+	term string
+}
+
+// Engine cuenta los términos de los tweets nuevos por intervalo e idioma y calcula periódicamente las
+// tendencias. Los conteos se guardan en memoria: cada instancia recibe todos los tweets nuevos y, al
+// iniciar, los de Window+Baseline hacia atrás (ver Since), por lo que todas calculan las mismas tendencias.
+type Engine struct {
+	config Config
+	clock  Clock
+
+	mu      sync.Mutex
+	buckets map[int64]map[termKey]int
+	seen    map[uint]int64 // bucket en que se contó cada tweet, para ignorar eventos repetidos
+
+	snapshotMu  sync.RWMutex
+	snapshot    map[string][]Trend // por idioma; "" agrupa todos los idiomas
+	refreshedAt time.Time
+}
+
+func NewEngine(config Config, clock Clock) *Engine {
+	return &Engine{
+		config:   config,
+		clock:    clock,
+		buckets:  make(map[int64]map[termKey]int),
+		seen:     make(map[uint]int64),
+		snapshot: make(map[string][]Trend),
+	}
+}
+
+func (e *Engine) bucketOf(t time.Time) int64 {
+	return t.UnixNano() / int64(e.config.Bucket)
+}
+
+// bounds devuelve el último intervalo de la ventana de referencia y el último anterior a la ventana
+// reciente; los intervalos hasta baselineStart ya no cuentan
+func (e *Engine) bounds(now time.Time) (windowStart, baselineStart int64) {
+	windowStart = e.bucketOf(now) - int64(e.config.Window/e.config.Bucket)
+	return windowStart, windowStart - int64(e.config.Baseline/e.config.Bucket)
+}
+
+// Since es la hora desde la que los tweets todavía cuentan para las tendencias
+func (e *Engine) Since() time.Time {
+	return e.clock.Now().Add(-e.config.Window - e.config.Baseline)
+}
+
+// Record cuenta los hashtags y términos de un tweet en el intervalo de su publicación, así que un evento
+// que llega tarde cuenta donde corresponde; uno anterior a la ventana de referencia se ignora. Los
+// retweets no tienen contenido propio y no cuentan; un tweet ya contado (reintentos o restauraciones)
+// se ignora.
+func (e *Engine) Record(tweet domain.Tweet) {
+	if tweet.RetweetOfTweetID != nil {
+		return
+	}
+	terms, lang := extractTerms(tweet)
+	if len(terms) == 0 {
+		return
+	}
+
+	now := e.clock.Now()
+	createdAt := tweet.CreatedAt
+	if createdAt.IsZero() || createdAt.After(now) {
+		createdAt = now
+	}
+	bucket := e.bucketOf(createdAt)
+	if _, baselineStart := e.bounds(now); bucket <= baselineStart {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if _, ok := e.seen[tweet.ID]; ok {
+		return
+	}
+	e.seen[tweet.ID] = bucket
+
+	counts := e.buckets[bucket]
+	if counts == nil {
+		counts = make(map[termKey]int)
+		e.buckets[bucket] = counts
+	}
+	for _, term := range terms {
+		counts[termKey{lang: lang, term: term}]++
+	}
+}
+
+// Refresh recalcula las tendencias con la hora del reloj y descarta los intervalos que ya salieron
+// de la ventana de referencia
+func (e *Engine) Refresh() {
+	now := e.clock.Now()
+	current, baseline := e.count(now)
+
+	snapshot := make(map[string][]Trend)
+	for lang, counts := range current {
+		snapshot[lang] = e.score(counts, baseline[lang])
+	}
+
+	e.snapshotMu.Lock()
+	e.snapshot, e.refreshedAt = snapshot, now
+	e.snapshotMu.Unlock()
+}
+
+// count suma los conteos por idioma de la ventana reciente y de la de referencia. Los tweets sin
+// idioma detectado solo cuentan en "".
+func (e *Engine) count(now time.Time) (current, baseline map[string]map[string]int) {
+	last := e.bucketOf(now)
+	windowStart, baselineStart := e.bounds(now)
+
+	current = make(map[string]map[string]int)
+	baseline = make(map[string]map[string]int)
+	add := func(totals map[string]map[string]int, key termKey, count int) {
+		langs := []string{""}
+		if key.lang != "" {
+			langs = append(langs, key.lang)
+		}
+		for _, lang := range langs {
+			if totals[lang] == nil {
+				totals[lang] = make(map[string]int)
+			}
+			totals[lang][key.term] += count
+		}
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for bucket, counts := range e.buckets {
+		switch {
+		case bucket <= baselineStart:
+			delete(e.buckets, bucket)
+		case bucket > last:
+			// Intervalo futuro: el reloj retrocedió; se cuenta cuando llegue
+		case bucket > windowStart:
+			for key, count := range counts {
+				add(current, key, count)
+			}
+		default:
+			for key, count := range counts {
+				add(baseline, key, count)
+			}
+		}
+	}
+	for tweetID, bucket := range e.seen {
+		if bucket <= baselineStart {
+			delete(e.seen, tweetID)
+		}
+	}
+	return current, baseline
+}
+
+// score ordena los términos por velocidad: cuántos tweets más de los esperados según la ventana de
+// referencia tuvo cada uno, dividido por la desviación esperada (sqrt) para que un término habitual
+// con mucho volumen no le gane a uno que recién aparece. Un término que se usa siempre al mismo
+// ritmo tiene puntaje cercano a cero.
+func (e *Engine) score(current, baseline map[string]int) []Trend {
+	ratio := float64(e.config.Window) / float64(e.config.Baseline)
+	trends := make([]Trend, 0)
+	for term, count := range current {
+		if count < e.config.MinCount {
+			continue
+		}
+		expected := float64(baseline[term]) * ratio
+		score := (float64(count) - expected) / math.Sqrt(expected+1)
+		if score <= 0 {
+			continue
+		}
+		trend := Trend{Name: term, Type: TypeTerm, Count: count, Score: math.Round(score*1000) / 1000}
+		if strings.HasPrefix(term, "#") {
+			trend.Type = TypeHashtag
+		}
+		trends = append(trends, trend)
+	}
+
+	sort.Slice(trends, func(i, j int) bool {
+		if trends[i].Score != trends[j].Score {
+			return trends[i].Score > trends[j].Score
+		}
+		if trends[i].Count != trends[j].Count {
+			return trends[i].Count > trends[j].Count
+		}
+		return trends[i].Name < trends[j].Name
+	})
+	if len(trends) > e.config.MaxTrends {
+		trends = trends[:e.config.MaxTrends]
+	}
+	return trends
+}
+
+// Trends devuelve hasta limit tendencias del último cálculo para el idioma ("" para todos) y la hora
+// en que se calcularon
+func (e *Engine) Trends(lang string, limit int) ([]Trend, time.Time) {
+	e.snapshotMu.RLock()
+	defer e.snapshotMu.RUnlock()
+
+	trends := e.snapshot[lang]
+	if len(trends) > limit {
+		trends = trends[:limit]
+	}
+	return append([]Trend{}, trends...), e.refreshedAt
+}
+
+// Run recalcula las tendencias cada RefreshInterval hasta que ctx se cancela
+func (e *Engine) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.config.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		e.Refresh()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package trends
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DevOpslp/microblogging-platform/timeline-service/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+// manualClock es un reloj que solo avanza cuando la prueba lo indica
+type manualClock struct {
+	now time.Time
+}
+
+func (c *manualClock) Now() time.Time { return c.now }
+
+func (c *manualClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+// syntheticStream genera tweets con IDs consecutivos, publicados a la hora del reloj
+type syntheticStream struct {
+	engine *Engine
+	clock  *manualClock
+	nextID uint
+}
+
+func (s *syntheticStream) tweet(content string, hashtags ...string) domain.Tweet {
+	s.nextID++
+	tweet := domain.Tweet{ID: s.nextID, Username: "user", Content: content, Entities: &domain.Entities{}, CreatedAt: s.clock.now}
+	for _, tag := range hashtags {
+		tweet.Content += " #" + tag
+		tweet.Entities.Hashtags = append(tweet.Entities.Hashtags, domain.HashtagEntity{Tag: tag})
+	}
+	return tweet
+}
+
+func (s *syntheticStream) publish(count int, content string, hashtags ...string) {
+	for i := 0; i < count; i++ {
+		s.engine.Record(s.tweet(content, hashtags...))
+	}
+}
+
+func newTestEngine() (*Engine, *manualClock, *syntheticStream) {
+	clock := &manualClock{now: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)}
+	engine := NewEngine(DefaultConfig(), clock)
+	return engine, clock, &syntheticStream{engine: engine, clock: clock}
+}
+
+func trendNames(trends []Trend) []string {
+	names := make([]string, 0, len(trends))
+	for _, trend := range trends {
+		names = append(names, trend.Name)
+	}
+	return names
+}
+
+func TestEngineScoresVelocityAgainstBaseline(t *testing.T) {
+	engine, clock, stream := newTestEngine()
+
+	// Durante 24 horas #siempre se usa 10 veces por hora
+	for hour := 0; hour < 24; hour++ {
+		stream.publish(10, "", "siempre")
+		clock.Advance(time.Hour)
+	}
+
+	// En la última hora #siempre mantiene su ritmo, #nuevo aparece y #poco no llega al mínimo
+	stream.publish(10, "", "siempre")
+	stream.publish(6, "", "nuevo")
+	stream.publish(DefaultConfig().MinCount-1, "", "poco")
+	clock.Advance(30 * time.Minute)
+	engine.Refresh()
+
+	trends, refreshedAt := engine.Trends("", 10)
+	assert.Equal(t, clock.now, refreshedAt)
+	if assert.Len(t, trends, 1) {
+		assert.Equal(t, Trend{Name: "#nuevo", Type: TypeHashtag, Count: 6, Score: 6}, trends[0])
+	}
+
+	// Si #siempre duplica su ritmo vuelve a ser tendencia, pero detrás del tema que recién aparece
+	stream.publish(10, "", "siempre")
+	engine.Refresh()
+	trends, _ = engine.Trends("", 10)
+	assert.Equal(t, []string{"#nuevo", "#siempre"}, trendNames(trends))
+	assert.Equal(t, 20, trends[1].Count)
+}
+
+func TestEngineSlidingWindow(t *testing.T) {
+	engine, clock, stream := newTestEngine()
+
+	stream.publish(5, "partido", "final")
+	engine.Refresh()
+	trends, _ := engine.Trends("", 10)
+	assert.Equal(t, []string{"#final", "partido"}, trendNames(trends))
+	assert.Equal(t, TypeTerm, trends[1].Type)
+
+	// Una hora después la ventana reciente ya no incluye esos tweets
+	clock.Advance(time.Hour)
+	engine.Refresh()
+	trends, _ = engine.Trends("", 10)
+	assert.Empty(t, trends)
+
+	// Y pasada la ventana de referencia se descartan los conteos
+	clock.Advance(DefaultConfig().Baseline)
+	engine.Refresh()
+	assert.Empty(t, engine.buckets)
+	assert.Empty(t, engine.seen)
+}
+
+func TestEngineCountsTweetsWhenPublished(t *testing.T) {
+	engine, clock, stream := newTestEngine()
+	assert.Equal(t, clock.now.Add(-25*time.Hour), engine.Since())
+
+	// Tweets de hace dos horas que llegan tarde cuentan en la ventana de referencia, no en la reciente
+	late := make([]domain.Tweet, 0)
+	for i := 0; i < 5; i++ {
+		tweet := stream.tweet("", "atrasado")
+		tweet.CreatedAt = clock.now.Add(-2 * time.Hour)
+		late = append(late, tweet)
+	}
+	// Los anteriores a la ventana de referencia se ignoran
+	for i := 0; i < 5; i++ {
+		tweet := stream.tweet("", "viejo")
+		tweet.CreatedAt = engine.Since().Add(-time.Minute)
+		engine.Record(tweet)
+	}
+	for _, tweet := range late {
+		engine.Record(tweet)
+	}
+	engine.Refresh()
+	trends, _ := engine.Trends("", 10)
+	assert.Empty(t, trends)
+	assert.Len(t, engine.seen, 5)
+
+	// Con 5 tweets recientes, el puntaje descuenta los atrasados de la referencia
+	stream.publish(5, "", "atrasado")
+	engine.Refresh()
+	trends, _ = engine.Trends("", 10)
+	if assert.Len(t, trends, 1) {
+		assert.Equal(t, 5, trends[0].Count)
+		assert.Less(t, trends[0].Score, 5.0)
+	}
+}
+
+func TestEngineIgnoresRepeatedTweetsAndRetweets(t *testing.T) {
+	engine, _, stream := newTestEngine()
+
+	tweet := stream.tweet("repetido", "repetido")
+	for i := 0; i < 5; i++ {
+		engine.Record(tweet)
+	}
+	for i := 0; i < 5; i++ {
+		retweet := stream.tweet("", "retuiteado")
+		retweet.RetweetOfTweetID = &tweet.ID
+		engine.Record(retweet)
+	}
+
+	engine.Refresh()
+	trends, _ := engine.Trends("", 10)
+	assert.Empty(t, trends)
+}
+
+func TestEngineLanguageFilter(t *testing.T) {
+	engine, _, stream := newTestEngine()
+
+	stream.publish(5, "el partido es en la cancha", "futbol")
+	stream.publish(6, "the game is on tonight at the stadium", "soccer")
+	stream.publish(7, "", "sinidioma")
+	engine.Refresh()
+
+	all, _ := engine.Trends("", 2)
+	assert.Equal(t, []string{"#sinidioma", "#soccer"}, trendNames(all), "Sin filtro se incluyen todos los idiomas")
+
+	spanish, _ := engine.Trends("es", 10)
+	assert.Equal(t, []string{"#futbol", "cancha", "partido"}, trendNames(spanish))
+
+	english, _ := engine.Trends("en", 10)
+	assert.Equal(t, []string{"#soccer", "game", "stadium", "tonight"}, trendNames(english))
+
+	portuguese, _ := engine.Trends("pt", 10)
+	assert.Empty(t, portuguese)
+}
+
+func TestExtractTerms(t *testing.T) {
+	tweet := domain.Tweet{
+		Content:  "¡La Final del Mundial! @pepe https://x.com/a #Mundial2026 2026 ok final",
+		Entities: &domain.Entities{Hashtags: []domain.HashtagEntity{{Tag: "Mundial2026"}}},
+	}
+	terms, lang := extractTerms(tweet)
+	assert.Equal(t, []string{"#mundial2026", "final", "mundial"}, terms)
+	assert.Equal(t, "es", lang)
+}
+
+func TestDetectLanguage(t *testing.T) {
+	cases := map[string]string{
+		"hoy es el día de la fiesta":      "es",
+		"this is the best day of my life": "en",
+		"eu não sei o que você quer":      "pt",
+		"golang rust zig":                 "",
+		"a":                               "",
+	}
+	for text, expected := range cases {
+		assert.Equal(t, expected, DetectLanguage(words(text)), fmt.Sprintf("%q", text))
+	}
+}
@@ -0,0 +1,61 @@
+package trends
+
+// Palabras vacías de cada idioma soportado. Sirven para detectar el idioma de un tweet y para no
+// contarlas como términos.
+var stopwords = map[string]map[string]bool{
+	"es": set("a", "al", "algo", "como", "con", "de", "del", "el", "ella", "en", "es", "esta", "este", "esto",
+		"hay", "la", "las", "le", "lo", "los", "mas", "más", "me", "mi", "muy", "no", "nos", "para", "pero", "por",
+		"porque", "que", "qué", "se", "si", "sí", "sin", "son", "su", "sus", "también", "te", "todo", "tu", "un",
+		"una", "uno", "y", "ya", "yo"),
+	"en": set("a", "about", "all", "an", "and", "are", "as", "at", "be", "but", "by", "for", "from", "have",
+		"he", "i", "in", "is", "it", "just", "me", "my", "not", "of", "on", "or", "so", "that", "the", "this",
+		"to", "was", "we", "what", "with", "you", "your"),
+	"pt": set("a", "ao", "as", "com", "como", "da", "das", "de", "do", "dos", "e", "é", "ela", "ele", "em",
+		"eu", "isso", "mais", "mas", "me", "meu", "muito", "na", "não", "no", "nos", "o", "os", "para", "por",
+		"que", "se", "sem", "seu", "sua", "também", "um", "uma", "você"),
+}
+
+// Mínimo de palabras vacías reconocidas para asignar un idioma
+const minLanguageEvidence = 2
+
+func set(words ...string) map[string]bool {
+	result := make(map[string]bool, len(words))
+	for _, word := range words {
+		result[word] = true
+	}
+	return result
+}
+
+// DetectLanguage estima el idioma (es, en o pt) por las palabras vacías que contiene el texto ya
+// separado en palabras en minúsculas. Devuelve "" si no hay evidencia suficiente o hay empate.
+func DetectLanguage(words []string) string {
+	best, bestCount, tied := "", 0, false
+	for _, lang := range []string{"es", "en", "pt"} {
+		count := 0
+		for _, word := range words {
+			if stopwords[lang][word] {
+				count++
+			}
+		}
+		switch {
+		case count > bestCount:
+			best, bestCount, tied = lang, count, false
+		case count == bestCount:
+			tied = true
+		}
+	}
+	if bestCount < minLanguageEvidence || tied {
+		return ""
+	}
+	return best
+}
+
+// isStopword indica si la palabra es vacía en alguno de los idiomas soportados
+func isStopword(word string) bool {
+	for _, words := range stopwords {
+		if words[word] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package trends
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/DevOpslp/microblogging-platform/timeline-service/internal/domain"
+)
+
+// Largo mínimo, en caracteres, de una palabra para contarla como término
+const minTermLength = 3
+
+// words separa el contenido en palabras en minúsculas. Los hashtags, las menciones y las URLs no
+// son palabras.
+func words(content string) []string {
+	result := make([]string, 0)
+	for _, field := range strings.Fields(content) {
+		if strings.HasPrefix(field, "#") || strings.HasPrefix(field, "＃") || strings.HasPrefix(field, "@") || strings.Contains(field, "://") {
+			continue
+		}
+		word := strings.ToLower(strings.TrimFunc(field, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+		}))
+		if word != "" {
+			result = append(result, word)
+		}
+	}
+	return result
+}
+
+// extractTerms devuelve los hashtags (con '#') y los términos del tweet sin repetir, y el idioma
+// detectado. Solo cuentan las palabras con al menos una letra que no son palabras vacías.
+func extractTerms(tweet domain.Tweet) ([]string, string) {
+	seen := make(map[string]bool)
+	terms := make([]string, 0)
+	add := func(term string) {
+		if !seen[term] {
+			seen[term] = true
+			terms = append(terms, term)
+		}
+	}
+
+	if tweet.Entities != nil {
+		for _, hashtag := range tweet.Entities.Hashtags {
+			add("#" + strings.ToLower(hashtag.Tag))
+		}
+	}
+
+	tweetWords := words(tweet.Content)
+	for _, word := range tweetWords {
+		if utf8.RuneCountInString(word) >= minTermLength && !isStopword(word) && strings.IndexFunc(word, unicode.IsLetter) >= 0 {
+			add(word)
+		}
+	}
+	return terms, DetectLanguage(tweetWords)
+}
@@ -1,9 +1,14 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
+	"time"
 
+	"github.com/DevOpslp/microblogging-platform/auth"
+	"github.com/DevOpslp/microblogging-platform/events"
+	"github.com/DevOpslp/microblogging-platform/pagination"
 	"github.com/DevOpslp/microblogging-platform/tweet-service/internal/domain"
 	"github.com/DevOpslp/microblogging-platform/tweet-service/internal/infrastructure/api"
 	"github.com/DevOpslp/microblogging-platform/tweet-service/internal/infrastructure/persistence"
@@ -13,6 +18,17 @@ import (
 )
 
 func main() {
+	// Secreto compartido para firmar y verificar tokens; sin él el servicio no arranca
+	authSecret, err := auth.SecretFromEnv()
+	if err != nil {
+		log.Fatalf("Error de configuración: %v", err)
+	}
+	// Secreto para firmar los cursores de paginación
+	paginationSecret, err := pagination.SecretFromEnv()
+	if err != nil {
+		log.Fatalf("Error de configuración: %v", err)
+	}
+
 	// Obtener las variables de entorno para la conexión a la base de datos
 	dbHost := os.Getenv("DB_HOST")
 	dbUser := os.Getenv("DB_USER")
@@ -29,20 +45,107 @@ func main() {
 		log.Fatalf("Error al conectar a tweetdb: %v", err)
 	}
 
-	if err := tweetDB.AutoMigrate(&domain.Tweet{}); err != nil {
-		log.Fatalf("Error al migrar el modelo Tweet: %v", err)
+	if err := tweetDB.AutoMigrate(&domain.Tweet{}, &domain.Like{}, &domain.TweetHashtag{}, &domain.TweetMention{}, &domain.Media{}, &domain.TweetRevision{}, &events.OutboxEvent{}); err != nil {
+		log.Fatalf("Error al migrar los modelos de tweet-service: %v", err)
+	}
+	if err := persistence.MigrateSearch(tweetDB); err != nil {
+		log.Fatalf("Error al migrar los modelos de tweet-service: %v", err)
 	}
+	if err := persistence.MigrateContentIndex(tweetDB); err != nil {
+		log.Fatalf("Error al migrar los modelos de tweet-service: %v", err)
+	}
+
+	// Tokens firmados con el secreto compartido entre servicios
+	tokens := auth.NewTokenManager(authSecret)
 
 	// Crear los repositorios de usuario y tweet
-	userRepo := persistence.NewHTTPUserRepository(userServiceURL) // URL de `user-service`
-	tweetRepo := persistence.NewTweetRepository(tweetDB, userRepo)
+	userRepo := persistence.NewHTTPUserRepository(userServiceURL, tokens) // URL de `user-service`
+	tweetRepo := persistence.NewTweetRepository(tweetDB, userRepo, newBlobStore())
+
+	// Plazo para editar un tweet desde su creación, en formato de time.ParseDuration (ej. 30m)
+	if value := os.Getenv("TWEET_EDIT_WINDOW"); value != "" {
+		window, err := time.ParseDuration(value)
+		if err != nil || window < 0 {
+			log.Fatalf("TWEET_EDIT_WINDOW inválido: %q", value)
+		}
+		tweetRepo.SetEditWindow(window)
+	}
+
+	// Plazo para restaurar un tweet desde su eliminación, en formato de time.ParseDuration (ej. 5m)
+	if value := os.Getenv("TWEET_RESTORE_WINDOW"); value != "" {
+		window, err := time.ParseDuration(value)
+		if err != nil || window < 0 {
+			log.Fatalf("TWEET_RESTORE_WINDOW inválido: %q", value)
+		}
+		tweetRepo.SetRestoreWindow(window)
+	}
+
+	// Tiempo que se conservan los tweets eliminados antes de borrarlos definitivamente (ej. 720h)
+	retention := domain.DefaultPurgeRetention
+	if value := os.Getenv("TWEET_PURGE_RETENTION"); value != "" {
+		retention, err = time.ParseDuration(value)
+		if err != nil || retention < 0 {
+			log.Fatalf("TWEET_PURGE_RETENTION inválido: %q", value)
+		}
+	}
+
+	// Tiempo que se conservan las imágenes subidas que no se adjuntaron a un tweet (ej. 24h)
+	orphanTTL := domain.DefaultOrphanMediaTTL
+	if value := os.Getenv("MEDIA_ORPHAN_TTL"); value != "" {
+		orphanTTL, err = time.ParseDuration(value)
+		if err != nil || orphanTTL < 0 {
+			log.Fatalf("MEDIA_ORPHAN_TTL inválido: %q", value)
+		}
+	}
+	go persistence.NewPurger(tweetRepo, retention, orphanTTL).Run(context.Background())
+
+	// Publicar los eventos de la outbox en el broker configurado por EVENT_BROKER
+	broker, err := events.NewBrokerFromEnv(context.Background())
+	if err != nil {
+		log.Fatalf("Error al conectar con el broker de eventos: %v", err)
+	}
+	defer broker.Close()
+	go events.NewRelay(events.NewGormOutbox(tweetDB), broker).Run(context.Background())
 
 	// Iniciar el servidor HTTP
 	router := gin.Default()
-	api.SetupRoutes(router, tweetRepo)
+	api.SetupRoutes(router, tweetRepo, tokens, pagination.NewSigner(paginationSecret))
 
 	// Escuchar en el puerto 8081
 	if err := router.Run(":8081"); err != nil {
 		log.Fatalf("Error al iniciar el servidor de Tweet-Service: %v", err)
 	}
 }
+
+// newBlobStore elige dónde se guardan las imágenes: en disco por defecto (MEDIA_DIR) o en un bucket
+// compatible con S3 si MEDIA_STORAGE=s3
+func newBlobStore() persistence.BlobStore {
+	switch os.Getenv("MEDIA_STORAGE") {
+	case "", "filesystem":
+		dir := os.Getenv("MEDIA_DIR")
+		if dir == "" {
+			dir = "media"
+		}
+		store, err := persistence.NewFilesystemBlobStore(dir)
+		if err != nil {
+			log.Fatalf("No se pudo preparar el directorio de imágenes: %v", err)
+		}
+		return store
+	case "s3":
+		store, err := persistence.NewS3BlobStore(persistence.S3Config{
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			Region:    os.Getenv("S3_REGION"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+			UseSSL:    os.Getenv("S3_USE_SSL") == "true",
+		})
+		if err != nil {
+			log.Fatalf("No se pudo configurar el almacenamiento S3: %v", err)
+		}
+		return store
+	default:
+		log.Fatalf("MEDIA_STORAGE desconocido: %q", os.Getenv("MEDIA_STORAGE"))
+		return nil
+	}
+}
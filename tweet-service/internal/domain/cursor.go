@@ -0,0 +1,14 @@
+package domain
+
+import "time"
+
+// Posición de un tweet en un listado ordenado por (created_at, id) de forma descendente.
+// Se usa como cursor para la paginación por keyset.
+type Cursor struct {
+	CreatedAt time.Time
+	ID        uint
+}
+
+func (t Tweet) Cursor() Cursor {
+	return Cursor{CreatedAt: t.CreatedAt, ID: t.ID}
+}
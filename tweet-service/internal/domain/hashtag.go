@@ -0,0 +1,105 @@
+package domain
+
+import (
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Largo máximo de un hashtag, sin contar el '#'
+const MaxHashtagLength = 100
+
+// Hashtag encontrado en el contenido de un tweet. Start y End son posiciones en caracteres
+// (code points, no bytes) del '#' inicial y del final exclusivo, para que los clientes puedan resaltarlo.
+type HashtagEntity struct {
+	Tag   string `json:"tag"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+}
+
+// Entidades reconocidas en el contenido de un tweet
+type Entities struct {
+	Hashtags []HashtagEntity `json:"hashtags"`
+	Mentions []MentionEntity `json:"mentions"`
+}
+
+// Relación entre un hashtag normalizado y los tweets que lo usan. Guarda la fecha del tweet para
+// paginar por (created_at, tweet_id) sin tener que unir con la tabla de tweets.
+type TweetHashtag struct {
+	Tag       string    `gorm:"primaryKey;size:100;index:idx_tweet_hashtags_tag_created,priority:1"`
+	TweetID   uint      `gorm:"primaryKey;index"`
+	CreatedAt time.Time `gorm:"not null;index:idx_tweet_hashtags_tag_created,priority:2"`
+}
+
+// ParseEntities extrae las entidades del contenido de un tweet. Solo se incluyen las menciones
+// de usuarios resueltos al crearlo (mentions); el resto de los '@' quedan como texto.
+func ParseEntities(content string, mentions []TweetMention) Entities {
+	return Entities{Hashtags: ExtractHashtags(content), Mentions: resolveMentions(content, mentions)}
+}
+
+// ExtractHashtags devuelve los hashtags del contenido en orden de aparición. Un hashtag empieza con '#'
+// (o '＃') que no está pegado a una palabra, sigue con letras, números, marcas o '_' de cualquier
+// alfabeto y debe contener al menos una letra.
+func ExtractHashtags(content string) []HashtagEntity {
+	runes := []rune(content)
+	hashtags := make([]HashtagEntity, 0)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '#' && runes[i] != '＃' {
+			continue
+		}
+		// "a#b" o "&#39;" no son hashtags
+		if i > 0 && (isHashtagRune(runes[i-1]) || runes[i-1] == '&') {
+			continue
+		}
+
+		end := i + 1
+		for end < len(runes) && isHashtagRune(runes[end]) {
+			end++
+		}
+		tag := runes[i+1 : end]
+		if len(tag) > 0 && len(tag) <= MaxHashtagLength && containsLetter(tag) {
+			hashtags = append(hashtags, HashtagEntity{Tag: string(tag), Start: i, End: end})
+		}
+		i = end - 1
+	}
+	return hashtags
+}
+
+// NormalizeHashtag devuelve la forma en que se guarda un hashtag (sin '#' y en minúsculas),
+// o false si no es un hashtag válido
+func NormalizeHashtag(tag string) (string, bool) {
+	tag = strings.TrimLeft(tag, "#＃")
+	hashtags := ExtractHashtags("#" + tag)
+	if len(hashtags) != 1 || hashtags[0].Tag != tag {
+		return "", false
+	}
+	return strings.ToLower(tag), true
+}
+
+// UniqueHashtags devuelve los hashtags normalizados del contenido, sin repetir
+func UniqueHashtags(content string) []string {
+	seen := make(map[string]bool)
+	tags := make([]string, 0)
+	for _, hashtag := range ExtractHashtags(content) {
+		tag := strings.ToLower(hashtag.Tag)
+		if !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+func isHashtagRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r) || r == '_' ||
+		r == '\u200c' || r == '\u200d' // ZWNJ y ZWJ, necesarios en escrituras como el persa o el hindi
+}
+
+func containsLetter(runes []rune) bool {
+	for _, r := range runes {
+		if unicode.IsLetter(r) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractHashtags(t *testing.T) {
+	cases := []struct {
+		content  string
+		expected []HashtagEntity
+	}{
+		{"Hola #golang y #Go_1", []HashtagEntity{{Tag: "golang", Start: 5, End: 12}, {Tag: "Go_1", Start: 15, End: 20}}},
+		// Las posiciones son en caracteres, no en bytes
+		{"¡Olé! #España", []HashtagEntity{{Tag: "España", Start: 6, End: 13}}},
+		{"日本語 #東京 ＃大阪", []HashtagEntity{{Tag: "東京", Start: 4, End: 7}, {Tag: "大阪", Start: 8, End: 11}}},
+		{"#café. fin", []HashtagEntity{{Tag: "café", Start: 0, End: 5}}},
+		// Solo números, pegado a una palabra, entidades HTML o sin texto no son hashtags
+		{"#123 a#b &#39; # ##", []HashtagEntity{}},
+	}
+
+	for _, tc := range cases {
+		assert.Equal(t, tc.expected, ExtractHashtags(tc.content), tc.content)
+	}
+}
+
+func TestNormalizeHashtag(t *testing.T) {
+	tag, ok := NormalizeHashtag("#España")
+	assert.True(t, ok)
+	assert.Equal(t, "españa", tag)
+
+	tag, ok = NormalizeHashtag("GoLang")
+	assert.True(t, ok)
+	assert.Equal(t, "golang", tag)
+
+	for _, invalid := range []string{"", "#", "123", "dos palabras", "a-b"} {
+		_, ok := NormalizeHashtag(invalid)
+		assert.False(t, ok, invalid)
+	}
+}
+
+func TestUniqueHashtags(t *testing.T) {
+	assert.Equal(t, []string{"go", "rust"}, UniqueHashtags("#Go #rust #GO"))
+	assert.Empty(t, UniqueHashtags("sin hashtags"))
+}
@@ -0,0 +1,28 @@
+package domain
+
+import "time"
+
+// Like de un usuario a un tweet; la clave primaria compuesta hace que cada usuario
+// pueda dar un único like por tweet
+type Like struct {
+	UserID    uint      `gorm:"primaryKey;index:idx_likes_user_created,priority:1"`
+	TweetID   uint      `gorm:"primaryKey;index:idx_likes_tweet_created,priority:1"`
+	CreatedAt time.Time `gorm:"autoCreateTime;index:idx_likes_user_created,priority:2;index:idx_likes_tweet_created,priority:2"`
+}
+
+// Usuario que dio like a un tweet, con su username resuelto en user-service
+type Liker struct {
+	UserID   uint
+	Username string
+	LikedAt  time.Time
+}
+
+// Cursor para listar los likes de un tweet, ordenados por (created_at, user_id) descendente
+func (l Like) TweetCursor() Cursor {
+	return Cursor{CreatedAt: l.CreatedAt, ID: l.UserID}
+}
+
+// Cursor para listar los likes de un usuario, ordenados por (created_at, tweet_id) descendente
+func (l Like) UserCursor() Cursor {
+	return Cursor{CreatedAt: l.CreatedAt, ID: l.TweetID}
+}
@@ -0,0 +1,115 @@
+package domain
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Tipos de imagen aceptados, identificados por sus magic bytes
+const (
+	MediaTypeJPEG = "image/jpeg"
+	MediaTypePNG  = "image/png"
+	MediaTypeGIF  = "image/gif"
+	MediaTypeWebP = "image/webp"
+)
+
+const (
+	// MaxMediaPerTweet es la cantidad máxima de imágenes adjuntas a un tweet
+	MaxMediaPerTweet = 4
+	// MaxAltTextLength es el largo máximo, en caracteres, del texto alternativo de una imagen
+	MaxAltTextLength = 1000
+	// MaxMediaSize es el tamaño máximo en bytes de una imagen subida
+	MaxMediaSize = 5 << 20
+	// DefaultOrphanMediaTTL es el tiempo por defecto que se conserva una imagen subida que no se adjuntó a ningún tweet
+	DefaultOrphanMediaTTL = 24 * time.Hour
+)
+
+// ErrUnsupportedMedia se devuelve cuando el contenido no es una imagen JPEG, PNG, GIF o WebP
+var ErrUnsupportedMedia = errors.New("tipo de imagen no soportado")
+
+// Media es una imagen subida por un usuario. Se crea sin tweet (TweetID nil) y queda adjunta al
+// incluir su ID al crear un tweet; Position es su orden dentro del tweet y AltText su descripción.
+// StorageKey y ThumbnailKey son las claves de los blobs en el BlobStore: la imagen sin metadatos
+// y la miniatura JPEG.
+type Media struct {
+	ID           uint   `gorm:"primaryKey"`
+	UserID       uint   `gorm:"not null;index"`
+	TweetID      *uint  `gorm:"index"`
+	Position     int    `gorm:"not null;default:0"`
+	ContentType  string `gorm:"not null"`
+	Size         int64  `gorm:"not null"`
+	Width        int    `gorm:"not null"`
+	Height       int    `gorm:"not null"`
+	AltText      string `gorm:"size:1000;not null;default:''"`
+	StorageKey   string `gorm:"not null"`
+	ThumbnailKey string `gorm:"not null"`
+	CreatedAt    time.Time
+}
+
+// URL es la ruta de tweet-service que sirve la imagen
+func (m Media) URL() string {
+	return fmt.Sprintf("/media/%d", m.ID)
+}
+
+// ThumbnailURL es la ruta de tweet-service que sirve la miniatura
+func (m Media) ThumbnailURL() string {
+	return fmt.Sprintf("/media/%d/thumbnail", m.ID)
+}
+
+// MediaAttachment es una imagen a adjuntar a un tweet nuevo
+type MediaAttachment struct {
+	MediaID uint   `json:"media_id" binding:"required"`
+	AltText string `json:"alt_text"`
+}
+
+// DetectMediaType identifica el tipo de imagen por sus primeros bytes, sin confiar en el
+// Content-Type o la extensión que envía el cliente
+func DetectMediaType(data []byte) (string, error) {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xFF, 0xD8, 0xFF}):
+		return MediaTypeJPEG, nil
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		return MediaTypePNG, nil
+	case bytes.HasPrefix(data, []byte("GIF87a")), bytes.HasPrefix(data, []byte("GIF89a")):
+		return MediaTypeGIF, nil
+	case len(data) >= 12 && bytes.Equal(data[:4], []byte("RIFF")) && bytes.Equal(data[8:12], []byte("WEBP")):
+		return MediaTypeWebP, nil
+	}
+	return "", ErrUnsupportedMedia
+}
+
+// MediaExtension devuelve la extensión de archivo de un tipo de imagen aceptado
+func MediaExtension(contentType string) string {
+	switch contentType {
+	case MediaTypeJPEG:
+		return "jpg"
+	case MediaTypePNG:
+		return "png"
+	case MediaTypeGIF:
+		return "gif"
+	case MediaTypeWebP:
+		return "webp"
+	}
+	return "bin"
+}
+
+// ValidateAttachments verifica la cantidad de imágenes, los IDs repetidos y el largo de los textos alternativos
+func ValidateAttachments(attachments []MediaAttachment) error {
+	if len(attachments) > MaxMediaPerTweet {
+		return fmt.Errorf("se aceptan como máximo %d imágenes por tweet", MaxMediaPerTweet)
+	}
+
+	seen := make(map[uint]bool, len(attachments))
+	for _, attachment := range attachments {
+		if attachment.MediaID == 0 || seen[attachment.MediaID] {
+			return errors.New("media_id inválido o repetido")
+		}
+		seen[attachment.MediaID] = true
+		if len([]rune(attachment.AltText)) > MaxAltTextLength {
+			return fmt.Errorf("el texto alternativo debe tener como máximo %d caracteres", MaxAltTextLength)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectMediaType(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		expected string
+	}{
+		{"JPEG", []byte{0xFF, 0xD8, 0xFF, 0xE0, 0, 0}, MediaTypeJPEG},
+		{"PNG", []byte("\x89PNG\r\n\x1a\n\x00\x00"), MediaTypePNG},
+		{"GIF", []byte("GIF89a\x01\x00"), MediaTypeGIF},
+		{"WebP", []byte("RIFF\x10\x00\x00\x00WEBPVP8L"), MediaTypeWebP},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			contentType, err := DetectMediaType(tt.data)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, contentType)
+		})
+	}
+
+	// Un SVG o un RIFF que no es WebP se rechazan aunque el cliente diga que son imágenes
+	for _, data := range [][]byte{[]byte("<svg></svg>"), []byte("RIFF\x10\x00\x00\x00WAVEfmt "), nil} {
+		_, err := DetectMediaType(data)
+		assert.ErrorIs(t, err, ErrUnsupportedMedia)
+	}
+}
+
+func TestValidateAttachments(t *testing.T) {
+	assert.NoError(t, ValidateAttachments(nil))
+	assert.NoError(t, ValidateAttachments([]MediaAttachment{{MediaID: 1}, {MediaID: 2, AltText: "Un gato"}}))
+
+	tooMany := make([]MediaAttachment, MaxMediaPerTweet+1)
+	for i := range tooMany {
+		tooMany[i].MediaID = uint(i + 1)
+	}
+	assert.Error(t, ValidateAttachments(tooMany))
+
+	assert.Error(t, ValidateAttachments([]MediaAttachment{{MediaID: 1}, {MediaID: 1}}), "IDs repetidos")
+	assert.Error(t, ValidateAttachments([]MediaAttachment{{MediaID: 0}}))
+
+	// El límite del texto alternativo se cuenta en caracteres, no en bytes
+	assert.NoError(t, ValidateAttachments([]MediaAttachment{{MediaID: 1, AltText: strings.Repeat("ñ", MaxAltTextLength)}}))
+	assert.Error(t, ValidateAttachments([]MediaAttachment{{MediaID: 1, AltText: strings.Repeat("a", MaxAltTextLength+1)}}))
+}
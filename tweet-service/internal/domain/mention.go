@@ -0,0 +1,90 @@
+package domain
+
+import "time"
+
+// Cantidad máxima de usuarios distintos que se resuelven por tweet; las menciones siguientes quedan como texto
+const MaxMentionsPerTweet = 10
+
+// Usuario mencionado en el contenido de un tweet. Start y End son posiciones en caracteres
+// del '@' inicial y del final exclusivo, igual que en HashtagEntity.
+type MentionEntity struct {
+	UserID   uint   `json:"user_id"`
+	Username string `json:"username"`
+	Start    int    `json:"start"`
+	End      int    `json:"end"`
+}
+
+// Usuario mencionado por un tweet, resuelto contra user-service al crearlo. Guarda la fecha del tweet
+// para paginar las menciones de un usuario por (created_at, tweet_id) sin unir con la tabla de tweets.
+type TweetMention struct {
+	TweetID   uint      `gorm:"primaryKey"`
+	UserID    uint      `gorm:"primaryKey;index:idx_tweet_mentions_user_created,priority:1"`
+	Username  string    `gorm:"not null"`
+	CreatedAt time.Time `gorm:"not null;index:idx_tweet_mentions_user_created,priority:2"`
+}
+
+// ExtractMentions devuelve las menciones del contenido en orden de aparición, sin resolver (UserID 0).
+// Una mención empieza con '@' (o '＠') que no está pegado a una palabra, como en un email,
+// y sigue con letras, números, marcas o '_' de cualquier alfabeto.
+func ExtractMentions(content string) []MentionEntity {
+	runes := []rune(content)
+	mentions := make([]MentionEntity, 0)
+	for i := 0; i < len(runes); i++ {
+		if !isMentionSign(runes[i]) {
+			continue
+		}
+		// "user@example.com" o "@@user" no son menciones
+		if i > 0 && (isHashtagRune(runes[i-1]) || isMentionSign(runes[i-1])) {
+			continue
+		}
+
+		end := i + 1
+		for end < len(runes) && isHashtagRune(runes[end]) {
+			end++
+		}
+		if end > i+1 {
+			mentions = append(mentions, MentionEntity{Username: string(runes[i+1 : end]), Start: i, End: end})
+		}
+		i = end - 1
+	}
+	return mentions
+}
+
+// UniqueMentions devuelve los usernames mencionados en el contenido, sin repetir y como máximo
+// MaxMentionsPerTweet, en orden de aparición
+func UniqueMentions(content string) []string {
+	seen := make(map[string]bool)
+	usernames := make([]string, 0)
+	for _, mention := range ExtractMentions(content) {
+		if seen[mention.Username] {
+			continue
+		}
+		if len(usernames) == MaxMentionsPerTweet {
+			break
+		}
+		seen[mention.Username] = true
+		usernames = append(usernames, mention.Username)
+	}
+	return usernames
+}
+
+// resolveMentions conserva solo las menciones del contenido que corresponden a usuarios resueltos
+func resolveMentions(content string, resolved []TweetMention) []MentionEntity {
+	userIDs := make(map[string]uint, len(resolved))
+	for _, mention := range resolved {
+		userIDs[mention.Username] = mention.UserID
+	}
+
+	mentions := make([]MentionEntity, 0)
+	for _, mention := range ExtractMentions(content) {
+		if userID, ok := userIDs[mention.Username]; ok {
+			mention.UserID = userID
+			mentions = append(mentions, mention)
+		}
+	}
+	return mentions
+}
+
+func isMentionSign(r rune) bool {
+	return r == '@' || r == '＠'
+}
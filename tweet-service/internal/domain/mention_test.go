@@ -0,0 +1,49 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractMentions(t *testing.T) {
+	cases := []struct {
+		content  string
+		expected []MentionEntity
+	}{
+		{"Hola @user2 y @user_3!", []MentionEntity{{Username: "user2", Start: 5, End: 11}, {Username: "user_3", Start: 14, End: 21}}},
+		// Las posiciones son en caracteres, no en bytes
+		{"¿Qué tal, @José? ＠ana", []MentionEntity{{Username: "José", Start: 10, End: 15}, {Username: "ana", Start: 17, End: 21}}},
+		// Emails, '@' repetidos o sin texto no son menciones
+		{"user@example.com @@user @ fin@", []MentionEntity{}},
+	}
+
+	for _, tc := range cases {
+		assert.Equal(t, tc.expected, ExtractMentions(tc.content), tc.content)
+	}
+}
+
+func TestUniqueMentionsCapped(t *testing.T) {
+	assert.Equal(t, []string{"a", "b"}, UniqueMentions("@a @b @a"))
+
+	handles := make([]string, 0, MaxMentionsPerTweet+2)
+	for i := 0; i < MaxMentionsPerTweet+2; i++ {
+		handles = append(handles, fmt.Sprintf("@user%d", i))
+	}
+	usernames := UniqueMentions(strings.Join(handles, " "))
+	assert.Len(t, usernames, MaxMentionsPerTweet)
+	assert.Equal(t, "user0", usernames[0])
+}
+
+func TestParseEntitiesOnlyResolvedMentions(t *testing.T) {
+	resolved := []TweetMention{{UserID: 7, Username: "user2"}}
+
+	entities := ParseEntities("@user2 @desconocido #go @user2", resolved)
+	assert.Equal(t, []MentionEntity{
+		{UserID: 7, Username: "user2", Start: 0, End: 6},
+		{UserID: 7, Username: "user2", Start: 24, End: 30},
+	}, entities.Mentions)
+	assert.Equal(t, []HashtagEntity{{Tag: "go", Start: 20, End: 23}}, entities.Hashtags)
+}
@@ -0,0 +1,24 @@
+package domain
+
+// Días de actividad que se consideran para la afinidad con los autores
+const AffinityDays = 30
+
+// Máximo de tweets por consulta de interacciones
+const MaxEngagementTweets = 100
+
+// AuthorAffinity resume cuánto interactuó un usuario con un autor: likes a sus tweets y respuestas
+// dirigidas a él en los últimos AffinityDays días. timeline-service la usa para ordenar el timeline.
+type AuthorAffinity struct {
+	UserID   uint
+	Username string
+	Likes    int
+	Replies  int
+}
+
+// TweetEngagement son los contadores actuales de un tweet
+type TweetEngagement struct {
+	TweetID      uint
+	ReplyCount   int
+	LikeCount    int
+	RetweetCount int
+}
@@ -0,0 +1,40 @@
+package domain
+
+import "time"
+
+// DefaultEditWindow es el plazo por defecto, desde la creación del tweet, en el que su autor puede editarlo
+const DefaultEditWindow = 30 * time.Minute
+
+// TweetRevision es una versión del contenido de un tweet editado. Revision empieza en 1 (el contenido
+// original) y CreatedAt es el momento en que se publicó esa versión. Los tweets que nunca se editaron
+// no tienen filas: su única versión es el propio tweet (ver Revisions).
+type TweetRevision struct {
+	ID        uint      `gorm:"primaryKey"`
+	TweetID   uint      `gorm:"not null;uniqueIndex:idx_tweet_revisions_tweet_revision,priority:1"`
+	Revision  int       `gorm:"not null;uniqueIndex:idx_tweet_revisions_tweet_revision,priority:2"`
+	Content   string    `gorm:"size:280"`
+	CreatedAt time.Time `gorm:"not null"`
+}
+
+// Edited indica si el contenido del tweet se editó alguna vez
+func (t Tweet) Edited() bool {
+	return t.EditCount > 0
+}
+
+// RevisionCount es la cantidad de versiones del contenido, incluida la original
+func (t Tweet) RevisionCount() int {
+	return t.EditCount + 1
+}
+
+// EditableUntil devuelve el momento hasta el que el autor puede editar el tweet
+func (t Tweet) EditableUntil(window time.Duration) time.Time {
+	return t.CreatedAt.Add(window)
+}
+
+// Revisions completa el historial guardado con la versión original de un tweet que nunca se editó
+func (t Tweet) Revisions(stored []TweetRevision) []TweetRevision {
+	if len(stored) > 0 {
+		return stored
+	}
+	return []TweetRevision{{TweetID: t.ID, Revision: 1, Content: t.Content, CreatedAt: t.CreatedAt}}
+}
@@ -0,0 +1,26 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTweetRevisions(t *testing.T) {
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tweet := Tweet{ID: 7, Content: "Hola", CreatedAt: created}
+
+	assert.False(t, tweet.Edited())
+	assert.Equal(t, 1, tweet.RevisionCount())
+	assert.Equal(t, created.Add(DefaultEditWindow), tweet.EditableUntil(DefaultEditWindow))
+
+	// Sin ediciones la única versión es el propio tweet
+	assert.Equal(t, []TweetRevision{{TweetID: 7, Revision: 1, Content: "Hola", CreatedAt: created}}, tweet.Revisions(nil))
+
+	tweet.EditCount = 2
+	stored := []TweetRevision{{Revision: 1}, {Revision: 2}, {Revision: 3}}
+	assert.True(t, tweet.Edited())
+	assert.Equal(t, 3, tweet.RevisionCount())
+	assert.Equal(t, stored, tweet.Revisions(stored))
+}
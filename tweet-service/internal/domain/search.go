@@ -0,0 +1,188 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// MaxSearchQueryLength es el largo máximo, en caracteres, de una búsqueda
+const MaxSearchQueryLength = 500
+
+// Orden de los resultados de una búsqueda
+const (
+	SearchSortRelevance = "relevance"
+	SearchSortRecent    = "recent"
+)
+
+// Formato de las fechas de since: y until:
+const searchDateLayout = "2006-01-02"
+
+var (
+	// ErrEmptySearchQuery se devuelve cuando la búsqueda no tiene ningún criterio
+	ErrEmptySearchQuery = errors.New("la búsqueda está vacía")
+	// ErrSearchQueryTooLong se devuelve cuando la búsqueda supera MaxSearchQueryLength
+	ErrSearchQueryTooLong = fmt.Errorf("la búsqueda no puede superar los %d caracteres", MaxSearchQueryLength)
+)
+
+// SearchQuery es una búsqueda de tweets ya interpretada. Todos los criterios se combinan con AND,
+// salvo From, donde alcanza con que el autor sea uno de los indicados. Since es inclusivo y Until
+// exclusivo, ambos en UTC.
+type SearchQuery struct {
+	Terms            []string
+	Phrases          []string
+	ExcludedTerms    []string
+	ExcludedPhrases  []string
+	From             []string
+	ExcludedFrom     []string
+	Hashtags         []string
+	ExcludedHashtags []string
+	Since            *time.Time
+	Until            *time.Time
+}
+
+// SearchCursor es la posición de la última búsqueda devuelta. Rank solo se usa al ordenar por relevancia.
+type SearchCursor struct {
+	Rank      float32
+	CreatedAt time.Time
+	ID        uint
+}
+
+// ParseSearchQuery interpreta el lenguaje de búsqueda:
+//
+//	palabra          el tweet contiene la palabra
+//	"una frase"      el tweet contiene las palabras en ese orden
+//	from:usuario     el autor es usuario (también con @usuario)
+//	#hashtag         el tweet usa el hashtag
+//	since:2024-01-31 publicado desde ese día inclusive
+//	until:2024-02-01 publicado antes de ese día
+//
+// Un '-' delante de una palabra, frase, from: o #hashtag lo excluye. Cualquier otro texto con ':'
+// se busca como palabra.
+func ParseSearchQuery(raw string) (SearchQuery, error) {
+	var query SearchQuery
+	if len([]rune(raw)) > MaxSearchQueryLength {
+		return query, ErrSearchQueryTooLong
+	}
+
+	for _, token := range tokenizeSearch(raw) {
+		value := token.value
+		key, operand, isOperator := strings.Cut(value, ":")
+		switch {
+		case token.phrase:
+			if words := strings.Fields(value); len(words) > 1 {
+				appendTo(&query.Phrases, &query.ExcludedPhrases, token.excluded, strings.Join(words, " "))
+			} else if len(words) == 1 {
+				appendTo(&query.Terms, &query.ExcludedTerms, token.excluded, words[0])
+			}
+		case strings.HasPrefix(value, "#") || strings.HasPrefix(value, "＃"):
+			if tag, ok := NormalizeHashtag(value); ok {
+				appendTo(&query.Hashtags, &query.ExcludedHashtags, token.excluded, tag)
+			}
+		case isOperator && strings.EqualFold(key, "from"):
+			if username := strings.TrimPrefix(operand, "@"); username != "" {
+				appendTo(&query.From, &query.ExcludedFrom, token.excluded, username)
+			}
+		case isOperator && (strings.EqualFold(key, "since") || strings.EqualFold(key, "until")):
+			day, err := time.Parse(searchDateLayout, operand)
+			if err != nil {
+				return query, fmt.Errorf("fecha inválida en %s: se espera AAAA-MM-DD", strings.ToLower(key))
+			}
+			if strings.EqualFold(key, "since") {
+				query.Since = &day
+			} else {
+				query.Until = &day
+			}
+		default:
+			if term := strings.Trim(value, `"`); term != "" {
+				appendTo(&query.Terms, &query.ExcludedTerms, token.excluded, term)
+			}
+		}
+	}
+
+	if query.empty() {
+		return query, ErrEmptySearchQuery
+	}
+	return query, nil
+}
+
+// TextQuery arma la parte de texto libre con la sintaxis de websearch_to_tsquery de PostgreSQL, o ""
+// si la búsqueda no tiene palabras ni frases
+func (q SearchQuery) TextQuery() string {
+	parts := make([]string, 0, len(q.Terms)+len(q.Phrases)+len(q.ExcludedTerms)+len(q.ExcludedPhrases))
+	parts = append(parts, q.Terms...)
+	for _, phrase := range q.Phrases {
+		parts = append(parts, `"`+phrase+`"`)
+	}
+	for _, term := range q.ExcludedTerms {
+		parts = append(parts, "-"+term)
+	}
+	for _, phrase := range q.ExcludedPhrases {
+		parts = append(parts, `-"`+phrase+`"`)
+	}
+	return strings.Join(parts, " ")
+}
+
+// HasPositiveText indica si la búsqueda tiene palabras o frases que el tweet debe contener; sin ellas
+// no hay relevancia que calcular
+func (q SearchQuery) HasPositiveText() bool {
+	return len(q.Terms) > 0 || len(q.Phrases) > 0
+}
+
+func (q SearchQuery) empty() bool {
+	return q.TextQuery() == "" && len(q.From) == 0 && len(q.ExcludedFrom) == 0 &&
+		len(q.Hashtags) == 0 && len(q.ExcludedHashtags) == 0 && q.Since == nil && q.Until == nil
+}
+
+func appendTo(included, excluded *[]string, isExcluded bool, value string) {
+	if isExcluded {
+		*excluded = append(*excluded, value)
+	} else {
+		*included = append(*included, value)
+	}
+}
+
+type searchToken struct {
+	value    string
+	phrase   bool
+	excluded bool
+}
+
+// tokenizeSearch separa la búsqueda por espacios respetando las frases entre comillas. Una comilla
+// sin cerrar toma el resto de la búsqueda como frase.
+func tokenizeSearch(raw string) []searchToken {
+	runes := []rune(raw)
+	tokens := make([]searchToken, 0)
+	for i := 0; i < len(runes); {
+		if unicode.IsSpace(runes[i]) {
+			i++
+			continue
+		}
+
+		token := searchToken{}
+		if runes[i] == '-' && i+1 < len(runes) && !unicode.IsSpace(runes[i+1]) {
+			token.excluded = true
+			i++
+		}
+
+		if runes[i] == '"' {
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+			token.value, token.phrase = string(runes[i+1:end]), true
+			i = end + 1
+		} else {
+			end := i
+			for end < len(runes) && !unicode.IsSpace(runes[end]) {
+				end++
+			}
+			token.value = string(runes[i:end])
+			i = end
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens
+}
@@ -0,0 +1,72 @@
+package domain
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSearchQuery(t *testing.T) {
+	query, err := ParseSearchQuery(`golang "hola mundo" -java -"sin frase" from:@ana -from:beto #Go -#spam since:2024-01-31 until:2024-02-01 http://x.com`)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []string{"golang", "http://x.com"}, query.Terms)
+	assert.Equal(t, []string{"hola mundo"}, query.Phrases)
+	assert.Equal(t, []string{"java"}, query.ExcludedTerms)
+	assert.Equal(t, []string{"sin frase"}, query.ExcludedPhrases)
+	assert.Equal(t, []string{"ana"}, query.From)
+	assert.Equal(t, []string{"beto"}, query.ExcludedFrom)
+	assert.Equal(t, []string{"go"}, query.Hashtags)
+	assert.Equal(t, []string{"spam"}, query.ExcludedHashtags)
+	assert.Equal(t, time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC), *query.Since)
+	assert.Equal(t, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), *query.Until)
+
+	assert.Equal(t, `golang http://x.com "hola mundo" -java -"sin frase"`, query.TextQuery())
+	assert.True(t, query.HasPositiveText())
+}
+
+func TestParseSearchQueryEdgeCases(t *testing.T) {
+	t.Run("Comilla sin cerrar", func(t *testing.T) {
+		query, err := ParseSearchQuery(`"frase  sin cerrar`)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"frase sin cerrar"}, query.Phrases)
+	})
+
+	t.Run("Frase de una palabra", func(t *testing.T) {
+		query, err := ParseSearchQuery(`"hola" - x`)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"hola", "-", "x"}, query.Terms)
+	})
+
+	t.Run("Solo filtros", func(t *testing.T) {
+		query, err := ParseSearchQuery("from:ana")
+		assert.NoError(t, err)
+		assert.Empty(t, query.TextQuery())
+		assert.False(t, query.HasPositiveText())
+
+		query, err = ParseSearchQuery("-golang")
+		assert.NoError(t, err)
+		assert.Equal(t, "-golang", query.TextQuery())
+		assert.False(t, query.HasPositiveText())
+	})
+
+	t.Run("Vacía", func(t *testing.T) {
+		for _, raw := range []string{"", "   ", `""`, "from:", "#123"} {
+			_, err := ParseSearchQuery(raw)
+			assert.ErrorIs(t, err, ErrEmptySearchQuery, raw)
+		}
+	})
+
+	t.Run("Fecha inválida", func(t *testing.T) {
+		_, err := ParseSearchQuery("since:ayer")
+		assert.Error(t, err)
+	})
+
+	t.Run("Demasiado larga", func(t *testing.T) {
+		_, err := ParseSearchQuery(strings.Repeat("a", MaxSearchQueryLength+1))
+		assert.ErrorIs(t, err, ErrSearchQueryTooLong)
+	})
+}
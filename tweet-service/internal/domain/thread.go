@@ -0,0 +1,22 @@
+package domain
+
+import "time"
+
+// Posición de una respuesta dentro de un hilo, ordenado por profundidad y luego por (created_at, id) ascendente.
+// Se usa como cursor para paginar las respuestas de GET /tweets/:id/thread.
+type ThreadCursor struct {
+	Depth     int
+	CreatedAt time.Time
+	ID        uint
+}
+
+func (t Tweet) ThreadCursor() ThreadCursor {
+	return ThreadCursor{Depth: t.Depth, CreatedAt: t.CreatedAt, ID: t.ID}
+}
+
+// Hilo de conversación de un tweet: sus ancestros (desde la raíz) y una página de sus respuestas
+type Thread struct {
+	Tweet     TweetWithUser
+	Ancestors []TweetWithUser
+	Replies   []TweetWithUser
+}
@@ -0,0 +1,32 @@
+package domain
+
+import "time"
+
+// TombstoneText es el contenido que se muestra en lugar de un tweet eliminado
+const TombstoneText = "Este tweet fue eliminado"
+
+const (
+	// DefaultRestoreWindow es el plazo por defecto, desde la eliminación, para restaurar un tweet
+	DefaultRestoreWindow = 5 * time.Minute
+	// DefaultPurgeRetention es el tiempo por defecto que se conserva un tweet eliminado antes de borrarlo definitivamente
+	DefaultPurgeRetention = 30 * 24 * time.Hour
+)
+
+// IsDeleted indica si el tweet se eliminó y se muestra como tombstone
+func (t Tweet) IsDeleted() bool {
+	return t.DeletedAt.Valid
+}
+
+// Tombstone devuelve el tweet eliminado sin autor, contenido, menciones ni imágenes. Conserva su lugar
+// en la conversación para que el hilo no se corte.
+func (t TweetWithUser) Tombstone() TweetWithUser {
+	return TweetWithUser{Tweet: Tweet{
+		ID:               t.ID,
+		InReplyToTweetID: t.InReplyToTweetID,
+		ConversationID:   t.ConversationID,
+		Depth:            t.Depth,
+		ReplyCount:       t.ReplyCount,
+		CreatedAt:        t.CreatedAt,
+		DeletedAt:        t.DeletedAt,
+	}}
+}
@@ -0,0 +1,49 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestTombstone(t *testing.T) {
+	parentID := uint(1)
+	tweet := TweetWithUser{
+		Tweet: Tweet{
+			ID:               2,
+			UserID:           7,
+			Content:          "Hola @user1",
+			InReplyToTweetID: &parentID,
+			InReplyToUserID:  &parentID,
+			ConversationID:   1,
+			Depth:            1,
+			ReplyCount:       3,
+			LikeCount:        5,
+			CreatedAt:        time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		},
+		Username: "user7",
+		Mentions: []TweetMention{{UserID: 1, Username: "user1"}},
+		Media:    []Media{{ID: 9}},
+	}
+	assert.False(t, tweet.IsDeleted())
+
+	tweet.DeletedAt = gorm.DeletedAt{Time: time.Date(2024, 5, 2, 12, 0, 0, 0, time.UTC), Valid: true}
+	tombstone := tweet.Tombstone()
+	assert.True(t, tombstone.IsDeleted())
+
+	// Se conserva la posición en el hilo pero nada que identifique al autor o el contenido
+	assert.Equal(t, uint(2), tombstone.ID)
+	assert.Equal(t, &parentID, tombstone.InReplyToTweetID)
+	assert.Equal(t, uint(1), tombstone.RootID())
+	assert.Equal(t, 1, tombstone.Depth)
+	assert.Equal(t, 3, tombstone.ReplyCount)
+	assert.Zero(t, tombstone.UserID)
+	assert.Nil(t, tombstone.InReplyToUserID)
+	assert.Empty(t, tombstone.Username)
+	assert.Empty(t, tombstone.Content)
+	assert.Empty(t, tombstone.Mentions)
+	assert.Empty(t, tombstone.Media)
+	assert.Zero(t, tombstone.LikeCount)
+}
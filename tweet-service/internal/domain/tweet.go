@@ -1,23 +1,89 @@
 package domain
 
-import "time"
+import (
+	"time"
 
+	"gorm.io/gorm"
+)
+
+// InReplyToTweetID apunta al tweet respondido (nil si no es una respuesta), ConversationID al tweet
+// raíz de la conversación (0 en tweets anteriores a los hilos, ver RootID) y Depth es la profundidad
+// dentro de ella; InReplyToUserID es el autor del tweet respondido. Un retweet es una fila sin contenido propio con RetweetOfTweetID apuntando al original
+// (un usuario solo puede retuitear una vez cada tweet); una cita tiene contenido y QuotedTweetID.
+// ReplyCount, LikeCount y RetweetCount son contadores desnormalizados que se actualizan en la misma
+// transacción que crea o elimina la respuesta, el like o el retweet. MediaCount es la cantidad de
+// imágenes adjuntas, para no buscar imágenes de los tweets que no tienen. EditCount y EditedAt
+// registran las ediciones del contenido (ver TweetRevision). DeletedAt marca un tweet eliminado: GORM
+// lo excluye de las consultas y se sigue mostrando como tombstone en hilos y citas (ver Tombstone);
+// DeletedByID es quien lo eliminó, el autor o un administrador.
 type Tweet struct {
-	ID        uint      `gorm:"primaryKey"`
-	UserID    uint      `gorm:"not null"`
-	Content   string    `gorm:"size:280"`
-	CreatedAt time.Time `gorm:"autoCreateTime"`
-	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+	ID               uint   `gorm:"primaryKey;index:idx_tweets_created_id,priority:2;index:idx_tweets_user_created_id,priority:3"`
+	UserID           uint   `gorm:"not null;index:idx_tweets_user_created_id,priority:1;uniqueIndex:idx_tweets_user_retweet,priority:1"`
+	Content          string `gorm:"size:280"`
+	InReplyToTweetID *uint  `gorm:"index"`
+	InReplyToUserID  *uint  `gorm:"index"`
+	ConversationID   uint   `gorm:"not null;default:0;index"`
+	Depth            int    `gorm:"not null;default:0"`
+	RetweetOfTweetID *uint  `gorm:"index;uniqueIndex:idx_tweets_user_retweet,priority:2"`
+	QuotedTweetID    *uint  `gorm:"index"`
+	ReplyCount       int    `gorm:"not null;default:0"`
+	LikeCount        int    `gorm:"not null;default:0"`
+	RetweetCount     int    `gorm:"not null;default:0"`
+	MediaCount       int    `gorm:"not null;default:0"`
+	EditCount        int    `gorm:"not null;default:0"`
+	EditedAt         *time.Time
+	CreatedAt        time.Time      `gorm:"autoCreateTime;index:idx_tweets_created_id,priority:1;index:idx_tweets_user_created_id,priority:2"`
+	UpdatedAt        time.Time      `gorm:"autoUpdateTime"`
+	DeletedAt        gorm.DeletedAt `gorm:"index"`
+	DeletedByID      uint           `gorm:"not null;default:0"`
+}
+
+// RootID devuelve el ID del tweet raíz de la conversación
+func (t Tweet) RootID() uint {
+	if t.ConversationID == 0 {
+		return t.ID
+	}
+	return t.ConversationID
 }
 
 type User struct {
-	ID       uint   `gorm:"primaryKey"`
-	Username string `gorm:"uniqueIndex;not null"`
-	Email    string `gorm:"uniqueIndex;not null"`
+	ID        uint   `gorm:"primaryKey"`
+	Username  string `gorm:"uniqueIndex;not null"`
+	Email     string `gorm:"uniqueIndex;not null"`
+	IsPrivate bool   `gorm:"-"`
+}
+
+// Username mostrado cuando el autor de un tweet ya no existe en user-service
+const UnknownUsername = "[usuario desconocido]"
+
+// IsRetweet indica si el tweet es un retweet sin contenido propio
+func (t Tweet) IsRetweet() bool {
+	return t.RetweetOfTweetID != nil
+}
+
+// OriginalID devuelve el ID del contenido que muestra el tweet: el original en un retweet, o el propio ID
+func (t Tweet) OriginalID() uint {
+	if t.RetweetOfTweetID != nil {
+		return *t.RetweetOfTweetID
+	}
+	return t.ID
 }
 
-// Estructura para enriquecer un tweet con datos de usuario
+// Estructura para enriquecer un tweet con datos de usuario.
+// Retweeted y Quoted embeben el tweet original (nil si no aplica o si el original se eliminó).
+// Mentions son los usuarios mencionados que se resolvieron al crear el tweet y Media sus imágenes en orden.
+// AuthorPrivate indica que el autor tiene la cuenta privada (ver VisibleTo).
 type TweetWithUser struct {
 	Tweet
-	Username string
+	Username      string
+	AuthorPrivate bool
+	Retweeted     *TweetWithUser
+	Quoted        *TweetWithUser
+	Mentions      []TweetMention
+	Media         []Media
+}
+
+// Entities devuelve los hashtags y las menciones resueltas del contenido del tweet
+func (t TweetWithUser) Entities() Entities {
+	return ParseEntities(t.Content, t.Mentions)
 }
@@ -0,0 +1,78 @@
+package domain
+
+// Viewer es quien consulta los tweets: UserID es 0 para lectores anónimos y All indica que puede
+// verlo todo (administradores y otros servicios).
+type Viewer struct {
+	UserID   uint
+	Username string
+	All      bool
+}
+
+// VisibleTo indica si el viewer puede ver el tweet. Los tweets de cuentas privadas solo los ven su
+// autor y los usuarios que lo siguen (followed, indexado por ID de autor).
+func (t TweetWithUser) VisibleTo(viewer Viewer, followed map[uint]bool) bool {
+	return viewer.All || !t.AuthorPrivate || t.UserID == viewer.UserID || followed[t.UserID]
+}
+
+// PrivateAuthorIDs devuelve, sin repetir, los autores de cuentas privadas de los tweets y de los tweets
+// que embeben cuya visibilidad depende de si el viewer los sigue
+func PrivateAuthorIDs(tweets []TweetWithUser, viewer Viewer) []uint {
+	ids := make([]uint, 0)
+	if viewer.All {
+		return ids
+	}
+
+	seen := make(map[uint]bool)
+	add := func(tweet *TweetWithUser) {
+		if tweet == nil || !tweet.AuthorPrivate || tweet.UserID == viewer.UserID || seen[tweet.UserID] {
+			return
+		}
+		seen[tweet.UserID] = true
+		ids = append(ids, tweet.UserID)
+	}
+	for i := range tweets {
+		add(&tweets[i])
+		add(tweets[i].Retweeted)
+		add(tweets[i].Quoted)
+	}
+	return ids
+}
+
+// FilterVisible quita los tweets que el viewer no puede ver y los retweets de originales que no puede ver.
+// Una cita de un tweet oculto se mantiene, pero sin embeber el tweet citado.
+func FilterVisible(tweets []TweetWithUser, viewer Viewer, followed map[uint]bool) []TweetWithUser {
+	visible := make([]TweetWithUser, 0, len(tweets))
+	for _, tweet := range tweets {
+		if !tweet.VisibleTo(viewer, followed) {
+			continue
+		}
+		if tweet.Retweeted != nil && !tweet.Retweeted.VisibleTo(viewer, followed) {
+			continue
+		}
+		if tweet.Quoted != nil && !tweet.Quoted.VisibleTo(viewer, followed) {
+			tweet.Quoted = nil
+		}
+		visible = append(visible, tweet)
+	}
+	return visible
+}
+
+// HideAuthors quita los tweets de los autores ocultos (bloqueados o silenciados, indexados por ID) y los
+// retweets de sus tweets. Una cita de un autor oculto se mantiene, pero sin embeber el tweet citado.
+func HideAuthors(tweets []TweetWithUser, hidden map[uint]bool) []TweetWithUser {
+	if len(hidden) == 0 {
+		return tweets
+	}
+
+	kept := make([]TweetWithUser, 0, len(tweets))
+	for _, tweet := range tweets {
+		if hidden[tweet.UserID] || (tweet.Retweeted != nil && hidden[tweet.Retweeted.UserID]) {
+			continue
+		}
+		if tweet.Quoted != nil && hidden[tweet.Quoted.UserID] {
+			tweet.Quoted = nil
+		}
+		kept = append(kept, tweet)
+	}
+	return kept
+}
@@ -0,0 +1,75 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilterVisible(t *testing.T) {
+	private := &TweetWithUser{Tweet: Tweet{ID: 1, UserID: 10}, AuthorPrivate: true}
+	public := &TweetWithUser{Tweet: Tweet{ID: 2, UserID: 20}}
+
+	tweets := []TweetWithUser{
+		*private,
+		*public,
+		{Tweet: Tweet{ID: 3, UserID: 20, RetweetOfTweetID: &private.ID}, Retweeted: private},
+		{Tweet: Tweet{ID: 4, UserID: 20, QuotedTweetID: &private.ID}, Quoted: private},
+		{Tweet: Tweet{ID: 5, UserID: 30}, AuthorPrivate: true},
+	}
+
+	ids := func(tweets []TweetWithUser) []uint {
+		result := make([]uint, 0)
+		for _, tweet := range tweets {
+			result = append(result, tweet.ID)
+		}
+		return result
+	}
+
+	t.Run("Lector anónimo", func(t *testing.T) {
+		viewer := Viewer{}
+		assert.Equal(t, []uint{10, 30}, PrivateAuthorIDs(tweets, viewer))
+
+		visible := FilterVisible(tweets, viewer, nil)
+		assert.Equal(t, []uint{2, 4}, ids(visible))
+		// La cita se mantiene sin el tweet citado
+		assert.Nil(t, visible[1].Quoted)
+		assert.NotNil(t, tweets[3].Quoted)
+	})
+
+	t.Run("Seguidor de la cuenta privada", func(t *testing.T) {
+		viewer := Viewer{UserID: 40}
+		visible := FilterVisible(tweets, viewer, map[uint]bool{10: true})
+		assert.Equal(t, []uint{1, 2, 3, 4}, ids(visible))
+		assert.NotNil(t, visible[3].Quoted)
+	})
+
+	t.Run("Autor y administradores", func(t *testing.T) {
+		assert.Equal(t, []uint{10}, PrivateAuthorIDs(tweets, Viewer{UserID: 30}))
+		assert.Equal(t, []uint{2, 4, 5}, ids(FilterVisible(tweets, Viewer{UserID: 30}, nil)))
+
+		assert.Empty(t, PrivateAuthorIDs(tweets, Viewer{All: true}))
+		assert.Len(t, FilterVisible(tweets, Viewer{All: true}, nil), len(tweets))
+	})
+}
+
+func TestHideAuthors(t *testing.T) {
+	blocked := &TweetWithUser{Tweet: Tweet{ID: 1, UserID: 10}}
+
+	tweets := []TweetWithUser{
+		*blocked,
+		{Tweet: Tweet{ID: 2, UserID: 20}},
+		{Tweet: Tweet{ID: 3, UserID: 20, RetweetOfTweetID: &blocked.ID}, Retweeted: blocked},
+		{Tweet: Tweet{ID: 4, UserID: 20, QuotedTweetID: &blocked.ID}, Quoted: blocked},
+	}
+
+	kept := HideAuthors(tweets, map[uint]bool{10: true})
+	assert.Len(t, kept, 2)
+	assert.Equal(t, uint(2), kept[0].ID)
+	assert.Equal(t, uint(4), kept[1].ID)
+	assert.Nil(t, kept[1].Quoted)
+	assert.NotNil(t, tweets[3].Quoted)
+
+	// Sin usuarios ocultos no se quita nada
+	assert.Len(t, HideAuthors(tweets, nil), len(tweets))
+}
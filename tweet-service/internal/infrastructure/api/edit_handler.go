@@ -0,0 +1,124 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/DevOpslp/microblogging-platform/auth"
+	"github.com/DevOpslp/microblogging-platform/tweet-service/internal/domain"
+	"github.com/DevOpslp/microblogging-platform/tweet-service/internal/infrastructure/persistence"
+	"github.com/gin-gonic/gin"
+)
+
+// Versión del contenido de un tweet editado; Revision 1 es el contenido original
+type RevisionResponse struct {
+	Revision  int    `json:"revision"`
+	Content   string `json:"content"`
+	CreatedAt string `json:"created_at"`
+}
+
+type HistoryResponse struct {
+	TweetID   uint               `json:"tweet_id"`
+	Edited    bool               `json:"edited"`
+	Revisions []RevisionResponse `json:"revisions"`
+}
+
+// EditTweet reemplaza el contenido de un tweet propio dentro del plazo de edición
+func (h *TweetHandler) EditTweet(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de tweet inválido"})
+		return
+	}
+
+	var body struct {
+		Content *string `json:"content" binding:"required,max=280"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Contenido del tweet inválido"})
+		return
+	}
+
+	tweet, err := h.repo.GetTweetByID(uint(id))
+	if err != nil {
+		respondEditError(c, err)
+		return
+	}
+	// Solo el autor puede editar el tweet, ni siquiera un administrador
+	if tweet.UserID != auth.UserID(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "No tienes permiso para editar este tweet"})
+		return
+	}
+
+	edited, err := h.repo.EditTweet(tweet.ID, tweet.UserID, *body.Content)
+	if err != nil {
+		respondEditError(c, err)
+		return
+	}
+
+	updated := domain.TweetWithUser{Tweet: *edited, Username: auth.Username(c)}
+	if hydrated, err := h.repo.Hydrate(*edited); err != nil {
+		log.Printf("No se pudieron embeber los tweets referenciados por %d: %v", edited.ID, err)
+	} else {
+		updated = *hydrated
+	}
+
+	c.JSON(http.StatusOK, formatTweetResponse(updated))
+}
+
+func respondEditError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, persistence.ErrTweetNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tweet no encontrado"})
+	case errors.Is(err, persistence.ErrEditWindowClosed):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Terminó el plazo para editar este tweet"})
+	case errors.Is(err, persistence.ErrRetweetNotEditable):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Los retweets no se pueden editar"})
+	case errors.Is(err, persistence.ErrEmptyContent):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Contenido del tweet inválido"})
+	case errors.Is(err, persistence.ErrMentionBlocked):
+		c.JSON(http.StatusForbidden, gin.H{"error": "No puedes mencionar a un usuario que te bloqueó"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo editar el tweet"})
+	}
+}
+
+// GetTweetHistory devuelve las versiones del contenido de un tweet; en un retweet, las del original
+func (h *TweetHandler) GetTweetHistory(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de tweet inválido"})
+		return
+	}
+
+	tweet := h.findVisibleTweet(c, uint(id), "Tweet no encontrado")
+	if tweet == nil {
+		return
+	}
+	if tweet.Retweeted != nil {
+		tweet = tweet.Retweeted
+	}
+
+	revisions, err := h.repo.GetTweetRevisions(tweet.Tweet)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo obtener el historial del tweet"})
+		return
+	}
+
+	response := HistoryResponse{
+		TweetID:   tweet.ID,
+		Edited:    tweet.Edited(),
+		Revisions: make([]RevisionResponse, 0, len(revisions)),
+	}
+	for _, revision := range revisions {
+		response.Revisions = append(response.Revisions, RevisionResponse{
+			Revision:  revision.Revision,
+			Content:   revision.Content,
+			CreatedAt: revision.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	c.JSON(http.StatusOK, response)
+}
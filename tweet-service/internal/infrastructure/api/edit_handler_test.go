@@ -0,0 +1,114 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DevOpslp/microblogging-platform/tweet-service/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEditTweet(t *testing.T) {
+	setupTestDB()
+
+	user, err := getRandomUser()
+	assert.NoError(t, err, "Debe haber al menos un usuario en user-service para realizar la prueba")
+
+	router := setupTestRouter()
+
+	editTweet := func(tweetID uint, user *domain.User, content string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("PATCH", fmt.Sprintf("/tweets/%d", tweetID), bytes.NewBufferString(fmt.Sprintf(`{"content":%q}`, content)))
+		req.Header.Set("Authorization", bearerFor(user))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/tweets", bytes.NewBufferString(`{"content":"Versión original #antes"}`))
+	req.Header.Set("Authorization", bearerFor(user))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var created TweetResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.False(t, created.Edited)
+	assert.Equal(t, 1, created.RevisionCount)
+
+	t.Run("Solo el Autor Puede Editar", func(t *testing.T) {
+		stranger := &domain.User{ID: user.ID + 1000, Username: "desconocido"}
+		w := editTweet(created.ID, stranger, "Versión ajena")
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("Editar Dos Veces", func(t *testing.T) {
+		w := editTweet(created.ID, user, "Versión corregida #despues")
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var edited TweetResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &edited))
+		assert.Equal(t, "Versión corregida #despues", edited.Content)
+		assert.True(t, edited.Edited)
+		assert.Equal(t, 2, edited.RevisionCount)
+		assert.NotNil(t, edited.EditedAt)
+		if assert.Len(t, edited.Entities.Hashtags, 1) {
+			assert.Equal(t, "despues", edited.Entities.Hashtags[0].Tag)
+		}
+
+		// Repetir el mismo contenido no crea una versión nueva
+		w = editTweet(created.ID, user, "Versión corregida #despues")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &edited))
+		assert.Equal(t, 2, edited.RevisionCount)
+
+		w = editTweet(created.ID, user, "Versión final")
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("Historial de Versiones", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/tweets/%d/history", created.ID), nil)
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var history HistoryResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &history))
+		assert.True(t, history.Edited)
+		contents := make([]string, 0, len(history.Revisions))
+		for _, revision := range history.Revisions {
+			contents = append(contents, revision.Content)
+		}
+		assert.Equal(t, []string{"Versión original #antes", "Versión corregida #despues", "Versión final"}, contents)
+
+		// El hashtag de la versión original ya no lista el tweet
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("GET", "/hashtags/antes/tweets", nil)
+		router.ServeHTTP(w, req)
+		assert.NotContains(t, w.Body.String(), fmt.Sprintf(`"id":%d,`, created.ID))
+	})
+
+	t.Run("Plazo de Edición Vencido", func(t *testing.T) {
+		past := time.Now().Add(-domain.DefaultEditWindow - time.Minute)
+		assert.NoError(t, testDB.Model(&domain.Tweet{}).Where("id = ?", created.ID).UpdateColumn("created_at", past).Error)
+
+		w := editTweet(created.ID, user, "Demasiado tarde")
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	deleteW := httptest.NewRecorder()
+	deleteReq, _ := http.NewRequest("DELETE", fmt.Sprintf("/tweets/%d", created.ID), nil)
+	deleteReq.Header.Set("Authorization", bearerFor(user))
+	router.ServeHTTP(deleteW, deleteReq)
+	assert.Equal(t, http.StatusOK, deleteW.Code)
+
+	var remaining int64
+	testDB.Model(&domain.TweetRevision{}).Where("tweet_id = ?", created.ID).Count(&remaining)
+	assert.Equal(t, int64(3), remaining, "Las versiones se conservan hasta purgar el tweet")
+}
@@ -0,0 +1,37 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/DevOpslp/microblogging-platform/tweet-service/internal/domain"
+	"github.com/gin-gonic/gin"
+)
+
+// GetHashtagTweets devuelve una página de los tweets que usan un hashtag, del más nuevo al más antiguo.
+// El hashtag se acepta con o sin '#' y sin distinguir mayúsculas.
+func (h *TweetHandler) GetHashtagTweets(c *gin.Context) {
+	tag, ok := domain.NormalizeHashtag(c.Param("tag"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Hashtag inválido"})
+		return
+	}
+
+	cursor, limit, err := parsePageParams(c, h.cursors)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tweets, next, err := h.repo.GetTweetsByHashtag(tag, viewerFrom(c), cursor, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudieron obtener los tweets"})
+		return
+	}
+
+	response := make([]TweetResponse, 0, len(tweets))
+	for _, tweet := range tweets {
+		response = append(response, formatTweetResponse(tweet))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"hashtag": tag, "tweets": response, "next_cursor": encodeCursor(h.cursors, next)})
+}
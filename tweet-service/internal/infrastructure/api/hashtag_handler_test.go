@@ -0,0 +1,83 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHashtagTweets(t *testing.T) {
+	setupTestDB()
+
+	user, err := getRandomUser()
+	assert.NoError(t, err, "Debe haber al menos un usuario en user-service para realizar la prueba")
+
+	router := setupTestRouter()
+
+	// Hashtag único por ejecución para no mezclar tweets de pruebas anteriores
+	tag := fmt.Sprintf("Canción%d", time.Now().UnixNano())
+
+	var created []TweetResponse
+	for _, content := range []string{"Primera #" + tag, "Segunda #" + tag + " y #otro", "Sin hashtag"} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/tweets", bytes.NewBufferString(fmt.Sprintf(`{"content":%q}`, content)))
+		req.Header.Set("Authorization", bearerFor(user))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusCreated, w.Code)
+
+		var tweet TweetResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &tweet))
+		created = append(created, tweet)
+	}
+
+	t.Run("Entidades con Posiciones", func(t *testing.T) {
+		hashtags := created[1].Entities.Hashtags
+		assert.Len(t, hashtags, 2)
+		assert.Equal(t, tag, hashtags[0].Tag)
+		assert.Equal(t, 8, hashtags[0].Start)
+		assert.Equal(t, 9+len([]rune(tag)), hashtags[0].End)
+		assert.Empty(t, created[2].Entities.Hashtags)
+	})
+
+	t.Run("Paginar Tweets del Hashtag", func(t *testing.T) {
+		getPage := func(query string) (int, []TweetResponse, string) {
+			w := httptest.NewRecorder()
+			// Sin distinguir mayúsculas y con '#' codificado
+			req, _ := http.NewRequest("GET", "/hashtags/%23"+tag+"/tweets?"+query, nil)
+			router.ServeHTTP(w, req)
+
+			var page struct {
+				Tweets     []TweetResponse `json:"tweets"`
+				NextCursor string          `json:"next_cursor"`
+			}
+			json.Unmarshal(w.Body.Bytes(), &page)
+			return w.Code, page.Tweets, page.NextCursor
+		}
+
+		code, tweets, next := getPage("limit=1")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Len(t, tweets, 1)
+		assert.Equal(t, created[1].ID, tweets[0].ID)
+		assert.NotEmpty(t, next)
+
+		code, tweets, next = getPage("limit=1&cursor=" + next)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Len(t, tweets, 1)
+		assert.Equal(t, created[0].ID, tweets[0].ID)
+		assert.Empty(t, next)
+	})
+
+	t.Run("Hashtag Inválido", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/hashtags/123/tweets", nil)
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
@@ -0,0 +1,128 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/DevOpslp/microblogging-platform/auth"
+	"github.com/DevOpslp/microblogging-platform/tweet-service/internal/domain"
+	"github.com/DevOpslp/microblogging-platform/tweet-service/internal/infrastructure/persistence"
+	"github.com/gin-gonic/gin"
+)
+
+type LikerResponse struct {
+	UserID   uint   `json:"user_id"`
+	Username string `json:"username"`
+	LikedAt  string `json:"liked_at"`
+}
+
+// LikeTweet da like al tweet como el usuario autenticado; repetirlo no tiene efecto
+func (h *TweetHandler) LikeTweet(c *gin.Context) {
+	h.changeLike(c, true)
+}
+
+// UnlikeTweet quita el like del usuario autenticado; repetirlo no tiene efecto
+func (h *TweetHandler) UnlikeTweet(c *gin.Context) {
+	h.changeLike(c, false)
+}
+
+func (h *TweetHandler) changeLike(c *gin.Context, liked bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de tweet inválido"})
+		return
+	}
+
+	// Solo se puede dar like a tweets visibles; quitar un like siempre se permite
+	if liked && h.findVisibleTweet(c, uint(id), "Tweet no encontrado") == nil {
+		return
+	}
+
+	var tweet *domain.Tweet
+	if liked {
+		tweet, err = h.repo.LikeTweet(auth.UserID(c), auth.Username(c), uint(id))
+	} else {
+		tweet, err = h.repo.UnlikeTweet(auth.UserID(c), uint(id))
+	}
+	if err != nil {
+		if errors.Is(err, persistence.ErrTweetNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Tweet no encontrado"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo actualizar el like"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tweet_id": tweet.ID, "liked": liked, "like_count": tweet.LikeCount})
+}
+
+// GetTweetLikes devuelve una página de los usuarios que dieron like a un tweet
+func (h *TweetHandler) GetTweetLikes(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de tweet inválido"})
+		return
+	}
+
+	cursor, limit, err := parsePageParams(c, h.cursors)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if h.findVisibleTweet(c, uint(id), "Tweet no encontrado") == nil {
+		return
+	}
+
+	likers, next, err := h.repo.GetTweetLikers(uint(id), cursor, limit)
+	if err != nil {
+		if errors.Is(err, persistence.ErrTweetNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Tweet no encontrado"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudieron obtener los likes"})
+		}
+		return
+	}
+
+	response := make([]LikerResponse, 0, len(likers))
+	for _, liker := range likers {
+		response = append(response, LikerResponse{
+			UserID:   liker.UserID,
+			Username: liker.Username,
+			LikedAt:  liker.LikedAt.Format(time.RFC3339),
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"users": response, "next_cursor": encodeCursor(h.cursors, next)})
+}
+
+// GetUserLikes devuelve una página de los tweets que le gustaron a un usuario, del like más nuevo al más antiguo
+func (h *TweetHandler) GetUserLikes(c *gin.Context) {
+	cursor, limit, err := parsePageParams(c, h.cursors)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tweets, next, err := h.repo.GetLikedTweets(c.Param("username"), viewerFrom(c), cursor, limit)
+	if err != nil {
+		switch {
+		case errors.Is(err, persistence.ErrUserNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Usuario no encontrado"})
+		case errors.Is(err, persistence.ErrPrivateAccount):
+			c.JSON(http.StatusForbidden, gin.H{"error": "Esta cuenta es privada"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudieron obtener los likes"})
+		}
+		return
+	}
+
+	response := make([]TweetResponse, 0, len(tweets))
+	for _, tweet := range tweets {
+		response = append(response, formatTweetResponse(tweet))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tweets": response, "next_cursor": encodeCursor(h.cursors, next)})
+}
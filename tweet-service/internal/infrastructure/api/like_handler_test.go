@@ -0,0 +1,136 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/DevOpslp/microblogging-platform/tweet-service/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLikeFlow(t *testing.T) {
+	setupTestDB()
+
+	user, err := getRandomUser()
+	assert.NoError(t, err, "Debe haber al menos un usuario en user-service para realizar la prueba")
+
+	router := setupTestRouter()
+
+	// Tweet de user al que se le darán likes
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/tweets", bytes.NewBufferString(`{"content":"Tweet para likes"}`))
+	req.Header.Set("Authorization", bearerFor(user))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var created TweetResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+
+	changeLike := func(method string, tweetID uint, liker *domain.User) (int, int) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, fmt.Sprintf("/tweets/%d/like", tweetID), nil)
+		req.Header.Set("Authorization", bearerFor(liker))
+		router.ServeHTTP(w, req)
+
+		var response struct {
+			LikeCount int `json:"like_count"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return w.Code, response.LikeCount
+	}
+
+	t.Run("Like Idempotente", func(t *testing.T) {
+		code, count := changeLike("POST", created.ID, user)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, 1, count)
+
+		code, count = changeLike("POST", created.ID, user)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, 1, count)
+	})
+
+	t.Run("Likes Concurrentes", func(t *testing.T) {
+		// Usuarios que solo existen en el token; cada uno da like varias veces en paralelo
+		var wg sync.WaitGroup
+		for i := uint(1); i <= 10; i++ {
+			liker := &domain.User{ID: user.ID + 1000 + i, Username: fmt.Sprintf("fan%d", i)}
+			for j := 0; j < 3; j++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					changeLike("POST", created.ID, liker)
+				}()
+			}
+		}
+		wg.Wait()
+
+		var tweet domain.Tweet
+		assert.NoError(t, testDB.First(&tweet, created.ID).Error)
+		var likes int64
+		testDB.Model(&domain.Like{}).Where("tweet_id = ?", created.ID).Count(&likes)
+		assert.Equal(t, int64(11), likes)
+		assert.Equal(t, int(likes), tweet.LikeCount)
+	})
+
+	t.Run("Listar Likes del Tweet", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/tweets/%d/likes?limit=5", created.ID), nil)
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var page struct {
+			Users      []LikerResponse `json:"users"`
+			NextCursor string          `json:"next_cursor"`
+		}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+		assert.Len(t, page.Users, 5)
+		assert.NotEmpty(t, page.NextCursor)
+	})
+
+	t.Run("Listar Likes del Usuario", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/users/"+user.Username+"/likes", nil)
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var page struct {
+			Tweets []TweetResponse `json:"tweets"`
+		}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+		assert.NotEmpty(t, page.Tweets)
+		assert.Equal(t, created.ID, page.Tweets[0].ID)
+		assert.Equal(t, 11, page.Tweets[0].LikeCount)
+	})
+
+	t.Run("Quitar Like Idempotente", func(t *testing.T) {
+		code, count := changeLike("DELETE", created.ID, user)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, 10, count)
+
+		code, count = changeLike("DELETE", created.ID, user)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, 10, count)
+	})
+
+	t.Run("Like a Tweet Inexistente", func(t *testing.T) {
+		code, _ := changeLike("POST", 2147483647, user)
+		assert.Equal(t, http.StatusNotFound, code)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/tweets/2147483647/likes", nil)
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Like sin Token", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", fmt.Sprintf("/tweets/%d/like", created.ID), nil)
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
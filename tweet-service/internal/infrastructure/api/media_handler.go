@@ -0,0 +1,157 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/DevOpslp/microblogging-platform/auth"
+	"github.com/DevOpslp/microblogging-platform/tweet-service/internal/domain"
+	"github.com/DevOpslp/microblogging-platform/tweet-service/internal/infrastructure/media"
+	"github.com/DevOpslp/microblogging-platform/tweet-service/internal/infrastructure/persistence"
+	"github.com/gin-gonic/gin"
+)
+
+// Margen para los encabezados y separadores del formulario multipart sobre el tamaño de la imagen
+const multipartOverhead = 64 << 10
+
+// MediaResponse describe una imagen; URL y ThumbnailURL son rutas de tweet-service
+type MediaResponse struct {
+	ID           uint   `json:"media_id"`
+	Type         string `json:"type"`
+	Width        int    `json:"width"`
+	Height       int    `json:"height"`
+	AltText      string `json:"alt_text"`
+	URL          string `json:"url"`
+	ThumbnailURL string `json:"thumbnail_url"`
+}
+
+func formatMediaResponse(item domain.Media) MediaResponse {
+	return MediaResponse{
+		ID:           item.ID,
+		Type:         item.ContentType,
+		Width:        item.Width,
+		Height:       item.Height,
+		AltText:      item.AltText,
+		URL:          item.URL(),
+		ThumbnailURL: item.ThumbnailURL(),
+	}
+}
+
+// UploadMedia recibe una imagen en el campo "file" de un formulario multipart. El tipo se decide por
+// el contenido y no por el nombre o el Content-Type enviados. Devuelve el media_id para adjuntarla
+// en POST /tweets.
+func (h *TweetHandler) UploadMedia(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, domain.MaxMediaSize+multipartOverhead)
+	header, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("La imagen supera los %d MB", domain.MaxMediaSize>>20)})
+		} else {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Falta el archivo de la imagen"})
+		}
+		return
+	}
+	if header.Size > domain.MaxMediaSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("La imagen supera los %d MB", domain.MaxMediaSize>>20)})
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No se pudo leer la imagen"})
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, domain.MaxMediaSize))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No se pudo leer la imagen"})
+		return
+	}
+
+	processed, err := media.Process(data)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrUnsupportedMedia):
+			c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Solo se aceptan imágenes JPEG, PNG, GIF o WebP"})
+		case errors.Is(err, media.ErrInvalidImage):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Imagen inválida"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo procesar la imagen"})
+		}
+		return
+	}
+
+	item, err := h.repo.SaveMedia(auth.UserID(c), processed)
+	if err != nil {
+		log.Printf("No se pudo guardar la imagen: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo guardar la imagen"})
+		return
+	}
+	c.JSON(http.StatusCreated, formatMediaResponse(*item))
+}
+
+// GetMedia devuelve la imagen sin metadatos
+func (h *TweetHandler) GetMedia(c *gin.Context) {
+	h.serveMedia(c, false)
+}
+
+// GetMediaThumbnail devuelve la miniatura JPEG de la imagen
+func (h *TweetHandler) GetMediaThumbnail(c *gin.Context) {
+	h.serveMedia(c, true)
+}
+
+// serveMedia responde el blob de una imagen que el viewer puede ver: una imagen adjunta sigue la
+// visibilidad de su tweet y una todavía sin adjuntar solo la ve quien la subió
+func (h *TweetHandler) serveMedia(c *gin.Context, thumbnail bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de imagen inválido"})
+		return
+	}
+
+	item, err := h.repo.GetMedia(uint(id))
+	if err != nil {
+		if errors.Is(err, persistence.ErrMediaNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Imagen no encontrada"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo obtener la imagen"})
+		}
+		return
+	}
+
+	if item.TweetID == nil {
+		if item.UserID != auth.UserID(c) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Imagen no encontrada"})
+			return
+		}
+	} else if h.findVisibleTweet(c, *item.TweetID, "Imagen no encontrada") == nil {
+		return
+	}
+
+	key, contentType := item.StorageKey, item.ContentType
+	if thumbnail {
+		key, contentType = item.ThumbnailKey, domain.MediaTypeJPEG
+	}
+
+	blob, err := h.repo.OpenMediaBlob(key)
+	if err != nil {
+		if errors.Is(err, persistence.ErrBlobNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Imagen no encontrada"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo obtener la imagen"})
+		}
+		return
+	}
+	defer blob.Close()
+
+	// Las imágenes no cambian; el navegador no debe interpretarlas como otro tipo
+	c.Header("Cache-Control", "private, max-age=86400")
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.DataFromReader(http.StatusOK, -1, contentType, blob, nil)
+}
@@ -0,0 +1,36 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/DevOpslp/microblogging-platform/tweet-service/internal/infrastructure/persistence"
+	"github.com/gin-gonic/gin"
+)
+
+// GetMentionTweets devuelve una página de los tweets que mencionan a un usuario, del más nuevo al más antiguo.
+// Lo usa timeline-service, con un token de servicio, para materializar el timeline de menciones.
+func (h *TweetHandler) GetMentionTweets(c *gin.Context) {
+	cursor, limit, err := parsePageParams(c, h.cursors)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tweets, next, err := h.repo.GetTweetsMentioning(c.Param("username"), viewerFrom(c), cursor, limit)
+	if err != nil {
+		if errors.Is(err, persistence.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Usuario no encontrado"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudieron obtener las menciones"})
+		}
+		return
+	}
+
+	response := make([]TweetResponse, 0, len(tweets))
+	for _, tweet := range tweets {
+		response = append(response, formatTweetResponse(tweet))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tweets": response, "next_cursor": encodeCursor(h.cursors, next)})
+}
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMentionTweets(t *testing.T) {
+	setupTestDB()
+
+	user, err := getRandomUser()
+	assert.NoError(t, err, "Debe haber al menos un usuario en user-service para realizar la prueba")
+
+	router := setupTestRouter()
+
+	// Un handle inexistente queda como texto y no se incluye en las entidades
+	unknown := fmt.Sprintf("desconocido%d", time.Now().UnixNano())
+	content := fmt.Sprintf("Hola @%s y @%s", user.Username, unknown)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/tweets", bytes.NewBufferString(fmt.Sprintf(`{"content":%q}`, content)))
+	req.Header.Set("Authorization", bearerFor(user))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var created TweetResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+
+	t.Run("Menciones Resueltas con ID", func(t *testing.T) {
+		mentions := created.Entities.Mentions
+		assert.Len(t, mentions, 1)
+		assert.Equal(t, user.ID, mentions[0].UserID)
+		assert.Equal(t, user.Username, mentions[0].Username)
+		assert.Equal(t, 5, mentions[0].Start)
+		assert.Equal(t, 6+len([]rune(user.Username)), mentions[0].End)
+	})
+
+	t.Run("Tweets que Mencionan al Usuario", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/tweets/mentions/"+user.Username+"?limit=1", nil)
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var page struct {
+			Tweets []TweetResponse `json:"tweets"`
+		}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+		assert.Len(t, page.Tweets, 1)
+		assert.Equal(t, created.ID, page.Tweets[0].ID)
+	})
+
+	t.Run("Usuario Inexistente", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/tweets/mentions/"+unknown, nil)
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	deleteW := httptest.NewRecorder()
+	deleteReq, _ := http.NewRequest("DELETE", fmt.Sprintf("/tweets/%d", created.ID), nil)
+	deleteReq.Header.Set("Authorization", bearerFor(user))
+	router.ServeHTTP(deleteW, deleteReq)
+	assert.Equal(t, http.StatusOK, deleteW.Code)
+}
@@ -0,0 +1,123 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DevOpslp/microblogging-platform/events"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTweetEventsWrittenToOutbox(t *testing.T) {
+	setupTestDB()
+
+	user, err := getRandomUser()
+	assert.NoError(t, err, "Debe haber al menos un usuario en user-service para realizar la prueba")
+
+	router := setupTestRouter()
+
+	// findEvent busca en la outbox el evento de un tipo sobre un tweet
+	findEvent := func(eventType string, tweetID uint) *events.OutboxEvent {
+		var row events.OutboxEvent
+		err := testDB.Where("type = ? AND (payload->>'tweet_id')::bigint = ?", eventType, tweetID).First(&row).Error
+		if err != nil {
+			return nil
+		}
+		return &row
+	}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/tweets", bytes.NewBufferString(fmt.Sprintf(`{"content":"Hola @%s"}`, user.Username)))
+	req.Header.Set("Authorization", bearerFor(user))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var created TweetResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+
+	t.Run("TweetCreated en la Outbox", func(t *testing.T) {
+		row := findEvent(events.TypeTweetCreated, created.ID)
+		if !assert.NotNil(t, row) {
+			return
+		}
+		assert.Equal(t, "tweet-service", row.Source)
+		assert.Len(t, row.EventID, 36)
+		assert.Nil(t, row.PublishedAt)
+
+		var payload events.TweetCreated
+		assert.NoError(t, row.Event().Decode(&payload))
+		assert.Equal(t, user.ID, payload.UserID)
+		assert.Equal(t, user.Username, payload.Username)
+		assert.Equal(t, created.ID, payload.ConversationID)
+		assert.Equal(t, []events.Mention{{UserID: user.ID, Username: user.Username}}, payload.Mentions)
+	})
+
+	t.Run("Un Solo TweetLiked por Like", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			req, _ := http.NewRequest("POST", fmt.Sprintf("/tweets/%d/like", created.ID), nil)
+			req.Header.Set("Authorization", bearerFor(user))
+			router.ServeHTTP(httptest.NewRecorder(), req)
+		}
+
+		var count int64
+		testDB.Model(&events.OutboxEvent{}).
+			Where("type = ? AND (payload->>'tweet_id')::bigint = ?", events.TypeTweetLiked, created.ID).Count(&count)
+		assert.Equal(t, int64(1), count)
+
+		row := findEvent(events.TypeTweetLiked, created.ID)
+		if assert.NotNil(t, row) {
+			var payload events.TweetLiked
+			assert.NoError(t, row.Event().Decode(&payload))
+			assert.Equal(t, user.ID, payload.TweetUserID)
+			assert.Equal(t, user.Username, payload.Username)
+		}
+	})
+
+	t.Run("TweetDeleted en la Outbox", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("DELETE", fmt.Sprintf("/tweets/%d", created.ID), nil)
+		req.Header.Set("Authorization", bearerFor(user))
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		assert.NotNil(t, findEvent(events.TypeTweetDeleted, created.ID))
+	})
+
+	t.Run("Restaurar No Vuelve a Publicar TweetCreated", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", fmt.Sprintf("/tweets/%d/restore", created.ID), nil)
+		req.Header.Set("Authorization", bearerFor(user))
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var count int64
+		testDB.Model(&events.OutboxEvent{}).
+			Where("type = ? AND (payload->>'tweet_id')::bigint = ?", events.TypeTweetCreated, created.ID).Count(&count)
+		assert.Equal(t, int64(1), count)
+		assert.NotNil(t, findEvent(events.TypeTweetRestored, created.ID))
+	})
+
+	t.Run("Relay Publica los Pendientes", func(t *testing.T) {
+		broker := events.NewMemoryBroker()
+		var received []string
+		broker.Subscribe("test", events.TypeTweetDeleted, func(_ context.Context, event events.Event) error {
+			received = append(received, event.ID)
+			return nil
+		})
+
+		_, err := events.NewRelay(events.NewGormOutbox(testDB), broker).PublishPending(context.Background())
+		assert.NoError(t, err)
+
+		row := findEvent(events.TypeTweetDeleted, created.ID)
+		if assert.NotNil(t, row) {
+			assert.NotNil(t, row.PublishedAt)
+			assert.Contains(t, received, row.EventID)
+		}
+	})
+}
@@ -0,0 +1,136 @@
+package api
+
+import (
+	"strconv"
+
+	"github.com/DevOpslp/microblogging-platform/pagination"
+	"github.com/DevOpslp/microblogging-platform/tweet-service/internal/domain"
+	"github.com/gin-gonic/gin"
+)
+
+// parsePageParams lee los parámetros `cursor` y `limit` de la query
+func parsePageParams(c *gin.Context, cursors *pagination.Signer) (*domain.Cursor, int, error) {
+	limit, err := pagination.ParseLimit(c.Query("limit"))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	value := c.Query("cursor")
+	if value == "" {
+		return nil, limit, nil
+	}
+	cursor, err := decodeCursor(cursors, value)
+	if err != nil {
+		return nil, 0, err
+	}
+	return cursor, limit, nil
+}
+
+// parseThreadPageParams lee los parámetros `cursor` y `limit` para paginar las respuestas de un hilo
+func parseThreadPageParams(c *gin.Context, cursors *pagination.Signer) (*domain.ThreadCursor, int, error) {
+	limit, err := pagination.ParseLimit(c.Query("limit"))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	value := c.Query("cursor")
+	if value == "" {
+		return nil, limit, nil
+	}
+	cursor, err := decodeThreadCursor(cursors, value)
+	if err != nil {
+		return nil, 0, err
+	}
+	return cursor, limit, nil
+}
+
+// encodeCursor firma el cursor de la página siguiente; vacío si no hay más resultados
+func encodeCursor(cursors *pagination.Signer, cursor *domain.Cursor) string {
+	if cursor == nil {
+		return ""
+	}
+	return cursors.EncodeTimeID(cursor.CreatedAt, cursor.ID)
+}
+
+func decodeCursor(cursors *pagination.Signer, value string) (*domain.Cursor, error) {
+	createdAt, tweetID, err := cursors.DecodeTimeID(value)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.Cursor{CreatedAt: createdAt, ID: tweetID}, nil
+}
+
+// encodeThreadCursor firma el cursor de un hilo, con la profundidad como primer campo
+func encodeThreadCursor(cursors *pagination.Signer, cursor *domain.ThreadCursor) string {
+	if cursor == nil {
+		return ""
+	}
+	return cursors.Encode(strconv.Itoa(cursor.Depth), pagination.FormatTime(cursor.CreatedAt), pagination.FormatID(cursor.ID))
+}
+
+func decodeThreadCursor(cursors *pagination.Signer, value string) (*domain.ThreadCursor, error) {
+	fields, err := cursors.Decode(value, 3)
+	if err != nil {
+		return nil, err
+	}
+	depth, err := strconv.Atoi(fields[0])
+	if err != nil || depth < 0 {
+		return nil, pagination.ErrInvalidCursor
+	}
+	createdAt, err := pagination.ParseTime(fields[1])
+	if err != nil {
+		return nil, err
+	}
+	tweetID, err := pagination.ParseID(fields[2])
+	if err != nil {
+		return nil, err
+	}
+	return &domain.ThreadCursor{Depth: depth, CreatedAt: createdAt, ID: tweetID}, nil
+}
+
+// parseSearchPageParams lee los parámetros `cursor` y `limit` para paginar una búsqueda
+func parseSearchPageParams(c *gin.Context, cursors *pagination.Signer) (*domain.SearchCursor, int, error) {
+	limit, err := pagination.ParseLimit(c.Query("limit"))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	value := c.Query("cursor")
+	if value == "" {
+		return nil, limit, nil
+	}
+	cursor, err := decodeSearchCursor(cursors, value)
+	if err != nil {
+		return nil, 0, err
+	}
+	return cursor, limit, nil
+}
+
+// encodeSearchCursor firma el cursor de una búsqueda, con la relevancia como primer campo
+func encodeSearchCursor(cursors *pagination.Signer, cursor *domain.SearchCursor) string {
+	if cursor == nil {
+		return ""
+	}
+	rank := strconv.FormatFloat(float64(cursor.Rank), 'g', -1, 32)
+	return cursors.Encode(rank, pagination.FormatTime(cursor.CreatedAt), pagination.FormatID(cursor.ID))
+}
+
+func decodeSearchCursor(cursors *pagination.Signer, value string) (*domain.SearchCursor, error) {
+	fields, err := cursors.Decode(value, 3)
+	if err != nil {
+		return nil, err
+	}
+	rank, err := strconv.ParseFloat(fields[0], 32)
+	if err != nil {
+		return nil, pagination.ErrInvalidCursor
+	}
+	createdAt, err := pagination.ParseTime(fields[1])
+	if err != nil {
+		return nil, err
+	}
+	tweetID, err := pagination.ParseID(fields[2])
+	if err != nil {
+		return nil, err
+	}
+	return &domain.SearchCursor{Rank: float32(rank), CreatedAt: createdAt, ID: tweetID}, nil
+}
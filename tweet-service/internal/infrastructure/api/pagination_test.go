@@ -0,0 +1,111 @@
+package api
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DevOpslp/microblogging-platform/pagination"
+	"github.com/DevOpslp/microblogging-platform/tweet-service/internal/domain"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func newPageContext(query string) *gin.Context {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/tweets?"+query, nil)
+	return c
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	cursor := &domain.Cursor{CreatedAt: time.Date(2024, 5, 1, 12, 30, 0, 123456000, time.UTC), ID: 42}
+
+	decoded, err := decodeCursor(cursors, encodeCursor(cursors, cursor))
+	assert.NoError(t, err)
+	assert.True(t, cursor.CreatedAt.Equal(decoded.CreatedAt))
+	assert.Equal(t, cursor.ID, decoded.ID)
+
+	assert.Equal(t, "", encodeCursor(cursors, nil))
+}
+
+func TestParsePageParams(t *testing.T) {
+	t.Run("Valores por defecto", func(t *testing.T) {
+		cursor, limit, err := parsePageParams(newPageContext(""), cursors)
+		assert.NoError(t, err)
+		assert.Nil(t, cursor)
+		assert.Equal(t, pagination.DefaultLimit, limit)
+	})
+
+	t.Run("Limit fuera de rango", func(t *testing.T) {
+		for _, query := range []string{"limit=0", "limit=-1", "limit=101", "limit=abc"} {
+			_, _, err := parsePageParams(newPageContext(query), cursors)
+			assert.ErrorIs(t, err, pagination.ErrInvalidLimit, query)
+		}
+	})
+
+	t.Run("Cursor adulterado", func(t *testing.T) {
+		valid := encodeCursor(cursors, &domain.Cursor{CreatedAt: time.Now(), ID: 7})
+		forged := encodeCursor(cursors, &domain.Cursor{CreatedAt: time.Now(), ID: 8})
+		tampered := forged[:len(forged)-16] + valid[len(valid)-16:]
+
+		for _, value := range []string{"abc", "abc.def", tampered, valid + "x"} {
+			_, _, err := parsePageParams(newPageContext("cursor="+value), cursors)
+			assert.ErrorIs(t, err, pagination.ErrInvalidCursor, value)
+		}
+	})
+
+	t.Run("Cursor válido", func(t *testing.T) {
+		value := encodeCursor(cursors, &domain.Cursor{CreatedAt: time.Now(), ID: 7})
+		cursor, limit, err := parsePageParams(newPageContext("limit=5&cursor="+value), cursors)
+		assert.NoError(t, err)
+		assert.Equal(t, uint(7), cursor.ID)
+		assert.Equal(t, 5, limit)
+	})
+}
+
+func TestThreadCursor(t *testing.T) {
+	cursor := &domain.ThreadCursor{Depth: 2, CreatedAt: time.Date(2024, 5, 1, 12, 30, 0, 123456000, time.UTC), ID: 42}
+
+	decoded, err := decodeThreadCursor(cursors, encodeThreadCursor(cursors, cursor))
+	assert.NoError(t, err)
+	assert.Equal(t, cursor.Depth, decoded.Depth)
+	assert.True(t, cursor.CreatedAt.Equal(decoded.CreatedAt))
+	assert.Equal(t, cursor.ID, decoded.ID)
+
+	assert.Equal(t, "", encodeThreadCursor(cursors, nil))
+
+	// Los cursores de listados y de hilos no son intercambiables
+	listCursor := encodeCursor(cursors, &domain.Cursor{CreatedAt: time.Now(), ID: 7})
+	_, err = decodeThreadCursor(cursors, listCursor)
+	assert.ErrorIs(t, err, pagination.ErrInvalidCursor)
+	_, err = decodeCursor(cursors, encodeThreadCursor(cursors, cursor))
+	assert.ErrorIs(t, err, pagination.ErrInvalidCursor)
+
+	c := newPageContext("limit=3&cursor=" + encodeThreadCursor(cursors, cursor))
+	parsed, limit, err := parseThreadPageParams(c, cursors)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, parsed.Depth)
+	assert.Equal(t, 3, limit)
+}
+
+func TestSearchCursor(t *testing.T) {
+	cursor := &domain.SearchCursor{Rank: 0.0607927, CreatedAt: time.Date(2024, 5, 1, 12, 30, 0, 123456000, time.UTC), ID: 42}
+
+	// La relevancia se recupera sin perder precisión para comparar con ts_rank
+	decoded, err := decodeSearchCursor(cursors, encodeSearchCursor(cursors, cursor))
+	assert.NoError(t, err)
+	assert.Equal(t, cursor.Rank, decoded.Rank)
+	assert.True(t, cursor.CreatedAt.Equal(decoded.CreatedAt))
+	assert.Equal(t, cursor.ID, decoded.ID)
+
+	assert.Equal(t, "", encodeSearchCursor(cursors, nil))
+
+	_, err = decodeSearchCursor(cursors, encodeCursor(cursors, &domain.Cursor{CreatedAt: time.Now(), ID: 7}))
+	assert.ErrorIs(t, err, pagination.ErrInvalidCursor)
+
+	c := newPageContext("limit=3&cursor=" + encodeSearchCursor(cursors, cursor))
+	parsed, limit, err := parseSearchPageParams(c, cursors)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(42), parsed.ID)
+	assert.Equal(t, 3, limit)
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DevOpslp/microblogging-platform/tweet-service/internal/domain"
+	"github.com/DevOpslp/microblogging-platform/tweet-service/internal/infrastructure/persistence"
+	"github.com/gin-gonic/gin"
+)
+
+// GetAuthorAffinity devuelve los likes y respuestas del usuario :username a cada autor en los últimos
+// días. Solo la consultan otros servicios, porque revela la actividad del usuario.
+func (h *TweetHandler) GetAuthorAffinity(c *gin.Context) {
+	since := time.Now().AddDate(0, 0, -domain.AffinityDays)
+	affinity, err := h.repo.GetAuthorAffinity(c.Param("username"), since)
+	if err != nil {
+		if errors.Is(err, persistence.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Usuario no encontrado"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo obtener la afinidad con los autores"})
+		}
+		return
+	}
+
+	response := make([]gin.H, 0, len(affinity))
+	for _, author := range affinity {
+		response = append(response, gin.H{"username": author.Username, "likes": author.Likes, "replies": author.Replies})
+	}
+	c.JSON(http.StatusOK, gin.H{"affinity": response})
+}
+
+// GetTweetEngagement devuelve los contadores actuales de los tweets ?ids=1,2,3
+func (h *TweetHandler) GetTweetEngagement(c *gin.Context) {
+	fields := strings.Split(c.Query("ids"), ",")
+	if len(fields) > domain.MaxEngagementTweets {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Se pueden consultar hasta %d tweets", domain.MaxEngagementTweets)})
+		return
+	}
+	ids := make([]uint, 0, len(fields))
+	for _, field := range fields {
+		id, err := strconv.ParseUint(field, 10, 64)
+		if err != nil || id == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "IDs de tweets inválidos"})
+			return
+		}
+		ids = append(ids, uint(id))
+	}
+
+	engagement, err := h.repo.GetTweetEngagement(ids)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudieron obtener las interacciones"})
+		return
+	}
+
+	response := make([]gin.H, 0, len(engagement))
+	for _, tweet := range engagement {
+		response = append(response, gin.H{
+			"tweet_id":      tweet.TweetID,
+			"reply_count":   tweet.ReplyCount,
+			"like_count":    tweet.LikeCount,
+			"retweet_count": tweet.RetweetCount,
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"engagement": response})
+}
@@ -0,0 +1,99 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DevOpslp/microblogging-platform/auth"
+	"github.com/DevOpslp/microblogging-platform/tweet-service/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRankingSignals(t *testing.T) {
+	setupTestDB()
+
+	// Se necesitan dos usuarios reales de user-service: el autor y quien interactúa con él
+	resp, err := http.Get("http://localhost:8080/users")
+	if !assert.NoError(t, err, "user-service debe estar disponible para realizar la prueba") {
+		return
+	}
+	var list struct {
+		Users []domain.User `json:"users"`
+	}
+	json.NewDecoder(resp.Body).Decode(&list)
+	resp.Body.Close()
+	if !assert.GreaterOrEqual(t, len(list.Users), 2, "Debe haber al menos dos usuarios en user-service") {
+		return
+	}
+	author, fan := &list.Users[0], &list.Users[1]
+
+	router := setupTestRouter()
+
+	send := func(method, path, body string, user *domain.User) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Authorization", bearerFor(user))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send("POST", "/tweets", `{"content":"Tweet para la afinidad"}`, author)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var tweet TweetResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &tweet))
+
+	assert.Equal(t, http.StatusOK, send("POST", fmt.Sprintf("/tweets/%d/like", tweet.ID), "", fan).Code)
+	w = send("POST", "/tweets", fmt.Sprintf(`{"content":"Respuesta","in_reply_to_tweet_id":%d}`, tweet.ID), fan)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	t.Run("Afinidad con los autores", func(t *testing.T) {
+		serviceToken, _ := tokens.IssueServiceToken("timeline-service")
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/tweets/affinity/"+fan.Username, nil)
+		req.Header.Set("Authorization", auth.BearerHeader(serviceToken))
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response struct {
+			Affinity []struct {
+				Username string `json:"username"`
+				Likes    int    `json:"likes"`
+				Replies  int    `json:"replies"`
+			} `json:"affinity"`
+		}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		found := false
+		for _, affinity := range response.Affinity {
+			assert.NotEqual(t, fan.Username, affinity.Username, "Las interacciones con los tweets propios no cuentan")
+			if affinity.Username == author.Username {
+				found = true
+				assert.GreaterOrEqual(t, affinity.Likes, 1)
+				assert.GreaterOrEqual(t, affinity.Replies, 1)
+			}
+		}
+		assert.True(t, found)
+
+		// Solo la consultan otros servicios
+		assert.Equal(t, http.StatusUnauthorized, send("GET", "/tweets/affinity/"+fan.Username, "", fan).Code)
+	})
+
+	t.Run("Interacciones de los tweets", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/tweets/engagement?ids=%d,999999999", tweet.ID), nil)
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, fmt.Sprintf(`{"engagement":[{"tweet_id":%d,"reply_count":1,"like_count":1,"retweet_count":0}]}`, tweet.ID), w.Body.String())
+
+		for _, query := range []string{"", "ids=", "ids=1,abc", "ids=0"} {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/tweets/engagement?"+query, nil)
+			router.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusBadRequest, w.Code, query)
+		}
+	})
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/DevOpslp/microblogging-platform/auth"
+	"github.com/DevOpslp/microblogging-platform/tweet-service/internal/infrastructure/persistence"
+	"github.com/gin-gonic/gin"
+)
+
+// RestoreTweet deshace la eliminación de un tweet dentro del plazo de restauración. Solo puede
+// restaurarlo quien lo eliminó o un administrador; el tweet y sus retweets vuelven a los timelines.
+func (h *TweetHandler) RestoreTweet(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de tweet inválido"})
+		return
+	}
+
+	tweet, err := h.repo.GetDeletedTweet(uint(id))
+	if err != nil {
+		respondRestoreError(c, err)
+		return
+	}
+	if tweet.DeletedByID != auth.UserID(c) && auth.Role(c) != auth.RoleAdmin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "No tienes permiso para restaurar este tweet"})
+		return
+	}
+
+	restored, _, err := h.repo.RestoreTweet(tweet.ID)
+	if err != nil {
+		respondRestoreError(c, err)
+		return
+	}
+
+	// El autor se resuelve al embeber si lo restaura un administrador. Restaurar no vuelve a notificar
+	// las respuestas ni las menciones: el repositorio solo guarda el evento TweetRestored, con el que
+	// timeline-service devuelve el tweet y sus retweets a los timelines.
+	username := ""
+	if restored.UserID == auth.UserID(c) {
+		username = auth.Username(c)
+	}
+	c.JSON(http.StatusOK, formatTweetResponse(h.withReferences(*restored, username)))
+}
+
+func respondRestoreError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, persistence.ErrTweetNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tweet eliminado no encontrado"})
+	case errors.Is(err, persistence.ErrRestoreWindowClosed):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Terminó el plazo para restaurar el tweet"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo restaurar el tweet"})
+	}
+}
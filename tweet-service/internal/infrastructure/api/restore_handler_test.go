@@ -0,0 +1,171 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DevOpslp/microblogging-platform/auth"
+	"github.com/DevOpslp/microblogging-platform/tweet-service/internal/domain"
+	"github.com/DevOpslp/microblogging-platform/tweet-service/internal/infrastructure/persistence"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeleteAndRestoreTweet(t *testing.T) {
+	setupTestDB()
+
+	user, err := getRandomUser()
+	assert.NoError(t, err, "Debe haber al menos un usuario en user-service para realizar la prueba")
+
+	router := setupTestRouter()
+
+	send := func(method, path, body string, user *domain.User, role string) (int, TweetResponse) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Authorization", bearerWithRole(user, role))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+
+		var response TweetResponse
+		_ = json.Unmarshal(w.Body.Bytes(), &response)
+		return w.Code, response
+	}
+	getThread := func(tweetID uint) ThreadResponse {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/tweets/%d/thread", tweetID), nil)
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var thread ThreadResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &thread))
+		return thread
+	}
+
+	code, root := send("POST", "/tweets", `{"content":"Raíz del hilo"}`, user, auth.RoleUser)
+	assert.Equal(t, http.StatusCreated, code)
+	code, reply := send("POST", "/tweets", fmt.Sprintf(`{"content":"Respuesta que se eliminará","in_reply_to_tweet_id":%d}`, root.ID), user, auth.RoleUser)
+	assert.Equal(t, http.StatusCreated, code)
+	code, nested := send("POST", "/tweets", fmt.Sprintf(`{"content":"Respuesta a la respuesta","in_reply_to_tweet_id":%d}`, reply.ID), user, auth.RoleUser)
+	assert.Equal(t, http.StatusCreated, code)
+	code, quote := send("POST", "/tweets", fmt.Sprintf(`{"content":"Cita","quoted_tweet_id":%d}`, reply.ID), user, auth.RoleUser)
+	assert.Equal(t, http.StatusCreated, code)
+
+	code, _ = send("DELETE", fmt.Sprintf("/tweets/%d", reply.ID), "", user, auth.RoleUser)
+	assert.Equal(t, http.StatusOK, code)
+
+	t.Run("Tombstone en el Hilo y la Cita", func(t *testing.T) {
+		code, _ := send("GET", fmt.Sprintf("/tweets/%d", reply.ID), "", user, auth.RoleUser)
+		assert.Equal(t, http.StatusNotFound, code)
+
+		thread := getThread(nested.ID)
+		if assert.Len(t, thread.Ancestors, 2) {
+			tombstone := thread.Ancestors[1]
+			assert.Equal(t, reply.ID, tombstone.ID)
+			assert.True(t, tombstone.Deleted)
+			assert.Equal(t, domain.TombstoneText, tombstone.Content)
+			assert.Empty(t, tombstone.Username)
+		}
+
+		thread = getThread(root.ID)
+		assert.Zero(t, thread.Tweet.ReplyCount)
+		if assert.Len(t, thread.Replies, 2) {
+			assert.True(t, thread.Replies[0].Deleted)
+			assert.Equal(t, nested.ID, thread.Replies[1].ID)
+		}
+
+		code, quoted := send("GET", fmt.Sprintf("/tweets/%d", quote.ID), "", user, auth.RoleUser)
+		assert.Equal(t, http.StatusOK, code)
+		if assert.NotNil(t, quoted.QuotedTweet) {
+			assert.True(t, quoted.QuotedTweet.Deleted)
+			assert.Equal(t, domain.TombstoneText, quoted.QuotedTweet.Content)
+		}
+	})
+
+	t.Run("Solo Quien lo Eliminó Puede Restaurarlo", func(t *testing.T) {
+		stranger := &domain.User{ID: user.ID + 1000, Username: "desconocido"}
+		code, _ := send("POST", fmt.Sprintf("/tweets/%d/restore", reply.ID), "", stranger, auth.RoleUser)
+		assert.Equal(t, http.StatusForbidden, code)
+
+		code, _ = send("POST", fmt.Sprintf("/tweets/%d/restore", root.ID), "", user, auth.RoleUser)
+		assert.Equal(t, http.StatusNotFound, code, "Un tweet que no está eliminado no se restaura")
+	})
+
+	t.Run("Restaurar", func(t *testing.T) {
+		code, restored := send("POST", fmt.Sprintf("/tweets/%d/restore", reply.ID), "", user, auth.RoleUser)
+		assert.Equal(t, http.StatusOK, code)
+		assert.False(t, restored.Deleted)
+		assert.Equal(t, "Respuesta que se eliminará", restored.Content)
+		assert.Equal(t, user.Username, restored.Username)
+
+		assert.Equal(t, 1, getThread(root.ID).Tweet.ReplyCount)
+	})
+
+	t.Run("Plazo de Restauración Vencido", func(t *testing.T) {
+		code, _ := send("DELETE", fmt.Sprintf("/tweets/%d", reply.ID), "", user, auth.RoleUser)
+		assert.Equal(t, http.StatusOK, code)
+
+		past := time.Now().Add(-domain.DefaultRestoreWindow - time.Minute)
+		assert.NoError(t, testDB.Unscoped().Model(&domain.Tweet{}).Where("id = ?", reply.ID).UpdateColumn("deleted_at", past).Error)
+
+		// Ni siquiera un administrador puede restaurarlo después del plazo
+		code, _ = send("POST", fmt.Sprintf("/tweets/%d/restore", reply.ID), "", user, auth.RoleAdmin)
+		assert.Equal(t, http.StatusForbidden, code)
+	})
+
+	t.Run("Purgar", func(t *testing.T) {
+		purger := persistence.NewPurger(persistence.NewTweetRepository(testDB, nil, nil), domain.DefaultPurgeRetention, domain.DefaultOrphanMediaTTL)
+		_, err := purger.Purge(time.Now())
+		assert.NoError(t, err)
+
+		// Dentro de la retención el tweet se conserva como tombstone
+		var count int64
+		testDB.Unscoped().Model(&domain.Tweet{}).Where("id = ?", reply.ID).Count(&count)
+		assert.Equal(t, int64(1), count)
+
+		_, err = purger.Purge(time.Now().Add(domain.DefaultPurgeRetention))
+		assert.NoError(t, err)
+		testDB.Unscoped().Model(&domain.Tweet{}).Where("id = ?", reply.ID).Count(&count)
+		assert.Zero(t, count)
+		testDB.Model(&domain.TweetHashtag{}).Where("tweet_id = ?", reply.ID).Count(&count)
+		assert.Zero(t, count)
+	})
+
+	for _, tweet := range []TweetResponse{quote, nested, root} {
+		code, _ := send("DELETE", fmt.Sprintf("/tweets/%d", tweet.ID), "", user, auth.RoleUser)
+		assert.Equal(t, http.StatusOK, code)
+	}
+}
+
+func TestPurgeOrphanMedia(t *testing.T) {
+	setupTestDB()
+
+	blobs, err := persistence.NewFilesystemBlobStore(t.TempDir())
+	assert.NoError(t, err)
+	purger := persistence.NewPurger(persistence.NewTweetRepository(testDB, nil, blobs), domain.DefaultPurgeRetention, time.Hour)
+
+	orphan := domain.Media{UserID: 1, ContentType: domain.MediaTypePNG, StorageKey: "orphan", ThumbnailKey: "orphan-thumbnail"}
+	assert.NoError(t, testDB.Create(&orphan).Error)
+	for _, key := range []string{orphan.StorageKey, orphan.ThumbnailKey} {
+		assert.NoError(t, blobs.Put(key, domain.MediaTypePNG, []byte("imagen")))
+	}
+
+	// Una imagen recién subida todavía puede adjuntarse a un tweet
+	_, err = purger.PurgeOrphanMedia(time.Now())
+	assert.NoError(t, err)
+	var count int64
+	testDB.Model(&domain.Media{}).Where("id = ?", orphan.ID).Count(&count)
+	assert.Equal(t, int64(1), count)
+
+	// Pasado el TTL se borran la imagen y sus blobs
+	purged, err := purger.PurgeOrphanMedia(time.Now().Add(2 * time.Hour))
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, purged, 1)
+	testDB.Model(&domain.Media{}).Where("id = ?", orphan.ID).Count(&count)
+	assert.Zero(t, count)
+	_, err = blobs.Open(orphan.StorageKey)
+	assert.Error(t, err)
+}
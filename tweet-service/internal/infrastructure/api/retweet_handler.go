@@ -0,0 +1,68 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/DevOpslp/microblogging-platform/auth"
+	"github.com/DevOpslp/microblogging-platform/tweet-service/internal/infrastructure/persistence"
+	"github.com/gin-gonic/gin"
+)
+
+// Retweet retuitea el tweet como el usuario autenticado. Responde 201 con el retweet nuevo,
+// o 200 con el existente si ya lo había retuiteado.
+func (h *TweetHandler) Retweet(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de tweet inválido"})
+		return
+	}
+
+	tweet := h.findVisibleTweet(c, uint(id), "Tweet no encontrado")
+	if tweet == nil || !checkShareable(c, tweet) {
+		return
+	}
+
+	retweet, created, err := h.repo.Retweet(auth.UserID(c), auth.Username(c), uint(id))
+	if err != nil {
+		respondRetweetError(c, err)
+		return
+	}
+
+	if !created {
+		existing, err := h.repo.Hydrate(*retweet)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo obtener el retweet"})
+			return
+		}
+		c.JSON(http.StatusOK, formatTweetResponse(*existing))
+		return
+	}
+
+	c.JSON(http.StatusCreated, formatTweetResponse(h.withReferences(*retweet, auth.Username(c))))
+}
+
+// Unretweet quita el retweet del usuario autenticado; repetirlo no tiene efecto
+func (h *TweetHandler) Unretweet(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de tweet inválido"})
+		return
+	}
+
+	if _, err := h.repo.Unretweet(auth.UserID(c), uint(id)); err != nil {
+		respondRetweetError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Retweet eliminado exitosamente"})
+}
+
+func respondRetweetError(c *gin.Context, err error) {
+	if errors.Is(err, persistence.ErrTweetNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tweet no encontrado"})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo actualizar el retweet"})
+}
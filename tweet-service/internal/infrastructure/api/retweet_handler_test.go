@@ -0,0 +1,163 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DevOpslp/microblogging-platform/tweet-service/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetweetAndQuoteFlow(t *testing.T) {
+	setupTestDB()
+
+	user, err := getRandomUser()
+	assert.NoError(t, err, "Debe haber al menos un usuario en user-service para realizar la prueba")
+
+	router := setupTestRouter()
+
+	send := func(method, path, body string, author *domain.User) (int, TweetResponse) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Authorization", bearerFor(author))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+
+		var response TweetResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return w.Code, response
+	}
+
+	code, original := send("POST", "/tweets", `{"content":"Tweet original para retuitear"}`, user)
+	assert.Equal(t, http.StatusCreated, code)
+
+	t.Run("Retweet Idempotente", func(t *testing.T) {
+		code, retweet := send("POST", fmt.Sprintf("/tweets/%d/retweet", original.ID), "", user)
+		assert.Equal(t, http.StatusCreated, code)
+		assert.Equal(t, user.Username, retweet.Username)
+		assert.Equal(t, original.ID, *retweet.RetweetOfTweetID)
+		assert.Equal(t, "Tweet original para retuitear", retweet.RetweetedTweet.Content)
+		assert.Equal(t, 1, retweet.RetweetedTweet.RetweetCount)
+
+		// Retuitear de nuevo, o retuitear el retweet, devuelve el mismo retweet
+		code, again := send("POST", fmt.Sprintf("/tweets/%d/retweet", retweet.ID), "", user)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, retweet.ID, again.ID)
+		assert.Equal(t, 1, again.RetweetedTweet.RetweetCount)
+	})
+
+	t.Run("Timeline del Usuario sin Duplicados", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/tweets/user/"+user.Username, nil)
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var page struct {
+			Tweets []TweetResponse `json:"tweets"`
+		}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+
+		// El retweet es más nuevo que el original, por lo que el original no se repite
+		occurrences := 0
+		for _, tweet := range page.Tweets {
+			if tweet.ID == original.ID || (tweet.RetweetOfTweetID != nil && *tweet.RetweetOfTweetID == original.ID) {
+				occurrences++
+			}
+		}
+		assert.Equal(t, 1, occurrences)
+		assert.NotNil(t, page.Tweets[0].RetweetedTweet)
+	})
+
+	t.Run("Páginas Completas sin Duplicados", func(t *testing.T) {
+		// El original y su retweet caen en páginas distintas: solo aparece el retweet y cada página
+		// trae limit tweets
+		code, other := send("POST", "/tweets", `{"content":"Otro tweet para paginar"}`, user)
+		assert.Equal(t, http.StatusCreated, code)
+		code, _ = send("POST", fmt.Sprintf("/tweets/%d/retweet", other.ID), "", user)
+		assert.Equal(t, http.StatusCreated, code)
+
+		seen := make(map[uint]bool)
+		cursor := ""
+		for pages := 0; pages < 100; pages++ {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/tweets/user/"+user.Username+"?limit=1&cursor="+cursor, nil)
+			router.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusOK, w.Code)
+
+			var page struct {
+				Tweets     []TweetResponse `json:"tweets"`
+				NextCursor string          `json:"next_cursor"`
+			}
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+			assert.Len(t, page.Tweets, 1)
+			for _, tweet := range page.Tweets {
+				originalID := tweet.ID
+				if tweet.RetweetOfTweetID != nil {
+					originalID = *tweet.RetweetOfTweetID
+				}
+				assert.False(t, seen[originalID], "El tweet %d se repite", originalID)
+				seen[originalID] = true
+			}
+			if page.NextCursor == "" {
+				break
+			}
+			cursor = page.NextCursor
+		}
+		assert.True(t, seen[other.ID])
+		assert.True(t, seen[original.ID])
+	})
+
+	t.Run("Cita", func(t *testing.T) {
+		body := fmt.Sprintf(`{"content":"Mi opinión","quoted_tweet_id":%d}`, original.ID)
+		code, quote := send("POST", "/tweets", body, user)
+		assert.Equal(t, http.StatusCreated, code)
+		assert.Equal(t, "Mi opinión", quote.Content)
+		assert.Equal(t, original.ID, *quote.QuotedTweetID)
+		assert.Equal(t, "Tweet original para retuitear", quote.QuotedTweet.Content)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/tweets/%d", quote.ID), nil)
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"quoted_tweet"`)
+
+		// Citar un tweet inexistente o con más de 280 caracteres
+		code, _ = send("POST", "/tweets", `{"content":"cita","quoted_tweet_id":2147483647}`, user)
+		assert.Equal(t, http.StatusNotFound, code)
+		code, _ = send("POST", "/tweets", `{"content":"`+strings.Repeat("á", 281)+`"}`, user)
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+
+	t.Run("Quitar Retweet", func(t *testing.T) {
+		code, _ := send("DELETE", fmt.Sprintf("/tweets/%d/retweet", original.ID), "", user)
+		assert.Equal(t, http.StatusOK, code)
+		code, _ = send("DELETE", fmt.Sprintf("/tweets/%d/retweet", original.ID), "", user)
+		assert.Equal(t, http.StatusOK, code)
+
+		var stored domain.Tweet
+		assert.NoError(t, testDB.First(&stored, original.ID).Error)
+		assert.Equal(t, 0, stored.RetweetCount)
+	})
+
+	t.Run("Eliminar Original Elimina Retweets", func(t *testing.T) {
+		code, retweet := send("POST", fmt.Sprintf("/tweets/%d/retweet", original.ID), "", user)
+		assert.Equal(t, http.StatusCreated, code)
+
+		code, _ = send("DELETE", fmt.Sprintf("/tweets/%d", original.ID), "", user)
+		assert.Equal(t, http.StatusOK, code)
+
+		var count int64
+		testDB.Model(&domain.Tweet{}).Where("id = ?", retweet.ID).Count(&count)
+		assert.Equal(t, int64(0), count)
+	})
+
+	t.Run("Retweet de Tweet Inexistente", func(t *testing.T) {
+		code, _ := send("POST", "/tweets/2147483647/retweet", "", user)
+		assert.Equal(t, http.StatusNotFound, code)
+	})
+}
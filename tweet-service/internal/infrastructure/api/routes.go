@@ -2,17 +2,45 @@
 package api
 
 import (
+	"github.com/DevOpslp/microblogging-platform/auth"
+	"github.com/DevOpslp/microblogging-platform/pagination"
 	"github.com/DevOpslp/microblogging-platform/tweet-service/internal/infrastructure/persistence"
 	"github.com/gin-gonic/gin"
 )
 
-func SetupRoutes(router *gin.Engine, tweetRepo *persistence.TweetRepository) {
-	handler := NewTweetHandler(tweetRepo)
+func SetupRoutes(router *gin.Engine, tweetRepo *persistence.TweetRepository, tokens *auth.TokenManager, cursors *pagination.Signer) {
+	handler := NewTweetHandler(tweetRepo, cursors)
 
-	router.POST("/tweets", handler.CreateTweet)
-	router.GET("/tweets", handler.GetAllTweets) // Nueva ruta para obtener todos los tweets
-	router.GET("/tweets/:id", handler.GetTweet)
-	router.GET("/tweets/user/:username", handler.GetTweetsByUser)
-	router.DELETE("/tweets/:id", handler.DeleteTweet)
+	// Lecturas públicas; con un token se incluyen los tweets de las cuentas privadas que sigue el usuario
+	public := router.Group("/", auth.OptionalMiddleware(tokens))
+	public.GET("/tweets", handler.GetAllTweets) // Nueva ruta para obtener todos los tweets
+	public.GET("/tweets/count", handler.CountUserTweets)
+	public.GET("/tweets/engagement", handler.GetTweetEngagement)
+	public.GET("/tweets/:id", handler.GetTweet)
+	public.GET("/tweets/:id/thread", handler.GetThread)
+	public.GET("/tweets/:id/history", handler.GetTweetHistory)
+	public.GET("/tweets/:id/likes", handler.GetTweetLikes)
+	public.GET("/tweets/user/:username", handler.GetTweetsByUser)
+	public.GET("/tweets/mentions/:username", handler.GetMentionTweets)
+	public.GET("/users/:username/likes", handler.GetUserLikes)
+	public.GET("/hashtags/:tag/tweets", handler.GetHashtagTweets)
+	public.GET("/search/tweets", handler.SearchTweets)
+	public.GET("/media/:id", handler.GetMedia)
+	public.GET("/media/:id/thumbnail", handler.GetMediaThumbnail)
 
+	// Acciones que requieren un usuario autenticado
+	authenticated := router.Group("/", auth.Middleware(tokens))
+	authenticated.POST("/tweets", handler.CreateTweet)
+	authenticated.POST("/media", handler.UploadMedia)
+	authenticated.PATCH("/tweets/:id", handler.EditTweet)
+	authenticated.DELETE("/tweets/:id", handler.DeleteTweet)
+	authenticated.POST("/tweets/:id/restore", handler.RestoreTweet)
+	authenticated.POST("/tweets/:id/like", handler.LikeTweet)
+	authenticated.DELETE("/tweets/:id/like", handler.UnlikeTweet)
+	authenticated.POST("/tweets/:id/retweet", handler.Retweet)
+	authenticated.DELETE("/tweets/:id/retweet", handler.Unretweet)
+
+	// Consultas de otros servicios que revelan la actividad de los usuarios
+	internal := router.Group("/", auth.ServiceMiddleware(tokens))
+	internal.GET("/tweets/affinity/:username", handler.GetAuthorAffinity)
 }
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/DevOpslp/microblogging-platform/tweet-service/internal/domain"
+	"github.com/gin-gonic/gin"
+)
+
+// SearchTweets busca tweets con el lenguaje de domain.ParseSearchQuery en el parámetro `q`.
+// `sort` puede ser `relevance` (por defecto) o `recent`.
+func (h *TweetHandler) SearchTweets(c *gin.Context) {
+	search, err := domain.ParseSearchQuery(c.Query("q"))
+	if err != nil {
+		if errors.Is(err, domain.ErrEmptySearchQuery) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Falta el parámetro q"})
+		} else {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	sort := c.DefaultQuery("sort", domain.SearchSortRelevance)
+	if sort != domain.SearchSortRelevance && sort != domain.SearchSortRecent {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sort debe ser relevance o recent"})
+		return
+	}
+
+	cursor, limit, err := parseSearchPageParams(c, h.cursors)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tweets, next, err := h.repo.SearchTweets(search, sort, viewerFrom(c), cursor, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudieron buscar los tweets"})
+		return
+	}
+
+	response := make([]TweetResponse, 0, len(tweets))
+	for _, tweet := range tweets {
+		response = append(response, formatTweetResponse(tweet))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"query": c.Query("q"), "sort": sort, "tweets": response, "next_cursor": encodeSearchCursor(h.cursors, next)})
+}
@@ -0,0 +1,125 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchTweets(t *testing.T) {
+	setupTestDB()
+
+	user, err := getRandomUser()
+	assert.NoError(t, err, "Debe haber al menos un usuario en user-service para realizar la prueba")
+
+	router := setupTestRouter()
+
+	// Palabra única por ejecución para no mezclar tweets de pruebas anteriores
+	marker := fmt.Sprintf("marca%d", time.Now().UnixNano())
+
+	contents := []string{
+		"Los gatos duermen " + marker,
+		"Un gato negro " + marker + " #felinos",
+		"Los perros ladran " + marker,
+		"El gato y el perro juegan juntos en el patio " + marker,
+		"gato gato gato " + marker,
+	}
+	ids := make([]uint, 0, len(contents))
+	for _, content := range contents {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/tweets", bytes.NewBufferString(fmt.Sprintf(`{"content":%q}`, content)))
+		req.Header.Set("Authorization", bearerFor(user))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusCreated, w.Code)
+
+		var tweet TweetResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &tweet))
+		ids = append(ids, tweet.ID)
+	}
+
+	search := func(query string) (int, []uint, string) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/search/tweets?"+query, nil)
+		router.ServeHTTP(w, req)
+
+		var page struct {
+			Tweets     []TweetResponse `json:"tweets"`
+			NextCursor string          `json:"next_cursor"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &page)
+		found := make([]uint, 0, len(page.Tweets))
+		for _, tweet := range page.Tweets {
+			found = append(found, tweet.ID)
+		}
+		return w.Code, found, page.NextCursor
+	}
+	searchIDs := func(q string) []uint {
+		code, found, _ := search("sort=recent&q=" + url.QueryEscape(q))
+		assert.Equal(t, http.StatusOK, code, q)
+		return found
+	}
+
+	t.Run("Lenguaje de Búsqueda", func(t *testing.T) {
+		tomorrow := time.Now().UTC().AddDate(0, 0, 1).Format("2006-01-02")
+		cases := []struct {
+			query    string
+			expected []uint
+		}{
+			// Los plurales se normalizan: "gatos" también coincide con "gato"
+			{"gato " + marker, []uint{ids[4], ids[3], ids[1], ids[0]}},
+			{`"gato negro" ` + marker, []uint{ids[1]}},
+			{marker + " -perros", []uint{ids[4], ids[1], ids[0]}},
+			{marker + ` -"gato negro" gato`, []uint{ids[4], ids[3], ids[0]}},
+			{marker + " #Felinos", []uint{ids[1]}},
+			{marker + " -#felinos -gato", []uint{ids[2]}},
+			{marker + " from:" + user.Username, []uint{ids[4], ids[3], ids[2], ids[1], ids[0]}},
+			{marker + " -from:@" + user.Username, []uint{}},
+			{marker + " from:usuario_que_no_existe", []uint{}},
+			{marker + " since:" + tomorrow, []uint{}},
+			{marker + " until:" + tomorrow + " perros", []uint{ids[3], ids[2]}},
+		}
+		for _, tc := range cases {
+			assert.Equal(t, tc.expected, searchIDs(tc.query), tc.query)
+		}
+	})
+
+	t.Run("Ordenar por Relevancia", func(t *testing.T) {
+		code, found, _ := search("q=" + url.QueryEscape("gato "+marker))
+		assert.Equal(t, http.StatusOK, code)
+		if assert.Len(t, found, 4) {
+			assert.Equal(t, ids[4], found[0], "El tweet que más repite la palabra es el más relevante")
+		}
+	})
+
+	t.Run("Paginar", func(t *testing.T) {
+		for _, sort := range []string{"recent", "relevance"} {
+			var all []uint
+			cursor := ""
+			for page := 0; page < 5; page++ {
+				code, found, next := search("limit=2&sort=" + sort + "&q=" + url.QueryEscape("gato "+marker) + "&cursor=" + cursor)
+				assert.Equal(t, http.StatusOK, code)
+				all = append(all, found...)
+				if next == "" {
+					break
+				}
+				cursor = next
+			}
+			assert.ElementsMatch(t, []uint{ids[0], ids[1], ids[3], ids[4]}, all, sort)
+		}
+	})
+
+	t.Run("Búsquedas Inválidas", func(t *testing.T) {
+		for _, query := range []string{"", "q=", "q=%20%22%22", "q=hola&sort=popular", "q=since%3Aayer", "q=hola&cursor=invalido"} {
+			code, _, _ := search(query)
+			assert.Equal(t, http.StatusBadRequest, code, query)
+		}
+	})
+}
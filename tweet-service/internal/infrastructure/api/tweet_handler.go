@@ -2,64 +2,195 @@
 package api
 
 import (
+	"errors"
+	"log"
 	"net/http"
 	"strconv"
 	"time"
 
+	"github.com/DevOpslp/microblogging-platform/auth"
+	"github.com/DevOpslp/microblogging-platform/pagination"
 	"github.com/DevOpslp/microblogging-platform/tweet-service/internal/domain"
 	"github.com/DevOpslp/microblogging-platform/tweet-service/internal/infrastructure/persistence"
 	"github.com/gin-gonic/gin"
 )
 
 type TweetHandler struct {
-	repo *persistence.TweetRepository
+	repo    *persistence.TweetRepository
+	cursors *pagination.Signer
 }
 
 type TweetResponse struct {
-	ID        uint   `json:"id"`
-	Username  string `json:"username"`
-	Content   string `json:"content"`
-	CreatedAt string `json:"created_at"`
-	UpdatedAt string `json:"updated_at"`
+	ID               uint            `json:"id"`
+	Username         string          `json:"username"`
+	Content          string          `json:"content"`
+	Entities         domain.Entities `json:"entities"`
+	InReplyToTweetID *uint           `json:"in_reply_to_tweet_id"`
+	ConversationID   uint            `json:"conversation_id"`
+	RetweetOfTweetID *uint           `json:"retweet_of_tweet_id,omitempty"`
+	RetweetedTweet   *TweetResponse  `json:"retweeted_tweet,omitempty"`
+	QuotedTweetID    *uint           `json:"quoted_tweet_id,omitempty"`
+	QuotedTweet      *TweetResponse  `json:"quoted_tweet,omitempty"`
+	Media            []MediaResponse `json:"media,omitempty"`
+	ReplyCount       int             `json:"reply_count"`
+	LikeCount        int             `json:"like_count"`
+	RetweetCount     int             `json:"retweet_count"`
+	Edited           bool            `json:"edited"`
+	RevisionCount    int             `json:"revision_count"`
+	EditedAt         *string         `json:"edited_at,omitempty"`
+	Deleted          bool            `json:"deleted,omitempty"`
+	CreatedAt        string          `json:"created_at"`
+	UpdatedAt        string          `json:"updated_at"`
 }
 
+// Respuesta dentro de un hilo; Depth es relativa al tweet consultado (1 para respuestas directas)
+type ThreadReplyResponse struct {
+	TweetResponse
+	Depth int `json:"depth"`
+}
+
+type ThreadResponse struct {
+	Tweet      TweetResponse         `json:"tweet"`
+	Ancestors  []TweetResponse       `json:"ancestors"`
+	Replies    []ThreadReplyResponse `json:"replies"`
+	NextCursor string                `json:"next_cursor"`
+}
+
+// formatTweetResponse arma la respuesta de un tweet; en retweets y citas embebe el tweet original.
+// Un retweet se atribuye a quien retuiteó (Username) y muestra el contenido del original en RetweetedTweet.
+// Un tweet eliminado (tombstone) se muestra con Deleted y un texto fijo en lugar del contenido.
 func formatTweetResponse(tweet domain.TweetWithUser) TweetResponse {
-	return TweetResponse{
-		ID:        tweet.ID,
-		Username:  tweet.Username,
-		Content:   tweet.Content,
-		CreatedAt: tweet.CreatedAt.Format(time.RFC3339),
-		UpdatedAt: tweet.UpdatedAt.Format(time.RFC3339),
+	response := TweetResponse{
+		ID:               tweet.ID,
+		Username:         tweet.Username,
+		Content:          tweet.Content,
+		Entities:         tweet.Entities(),
+		InReplyToTweetID: tweet.InReplyToTweetID,
+		ConversationID:   tweet.RootID(),
+		RetweetOfTweetID: tweet.RetweetOfTweetID,
+		QuotedTweetID:    tweet.QuotedTweetID,
+		ReplyCount:       tweet.ReplyCount,
+		LikeCount:        tweet.LikeCount,
+		RetweetCount:     tweet.RetweetCount,
+		Edited:           tweet.Edited(),
+		RevisionCount:    tweet.RevisionCount(),
+		CreatedAt:        tweet.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:        tweet.UpdatedAt.Format(time.RFC3339),
+	}
+	if tweet.IsDeleted() {
+		response.Content = domain.TombstoneText
+		response.Deleted = true
+	}
+	if tweet.EditedAt != nil {
+		editedAt := tweet.EditedAt.Format(time.RFC3339)
+		response.EditedAt = &editedAt
 	}
+	for _, item := range tweet.Media {
+		response.Media = append(response.Media, formatMediaResponse(item))
+	}
+	if tweet.Retweeted != nil {
+		retweeted := formatTweetResponse(*tweet.Retweeted)
+		response.RetweetedTweet = &retweeted
+	}
+	if tweet.Quoted != nil {
+		quoted := formatTweetResponse(*tweet.Quoted)
+		response.QuotedTweet = &quoted
+	}
+	return response
 }
 
-func NewTweetHandler(repo *persistence.TweetRepository) *TweetHandler {
-	return &TweetHandler{repo: repo}
+func NewTweetHandler(repo *persistence.TweetRepository, cursors *pagination.Signer) *TweetHandler {
+	return &TweetHandler{repo: repo, cursors: cursors}
 }
 
 func (h *TweetHandler) CreateTweet(c *gin.Context) {
 	var body struct {
-		Content string `json:"content" binding:"required"`
+		Content          string                   `json:"content" binding:"max=280"`
+		InReplyToTweetID *uint                    `json:"in_reply_to_tweet_id"`
+		ConversationID   *uint                    `json:"conversation_id"`
+		QuotedTweetID    *uint                    `json:"quoted_tweet_id"`
+		Media            []domain.MediaAttachment `json:"media" binding:"dive"`
 	}
 
-	if err := c.ShouldBindJSON(&body); err != nil {
+	// El contenido puede quedar vacío solo si el tweet tiene imágenes
+	if err := c.ShouldBindJSON(&body); err != nil || (body.Content == "" && len(body.Media) == 0) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Contenido del tweet inválido"})
 		return
 	}
+	if err := domain.ValidateAttachments(body.Media); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	username := c.GetHeader("Username")
-	if username == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Username no proporcionado en el header"})
+	if body.InReplyToTweetID != nil && *body.InReplyToTweetID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "in_reply_to_tweet_id inválido"})
+		return
+	}
+	if body.QuotedTweetID != nil && *body.QuotedTweetID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "quoted_tweet_id inválido"})
+		return
+	}
+
+	if body.ConversationID != nil && body.InReplyToTweetID == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "conversation_id requiere in_reply_to_tweet_id"})
 		return
 	}
 
-	tweet, err := h.repo.CreateTweet(username, body.Content)
+	// Solo se puede responder a tweets visibles para el usuario
+	if body.InReplyToTweetID != nil {
+		parent := h.findVisibleTweet(c, *body.InReplyToTweetID, "Tweet respondido no encontrado")
+		if parent == nil {
+			return
+		}
+		// conversation_id es opcional; si se envía debe coincidir con la conversación del tweet respondido
+		if body.ConversationID != nil && parent.RootID() != *body.ConversationID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "conversation_id no coincide con el tweet respondido"})
+			return
+		}
+	}
+	if body.QuotedTweetID != nil {
+		quoted := h.findVisibleTweet(c, *body.QuotedTweetID, "Tweet citado no encontrado")
+		if quoted == nil || !checkShareable(c, quoted) {
+			return
+		}
+	}
+
+	// Usuario autenticado por auth.Middleware
+	userID, username := auth.UserID(c), auth.Username(c)
+
+	tweet, err := h.repo.CreateTweet(userID, username, body.Content, body.InReplyToTweetID, body.QuotedTweetID, body.Media)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo crear el tweet"})
+		switch {
+		case errors.Is(err, persistence.ErrParentNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Tweet respondido no encontrado"})
+		case errors.Is(err, persistence.ErrQuotedNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Tweet citado no encontrado"})
+		case errors.Is(err, persistence.ErrMediaNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Imagen no encontrada o ya adjunta a otro tweet"})
+		case errors.Is(err, persistence.ErrReplyBlocked):
+			c.JSON(http.StatusForbidden, gin.H{"error": "No puedes responder a este usuario"})
+		case errors.Is(err, persistence.ErrMentionBlocked):
+			c.JSON(http.StatusForbidden, gin.H{"error": "No puedes mencionar a un usuario que te bloqueó"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo crear el tweet"})
+		}
 		return
 	}
 
-	c.JSON(http.StatusCreated, tweet)
+	c.JSON(http.StatusCreated, formatTweetResponse(h.withReferences(*tweet, username)))
+}
+
+// withReferences embebe el tweet citado o retuiteado en la respuesta. El tweet ya está guardado; si algo
+// falla solo se registra el error. timeline-service y notification-service reciben el tweet por el
+// evento TweetCreated que el repositorio guardó en la outbox.
+func (h *TweetHandler) withReferences(tweet domain.Tweet, username string) domain.TweetWithUser {
+	created := domain.TweetWithUser{Tweet: tweet, Username: username}
+	if hydrated, err := h.repo.Hydrate(tweet); err != nil {
+		log.Printf("No se pudieron embeber los tweets referenciados por %d: %v", tweet.ID, err)
+	} else {
+		created = *hydrated
+	}
+	return created
 }
 
 func (h *TweetHandler) GetTweet(c *gin.Context) {
@@ -69,29 +200,78 @@ func (h *TweetHandler) GetTweet(c *gin.Context) {
 		return
 	}
 
-	tweet, err := h.repo.GetTweetByID(uint(id))
+	// Los tweets de cuentas privadas responden 404 a quien no sigue al autor
+	tweet := h.findVisibleTweet(c, uint(id), "Tweet no encontrado")
+	if tweet == nil {
+		return
+	}
+
+	c.JSON(http.StatusOK, formatTweetResponse(*tweet))
+}
+
+// GetThread devuelve los ancestros de un tweet y una página de sus respuestas ordenadas por profundidad
+func (h *TweetHandler) GetThread(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de tweet inválido"})
+		return
+	}
+
+	cursor, limit, err := parseThreadPageParams(c, h.cursors)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Tweet no encontrado"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	thread, next, err := h.repo.GetThread(uint(id), viewerFrom(c), cursor, limit)
+	if err != nil {
+		if errors.Is(err, persistence.ErrTweetNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Tweet no encontrado"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo obtener el hilo"})
+		}
 		return
 	}
 
-	c.JSON(http.StatusOK, tweet)
+	response := ThreadResponse{
+		Tweet:      formatTweetResponse(thread.Tweet),
+		Ancestors:  make([]TweetResponse, 0, len(thread.Ancestors)),
+		Replies:    make([]ThreadReplyResponse, 0, len(thread.Replies)),
+		NextCursor: encodeThreadCursor(h.cursors, next),
+	}
+	for _, ancestor := range thread.Ancestors {
+		response.Ancestors = append(response.Ancestors, formatTweetResponse(ancestor))
+	}
+	for _, reply := range thread.Replies {
+		response.Replies = append(response.Replies, ThreadReplyResponse{
+			TweetResponse: formatTweetResponse(reply),
+			Depth:         reply.Depth - thread.Tweet.Depth,
+		})
+	}
+
+	c.JSON(http.StatusOK, response)
 }
 
 func (h *TweetHandler) GetAllTweets(c *gin.Context) {
-	tweets, err := h.repo.GetAllTweets()
+	cursor, limit, err := parsePageParams(c, h.cursors)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tweets, next, err := h.repo.GetAllTweets(viewerFrom(c), cursor, limit)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudieron obtener los tweets"})
 		return
 	}
 
 	// Formatear cada tweet para la respuesta
-	var response []TweetResponse
+	response := make([]TweetResponse, 0, len(tweets))
 	for _, tweet := range tweets {
 		response = append(response, formatTweetResponse(tweet))
 	}
 
-	c.JSON(http.StatusOK, response)
+	c.JSON(http.StatusOK, gin.H{"tweets": response, "next_cursor": encodeCursor(h.cursors, next)})
 }
 
 func (h *TweetHandler) GetTweetsByUser(c *gin.Context) {
@@ -101,13 +281,46 @@ func (h *TweetHandler) GetTweetsByUser(c *gin.Context) {
 		return
 	}
 
-	tweets, err := h.repo.GetTweetsByUsername(username)
+	cursor, limit, err := parsePageParams(c, h.cursors)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudieron obtener los tweets"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tweets, next, err := h.repo.GetTweetsByUsername(username, viewerFrom(c), cursor, limit)
+	if err != nil {
+		if errors.Is(err, persistence.ErrPrivateAccount) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Esta cuenta es privada"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudieron obtener los tweets"})
+		}
+		return
+	}
+
+	// Formatear cada tweet igual que en GET /tweets; los retweets se atribuyen al usuario que retuiteó
+	response := make([]TweetResponse, 0, len(tweets))
+	for _, tweet := range tweets {
+		response = append(response, formatTweetResponse(tweet))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tweets": response, "next_cursor": encodeCursor(h.cursors, next)})
+}
+
+// CountUserTweets devuelve la cantidad de tweets (incluidos los retweets) de ?user_id=, usado por
+// user-service para los perfiles
+func (h *TweetHandler) CountUserTweets(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Query("user_id"), 10, 64)
+	if err != nil || userID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de usuario inválido"})
 		return
 	}
 
-	c.JSON(http.StatusOK, tweets)
+	count, err := h.repo.CountTweetsByUser(uint(userID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudieron contar los tweets"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"user_id": userID, "tweet_count": count})
 }
 
 func (h *TweetHandler) DeleteTweet(c *gin.Context) {
@@ -117,10 +330,32 @@ func (h *TweetHandler) DeleteTweet(c *gin.Context) {
 		return
 	}
 
-	if err := h.repo.DeleteTweetByID(uint(id)); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo eliminar el tweet"})
+	tweet, err := h.repo.GetTweetByID(uint(id))
+	if err != nil {
+		respondDeleteError(c, err)
+		return
+	}
+
+	// Solo el autor del tweet o un administrador pueden eliminarlo
+	isAuthor := tweet.UserID == auth.UserID(c)
+	if !isAuthor && auth.Role(c) != auth.RoleAdmin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "No tienes permiso para eliminar este tweet"})
+		return
+	}
+
+	// Los retweets del tweet se eliminan con él
+	if _, err := h.repo.DeleteTweetByID(tweet.ID, auth.UserID(c)); err != nil {
+		respondDeleteError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Tweet eliminado exitosamente"})
 }
+
+func respondDeleteError(c *gin.Context, err error) {
+	if errors.Is(err, persistence.ErrTweetNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tweet no encontrado"})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo eliminar el tweet"})
+}
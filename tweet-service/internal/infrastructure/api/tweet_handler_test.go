@@ -6,10 +6,16 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"math"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/DevOpslp/microblogging-platform/auth"
+	"github.com/DevOpslp/microblogging-platform/events"
+	"github.com/DevOpslp/microblogging-platform/pagination"
 	"github.com/DevOpslp/microblogging-platform/tweet-service/internal/domain"
 	"github.com/DevOpslp/microblogging-platform/tweet-service/internal/infrastructure/persistence"
 	"github.com/gin-gonic/gin"
@@ -20,6 +26,21 @@ import (
 
 var testDB *gorm.DB
 
+var (
+	tokens  = auth.NewTokenManager("secreto-de-prueba")
+	cursors = pagination.NewSigner("secreto-de-prueba")
+)
+
+// bearerFor genera el header Authorization de un usuario para las pruebas
+func bearerFor(user *domain.User) string {
+	return bearerWithRole(user, auth.RoleUser)
+}
+
+func bearerWithRole(user *domain.User, role string) string {
+	token, _ := tokens.IssueAccessToken(user.ID, user.Username, role)
+	return auth.BearerHeader(token)
+}
+
 func setupTestDB() {
 	dsn := "host=localhost user=devuser password=devpassword dbname=tweetdb port=5432 sslmode=disable"
 	var err error
@@ -29,15 +50,25 @@ func setupTestDB() {
 	}
 
 	// Migración automática de la base de datos para el modelo Tweet
-	testDB.AutoMigrate(&domain.Tweet{})
+	testDB.AutoMigrate(&domain.Tweet{}, &domain.Like{}, &domain.TweetHashtag{}, &domain.TweetMention{}, &domain.Media{}, &domain.TweetRevision{}, &events.OutboxEvent{})
+	if err := persistence.MigrateSearch(testDB); err != nil {
+		log.Fatalf("Error al crear el índice de búsqueda de pruebas: %v", err)
+	}
+	if err := persistence.MigrateContentIndex(testDB); err != nil {
+		log.Fatalf("Error al crear el índice de contenido de pruebas: %v", err)
+	}
 }
 
 func setupTestRouter() *gin.Engine {
 	gin.SetMode(gin.TestMode)
-	userRepo := persistence.NewHTTPUserRepository("http://localhost:8080")
-	tweetRepo := persistence.NewTweetRepository(testDB, userRepo)
+	userRepo := persistence.NewHTTPUserRepository("http://localhost:8080", tokens)
+	blobs, err := persistence.NewFilesystemBlobStore(filepath.Join(os.TempDir(), "tweet-service-media"))
+	if err != nil {
+		log.Fatalf("Error al preparar el directorio de imágenes de pruebas: %v", err)
+	}
+	tweetRepo := persistence.NewTweetRepository(testDB, userRepo, blobs)
 	router := gin.Default()
-	SetupRoutes(router, tweetRepo)
+	SetupRoutes(router, tweetRepo, tokens, cursors)
 	return router
 }
 
@@ -82,12 +113,37 @@ func TestTweetFlowWithHTTPUserRepo(t *testing.T) {
 
 	router := setupTestRouter()
 
+	// createTweet publica un tweet como el usuario indicado y devuelve su ID
+	createTweet := func(t *testing.T, user *domain.User, content string) uint {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/tweets", bytes.NewBufferString(`{"content":"`+content+`"}`))
+		req.Header.Set("Authorization", bearerFor(user))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusCreated, w.Code)
+
+		var created TweetResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+		return created.ID
+	}
+
+	deleteTweet := func(tweetID uint, authorization string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("DELETE", fmt.Sprintf("/tweets/%d", tweetID), nil)
+		req.Header.Set("Authorization", authorization)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// Usuario distinto del autor, no necesita existir en user-service porque solo se valida el token
+	stranger := &domain.User{ID: user1.ID + 1000, Username: "desconocido"}
+
 	// Crear un tweet para user1
 	t.Run("Crear Tweet para User1", func(t *testing.T) {
 		w := httptest.NewRecorder()
 		reqBody := `{"content":"Tweet de prueba para user1"}`
 		req, _ := http.NewRequest("POST", "/tweets", bytes.NewBufferString(reqBody))
-		req.Header.Set("Username", user1.Username)
+		req.Header.Set("Authorization", bearerFor(user1))
 		req.Header.Set("Content-Type", "application/json")
 
 		router.ServeHTTP(w, req)
@@ -101,7 +157,7 @@ func TestTweetFlowWithHTTPUserRepo(t *testing.T) {
 		w := httptest.NewRecorder()
 		reqBody := `{"content":"Tweet de prueba para user2"}`
 		req, _ := http.NewRequest("POST", "/tweets", bytes.NewBufferString(reqBody))
-		req.Header.Set("Username", user2.Username)
+		req.Header.Set("Authorization", bearerFor(user2))
 		req.Header.Set("Content-Type", "application/json")
 
 		router.ServeHTTP(w, req)
@@ -133,17 +189,167 @@ func TestTweetFlowWithHTTPUserRepo(t *testing.T) {
 		assert.Contains(t, w.Body.String(), "Tweet de prueba para user2")
 	})
 
-	// Eliminar tweet de user1
-	t.Run("Eliminar Tweet de User1", func(t *testing.T) {
-		// Obtener ID de un tweet de user1
-		tweetID := uint(1) // Reemplaza con un ID válido o realiza un GET para obtener un tweet específico.
+	// Paginar todos los tweets de a uno usando next_cursor
+	t.Run("Paginar Todos los Tweets", func(t *testing.T) {
 		w := httptest.NewRecorder()
-		req, _ := http.NewRequest("DELETE", fmt.Sprintf("/tweets/%d", tweetID), nil)
-		req.Header.Set("Username", user1.Username)
+		req, _ := http.NewRequest("GET", "/tweets?limit=1", nil)
+		router.ServeHTTP(w, req)
 
+		assert.Equal(t, http.StatusOK, w.Code)
+		var firstPage struct {
+			Tweets     []TweetResponse `json:"tweets"`
+			NextCursor string          `json:"next_cursor"`
+		}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &firstPage))
+		assert.Len(t, firstPage.Tweets, 1)
+		assert.Equal(t, "Tweet de prueba para user2", firstPage.Tweets[0].Content)
+		assert.NotEmpty(t, firstPage.NextCursor)
+
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("GET", "/tweets?limit=1&cursor="+firstPage.NextCursor, nil)
 		router.ServeHTTP(w, req)
 
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "Tweet de prueba para user1")
+	})
+
+	// Rechazar parámetros de paginación inválidos
+	t.Run("Paginación Inválida", func(t *testing.T) {
+		for _, query := range []string{"limit=0", "limit=1000", "cursor=adulterado"} {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/tweets?"+query, nil)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code, query)
+		}
+	})
+
+	// Responder tweets y consultar el hilo de la conversación
+	t.Run("Hilo de Respuestas", func(t *testing.T) {
+		reply := func(t *testing.T, parentID uint, content string) uint {
+			w := httptest.NewRecorder()
+			reqBody := fmt.Sprintf(`{"content":%q,"in_reply_to_tweet_id":%d}`, content, parentID)
+			req, _ := http.NewRequest("POST", "/tweets", bytes.NewBufferString(reqBody))
+			req.Header.Set("Authorization", bearerFor(user1))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusCreated, w.Code)
+
+			var created TweetResponse
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+			return created.ID
+		}
+		getThread := func(t *testing.T, tweetID uint, query string) ThreadResponse {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", fmt.Sprintf("/tweets/%d/thread?%s", tweetID, query), nil)
+			router.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusOK, w.Code)
+
+			var thread ThreadResponse
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &thread))
+			return thread
+		}
+
+		rootID := createTweet(t, user1, "Raíz de la conversación")
+		firstID := reply(t, rootID, "Primera respuesta")
+		secondID := reply(t, rootID, "Segunda respuesta")
+		nestedID := reply(t, firstID, "Respuesta anidada")
+
+		// Ancestros del tweet anidado desde la raíz
+		thread := getThread(t, nestedID, "")
+		assert.Equal(t, nestedID, thread.Tweet.ID)
+		assert.Equal(t, rootID, thread.Tweet.ConversationID)
+		assert.Len(t, thread.Ancestors, 2)
+		assert.Equal(t, rootID, thread.Ancestors[0].ID)
+		assert.Equal(t, firstID, thread.Ancestors[1].ID)
+		assert.Empty(t, thread.Replies)
+
+		// Respuestas de la raíz ordenadas por profundidad, paginadas de a dos
+		thread = getThread(t, rootID, "limit=2")
+		assert.Equal(t, 2, thread.Tweet.ReplyCount)
+		assert.Empty(t, thread.Ancestors)
+		assert.Len(t, thread.Replies, 2)
+		assert.Equal(t, firstID, thread.Replies[0].ID)
+		assert.Equal(t, 1, thread.Replies[0].Depth)
+		assert.Equal(t, 1, thread.Replies[0].ReplyCount)
+		assert.Equal(t, secondID, thread.Replies[1].ID)
+		assert.NotEmpty(t, thread.NextCursor)
+
+		thread = getThread(t, rootID, "limit=2&cursor="+thread.NextCursor)
+		assert.Len(t, thread.Replies, 1)
+		assert.Equal(t, nestedID, thread.Replies[0].ID)
+		assert.Equal(t, 2, thread.Replies[0].Depth)
+		assert.Equal(t, firstID, *thread.Replies[0].InReplyToTweetID)
+		assert.Empty(t, thread.NextCursor)
+
+		// Eliminar una respuesta descuenta el contador del tweet respondido
+		assert.Equal(t, http.StatusOK, deleteTweet(secondID, bearerFor(user1)).Code)
+		thread = getThread(t, rootID, "")
+		assert.Equal(t, 1, thread.Tweet.ReplyCount)
+
+		// Responder a un tweet inexistente
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/tweets", bytes.NewBufferString(`{"content":"huérfano","in_reply_to_tweet_id":2147483647}`))
+		req.Header.Set("Authorization", bearerFor(user1))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		// conversation_id que no coincide con el tweet respondido
+		w = httptest.NewRecorder()
+		reqBody := fmt.Sprintf(`{"content":"mezcla","in_reply_to_tweet_id":%d,"conversation_id":%d}`, nestedID, nestedID)
+		req, _ = http.NewRequest("POST", "/tweets", bytes.NewBufferString(reqBody))
+		req.Header.Set("Authorization", bearerFor(user1))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	// Eliminar tweet de user1
+	t.Run("Eliminar Tweet de User1", func(t *testing.T) {
+		tweetID := createTweet(t, user1, "Tweet de user1 para eliminar")
+
+		w := deleteTweet(tweetID, bearerFor(user1))
+
 		assert.Equal(t, http.StatusOK, w.Code)
 		assert.Contains(t, w.Body.String(), "Tweet eliminado exitosamente")
+
+		// Un tweet ya eliminado no existe
+		w = deleteTweet(tweetID, bearerFor(user1))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	// Otro usuario no puede eliminar el tweet
+	t.Run("Eliminar Tweet Ajeno", func(t *testing.T) {
+		tweetID := createTweet(t, user1, "Tweet de user1 protegido")
+
+		w := deleteTweet(tweetID, bearerFor(stranger))
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		// El tweet sigue existiendo
+		w = httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/tweets/%d", tweetID), nil)
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	// Un administrador puede eliminar cualquier tweet
+	t.Run("Eliminar Tweet como Admin", func(t *testing.T) {
+		tweetID := createTweet(t, user1, "Tweet de user1 moderado")
+
+		w := deleteTweet(tweetID, bearerWithRole(stranger, auth.RoleAdmin))
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	// Eliminar un tweet inexistente
+	t.Run("Eliminar Tweet Inexistente", func(t *testing.T) {
+		w := deleteTweet(math.MaxInt32, bearerFor(user1))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	// Eliminar sin token
+	t.Run("Eliminar Tweet sin Token", func(t *testing.T) {
+		w := deleteTweet(1, "")
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
 	})
 }
@@ -0,0 +1,46 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/DevOpslp/microblogging-platform/auth"
+	"github.com/DevOpslp/microblogging-platform/tweet-service/internal/domain"
+	"github.com/DevOpslp/microblogging-platform/tweet-service/internal/infrastructure/persistence"
+	"github.com/gin-gonic/gin"
+)
+
+// viewerFrom arma el viewer de la petición a partir del token opcional. Los administradores y los
+// otros servicios ven también los tweets de cuentas privadas.
+func viewerFrom(c *gin.Context) domain.Viewer {
+	role := auth.Role(c)
+	return domain.Viewer{UserID: auth.UserID(c), Username: auth.Username(c), All: role == auth.RoleAdmin || role == auth.RoleService}
+}
+
+// findVisibleTweet carga un tweet que el viewer puede ver. Si no existe o está oculto responde 404
+// con el mensaje indicado y devuelve nil.
+func (h *TweetHandler) findVisibleTweet(c *gin.Context, tweetID uint, notFound string) *domain.TweetWithUser {
+	tweet, err := h.repo.GetVisibleTweet(tweetID, viewerFrom(c))
+	if err != nil {
+		if errors.Is(err, persistence.ErrTweetNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": notFound})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo obtener el tweet"})
+		}
+		return nil
+	}
+	return tweet
+}
+
+// checkShareable responde 403 si el tweet (o el original de un retweet) es de otra cuenta privada:
+// retuitearlo o citarlo lo mostraría a usuarios que no siguen al autor
+func checkShareable(c *gin.Context, tweet *domain.TweetWithUser) bool {
+	if tweet.Retweeted != nil {
+		tweet = tweet.Retweeted
+	}
+	if tweet.AuthorPrivate && tweet.UserID != auth.UserID(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Los tweets de cuentas privadas no se pueden compartir"})
+		return false
+	}
+	return true
+}
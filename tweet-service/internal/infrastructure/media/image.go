@@ -0,0 +1,204 @@
+// Package media valida las imágenes subidas, les quita los metadatos y genera sus miniaturas
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	_ "image/png"
+
+	"github.com/DevOpslp/microblogging-platform/tweet-service/internal/domain"
+	xdraw "golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	// ThumbnailSize es el lado máximo en píxeles de las miniaturas
+	ThumbnailSize = 400
+	// MaxPixels limita el tamaño de las imágenes decodificadas, para que un archivo chico muy
+	// comprimido no ocupe cientos de MB de memoria
+	MaxPixels = 40_000_000
+	// MaxAnimationPixels limita la suma de las áreas de todos los cuadros de un GIF, que se decodifican
+	// juntos al quitarle los metadatos
+	MaxAnimationPixels = 100_000_000
+
+	thumbnailQuality = 80
+	reencodeQuality  = 90
+)
+
+// ErrInvalidImage se devuelve cuando el contenido tiene la firma de un tipo aceptado pero no se puede decodificar
+var ErrInvalidImage = errors.New("imagen inválida")
+
+// Processed es una imagen lista para guardar: Data es la imagen sin metadatos en su formato original
+// y Thumbnail una miniatura JPEG. Width y Height son las dimensiones ya aplicada la orientación EXIF.
+type Processed struct {
+	ContentType string
+	Data        []byte
+	Width       int
+	Height      int
+	Thumbnail   []byte
+}
+
+// Process identifica el tipo de imagen por sus magic bytes, le quita los metadatos (EXIF, XMP,
+// comentarios) y genera la miniatura. Las fotos JPEG rotadas con la orientación EXIF se reescriben
+// ya rotadas, porque al quitar el EXIF se perdería la orientación.
+func Process(data []byte) (*Processed, error) {
+	contentType, err := domain.DetectMediaType(data)
+	if err != nil {
+		return nil, err
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > MaxPixels {
+		return nil, fmt.Errorf("%w: dimensiones %dx%d no permitidas", ErrInvalidImage, config.Width, config.Height)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+
+	var stripped []byte
+	switch contentType {
+	case domain.MediaTypeJPEG:
+		orientation := jpegOrientation(data)
+		if orientation == 1 {
+			stripped, err = stripJPEG(data)
+			break
+		}
+		img = orient(img, orientation)
+		var buf bytes.Buffer
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: reencodeQuality})
+		stripped = buf.Bytes()
+	case domain.MediaTypePNG:
+		stripped, err = stripPNG(data)
+	case domain.MediaTypeGIF:
+		stripped, err = stripGIF(data)
+	case domain.MediaTypeWebP:
+		stripped, err = stripWebP(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+
+	thumbnail, err := makeThumbnail(img)
+	if err != nil {
+		return nil, err
+	}
+
+	bounds := img.Bounds()
+	return &Processed{
+		ContentType: contentType,
+		Data:        stripped,
+		Width:       bounds.Dx(),
+		Height:      bounds.Dy(),
+		Thumbnail:   thumbnail,
+	}, nil
+}
+
+// stripGIF decodifica y vuelve a codificar todos los cuadros: la compresión LZW no pierde calidad y
+// se descartan los comentarios y las extensiones de aplicación (salvo la de repetición). Antes de
+// decodificar se comprueba que los cuadros no superen MaxAnimationPixels.
+func stripGIF(data []byte) ([]byte, error) {
+	pixels, err := gifPixels(data)
+	if err != nil {
+		return nil, err
+	}
+	if pixels > MaxAnimationPixels {
+		return nil, fmt.Errorf("la animación tiene %d píxeles, el máximo es %d", pixels, MaxAnimationPixels)
+	}
+
+	animation, err := gif.DecodeAll(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, animation); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// gifPixels recorre los bloques de un GIF sin decodificar los cuadros y devuelve la suma de sus áreas
+func gifPixels(data []byte) (int, error) {
+	const headerLength = 13 // firma, versión y descriptor de pantalla lógica
+	if len(data) < headerLength {
+		return 0, errTruncated
+	}
+	i := headerLength + gifColorTableLength(data[10])
+
+	pixels := 0
+	for {
+		if i >= len(data) {
+			return 0, errTruncated
+		}
+		switch data[i] {
+		case 0x21: // extensión: etiqueta y sub-bloques
+			i += 2
+		case 0x2C: // descriptor de cuadro: posición, tamaño, indicadores y tamaño mínimo de código LZW
+			if i+11 > len(data) {
+				return 0, errTruncated
+			}
+			width := int(binary.LittleEndian.Uint16(data[i+5:]))
+			height := int(binary.LittleEndian.Uint16(data[i+7:]))
+			pixels += width * height
+			i += 10 + gifColorTableLength(data[i+9]) + 1
+		case 0x3B: // fin del archivo
+			return pixels, nil
+		default:
+			return 0, fmt.Errorf("bloque GIF desconocido 0x%02x", data[i])
+		}
+
+		// Los sub-bloques terminan con uno de largo 0
+		for {
+			if i >= len(data) {
+				return 0, errTruncated
+			}
+			size := int(data[i])
+			i += 1 + size
+			if size == 0 {
+				break
+			}
+		}
+	}
+}
+
+// gifColorTableLength devuelve el largo de la tabla de colores indicada en un byte de indicadores
+func gifColorTableLength(flags byte) int {
+	if flags&0x80 == 0 {
+		return 0
+	}
+	return 3 << ((flags & 0x07) + 1)
+}
+
+// makeThumbnail reduce la imagen para que entre en ThumbnailSize x ThumbnailSize sin agrandarla y la
+// codifica en JPEG; las transparencias se pintan sobre fondo blanco
+func makeThumbnail(img image.Image) ([]byte, error) {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width > ThumbnailSize || height > ThumbnailSize {
+		if width >= height {
+			width, height = ThumbnailSize, max(1, height*ThumbnailSize/bounds.Dx())
+		} else {
+			width, height = max(1, width*ThumbnailSize/bounds.Dy()), ThumbnailSize
+		}
+	}
+
+	thumbnail := image.NewRGBA(image.Rect(0, 0, width, height))
+	xdraw.Draw(thumbnail, thumbnail.Bounds(), image.NewUniform(color.White), image.Point{}, xdraw.Src)
+	xdraw.CatmullRom.Scale(thumbnail, thumbnail.Bounds(), img, bounds, xdraw.Over, nil)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, thumbnail, &jpeg.Options{Quality: thumbnailQuality}); err != nil {
+		return nil, fmt.Errorf("no se pudo generar la miniatura: %w", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,210 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/DevOpslp/microblogging-platform/tweet-service/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/image/webp"
+)
+
+func testImage(width, height int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.NRGBA{R: uint8(x * 10), G: uint8(y * 10), B: 100, A: 255})
+		}
+	}
+	return img
+}
+
+// exifSegment arma un segmento APP1 con un EXIF mínimo que solo tiene la orientación
+func exifSegment(orientation uint16) []byte {
+	tiff := []byte{'M', 'M', 0, 42, 0, 0, 0, 8, 0, 1, 0x01, 0x12, 0, 3, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0}
+	binary.BigEndian.PutUint16(tiff[18:], orientation)
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	segment := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+	return append(segment, payload...)
+}
+
+// withJPEGSegments inserta segmentos después del marcador SOI
+func withJPEGSegments(data []byte, segments ...[]byte) []byte {
+	out := append([]byte(nil), data[:2]...)
+	for _, segment := range segments {
+		out = append(out, segment...)
+	}
+	return append(out, data[2:]...)
+}
+
+func pngChunk(chunkType string, data []byte) []byte {
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(len(data)))
+	chunk = append(append(chunk, chunkType...), data...)
+	return binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+}
+
+func TestProcessRejectsInvalidImages(t *testing.T) {
+	_, err := Process([]byte("<svg xmlns='http://www.w3.org/2000/svg'></svg>"))
+	assert.ErrorIs(t, err, domain.ErrUnsupportedMedia)
+
+	// La firma es de PNG pero el contenido no
+	_, err = Process([]byte("\x89PNG\r\n\x1a\nno es una imagen"))
+	assert.ErrorIs(t, err, ErrInvalidImage)
+}
+
+func TestProcessStripsPNGMetadata(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, testImage(10, 10)))
+	original := buf.Bytes()
+
+	// Insertar un chunk tEXt después de IHDR (firma de 8 bytes y chunk de 25)
+	text := pngChunk("tEXt", []byte("Comment\x00Datos privados"))
+	data := append(append(append([]byte(nil), original[:33]...), text...), original[33:]...)
+
+	processed, err := Process(data)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, domain.MediaTypePNG, processed.ContentType)
+	assert.Equal(t, original, processed.Data)
+	assert.Equal(t, 10, processed.Width)
+	assert.Equal(t, 10, processed.Height)
+
+	thumbnail, err := jpeg.Decode(bytes.NewReader(processed.Thumbnail))
+	assert.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 10, 10), thumbnail.Bounds())
+}
+
+func TestProcessJPEG(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, jpeg.Encode(&buf, testImage(20, 10), nil))
+	original := buf.Bytes()
+
+	t.Run("Sin rotación quita el EXIF sin recomprimir", func(t *testing.T) {
+		comment := []byte{0xFF, 0xFE, 0, 7, 'h', 'o', 'l', 'a', '!'}
+		processed, err := Process(withJPEGSegments(original, exifSegment(1), comment))
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, original, processed.Data)
+		assert.Equal(t, 20, processed.Width)
+		assert.Equal(t, 10, processed.Height)
+	})
+
+	t.Run("Descarta los datos después de EOI", func(t *testing.T) {
+		data := append(append([]byte(nil), original...), "datos ocultos"...)
+		processed, err := Process(data)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, original, processed.Data)
+	})
+
+	t.Run("Aplica la orientación antes de quitar el EXIF", func(t *testing.T) {
+		processed, err := Process(withJPEGSegments(original, exifSegment(6)))
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.NotContains(t, string(processed.Data), "Exif")
+		assert.Equal(t, 10, processed.Width)
+		assert.Equal(t, 20, processed.Height)
+
+		rotated, err := jpeg.Decode(bytes.NewReader(processed.Data))
+		assert.NoError(t, err)
+		assert.Equal(t, image.Rect(0, 0, 10, 20), rotated.Bounds())
+	})
+}
+
+func TestProcessWebPStripsEXIF(t *testing.T) {
+	// WebP sin pérdida de 1x1 dentro de un contenedor extendido (VP8X) con un chunk EXIF
+	vp8l := []byte("VP8L\x0d\x00\x00\x00\x2f\x00\x00\x00\x10\x07\x10\x11\x11\x88\x88\xfe\x07\x00")
+	vp8x := []byte("VP8X\x0a\x00\x00\x00\x08\x00\x00\x00\x00\x00\x00\x00\x00\x00")
+	exif := []byte("EXIF\x05\x00\x00\x00datos\x00")
+	body := append(append(append([]byte("WEBP"), vp8x...), exif...), vp8l...)
+	data := binary.LittleEndian.AppendUint32([]byte("RIFF"), uint32(len(body)))
+	data = append(data, body...)
+
+	processed, err := Process(data)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, domain.MediaTypeWebP, processed.ContentType)
+	assert.NotContains(t, string(processed.Data), "EXIF")
+	assert.Equal(t, byte(0), processed.Data[20]&webpFlagEXIF)
+	assert.Equal(t, uint32(len(processed.Data)-8), binary.LittleEndian.Uint32(processed.Data[4:]))
+
+	_, err = webp.Decode(bytes.NewReader(processed.Data))
+	assert.NoError(t, err)
+}
+
+func TestProcessGIFThumbnail(t *testing.T) {
+	palette := color.Palette{color.White, color.Black}
+	frame := image.NewPaletted(image.Rect(0, 0, 800, 200), palette)
+	var buf bytes.Buffer
+	assert.NoError(t, gif.EncodeAll(&buf, &gif.GIF{Image: []*image.Paletted{frame, frame}, Delay: []int{10, 10}}))
+
+	processed, err := Process(buf.Bytes())
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	animation, err := gif.DecodeAll(bytes.NewReader(processed.Data))
+	assert.NoError(t, err)
+	assert.Len(t, animation.Image, 2, "Se conservan todos los cuadros")
+
+	// La miniatura mantiene la proporción dentro de ThumbnailSize
+	thumbnail, err := jpeg.Decode(bytes.NewReader(processed.Thumbnail))
+	assert.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, ThumbnailSize, ThumbnailSize/4), thumbnail.Bounds())
+}
+
+func TestStripGIFRejectsLargeAnimations(t *testing.T) {
+	// Pantalla de 1000x1000 con 101 cuadros del mismo tamaño y sin datos: el archivo es chico pero
+	// decodificarlo ocuparía más de MaxAnimationPixels
+	data := []byte("GIF89a\xe8\x03\xe8\x03\x00\x00\x00")
+	for i := 0; i < 101; i++ {
+		data = append(data, 0x2C, 0, 0, 0, 0, 0xe8, 0x03, 0xe8, 0x03, 0x00, 0x02, 0x00)
+	}
+	data = append(data, 0x3B)
+
+	pixels, err := gifPixels(data)
+	assert.NoError(t, err)
+	assert.Equal(t, 101_000_000, pixels)
+
+	_, err = stripGIF(data)
+	assert.ErrorContains(t, err, "la animación tiene")
+
+	_, err = gifPixels(data[:len(data)-1])
+	assert.ErrorIs(t, err, errTruncated)
+}
+
+func TestOrient(t *testing.T) {
+	// Imagen de 2x1: rojo a la izquierda y azul a la derecha
+	img := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	red, blue := color.NRGBA{R: 255, A: 255}, color.NRGBA{B: 255, A: 255}
+	img.Set(0, 0, red)
+	img.Set(1, 0, blue)
+
+	// Rotada 90° en sentido horario queda de 1x2 con el rojo arriba
+	rotated := orient(img, 6)
+	assert.Equal(t, image.Rect(0, 0, 1, 2), rotated.Bounds())
+	assert.Equal(t, red, rotated.At(0, 0))
+	assert.Equal(t, blue, rotated.At(0, 1))
+
+	// Rotada 90° en sentido antihorario queda con el azul arriba
+	rotated = orient(img, 8)
+	assert.Equal(t, blue, rotated.At(0, 0))
+	assert.Equal(t, red, rotated.At(0, 1))
+
+	mirrored := orient(img, 2)
+	assert.Equal(t, blue, mirrored.At(0, 0))
+	assert.Same(t, img, orient(img, 1))
+}
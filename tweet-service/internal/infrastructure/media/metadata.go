@@ -0,0 +1,265 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+)
+
+var errTruncated = errors.New("archivo truncado")
+
+// jpegSegments recorre los segmentos de un JPEG hasta el marcador EOI. visit recibe el marcador y el
+// segmento completo (marcador y largo incluidos); el segmento SOS incluye además los datos comprimidos
+// que lo siguen. Lo que haya después de EOI no se visita.
+func jpegSegments(data []byte, visit func(marker byte, segment []byte)) error {
+	if len(data) < 2 || data[0] != 0xFF || data[1] != 0xD8 {
+		return errors.New("falta el marcador SOI")
+	}
+
+	i := 2
+	for {
+		// Un marcador es 0xFF seguido de un código; puede haber 0xFF de relleno antes del código
+		if i >= len(data) || data[i] != 0xFF {
+			return errTruncated
+		}
+		for i < len(data) && data[i] == 0xFF {
+			i++
+		}
+		if i >= len(data) {
+			return errTruncated
+		}
+		marker := data[i]
+		start := i - 1
+		i++
+
+		switch {
+		case marker == 0xD9: // EOI: fin de la imagen
+			visit(marker, data[start:i])
+			return nil
+		case marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7): // marcadores sin largo
+			visit(marker, data[start:i])
+			continue
+		}
+
+		if i+2 > len(data) {
+			return errTruncated
+		}
+		length := int(binary.BigEndian.Uint16(data[i:]))
+		if length < 2 || i+length > len(data) {
+			return errTruncated
+		}
+		i += length
+		if marker == 0xDA { // SOS: le siguen los datos comprimidos hasta el próximo marcador
+			i = skipEntropyData(data, i)
+		}
+		visit(marker, data[start:i])
+	}
+}
+
+// skipEntropyData avanza sobre los datos comprimidos de un SOS y devuelve la posición del próximo
+// marcador. Dentro de los datos un 0xFF va seguido de 0x00 (byte escapado) o de un marcador RSTn.
+func skipEntropyData(data []byte, i int) int {
+	for ; i < len(data); i++ {
+		if data[i] != 0xFF {
+			continue
+		}
+		next := i + 1
+		for next < len(data) && data[next] == 0xFF {
+			next++
+		}
+		if next >= len(data) {
+			return len(data)
+		}
+		if code := data[next]; code != 0x00 && (code < 0xD0 || code > 0xD7) {
+			return i
+		}
+		i = next
+	}
+	return len(data)
+}
+
+// stripJPEG quita sin recomprimir los segmentos de metadatos: EXIF y XMP (APP1), IPTC (APP13), los
+// comentarios y los segmentos de aplicación de fabricantes. Se conservan JFIF (APP0), el perfil de
+// color ICC (APP2) y el segmento Adobe (APP14), necesarios para mostrar bien los colores. Los datos
+// agregados después de EOI se descartan.
+func stripJPEG(data []byte) ([]byte, error) {
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(data[:2])
+
+	err := jpegSegments(data, func(marker byte, segment []byte) {
+		payload := segment[min(len(segment), 4):]
+		switch {
+		case marker == 0xFE: // COM
+			return
+		case marker == 0xE2 && bytes.HasPrefix(payload, []byte("ICC_PROFILE\x00")):
+		case marker == 0xE0, marker == 0xEE:
+		case marker >= 0xE1 && marker <= 0xEF:
+			return
+		}
+		out.Write(segment)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// jpegOrientation lee la orientación (tag 0x0112) del EXIF de un JPEG; devuelve 1 si no tiene o es inválida
+func jpegOrientation(data []byte) int {
+	orientation := 1
+	_ = jpegSegments(data, func(marker byte, segment []byte) {
+		if marker != 0xE1 || len(segment) < 4 || !bytes.HasPrefix(segment[4:], []byte("Exif\x00\x00")) {
+			return
+		}
+		if value := tiffOrientation(segment[10:]); value >= 1 && value <= 8 {
+			orientation = value
+		}
+	})
+	return orientation
+}
+
+// tiffOrientation busca la orientación en el primer IFD de un bloque TIFF
+func tiffOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 0
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 0
+	}
+	if order.Uint16(tiff[2:]) != 42 {
+		return 0
+	}
+
+	offset := int(order.Uint32(tiff[4:]))
+	if offset < 8 || offset+2 > len(tiff) {
+		return 0
+	}
+	entries := int(order.Uint16(tiff[offset:]))
+	for n := 0; n < entries; n++ {
+		entry := offset + 2 + n*12
+		if entry+12 > len(tiff) {
+			return 0
+		}
+		// Tag 0x0112 de tipo SHORT (3): el valor está en los primeros dos bytes del campo de valor
+		if order.Uint16(tiff[entry:]) == 0x0112 && order.Uint16(tiff[entry+2:]) == 3 {
+			return int(order.Uint16(tiff[entry+8:]))
+		}
+	}
+	return 0
+}
+
+// Chunks de PNG con texto, fecha o EXIF que se descartan
+var pngMetadataChunks = map[string]bool{"eXIf": true, "tEXt": true, "zTXt": true, "iTXt": true, "tIME": true}
+
+// stripPNG quita los chunks de metadatos sin tocar los datos de la imagen
+func stripPNG(data []byte) ([]byte, error) {
+	const signatureLength = 8
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(data[:signatureLength])
+
+	for i := signatureLength; ; {
+		if i+12 > len(data) {
+			return nil, errTruncated
+		}
+		length := int(binary.BigEndian.Uint32(data[i:]))
+		end := i + 12 + length
+		if end > len(data) {
+			return nil, errTruncated
+		}
+
+		chunkType := string(data[i+4 : i+8])
+		if !pngMetadataChunks[chunkType] {
+			out.Write(data[i:end])
+		}
+		if chunkType == "IEND" {
+			return out.Bytes(), nil
+		}
+		i = end
+	}
+}
+
+// Bits del chunk VP8X de WebP que indican la presencia de EXIF y XMP
+const (
+	webpFlagEXIF = 0x08
+	webpFlagXMP  = 0x04
+)
+
+// stripWebP quita los chunks EXIF y XMP del contenedor RIFF y actualiza los indicadores de VP8X y el largo total
+func stripWebP(data []byte) ([]byte, error) {
+	const headerLength = 12
+	out := make([]byte, headerLength, len(data))
+	copy(out, data[:headerLength])
+
+	for i := headerLength; i < len(data); {
+		if i+8 > len(data) {
+			return nil, errTruncated
+		}
+		size := int(binary.LittleEndian.Uint32(data[i+4:]))
+		end := i + 8 + size + size%2 // los chunks se rellenan hasta un largo par
+		if end > len(data) {
+			return nil, errTruncated
+		}
+
+		switch string(data[i : i+4]) {
+		case "EXIF", "XMP ":
+		case "VP8X":
+			chunk := append([]byte(nil), data[i:end]...)
+			if size > 0 {
+				chunk[8] &^= webpFlagEXIF | webpFlagXMP
+			}
+			out = append(out, chunk...)
+		default:
+			out = append(out, data[i:end]...)
+		}
+		i = end
+	}
+
+	binary.LittleEndian.PutUint32(out[4:], uint32(len(out)-8))
+	return out, nil
+}
+
+// orient aplica una orientación EXIF (2 a 8) a la imagen
+func orient(img image.Image, orientation int) image.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if orientation < 2 || orientation > 8 {
+		return img
+	}
+
+	dstWidth, dstHeight := width, height
+	if orientation >= 5 {
+		dstWidth, dstHeight = height, width
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+
+	for y := 0; y < dstHeight; y++ {
+		for x := 0; x < dstWidth; x++ {
+			var srcX, srcY int
+			switch orientation {
+			case 2: // espejo horizontal
+				srcX, srcY = width-1-x, y
+			case 3: // rotada 180°
+				srcX, srcY = width-1-x, height-1-y
+			case 4: // espejo vertical
+				srcX, srcY = x, height-1-y
+			case 5: // transpuesta
+				srcX, srcY = y, x
+			case 6: // rotada 90° en sentido horario
+				srcX, srcY = y, height-1-x
+			case 7: // transversa
+				srcX, srcY = width-1-y, height-1-x
+			case 8: // rotada 90° en sentido antihorario
+				srcX, srcY = width-1-y, x
+			}
+			dst.Set(x, y, img.At(bounds.Min.X+srcX, bounds.Min.Y+srcY))
+		}
+	}
+	return dst
+}
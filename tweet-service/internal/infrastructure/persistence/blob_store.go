@@ -0,0 +1,93 @@
+package persistence
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrBlobNotFound se devuelve al leer un blob que no existe
+var ErrBlobNotFound = errors.New("blob no encontrado")
+
+// BlobStore guarda los archivos de las imágenes subidas. Las claves son rutas relativas separadas
+// por "/" (ej. media/ab12cd.jpg).
+type BlobStore interface {
+	Put(key, contentType string, data []byte) error
+	// Open devuelve ErrBlobNotFound si la clave no existe
+	Open(key string) (io.ReadCloser, error)
+	// Delete no devuelve error si la clave no existe
+	Delete(key string) error
+}
+
+// FilesystemBlobStore guarda los blobs como archivos debajo de un directorio
+type FilesystemBlobStore struct {
+	root string
+}
+
+func NewFilesystemBlobStore(root string) (*FilesystemBlobStore, error) {
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, fmt.Errorf("no se pudo crear el directorio de blobs: %w", err)
+	}
+	return &FilesystemBlobStore{root: root}, nil
+}
+
+// path resuelve la clave dentro del directorio raíz, rechazando claves que salen de él
+func (s *FilesystemBlobStore) path(key string) (string, error) {
+	cleaned := filepath.Clean(filepath.FromSlash(key))
+	if key == "" || filepath.IsAbs(cleaned) || cleaned == ".." || strings.HasPrefix(cleaned, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("clave de blob inválida: %q", key)
+	}
+	return filepath.Join(s.root, cleaned), nil
+}
+
+// Put escribe el blob en un archivo temporal y lo renombra, para no dejar archivos a medio escribir
+func (s *FilesystemBlobStore) Put(key, contentType string, data []byte) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func (s *FilesystemBlobStore) Open(key string) (io.ReadCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrBlobNotFound
+	}
+	return file, err
+}
+
+func (s *FilesystemBlobStore) Delete(key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
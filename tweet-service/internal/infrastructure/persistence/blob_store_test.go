@@ -0,0 +1,121 @@
+package persistence
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testBlobStore verifica el contrato de BlobStore común a todas las implementaciones
+func testBlobStore(t *testing.T, store BlobStore) {
+	assert.NoError(t, store.Put("media/abc.png", "image/png", []byte("datos")))
+
+	blob, err := store.Open("media/abc.png")
+	if !assert.NoError(t, err) {
+		return
+	}
+	data, err := io.ReadAll(blob)
+	blob.Close()
+	assert.NoError(t, err)
+	assert.Equal(t, "datos", string(data))
+
+	// Sobrescribir una clave reemplaza el contenido
+	assert.NoError(t, store.Put("media/abc.png", "image/png", []byte("otros")))
+	blob, err = store.Open("media/abc.png")
+	if !assert.NoError(t, err) {
+		return
+	}
+	data, _ = io.ReadAll(blob)
+	blob.Close()
+	assert.Equal(t, "otros", string(data))
+
+	assert.NoError(t, store.Delete("media/abc.png"))
+	_, err = store.Open("media/abc.png")
+	assert.ErrorIs(t, err, ErrBlobNotFound)
+
+	// Borrar una clave inexistente no es un error
+	assert.NoError(t, store.Delete("media/abc.png"))
+}
+
+func TestFilesystemBlobStore(t *testing.T) {
+	store, err := NewFilesystemBlobStore(t.TempDir())
+	if !assert.NoError(t, err) {
+		return
+	}
+	testBlobStore(t, store)
+
+	// Las claves no pueden salir del directorio raíz
+	assert.Error(t, store.Put("../fuera.png", "image/png", []byte("datos")))
+	_, err = store.Open("media/../../fuera.png")
+	assert.Error(t, err)
+	assert.Error(t, store.Delete("/etc/passwd"))
+}
+
+// fakeS3Server simula un bucket S3 en memoria con peticiones de tipo /bucket/clave
+func fakeS3Server(t *testing.T, bucket string) *httptest.Server {
+	var mu sync.Mutex
+	objects := make(map[string][]byte)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		key, ok := strings.CutPrefix(r.URL.Path, "/"+bucket+"/")
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method {
+		case http.MethodPut:
+			data, _ := io.ReadAll(r.Body)
+			objects[key] = data
+			w.Header().Set("ETag", `"etag"`)
+		case http.MethodGet, http.MethodHead:
+			data, found := objects[key]
+			if !found {
+				w.Header().Set("Content-Type", "application/xml")
+				w.WriteHeader(http.StatusNotFound)
+				io.WriteString(w, `<?xml version="1.0" encoding="UTF-8"?><Error><Code>NoSuchKey</Code><Message>No existe</Message></Error>`)
+				return
+			}
+			w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+			w.Header().Set("Last-Modified", "Mon, 02 Jan 2006 15:04:05 GMT")
+			w.Header().Set("ETag", `"etag"`)
+			if r.Method == http.MethodGet {
+				w.Write(data)
+			}
+		case http.MethodDelete:
+			delete(objects, key)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestS3BlobStore(t *testing.T) {
+	server := fakeS3Server(t, "media")
+	store, err := NewS3BlobStore(S3Config{
+		Endpoint:  strings.TrimPrefix(server.URL, "http://"),
+		Region:    "us-east-1",
+		Bucket:    "media",
+		AccessKey: "access",
+		SecretKey: "secret",
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	testBlobStore(t, store)
+}
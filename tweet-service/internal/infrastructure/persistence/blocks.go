@@ -0,0 +1,67 @@
+package persistence
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/DevOpslp/microblogging-platform/tweet-service/internal/domain"
+)
+
+var (
+	// ErrReplyBlocked se devuelve al responder a un usuario que bloqueó al autor
+	ErrReplyBlocked = errors.New("el autor del tweet respondido te bloqueó")
+	// ErrMentionBlocked se devuelve al mencionar a un usuario que bloqueó al autor
+	ErrMentionBlocked = errors.New("un usuario mencionado te bloqueó")
+)
+
+// checkNotBlocked verifica con una sola petición a user-service que ni el autor del tweet respondido
+// ni los usuarios mencionados hayan bloqueado a userID. Si el tweet respondido no existe no se
+// verifica nada: la transacción de CreateTweet devuelve ErrParentNotFound.
+func (repo *TweetRepository) checkNotBlocked(userID uint, inReplyTo *uint, mentions []domain.TweetMention) error {
+	var parentAuthorID uint
+	if inReplyTo != nil {
+		parent, err := findOriginal(repo.tweetDB, *inReplyTo)
+		if err != nil && !errors.Is(err, ErrTweetNotFound) {
+			return err
+		}
+		if parent != nil && parent.UserID != userID {
+			parentAuthorID = parent.UserID
+		}
+	}
+
+	ids := make([]uint, 0, 1+len(mentions))
+	if parentAuthorID != 0 {
+		ids = append(ids, parentAuthorID)
+	}
+	for _, mention := range mentions {
+		if mention.UserID != userID {
+			ids = append(ids, mention.UserID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	blockers, err := repo.userRepo.FindBlockerIDs(userID, ids)
+	if err != nil {
+		return fmt.Errorf("error al consultar los bloqueos: %w", err)
+	}
+	if blockers[parentAuthorID] {
+		return ErrReplyBlocked
+	}
+	for _, mention := range mentions {
+		if blockers[mention.UserID] {
+			return ErrMentionBlocked
+		}
+	}
+	return nil
+}
+
+// hiddenAuthors devuelve los usuarios bloqueados o silenciados por el viewer y los que lo bloquearon.
+// Los lectores anónimos y los que pueden ver todo no ocultan a nadie.
+func (repo *TweetRepository) hiddenAuthors(viewer domain.Viewer) (map[uint]bool, error) {
+	if viewer.All || viewer.UserID == 0 || viewer.Username == "" {
+		return map[uint]bool{}, nil
+	}
+	return repo.userRepo.FindHiddenUserIDs(viewer.Username)
+}
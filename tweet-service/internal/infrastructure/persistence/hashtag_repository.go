@@ -0,0 +1,56 @@
+package persistence
+
+import (
+	"fmt"
+
+	"github.com/DevOpslp/microblogging-platform/tweet-service/internal/domain"
+	"gorm.io/gorm"
+)
+
+// saveHashtags guarda la relación entre el tweet y cada hashtag de su contenido
+func saveHashtags(tx *gorm.DB, tweet *domain.Tweet) error {
+	tags := domain.UniqueHashtags(tweet.Content)
+	if len(tags) == 0 {
+		return nil
+	}
+
+	rows := make([]domain.TweetHashtag, 0, len(tags))
+	for _, tag := range tags {
+		rows = append(rows, domain.TweetHashtag{Tag: tag, TweetID: tweet.ID, CreatedAt: tweet.CreatedAt})
+	}
+	return tx.Create(&rows).Error
+}
+
+// Obtener una página de los tweets que usan un hashtag normalizado, del más nuevo al más antiguo
+func (repo *TweetRepository) GetTweetsByHashtag(tag string, viewer domain.Viewer, cursor *domain.Cursor, limit int) ([]domain.TweetWithUser, *domain.Cursor, error) {
+	query := repo.tweetDB.Where("tag = ?", tag)
+	if cursor != nil {
+		query = query.Where("(created_at, tweet_id) < (?, ?)", cursor.CreatedAt, cursor.ID)
+	}
+
+	var rows []domain.TweetHashtag
+	if err := query.Order("created_at DESC, tweet_id DESC").Limit(limit + 1).Find(&rows).Error; err != nil {
+		return nil, nil, fmt.Errorf("error al obtener tweets del hashtag: %w", err)
+	}
+
+	var next *domain.Cursor
+	if len(rows) > limit {
+		rows = rows[:limit]
+		next = &domain.Cursor{CreatedAt: rows[limit-1].CreatedAt, ID: rows[limit-1].TweetID}
+	}
+
+	tweetIDs := make([]uint, 0, len(rows))
+	for _, row := range rows {
+		tweetIDs = append(tweetIDs, row.TweetID)
+	}
+	tweets, err := repo.findTweetsInOrder(tweetIDs)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error al obtener tweets: %w", err)
+	}
+
+	tweetsWithUser, err := repo.visibleWithUsernames(tweets, viewer)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error al obtener usuarios de los tweets: %w", err)
+	}
+	return tweetsWithUser, next, nil
+}
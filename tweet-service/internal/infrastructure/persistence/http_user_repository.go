@@ -2,52 +2,148 @@ package persistence
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 
+	"github.com/DevOpslp/microblogging-platform/auth"
 	"github.com/DevOpslp/microblogging-platform/tweet-service/internal/domain"
 )
 
+// ErrUserNotFound se devuelve cuando user-service no reconoce al usuario solicitado
+var ErrUserNotFound = errors.New("usuario no encontrado")
+
+// Las peticiones a user-service llevan un token de servicio, que exigen las consultas de bloqueos
 type HTTPUserRepository struct {
 	baseURL string
+	tokens  *auth.TokenManager
 }
 
 type UserRepository interface {
 	FindUserByUsername(username string) (*domain.User, error)
 	FindUserByID(userID uint) (*domain.User, error) // Añadir este método
+	// Buscar varios usuarios en una sola petición; los IDs inexistentes no aparecen en el resultado
+	FindUsersByIDs(userIDs []uint) (map[uint]*domain.User, error)
+	// Buscar varios usuarios por username exacto; los inexistentes no aparecen en el resultado
+	FindUsersByUsernames(usernames []string) (map[string]*domain.User, error)
+	// Indicar cuáles de userIDs sigue followerID, para mostrar los tweets de cuentas privadas
+	FindFollowedIDs(followerID uint, userIDs []uint) (map[uint]bool, error)
+	// Indicar cuáles de userIDs bloquearon a blockedID
+	FindBlockerIDs(blockedID uint, userIDs []uint) (map[uint]bool, error)
+	// Usuarios cuyos tweets no se le muestran a username: bloqueados en cualquier dirección o silenciados
+	FindHiddenUserIDs(username string) (map[uint]bool, error)
 }
 
-func NewHTTPUserRepository(baseURL string) *HTTPUserRepository {
-	return &HTTPUserRepository{baseURL: baseURL}
+func NewHTTPUserRepository(baseURL string, tokens *auth.TokenManager) *HTTPUserRepository {
+	return &HTTPUserRepository{baseURL: baseURL, tokens: tokens}
+}
+
+// get hace una petición GET a user-service autenticada con un token de servicio
+func (repo *HTTPUserRepository) get(endpoint string) (*http.Response, error) {
+	token, err := repo.tokens.IssueServiceToken("tweet-service")
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", auth.BearerHeader(token))
+	return http.DefaultClient.Do(req)
 }
 
 func (repo *HTTPUserRepository) FindUserByUsername(username string) (*domain.User, error) {
 	url := fmt.Sprintf("%s/user/%s", repo.baseURL, username) // Asegúrate de usar /user/:username
-	resp, err := http.Get(url)
+	resp, err := repo.get(url)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrUserNotFound
+	}
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("error: user-service devolvió estado %d", resp.StatusCode)
 	}
 
-	var result struct {
-		UserID   uint   `json:"user_id"`
-		Username string `json:"username"`
-	}
-
+	var result userResponse
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
 		return nil, err
 	}
 
-	return &domain.User{ID: result.UserID, Username: result.Username}, nil
+	return result.toDomain(), nil
 }
 
 func (repo *HTTPUserRepository) FindUserByID(userID uint) (*domain.User, error) {
 	url := fmt.Sprintf("%s/user-by-id/%d", repo.baseURL, userID) // Endpoint nuevo
-	resp, err := http.Get(url)
+	resp, err := repo.get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error: user-service devolvió estado %d", resp.StatusCode)
+	}
+
+	var result userResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	return result.toDomain(), nil
+}
+
+func (repo *HTTPUserRepository) FindUsersByIDs(userIDs []uint) (map[uint]*domain.User, error) {
+	users := make(map[uint]*domain.User, len(userIDs))
+	if len(userIDs) == 0 {
+		return users, nil
+	}
+
+	ids := make([]string, len(userIDs))
+	for i, userID := range userIDs {
+		ids[i] = strconv.FormatUint(uint64(userID), 10)
+	}
+
+	found, err := repo.getUsersBatch("ids=" + strings.Join(ids, ","))
+	if err != nil {
+		return nil, err
+	}
+	for _, user := range found {
+		users[user.ID] = user
+	}
+	return users, nil
+}
+
+func (repo *HTTPUserRepository) FindUsersByUsernames(usernames []string) (map[string]*domain.User, error) {
+	users := make(map[string]*domain.User, len(usernames))
+	if len(usernames) == 0 {
+		return users, nil
+	}
+
+	escaped := make([]string, len(usernames))
+	for i, username := range usernames {
+		escaped[i] = url.QueryEscape(username)
+	}
+
+	found, err := repo.getUsersBatch("usernames=" + strings.Join(escaped, ","))
+	if err != nil {
+		return nil, err
+	}
+	for _, user := range found {
+		users[user.Username] = user
+	}
+	return users, nil
+}
+
+// getUsersBatch llama a GET /users/batch de user-service con la query indicada
+func (repo *HTTPUserRepository) getUsersBatch(query string) ([]*domain.User, error) {
+	resp, err := repo.get(fmt.Sprintf("%s/users/batch?%s", repo.baseURL, query))
 	if err != nil {
 		return nil, err
 	}
@@ -58,13 +154,99 @@ func (repo *HTTPUserRepository) FindUserByID(userID uint) (*domain.User, error)
 	}
 
 	var result struct {
-		UserID   uint   `json:"user_id"`
-		Username string `json:"username"`
+		Users []userResponse `json:"users"`
 	}
 
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
 		return nil, err
 	}
 
-	return &domain.User{ID: result.UserID, Username: result.Username}, nil
+	users := make([]*domain.User, 0, len(result.Users))
+	for _, user := range result.Users {
+		users = append(users, user.toDomain())
+	}
+	return users, nil
+}
+
+// FindFollowedIDs consulta en GET /users/follows de user-service cuáles de userIDs sigue followerID
+func (repo *HTTPUserRepository) FindFollowedIDs(followerID uint, userIDs []uint) (map[uint]bool, error) {
+	return repo.getIDSet(fmt.Sprintf("/users/follows?follower_id=%d", followerID), "followed_ids", userIDs)
+}
+
+// FindBlockerIDs consulta en GET /users/blockers de user-service cuáles de userIDs bloquearon a blockedID
+func (repo *HTTPUserRepository) FindBlockerIDs(blockedID uint, userIDs []uint) (map[uint]bool, error) {
+	return repo.getIDSet(fmt.Sprintf("/users/blockers?user_id=%d", blockedID), "blocker_ids", userIDs)
+}
+
+// getIDSet agrega ?ids=userIDs a la consulta y devuelve como conjunto los IDs del campo indicado de la respuesta
+func (repo *HTTPUserRepository) getIDSet(query, field string, userIDs []uint) (map[uint]bool, error) {
+	set := make(map[uint]bool, len(userIDs))
+	if len(userIDs) == 0 {
+		return set, nil
+	}
+
+	ids := make([]string, len(userIDs))
+	for i, userID := range userIDs {
+		ids[i] = strconv.FormatUint(uint64(userID), 10)
+	}
+
+	resp, err := repo.get(fmt.Sprintf("%s%s&ids=%s", repo.baseURL, query, strings.Join(ids, ",")))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error: user-service devolvió estado %d", resp.StatusCode)
+	}
+
+	var result map[string][]uint
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	for _, userID := range result[field] {
+		set[userID] = true
+	}
+	return set, nil
+}
+
+// FindHiddenUserIDs consulta GET /users/:username/hidden de user-service
+func (repo *HTTPUserRepository) FindHiddenUserIDs(username string) (map[uint]bool, error) {
+	resp, err := repo.get(fmt.Sprintf("%s/users/%s/hidden", repo.baseURL, url.PathEscape(username)))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrUserNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error: user-service devolvió estado %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Hidden []userResponse `json:"hidden"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	hidden := make(map[uint]bool, len(result.Hidden))
+	for _, user := range result.Hidden {
+		hidden[user.UserID] = true
+	}
+	return hidden, nil
+}
+
+// Usuario tal como lo devuelven los endpoints de user-service
+type userResponse struct {
+	UserID    uint   `json:"user_id"`
+	Username  string `json:"username"`
+	IsPrivate bool   `json:"is_private"`
+}
+
+func (u userResponse) toDomain() *domain.User {
+	return &domain.User{ID: u.UserID, Username: u.Username, IsPrivate: u.IsPrivate}
 }
@@ -0,0 +1,168 @@
+package persistence
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/DevOpslp/microblogging-platform/tweet-service/internal/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// LikeTweet registra el like de un usuario. Es idempotente: si el like ya existía no cambia nada.
+// El contador del tweet solo se incrementa cuando se insertó una fila nueva, dentro de la misma transacción,
+// por lo que se mantiene consistente con la tabla de likes aunque haya peticiones concurrentes.
+// Dar like a un retweet equivale a dar like al original. Solo un like nuevo guarda el evento TweetLiked.
+func (repo *TweetRepository) LikeTweet(userID uint, username string, tweetID uint) (*domain.Tweet, error) {
+	return repo.changeLike(tweetID, 1, func(tx *gorm.DB, originalID uint) *gorm.DB {
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&domain.Like{UserID: userID, TweetID: originalID})
+	}, func(tx *gorm.DB, tweet *domain.Tweet) error {
+		return enqueueTweetLiked(tx, tweet, userID, username)
+	})
+}
+
+// UnlikeTweet quita el like de un usuario. Es idempotente: si no existía no cambia nada.
+func (repo *TweetRepository) UnlikeTweet(userID, tweetID uint) (*domain.Tweet, error) {
+	return repo.changeLike(tweetID, -1, func(tx *gorm.DB, originalID uint) *gorm.DB {
+		return tx.Where("user_id = ? AND tweet_id = ?", userID, originalID).Delete(&domain.Like{})
+	}, nil)
+}
+
+// changeLike aplica el cambio sobre la tabla de likes y, si afectó una fila, actualiza el contador con un
+// UPDATE atómico y llama a changed, si no es nil. Si el tweet no existe (o se eliminó en paralelo) la
+// transacción se revierte.
+func (repo *TweetRepository) changeLike(tweetID uint, delta int, change func(tx *gorm.DB, originalID uint) *gorm.DB, changed func(tx *gorm.DB, tweet *domain.Tweet) error) (*domain.Tweet, error) {
+	var tweet domain.Tweet
+	err := repo.tweetDB.Transaction(func(tx *gorm.DB) error {
+		original, err := findOriginal(tx, tweetID)
+		if err != nil {
+			return err
+		}
+		tweet = *original
+
+		result := change(tx, original.ID)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		updated := tx.Model(&tweet).Clauses(clause.Returning{}).
+			UpdateColumn("like_count", gorm.Expr("like_count + ?", delta))
+		if updated.Error != nil {
+			return updated.Error
+		}
+		if updated.RowsAffected == 0 {
+			return ErrTweetNotFound
+		}
+		if changed != nil {
+			return changed(tx, &tweet)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &tweet, nil
+}
+
+// Obtener una página de los usuarios que dieron like a un tweet, del like más nuevo al más antiguo
+func (repo *TweetRepository) GetTweetLikers(tweetID uint, cursor *domain.Cursor, limit int) ([]domain.Liker, *domain.Cursor, error) {
+	if _, err := repo.GetTweetByID(tweetID); err != nil {
+		return nil, nil, err
+	}
+
+	query := repo.tweetDB.Where("tweet_id = ?", tweetID)
+	if cursor != nil {
+		query = query.Where("(created_at, user_id) < (?, ?)", cursor.CreatedAt, cursor.ID)
+	}
+
+	var likes []domain.Like
+	if err := query.Order("created_at DESC, user_id DESC").Limit(limit + 1).Find(&likes).Error; err != nil {
+		return nil, nil, fmt.Errorf("error al obtener likes: %w", err)
+	}
+
+	var next *domain.Cursor
+	if len(likes) > limit {
+		likes = likes[:limit]
+		cursor := likes[limit-1].TweetCursor()
+		next = &cursor
+	}
+
+	// Resolver todos los usuarios de la página en una sola petición a `user-service`
+	userIDs := make([]uint, 0, len(likes))
+	for _, like := range likes {
+		userIDs = append(userIDs, like.UserID)
+	}
+	users, err := repo.userRepo.FindUsersByIDs(userIDs)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error al obtener usuarios de los likes: %w", err)
+	}
+
+	likers := make([]domain.Liker, 0, len(likes))
+	for _, like := range likes {
+		username := domain.UnknownUsername
+		if user, ok := users[like.UserID]; ok {
+			username = user.Username
+		}
+		likers = append(likers, domain.Liker{UserID: like.UserID, Username: username, LikedAt: like.CreatedAt})
+	}
+	return likers, next, nil
+}
+
+// Obtener una página de los tweets que le gustaron a un usuario, del like más nuevo al más antiguo.
+// Si la cuenta es privada y el viewer no la sigue devuelve ErrPrivateAccount.
+func (repo *TweetRepository) GetLikedTweets(username string, viewer domain.Viewer, cursor *domain.Cursor, limit int) ([]domain.TweetWithUser, *domain.Cursor, error) {
+	user, err := repo.userRepo.FindUserByUsername(username)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := repo.checkCanViewUser(user, viewer); err != nil {
+		return nil, nil, err
+	}
+
+	query := repo.tweetDB.Where("user_id = ?", user.ID)
+	if cursor != nil {
+		query = query.Where("(created_at, tweet_id) < (?, ?)", cursor.CreatedAt, cursor.ID)
+	}
+
+	var likes []domain.Like
+	if err := query.Order("created_at DESC, tweet_id DESC").Limit(limit + 1).Find(&likes).Error; err != nil {
+		return nil, nil, fmt.Errorf("error al obtener likes: %w", err)
+	}
+
+	var next *domain.Cursor
+	if len(likes) > limit {
+		likes = likes[:limit]
+		cursor := likes[limit-1].UserCursor()
+		next = &cursor
+	}
+
+	tweetIDs := make([]uint, 0, len(likes))
+	for _, like := range likes {
+		tweetIDs = append(tweetIDs, like.TweetID)
+	}
+	tweets, err := repo.findTweetsInOrder(tweetIDs)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error al obtener tweets: %w", err)
+	}
+
+	tweetsWithUser, err := repo.visibleWithUsernames(tweets, viewer)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error al obtener usuarios de los tweets: %w", err)
+	}
+	return tweetsWithUser, next, nil
+}
+
+// findTweet carga un tweet dentro de una transacción, devuelve ErrTweetNotFound si no existe
+func findTweet(tx *gorm.DB, tweetID uint, tweet *domain.Tweet) error {
+	if err := tx.First(tweet, tweetID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrTweetNotFound
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package persistence
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/DevOpslp/microblogging-platform/tweet-service/internal/domain"
+	"github.com/DevOpslp/microblogging-platform/tweet-service/internal/infrastructure/media"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrMediaNotFound se devuelve cuando la imagen no existe o, al adjuntarla, no es del autor o ya
+// está adjunta a otro tweet
+var ErrMediaNotFound = errors.New("imagen no encontrada")
+
+// SaveMedia guarda en el BlobStore la imagen ya procesada y su miniatura, y crea la fila sin tweet.
+// Las claves son aleatorias para que no se puedan adivinar a partir del ID.
+func (repo *TweetRepository) SaveMedia(userID uint, processed *media.Processed) (*domain.Media, error) {
+	name, err := randomBlobName()
+	if err != nil {
+		return nil, err
+	}
+
+	item := &domain.Media{
+		UserID:       userID,
+		ContentType:  processed.ContentType,
+		Size:         int64(len(processed.Data)),
+		Width:        processed.Width,
+		Height:       processed.Height,
+		StorageKey:   fmt.Sprintf("media/%s.%s", name, domain.MediaExtension(processed.ContentType)),
+		ThumbnailKey: fmt.Sprintf("media/%s_thumb.jpg", name),
+	}
+
+	if err := repo.blobs.Put(item.StorageKey, item.ContentType, processed.Data); err != nil {
+		return nil, fmt.Errorf("error al guardar la imagen: %w", err)
+	}
+	if err := repo.blobs.Put(item.ThumbnailKey, domain.MediaTypeJPEG, processed.Thumbnail); err != nil {
+		repo.deleteBlobs([]domain.Media{{StorageKey: item.StorageKey}})
+		return nil, fmt.Errorf("error al guardar la miniatura: %w", err)
+	}
+
+	if err := repo.tweetDB.Create(item).Error; err != nil {
+		repo.deleteBlobs([]domain.Media{*item})
+		return nil, err
+	}
+	return item, nil
+}
+
+func randomBlobName() (string, error) {
+	name := make([]byte, 16)
+	if _, err := rand.Read(name); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(name), nil
+}
+
+// GetMedia busca una imagen por ID; devuelve ErrMediaNotFound si no existe
+func (repo *TweetRepository) GetMedia(mediaID uint) (*domain.Media, error) {
+	var item domain.Media
+	if err := repo.tweetDB.First(&item, mediaID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrMediaNotFound
+		}
+		return nil, err
+	}
+	return &item, nil
+}
+
+// OpenMediaBlob abre el blob de una imagen o de su miniatura
+func (repo *TweetRepository) OpenMediaBlob(key string) (io.ReadCloser, error) {
+	return repo.blobs.Open(key)
+}
+
+// attachMedia adjunta las imágenes al tweet en el orden recibido. El UPDATE condicional evita que
+// dos tweets creados en paralelo se queden con la misma imagen.
+func attachMedia(tx *gorm.DB, tweet *domain.Tweet, attachments []domain.MediaAttachment) error {
+	for position, attachment := range attachments {
+		result := tx.Model(&domain.Media{}).
+			Where("id = ? AND user_id = ? AND tweet_id IS NULL", attachment.MediaID, tweet.UserID).
+			Updates(map[string]interface{}{"tweet_id": tweet.ID, "position": position, "alt_text": attachment.AltText})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("%w: %d", ErrMediaNotFound, attachment.MediaID)
+		}
+	}
+	return nil
+}
+
+// findMedia carga en una sola consulta las imágenes de los tweets dados, en el orden en que se adjuntaron
+func (repo *TweetRepository) findMedia(tweets []domain.Tweet) (map[uint][]domain.Media, error) {
+	ids := make([]uint, 0, len(tweets))
+	for _, tweet := range tweets {
+		if tweet.MediaCount > 0 {
+			ids = append(ids, tweet.ID)
+		}
+	}
+
+	found := make(map[uint][]domain.Media, len(ids))
+	if len(ids) == 0 {
+		return found, nil
+	}
+
+	var rows []domain.Media
+	if err := repo.tweetDB.Where("tweet_id IN ?", ids).Order("tweet_id, position").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		found[*row.TweetID] = append(found[*row.TweetID], row)
+	}
+	return found, nil
+}
+
+// detachMedia elimina las filas de las imágenes de un tweet y las devuelve para borrar sus blobs
+// después de confirmar la transacción
+func detachMedia(tx *gorm.DB, tweetID uint) ([]domain.Media, error) {
+	var removed []domain.Media
+	if err := tx.Clauses(clause.Returning{}).Where("tweet_id = ?", tweetID).Delete(&removed).Error; err != nil {
+		return nil, err
+	}
+	return removed, nil
+}
+
+// deleteBlobs borra los blobs de las imágenes eliminadas. Si falla solo se registra el error: un blob
+// huérfano ocupa espacio pero ya no es accesible.
+func (repo *TweetRepository) deleteBlobs(items []domain.Media) {
+	for _, item := range items {
+		for _, key := range []string{item.StorageKey, item.ThumbnailKey} {
+			if key == "" {
+				continue
+			}
+			if err := repo.blobs.Delete(key); err != nil {
+				log.Printf("No se pudo borrar el blob %s: %v", key, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package persistence
+
+import (
+	"fmt"
+
+	"github.com/DevOpslp/microblogging-platform/tweet-service/internal/domain"
+	"gorm.io/gorm"
+)
+
+// resolveMentions busca en user-service, con una sola petición, los usuarios mencionados en el contenido.
+// Los usernames que no existen se ignoran y quedan como texto.
+func (repo *TweetRepository) resolveMentions(content string) ([]domain.TweetMention, error) {
+	usernames := domain.UniqueMentions(content)
+	mentions := make([]domain.TweetMention, 0, len(usernames))
+	if len(usernames) == 0 {
+		return mentions, nil
+	}
+
+	users, err := repo.userRepo.FindUsersByUsernames(usernames)
+	if err != nil {
+		return nil, fmt.Errorf("error al resolver las menciones: %w", err)
+	}
+	for _, username := range usernames {
+		if user, ok := users[username]; ok {
+			mentions = append(mentions, domain.TweetMention{UserID: user.ID, Username: user.Username})
+		}
+	}
+	return mentions, nil
+}
+
+// saveMentions guarda los usuarios mencionados por el tweet, ya resueltos con resolveMentions
+func saveMentions(tx *gorm.DB, tweet *domain.Tweet, mentions []domain.TweetMention) error {
+	if len(mentions) == 0 {
+		return nil
+	}
+
+	rows := make([]domain.TweetMention, 0, len(mentions))
+	for _, mention := range mentions {
+		mention.TweetID = tweet.ID
+		mention.CreatedAt = tweet.CreatedAt
+		rows = append(rows, mention)
+	}
+	return tx.Create(&rows).Error
+}
+
+// findMentions carga en una sola consulta los usuarios mencionados por los tweets dados.
+// Solo se consultan los tweets cuyo contenido tiene algún '@'.
+func (repo *TweetRepository) findMentions(tweets []domain.Tweet) (map[uint][]domain.TweetMention, error) {
+	ids := make([]uint, 0)
+	for _, tweet := range tweets {
+		if len(domain.ExtractMentions(tweet.Content)) > 0 {
+			ids = append(ids, tweet.ID)
+		}
+	}
+
+	mentions := make(map[uint][]domain.TweetMention, len(ids))
+	if len(ids) == 0 {
+		return mentions, nil
+	}
+
+	var rows []domain.TweetMention
+	if err := repo.tweetDB.Where("tweet_id IN ?", ids).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		mentions[row.TweetID] = append(mentions[row.TweetID], row)
+	}
+	return mentions, nil
+}
+
+// Obtener una página de los tweets que mencionan a un usuario, del más nuevo al más antiguo
+func (repo *TweetRepository) GetTweetsMentioning(username string, viewer domain.Viewer, cursor *domain.Cursor, limit int) ([]domain.TweetWithUser, *domain.Cursor, error) {
+	user, err := repo.userRepo.FindUserByUsername(username)
+	if err != nil {
+		return nil, nil, fmt.Errorf("usuario no encontrado: %w", err)
+	}
+
+	query := repo.tweetDB.Where("user_id = ?", user.ID)
+	if cursor != nil {
+		query = query.Where("(created_at, tweet_id) < (?, ?)", cursor.CreatedAt, cursor.ID)
+	}
+
+	var rows []domain.TweetMention
+	if err := query.Order("created_at DESC, tweet_id DESC").Limit(limit + 1).Find(&rows).Error; err != nil {
+		return nil, nil, fmt.Errorf("error al obtener las menciones: %w", err)
+	}
+
+	var next *domain.Cursor
+	if len(rows) > limit {
+		rows = rows[:limit]
+		next = &domain.Cursor{CreatedAt: rows[limit-1].CreatedAt, ID: rows[limit-1].TweetID}
+	}
+
+	tweetIDs := make([]uint, 0, len(rows))
+	for _, row := range rows {
+		tweetIDs = append(tweetIDs, row.TweetID)
+	}
+	tweets, err := repo.findTweetsInOrder(tweetIDs)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error al obtener tweets: %w", err)
+	}
+
+	tweetsWithUser, err := repo.visibleWithUsernames(tweets, viewer)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error al obtener usuarios de los tweets: %w", err)
+	}
+
+	// Las menciones de usuarios bloqueados o silenciados por el viewer no se muestran
+	hidden, err := repo.hiddenAuthors(viewer)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error al obtener los usuarios ocultos: %w", err)
+	}
+	return domain.HideAuthors(tweetsWithUser, hidden), next, nil
+}
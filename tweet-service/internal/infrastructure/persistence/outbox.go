@@ -0,0 +1,92 @@
+package persistence
+
+import (
+	"github.com/DevOpslp/microblogging-platform/events"
+	"github.com/DevOpslp/microblogging-platform/tweet-service/internal/domain"
+	"gorm.io/gorm"
+)
+
+// Origen de los eventos que tweet-service guarda en la outbox
+const eventSource = "tweet-service"
+
+// enqueueTweetCreated guarda el evento TweetCreated en la transacción que crea el tweet.
+// referencedUserID es el autor del tweet retuiteado o citado, si lo hay.
+func enqueueTweetCreated(tx *gorm.DB, tweet *domain.Tweet, username string, referencedUserID *uint, mentions []domain.TweetMention) error {
+	resolved := make([]events.Mention, 0, len(mentions))
+	for _, mention := range mentions {
+		resolved = append(resolved, events.Mention{UserID: mention.UserID, Username: mention.Username})
+	}
+
+	event := events.TweetCreated{
+		TweetID:          tweet.ID,
+		UserID:           tweet.UserID,
+		Username:         username,
+		Content:          tweet.Content,
+		InReplyToTweetID: tweet.InReplyToTweetID,
+		InReplyToUserID:  tweet.InReplyToUserID,
+		ConversationID:   tweet.RootID(),
+		RetweetOfTweetID: tweet.RetweetOfTweetID,
+		QuotedTweetID:    tweet.QuotedTweetID,
+		Mentions:         resolved,
+		CreatedAt:        tweet.CreatedAt,
+	}
+	if tweet.RetweetOfTweetID != nil {
+		event.RetweetOfUserID = referencedUserID
+	} else if tweet.QuotedTweetID != nil {
+		event.QuotedUserID = referencedUserID
+	}
+	return events.Enqueue(tx, eventSource, event)
+}
+
+// enqueueTweetLiked guarda el evento TweetLiked en la transacción que registra el like
+func enqueueTweetLiked(tx *gorm.DB, tweet *domain.Tweet, userID uint, username string) error {
+	return events.Enqueue(tx, eventSource, events.TweetLiked{
+		TweetID:     tweet.ID,
+		TweetUserID: tweet.UserID,
+		UserID:      userID,
+		Username:    username,
+	})
+}
+
+// enqueueTweetUpdated guarda el evento TweetUpdated en la transacción que edita el tweet
+func enqueueTweetUpdated(tx *gorm.DB, tweet *domain.Tweet, mentions []domain.TweetMention) error {
+	resolved := make([]events.Mention, 0, len(mentions))
+	for _, mention := range mentions {
+		resolved = append(resolved, events.Mention{UserID: mention.UserID, Username: mention.Username})
+	}
+
+	return events.Enqueue(tx, eventSource, events.TweetUpdated{
+		TweetID:  tweet.ID,
+		UserID:   tweet.UserID,
+		Content:  tweet.Content,
+		Mentions: resolved,
+		Revision: tweet.RevisionCount(),
+		EditedAt: *tweet.EditedAt,
+	})
+}
+
+// enqueueTweetsDeleted guarda un evento TweetDeleted por cada tweet eliminado en la transacción
+func enqueueTweetsDeleted(tx *gorm.DB, tweets ...domain.Tweet) error {
+	payloads := make([]events.Payload, 0, len(tweets))
+	for _, tweet := range tweets {
+		payloads = append(payloads, events.TweetDeleted{
+			TweetID:          tweet.ID,
+			UserID:           tweet.UserID,
+			RetweetOfTweetID: tweet.RetweetOfTweetID,
+		})
+	}
+	return events.Enqueue(tx, eventSource, payloads...)
+}
+
+// enqueueTweetsRestored guarda un evento TweetRestored por cada tweet restaurado en la transacción
+func enqueueTweetsRestored(tx *gorm.DB, tweets ...domain.Tweet) error {
+	payloads := make([]events.Payload, 0, len(tweets))
+	for _, tweet := range tweets {
+		payloads = append(payloads, events.TweetRestored{
+			TweetID:          tweet.ID,
+			UserID:           tweet.UserID,
+			RetweetOfTweetID: tweet.RetweetOfTweetID,
+		})
+	}
+	return events.Enqueue(tx, eventSource, payloads...)
+}
@@ -0,0 +1,129 @@
+package persistence
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/DevOpslp/microblogging-platform/tweet-service/internal/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultPurgeInterval  = time.Hour
+	defaultPurgeBatchSize = 100
+)
+
+// Purger borra definitivamente los tweets eliminados hace más de retention, junto con sus likes,
+// hashtags, menciones, versiones e imágenes. Pasado ese tiempo ya no se muestran como tombstone: las
+// respuestas y citas que los referencian quedan sin el tweet. También borra las imágenes subidas hace
+// más de orphanTTL que nunca se adjuntaron a un tweet.
+type Purger struct {
+	repo      *TweetRepository
+	retention time.Duration
+	orphanTTL time.Duration
+	interval  time.Duration
+	batchSize int
+}
+
+func NewPurger(repo *TweetRepository, retention, orphanTTL time.Duration) *Purger {
+	return &Purger{repo: repo, retention: retention, orphanTTL: orphanTTL, interval: defaultPurgeInterval, batchSize: defaultPurgeBatchSize}
+}
+
+// Purge borra en lotes los tweets eliminados antes de now menos la retención hasta no quedar ninguno
+// o hasta el primer error. Devuelve cuántos tweets se borraron.
+func (p *Purger) Purge(now time.Time) (int, error) {
+	cutoff := now.Add(-p.retention)
+	total := 0
+	for {
+		purged, err := p.purgeBatch(cutoff)
+		total += purged
+		if err != nil || purged < p.batchSize {
+			return total, err
+		}
+	}
+}
+
+// purgeBatch borra un lote de tweets en una transacción; los blobs de sus imágenes se borran después
+// de confirmarla
+func (p *Purger) purgeBatch(cutoff time.Time) (int, error) {
+	var ids []uint
+	var removed []domain.Media
+	err := p.repo.tweetDB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Unscoped().Model(&domain.Tweet{}).Where("deleted_at < ?", cutoff).
+			Order("deleted_at, id").Limit(p.batchSize).Pluck("id", &ids).Error
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+
+		for _, model := range []interface{}{&domain.Like{}, &domain.TweetHashtag{}, &domain.TweetMention{}, &domain.TweetRevision{}} {
+			if err := tx.Where("tweet_id IN ?", ids).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		for _, tweetID := range ids {
+			detached, err := detachMedia(tx, tweetID)
+			if err != nil {
+				return err
+			}
+			removed = append(removed, detached...)
+		}
+		return tx.Unscoped().Where("id IN ?", ids).Delete(&domain.Tweet{}).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	p.repo.deleteBlobs(removed)
+	return len(ids), nil
+}
+
+// PurgeOrphanMedia borra en lotes las imágenes sin tweet subidas antes de now menos orphanTTL, junto
+// con sus blobs. Devuelve cuántas imágenes se borraron.
+func (p *Purger) PurgeOrphanMedia(now time.Time) (int, error) {
+	cutoff := now.Add(-p.orphanTTL)
+	total := 0
+	for {
+		// tweet_id IS NULL se repite fuera de la subconsulta para no borrar una imagen que se adjunta a
+		// un tweet mientras tanto
+		var removed []domain.Media
+		pending := p.repo.tweetDB.Model(&domain.Media{}).Select("id").
+			Where("tweet_id IS NULL AND created_at < ?", cutoff).Order("id").Limit(p.batchSize)
+		err := p.repo.tweetDB.Clauses(clause.Returning{}).
+			Where("tweet_id IS NULL AND id IN (?)", pending).Delete(&removed).Error
+		if err != nil {
+			return total, err
+		}
+		p.repo.deleteBlobs(removed)
+		total += len(removed)
+		if len(removed) < p.batchSize {
+			return total, nil
+		}
+	}
+}
+
+// Run purga periódicamente hasta que ctx se cancela. Los errores solo se registran: los tweets e
+// imágenes que no se pudieron borrar se reintentan en la siguiente pasada.
+func (p *Purger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		if purged, err := p.Purge(time.Now()); err != nil {
+			log.Printf("Error al purgar tweets eliminados: %v", err)
+		} else if purged > 0 {
+			log.Printf("Se purgaron %d tweets eliminados", purged)
+		}
+		if purged, err := p.PurgeOrphanMedia(time.Now()); err != nil {
+			log.Printf("Error al purgar imágenes sin tweet: %v", err)
+		} else if purged > 0 {
+			log.Printf("Se purgaron %d imágenes sin tweet", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
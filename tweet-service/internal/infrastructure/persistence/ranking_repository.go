@@ -0,0 +1,89 @@
+package persistence
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/DevOpslp/microblogging-platform/tweet-service/internal/domain"
+)
+
+// GetAuthorAffinity devuelve los autores con los que interactuó username desde since, con la cantidad
+// de likes que dio a sus tweets y de respuestas que les dirigió, ordenados por username. Las
+// interacciones con sus propios tweets no cuentan.
+func (repo *TweetRepository) GetAuthorAffinity(username string, since time.Time) ([]domain.AuthorAffinity, error) {
+	user, err := repo.userRepo.FindUserByUsername(username)
+	if err != nil {
+		return nil, err
+	}
+
+	type authorCount struct {
+		AuthorID uint
+		Count    int
+	}
+	var likes, replies []authorCount
+	err = repo.tweetDB.Table("likes").
+		Select("tweets.user_id AS author_id, COUNT(*) AS count").
+		Joins("JOIN tweets ON tweets.id = likes.tweet_id AND tweets.deleted_at IS NULL").
+		Where("likes.user_id = ? AND likes.created_at >= ? AND tweets.user_id <> ?", user.ID, since, user.ID).
+		Group("tweets.user_id").Find(&likes).Error
+	if err != nil {
+		return nil, fmt.Errorf("error al contar los likes por autor: %w", err)
+	}
+	err = repo.tweetDB.Model(&domain.Tweet{}).
+		Select("in_reply_to_user_id AS author_id, COUNT(*) AS count").
+		Where("user_id = ? AND created_at >= ? AND in_reply_to_user_id IS NOT NULL AND in_reply_to_user_id <> ?", user.ID, since, user.ID).
+		Group("in_reply_to_user_id").Find(&replies).Error
+	if err != nil {
+		return nil, fmt.Errorf("error al contar las respuestas por autor: %w", err)
+	}
+
+	byAuthor := make(map[uint]*domain.AuthorAffinity)
+	authorIDs := make([]uint, 0)
+	affinityOf := func(authorID uint) *domain.AuthorAffinity {
+		if byAuthor[authorID] == nil {
+			byAuthor[authorID] = &domain.AuthorAffinity{UserID: authorID}
+			authorIDs = append(authorIDs, authorID)
+		}
+		return byAuthor[authorID]
+	}
+	for _, like := range likes {
+		affinityOf(like.AuthorID).Likes = like.Count
+	}
+	for _, reply := range replies {
+		affinityOf(reply.AuthorID).Replies = reply.Count
+	}
+
+	users, err := repo.userRepo.FindUsersByIDs(authorIDs)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener los autores: %w", err)
+	}
+	affinity := make([]domain.AuthorAffinity, 0, len(authorIDs))
+	for _, authorID := range authorIDs {
+		// Los autores que ya no existen en user-service se omiten
+		if author, ok := users[authorID]; ok {
+			byAuthor[authorID].Username = author.Username
+			affinity = append(affinity, *byAuthor[authorID])
+		}
+	}
+	sort.Slice(affinity, func(i, j int) bool {
+		return affinity[i].Username < affinity[j].Username
+	})
+	return affinity, nil
+}
+
+// GetTweetEngagement devuelve los contadores actuales de los tweets indicados, ordenados por ID.
+// Los tweets que no existen o se eliminaron no aparecen.
+func (repo *TweetRepository) GetTweetEngagement(tweetIDs []uint) ([]domain.TweetEngagement, error) {
+	engagement := make([]domain.TweetEngagement, 0, len(tweetIDs))
+	if len(tweetIDs) == 0 {
+		return engagement, nil
+	}
+	err := repo.tweetDB.Model(&domain.Tweet{}).
+		Select("id AS tweet_id, reply_count, like_count, retweet_count").
+		Where("id IN ?", tweetIDs).Order("id").Find(&engagement).Error
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener las interacciones de los tweets: %w", err)
+	}
+	return engagement, nil
+}
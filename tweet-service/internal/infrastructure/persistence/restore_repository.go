@@ -0,0 +1,84 @@
+package persistence
+
+import (
+	"errors"
+	"time"
+
+	"github.com/DevOpslp/microblogging-platform/tweet-service/internal/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrRestoreWindowClosed se devuelve al restaurar un tweet después del plazo de restauración
+var ErrRestoreWindowClosed = errors.New("terminó el plazo para restaurar el tweet")
+
+// SetRestoreWindow cambia el plazo, desde la eliminación, en el que se puede restaurar un tweet
+func (repo *TweetRepository) SetRestoreWindow(window time.Duration) {
+	repo.restoreWindow = window
+}
+
+// GetDeletedTweet busca un tweet eliminado que todavía no se purgó; devuelve ErrTweetNotFound si no
+// existe o no está eliminado
+func (repo *TweetRepository) GetDeletedTweet(tweetID uint) (*domain.Tweet, error) {
+	var tweet domain.Tweet
+	if err := repo.tweetDB.Unscoped().Where("deleted_at IS NOT NULL").First(&tweet, tweetID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTweetNotFound
+		}
+		return nil, err
+	}
+	return &tweet, nil
+}
+
+// RestoreTweet deshace la eliminación de un tweet dentro del plazo de restauración. También se
+// restauran los retweets que se eliminaron con él (los que tienen el mismo DeletedAt), que se
+// devuelven para volver a agregarlos a los timelines. Si era una respuesta se vuelve a sumar al
+// contador del tweet respondido. La misma transacción guarda un evento TweetRestored por cada tweet.
+func (repo *TweetRepository) RestoreTweet(tweetID uint) (*domain.Tweet, []domain.Tweet, error) {
+	var tweet domain.Tweet
+	var retweets []domain.Tweet
+	err := repo.tweetDB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("deleted_at IS NOT NULL").First(&tweet, tweetID).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrTweetNotFound
+			}
+			return err
+		}
+		if time.Now().After(tweet.DeletedAt.Time.Add(repo.restoreWindow)) {
+			return ErrRestoreWindowClosed
+		}
+
+		deletedAt := tweet.DeletedAt.Time
+		err = tx.Unscoped().Where("retweet_of_tweet_id = ? AND deleted_at = ?", tweet.ID, deletedAt).
+			Find(&retweets).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Unscoped().Model(&domain.Tweet{}).
+			Where("(id = ? OR retweet_of_tweet_id = ?) AND deleted_at = ?", tweet.ID, tweet.ID, deletedAt).
+			UpdateColumns(map[string]interface{}{"deleted_at": nil, "deleted_by_id": 0}).Error
+		if err != nil {
+			return err
+		}
+
+		if tweet.InReplyToTweetID != nil {
+			err := tx.Unscoped().Model(&domain.Tweet{}).Where("id = ?", *tweet.InReplyToTweetID).
+				UpdateColumn("reply_count", gorm.Expr("reply_count + 1")).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		tweet.DeletedAt, tweet.DeletedByID = gorm.DeletedAt{}, 0
+		for i := range retweets {
+			retweets[i].DeletedAt, retweets[i].DeletedByID = gorm.DeletedAt{}, 0
+		}
+		return enqueueTweetsRestored(tx, append([]domain.Tweet{tweet}, retweets...)...)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return &tweet, retweets, nil
+}
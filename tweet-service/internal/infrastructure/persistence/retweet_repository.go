@@ -0,0 +1,95 @@
+package persistence
+
+import (
+	"github.com/DevOpslp/microblogging-platform/tweet-service/internal/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Retweet registra el retweet de un usuario y lo devuelve. Es idempotente: si el usuario ya lo había
+// retuiteado devuelve el retweet existente con created en false (y no se publica ningún evento).
+// Retuitear un retweet equivale a retuitear el original.
+func (repo *TweetRepository) Retweet(userID uint, username string, tweetID uint) (*domain.Tweet, bool, error) {
+	var retweet domain.Tweet
+	created := false
+	err := repo.tweetDB.Transaction(func(tx *gorm.DB) error {
+		original, err := findOriginal(tx, tweetID)
+		if err != nil {
+			return err
+		}
+
+		retweet = domain.Tweet{UserID: userID, RetweetOfTweetID: &original.ID}
+		result := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "retweet_of_tweet_id"}},
+			DoNothing: true,
+		}).Create(&retweet)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return tx.Where("user_id = ? AND retweet_of_tweet_id = ?", userID, original.ID).First(&retweet).Error
+		}
+
+		// El UPDATE atómico no afecta filas si el original se eliminó en paralelo
+		updated := tx.Model(&domain.Tweet{}).Where("id = ?", original.ID).
+			UpdateColumn("retweet_count", gorm.Expr("retweet_count + 1"))
+		if updated.Error != nil {
+			return updated.Error
+		}
+		if updated.RowsAffected == 0 {
+			return ErrTweetNotFound
+		}
+		created = true
+		return enqueueTweetCreated(tx, &retweet, username, &original.UserID, nil)
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return &retweet, created, nil
+}
+
+// Unretweet quita el retweet de un usuario y lo devuelve, o nil si no existía (es idempotente).
+// tweetID puede ser el original o el propio retweet.
+func (repo *TweetRepository) Unretweet(userID, tweetID uint) (*domain.Tweet, error) {
+	var retweets []domain.Tweet
+	err := repo.tweetDB.Transaction(func(tx *gorm.DB) error {
+		original, err := findOriginal(tx, tweetID)
+		if err != nil {
+			return err
+		}
+
+		result := tx.Unscoped().Clauses(clause.Returning{}).
+			Where("user_id = ? AND retweet_of_tweet_id = ?", userID, original.ID).Delete(&retweets)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		if err := decrementCounter(tx, original.ID, "retweet_count"); err != nil {
+			return err
+		}
+		return enqueueTweetsDeleted(tx, retweets...)
+	})
+	if err != nil || len(retweets) == 0 {
+		return nil, err
+	}
+	return &retweets[0], nil
+}
+
+// findOriginal carga un tweet y, si es un retweet, el tweet original al que apunta
+func findOriginal(tx *gorm.DB, tweetID uint) (*domain.Tweet, error) {
+	var tweet domain.Tweet
+	if err := findTweet(tx, tweetID, &tweet); err != nil {
+		return nil, err
+	}
+	if !tweet.IsRetweet() {
+		return &tweet, nil
+	}
+
+	var original domain.Tweet
+	if err := findTweet(tx, *tweet.RetweetOfTweetID, &original); err != nil {
+		return nil, err
+	}
+	return &original, nil
+}
@@ -0,0 +1,117 @@
+package persistence
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/DevOpslp/microblogging-platform/tweet-service/internal/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrEditWindowClosed se devuelve al editar un tweet después del plazo de edición
+	ErrEditWindowClosed = errors.New("terminó el plazo para editar el tweet")
+	// ErrRetweetNotEditable se devuelve al editar un retweet, que no tiene contenido propio
+	ErrRetweetNotEditable = errors.New("un retweet no se puede editar")
+	// ErrEmptyContent se devuelve al dejar sin contenido un tweet que no tiene imágenes
+	ErrEmptyContent = errors.New("el tweet no puede quedar vacío")
+)
+
+// SetEditWindow cambia el plazo, desde la creación del tweet, en el que su autor puede editarlo
+func (repo *TweetRepository) SetEditWindow(window time.Duration) {
+	repo.editWindow = window
+}
+
+// EditTweet reemplaza el contenido de un tweet de userID dentro del plazo de edición y guarda la
+// versión nueva en tweet_revisions; en la primera edición también se guarda el contenido original.
+// Los hashtags y las menciones se recalculan y la misma transacción guarda el evento TweetUpdated.
+// Si el contenido no cambia no se crea una versión nueva. Devuelve ErrTweetNotFound si el tweet no
+// existe o es de otro usuario.
+func (repo *TweetRepository) EditTweet(tweetID, userID uint, content string) (*domain.Tweet, error) {
+	mentions, err := repo.resolveMentions(content)
+	if err != nil {
+		return nil, err
+	}
+	if err := repo.checkNotBlocked(userID, nil, mentions); err != nil {
+		return nil, err
+	}
+
+	var tweet domain.Tweet
+	err = repo.tweetDB.Transaction(func(tx *gorm.DB) error {
+		// El bloqueo evita que dos ediciones en paralelo usen el mismo número de versión
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND user_id = ?", tweetID, userID).First(&tweet).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrTweetNotFound
+			}
+			return err
+		}
+
+		now := time.Now()
+		switch {
+		case tweet.IsRetweet():
+			return ErrRetweetNotEditable
+		case now.After(tweet.EditableUntil(repo.editWindow)):
+			return ErrEditWindowClosed
+		case content == "" && tweet.MediaCount == 0:
+			return ErrEmptyContent
+		case content == tweet.Content:
+			return nil
+		}
+
+		if !tweet.Edited() {
+			original := domain.TweetRevision{TweetID: tweet.ID, Revision: 1, Content: tweet.Content, CreatedAt: tweet.CreatedAt}
+			if err := tx.Create(&original).Error; err != nil {
+				return err
+			}
+		}
+
+		tweet.Content = content
+		tweet.EditCount++
+		tweet.EditedAt = &now
+		revision := domain.TweetRevision{TweetID: tweet.ID, Revision: tweet.RevisionCount(), Content: content, CreatedAt: now}
+		if err := tx.Create(&revision).Error; err != nil {
+			return err
+		}
+		err = tx.Model(&tweet).Updates(map[string]interface{}{
+			"content":    tweet.Content,
+			"edit_count": tweet.EditCount,
+			"edited_at":  now,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Where("tweet_id = ?", tweet.ID).Delete(&domain.TweetHashtag{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("tweet_id = ?", tweet.ID).Delete(&domain.TweetMention{}).Error; err != nil {
+			return err
+		}
+		if err := saveHashtags(tx, &tweet); err != nil {
+			return err
+		}
+		if err := saveMentions(tx, &tweet, mentions); err != nil {
+			return err
+		}
+		return enqueueTweetUpdated(tx, &tweet, mentions)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &tweet, nil
+}
+
+// GetTweetRevisions devuelve las versiones del contenido de un tweet, de la original a la actual
+func (repo *TweetRepository) GetTweetRevisions(tweet domain.Tweet) ([]domain.TweetRevision, error) {
+	var revisions []domain.TweetRevision
+	if tweet.Edited() {
+		if err := repo.tweetDB.Where("tweet_id = ?", tweet.ID).Order("revision").Find(&revisions).Error; err != nil {
+			return nil, fmt.Errorf("error al obtener las versiones del tweet: %w", err)
+		}
+	}
+	return tweet.Revisions(revisions), nil
+}
@@ -0,0 +1,95 @@
+package persistence
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// Tiempo máximo de cada operación contra el almacenamiento S3
+const s3Timeout = 30 * time.Second
+
+// S3Config configura un almacenamiento compatible con S3 (AWS S3, MinIO, etc.)
+type S3Config struct {
+	Endpoint  string // host:puerto, sin esquema
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	UseSSL    bool
+}
+
+// S3BlobStore guarda los blobs como objetos de un bucket compatible con S3
+type S3BlobStore struct {
+	client *minio.Client
+	bucket string
+}
+
+func NewS3BlobStore(config S3Config) (*S3BlobStore, error) {
+	client, err := minio.New(config.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(config.AccessKey, config.SecretKey, ""),
+		Secure: config.UseSSL,
+		Region: config.Region,
+		// Direcciones de tipo endpoint/bucket/clave, que también soportan los servicios locales tipo MinIO
+		BucketLookup: minio.BucketLookupPath,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("configuración de S3 inválida: %w", err)
+	}
+	return &S3BlobStore{client: client, bucket: config.Bucket}, nil
+}
+
+func (s *S3BlobStore) Put(key, contentType string, data []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s3Timeout)
+	defer cancel()
+
+	_, err := s.client.PutObject(ctx, s.bucket, key, bytes.NewReader(data), int64(len(data)), minio.PutObjectOptions{
+		ContentType: contentType,
+		// Los blobs son chicos y se suben en una sola petición sin firmar el contenido por partes
+		DisableContentSha256: true,
+	})
+	return err
+}
+
+// Open descarga el objeto completo para distinguir un objeto inexistente antes de empezar a responder
+func (s *S3BlobStore) Open(key string) (io.ReadCloser, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s3Timeout)
+	defer cancel()
+
+	object, err := s.client.GetObject(ctx, s.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, translateS3Error(err)
+	}
+	defer object.Close()
+
+	data, err := io.ReadAll(object)
+	if err != nil {
+		return nil, translateS3Error(err)
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (s *S3BlobStore) Delete(key string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s3Timeout)
+	defer cancel()
+
+	err := translateS3Error(s.client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{}))
+	if errors.Is(err, ErrBlobNotFound) {
+		return nil
+	}
+	return err
+}
+
+func translateS3Error(err error) error {
+	if err != nil && minio.ToErrorResponse(err).StatusCode == http.StatusNotFound {
+		return ErrBlobNotFound
+	}
+	return err
+}
@@ -0,0 +1,159 @@
+package persistence
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/DevOpslp/microblogging-platform/tweet-service/internal/domain"
+	"gorm.io/gorm"
+)
+
+// Configuración de búsqueda de texto de PostgreSQL: normaliza las palabras en español (plurales,
+// conjugaciones) y descarta las palabras vacías
+const searchConfig = "spanish"
+
+// tsquery convierte el texto de la búsqueda con la misma configuración que search_vector
+const tsquery = "websearch_to_tsquery('" + searchConfig + "', ?)"
+
+// MigrateSearch agrega a tweets la columna search_vector, generada por PostgreSQL a partir del
+// contenido, y su índice GIN. Se ejecuta después de AutoMigrate y es idempotente.
+func MigrateSearch(db *gorm.DB) error {
+	statements := []string{
+		`ALTER TABLE tweets ADD COLUMN IF NOT EXISTS search_vector tsvector
+			GENERATED ALWAYS AS (to_tsvector('` + searchConfig + `', content)) STORED`,
+		`CREATE INDEX IF NOT EXISTS idx_tweets_search_vector ON tweets USING GIN (search_vector)`,
+	}
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			return fmt.Errorf("error al crear el índice de búsqueda: %w", err)
+		}
+	}
+	return nil
+}
+
+// searchHit es un tweet que cumple la búsqueda, con su relevancia
+type searchHit struct {
+	ID        uint
+	CreatedAt time.Time
+	Rank      float32
+}
+
+// SearchTweets devuelve una página de los tweets que cumplen la búsqueda y que el viewer puede ver,
+// sin retweets. Con domain.SearchSortRelevance se ordenan por relevancia (ts_rank) y luego del más
+// nuevo al más antiguo; si la búsqueda no tiene palabras que buscar, o con domain.SearchSortRecent,
+// solo del más nuevo al más antiguo. Los tweets ocultos se quitan después de paginar, por lo que una
+// página puede traer menos de limit.
+func (repo *TweetRepository) SearchTweets(search domain.SearchQuery, sort string, viewer domain.Viewer, cursor *domain.SearchCursor, limit int) ([]domain.TweetWithUser, *domain.SearchCursor, error) {
+	query, ok, err := repo.searchFilter(search)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error al resolver los autores de la búsqueda: %w", err)
+	}
+	if !ok {
+		return []domain.TweetWithUser{}, nil, nil
+	}
+
+	byRelevance := sort == domain.SearchSortRelevance && search.HasPositiveText()
+	if byRelevance {
+		rank := gorm.Expr("ts_rank(search_vector, "+tsquery+")", search.TextQuery())
+		query = query.Select("id, created_at, ? AS rank", rank)
+		if cursor != nil {
+			query = query.Where("(?, id) < (?, ?)", rank, cursor.Rank, cursor.ID)
+		}
+		query = query.Order("rank DESC, id DESC")
+	} else {
+		query = query.Select("id, created_at")
+		if cursor != nil {
+			query = query.Where("(created_at, id) < (?, ?)", cursor.CreatedAt, cursor.ID)
+		}
+		query = query.Order("created_at DESC, id DESC")
+	}
+
+	var hits []searchHit
+	if err := query.Limit(limit + 1).Find(&hits).Error; err != nil {
+		return nil, nil, fmt.Errorf("error al buscar tweets: %w", err)
+	}
+
+	var next *domain.SearchCursor
+	if len(hits) > limit {
+		hits = hits[:limit]
+		last := hits[limit-1]
+		next = &domain.SearchCursor{Rank: last.Rank, CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+
+	tweetIDs := make([]uint, 0, len(hits))
+	for _, hit := range hits {
+		tweetIDs = append(tweetIDs, hit.ID)
+	}
+	tweets, err := repo.findTweetsInOrder(tweetIDs)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error al obtener tweets: %w", err)
+	}
+
+	tweetsWithUser, err := repo.visibleWithUsernames(tweets, viewer)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error al obtener usuarios de los tweets: %w", err)
+	}
+
+	// Los tweets de usuarios bloqueados o silenciados por el viewer no se muestran
+	hidden, err := repo.hiddenAuthors(viewer)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error al obtener los usuarios ocultos: %w", err)
+	}
+	return domain.HideAuthors(tweetsWithUser, hidden), next, nil
+}
+
+// searchFilter arma las condiciones de la búsqueda sobre tweets. Devuelve false si ningún tweet
+// puede cumplirla porque no existe ninguno de los autores de from:.
+func (repo *TweetRepository) searchFilter(search domain.SearchQuery) (*gorm.DB, bool, error) {
+	query := repo.tweetDB.Model(&domain.Tweet{}).Where("retweet_of_tweet_id IS NULL")
+
+	if text := search.TextQuery(); text != "" {
+		query = query.Where("search_vector @@ "+tsquery, text)
+	}
+	for _, tag := range search.Hashtags {
+		query = query.Where("EXISTS (SELECT 1 FROM tweet_hashtags WHERE tweet_hashtags.tweet_id = tweets.id AND tweet_hashtags.tag = ?)", tag)
+	}
+	for _, tag := range search.ExcludedHashtags {
+		query = query.Where("NOT EXISTS (SELECT 1 FROM tweet_hashtags WHERE tweet_hashtags.tweet_id = tweets.id AND tweet_hashtags.tag = ?)", tag)
+	}
+	if search.Since != nil {
+		query = query.Where("created_at >= ?", *search.Since)
+	}
+	if search.Until != nil {
+		query = query.Where("created_at < ?", *search.Until)
+	}
+
+	if len(search.From) > 0 {
+		authorIDs, err := repo.findUserIDs(search.From)
+		if err != nil {
+			return nil, false, err
+		}
+		if len(authorIDs) == 0 {
+			return nil, false, nil
+		}
+		query = query.Where("user_id IN ?", authorIDs)
+	}
+	if len(search.ExcludedFrom) > 0 {
+		excludedIDs, err := repo.findUserIDs(search.ExcludedFrom)
+		if err != nil {
+			return nil, false, err
+		}
+		if len(excludedIDs) > 0 {
+			query = query.Where("user_id NOT IN ?", excludedIDs)
+		}
+	}
+	return query, true, nil
+}
+
+// findUserIDs resuelve los usernames en una sola petición a user-service, ignorando los inexistentes
+func (repo *TweetRepository) findUserIDs(usernames []string) ([]uint, error) {
+	users, err := repo.userRepo.FindUsersByUsernames(usernames)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uint, 0, len(users))
+	for _, user := range users {
+		ids = append(ids, user.ID)
+	}
+	return ids, nil
+}
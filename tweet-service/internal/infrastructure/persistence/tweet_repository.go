@@ -8,91 +8,534 @@ import (
 
 	"github.com/DevOpslp/microblogging-platform/tweet-service/internal/domain"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+var (
+	// ErrTweetNotFound se devuelve cuando el tweet solicitado no existe
+	ErrTweetNotFound = errors.New("tweet no encontrado")
+	// ErrParentNotFound se devuelve al responder a un tweet que no existe
+	ErrParentNotFound = errors.New("tweet respondido no encontrado")
+	// ErrQuotedNotFound se devuelve al citar un tweet que no existe
+	ErrQuotedNotFound = errors.New("tweet citado no encontrado")
+)
+
+// blobs guarda los archivos de las imágenes adjuntas (ver SaveMedia), editWindow es el plazo en el
+// que el autor puede editar un tweet (ver EditTweet) y restoreWindow el plazo para restaurar un tweet
+// eliminado (ver RestoreTweet)
 type TweetRepository struct {
-	tweetDB  *gorm.DB
-	userRepo UserRepository
+	tweetDB       *gorm.DB
+	userRepo      UserRepository
+	blobs         BlobStore
+	editWindow    time.Duration
+	restoreWindow time.Duration
 }
 
-func NewTweetRepository(tweetDB *gorm.DB, userRepo UserRepository) *TweetRepository {
-	return &TweetRepository{tweetDB: tweetDB, userRepo: userRepo}
+func NewTweetRepository(tweetDB *gorm.DB, userRepo UserRepository, blobs BlobStore) *TweetRepository {
+	return &TweetRepository{
+		tweetDB:       tweetDB,
+		userRepo:      userRepo,
+		blobs:         blobs,
+		editWindow:    domain.DefaultEditWindow,
+		restoreWindow: domain.DefaultRestoreWindow,
+	}
 }
 
-// Crear un tweet usando el username del `user-service`
-func (repo *TweetRepository) CreateTweet(username, content string) (*domain.Tweet, error) {
-	// Obtener `UserID` desde `user-service`
-	user, err := repo.userRepo.FindUserByUsername(username)
+// Crear un tweet para el usuario autenticado. Si inReplyTo no es nil el tweet es una respuesta
+// y hereda la conversación del tweet respondido; si quoted no es nil el tweet cita a otro.
+// Responder o citar un retweet equivale a responder o citar el tweet original.
+// attachments son imágenes subidas por el mismo usuario que todavía no están adjuntas a otro tweet.
+// Las menciones y los bloqueos se resuelven contra user-service antes de abrir la transacción, que
+// también guarda el evento TweetCreated en la outbox.
+func (repo *TweetRepository) CreateTweet(userID uint, username, content string, inReplyTo, quoted *uint, attachments []domain.MediaAttachment) (*domain.Tweet, error) {
+	mentions, err := repo.resolveMentions(content)
 	if err != nil {
-		return nil, fmt.Errorf("usuario no encontrado en user-service: %w", err)
+		return nil, err
+	}
+	if err := repo.checkNotBlocked(userID, inReplyTo, mentions); err != nil {
+		return nil, err
 	}
 
 	// Crear el tweet asociado a `UserID`
 	tweet := &domain.Tweet{
-		UserID:    user.ID,
-		Content:   content,
-		CreatedAt: time.Now(), // Asignar explícitamente la fecha de creación
+		UserID:     userID,
+		Content:    content,
+		MediaCount: len(attachments),
+		CreatedAt:  time.Now(), // Asignar explícitamente la fecha de creación
 
 	}
-	if err := repo.tweetDB.Create(tweet).Error; err != nil {
+
+	var quotedUserID *uint
+	err = repo.tweetDB.Transaction(func(tx *gorm.DB) error {
+		if inReplyTo != nil {
+			parent, err := findOriginal(tx, *inReplyTo)
+			if err != nil {
+				if errors.Is(err, ErrTweetNotFound) {
+					return ErrParentNotFound
+				}
+				return err
+			}
+			tweet.InReplyToTweetID = &parent.ID
+			tweet.InReplyToUserID = &parent.UserID
+			tweet.ConversationID = parent.RootID()
+			tweet.Depth = parent.Depth + 1
+		}
+
+		if quoted != nil {
+			original, err := findOriginal(tx, *quoted)
+			if err != nil {
+				if errors.Is(err, ErrTweetNotFound) {
+					return ErrQuotedNotFound
+				}
+				return err
+			}
+			tweet.QuotedTweetID = &original.ID
+			quotedUserID = &original.UserID
+		}
+
+		if err := tx.Create(tweet).Error; err != nil {
+			return err
+		}
+		if err := saveHashtags(tx, tweet); err != nil {
+			return err
+		}
+		if err := saveMentions(tx, tweet, mentions); err != nil {
+			return err
+		}
+		if err := attachMedia(tx, tweet, attachments); err != nil {
+			return err
+		}
+
+		if inReplyTo == nil {
+			// Un tweet que no responde a otro inicia su propia conversación
+			tweet.ConversationID = tweet.ID
+			if err := tx.Model(tweet).UpdateColumn("conversation_id", tweet.ID).Error; err != nil {
+				return err
+			}
+		} else {
+			// El UPDATE atómico no afecta filas si el tweet respondido se eliminó en paralelo
+			result := tx.Model(&domain.Tweet{}).Where("id = ?", *tweet.InReplyToTweetID).
+				UpdateColumn("reply_count", gorm.Expr("reply_count + 1"))
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return ErrParentNotFound
+			}
+		}
+		return enqueueTweetCreated(tx, tweet, username, quotedUserID, mentions)
+	})
+	if err != nil {
 		return nil, err
 	}
 
 	return tweet, nil
 }
 
-// Obtener tweets por username
-func (repo *TweetRepository) GetTweetsByUsername(username string) ([]domain.Tweet, error) {
+// Contar los tweets publicados por un usuario, incluidos sus retweets
+func (repo *TweetRepository) CountTweetsByUser(userID uint) (int64, error) {
+	var count int64
+	if err := repo.tweetDB.Model(&domain.Tweet{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// Obtener una página de tweets por username, del más nuevo al más antiguo.
+// Incluye los retweets del usuario; un tweet y sus retweets aparecen una sola vez (ver findNewestPage).
+// Si la cuenta es privada y el viewer no la sigue devuelve ErrPrivateAccount.
+func (repo *TweetRepository) GetTweetsByUsername(username string, viewer domain.Viewer, cursor *domain.Cursor, limit int) ([]domain.TweetWithUser, *domain.Cursor, error) {
 	user, err := repo.userRepo.FindUserByUsername(username)
 	if err != nil {
-		return nil, fmt.Errorf("usuario no encontrado: %w", err)
+		return nil, nil, fmt.Errorf("usuario no encontrado: %w", err)
+	}
+	if err := repo.checkCanViewUser(user, viewer); err != nil {
+		return nil, nil, err
 	}
 
-	var tweets []domain.Tweet
-	if err := repo.tweetDB.Where("user_id = ?", user.ID).Find(&tweets).Error; err != nil {
+	tweets, next, err := repo.findNewestPage(user.ID, cursor, limit)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error al obtener tweets: %w", err)
+	}
+
+	tweetsWithUser, err := repo.visibleWithUsernames(tweets, viewer)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error al obtener usuarios de los tweets: %w", err)
+	}
+
+	return tweetsWithUser, next, nil
+}
+
+// Obtener una página de todos los tweets que el viewer puede ver con información del usuario.
+// Los tweets ocultos se quitan después de paginar, por lo que una página puede traer menos de limit.
+func (repo *TweetRepository) GetAllTweets(viewer domain.Viewer, cursor *domain.Cursor, limit int) ([]domain.TweetWithUser, *domain.Cursor, error) {
+	tweets, next, err := repo.findNewestPage(0, cursor, limit)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error al obtener tweets: %w", err)
+	}
+
+	// Resolver todos los autores de la página en una sola petición a `user-service`
+	tweetsWithUser, err := repo.visibleWithUsernames(tweets, viewer)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error al obtener usuarios de los tweets: %w", err)
+	}
+
+	return tweetsWithUser, next, nil
+}
+
+// Hydrate agrega el username del autor y los tweets retuiteados o citados a un único tweet
+func (repo *TweetRepository) Hydrate(tweet domain.Tweet) (*domain.TweetWithUser, error) {
+	tweetsWithUser, err := repo.withUsernames([]domain.Tweet{tweet})
+	if err != nil {
 		return nil, err
 	}
-	return tweets, nil
+	return &tweetsWithUser[0], nil
 }
 
-// Obtener todos los tweets
-// Obtener todos los tweets con información del usuario
-func (repo *TweetRepository) GetAllTweets() ([]domain.TweetWithUser, error) {
-	var tweets []domain.Tweet
-	if err := repo.tweetDB.Find(&tweets).Error; err != nil {
-		fmt.Printf("Error al obtener tweets: %v\n", err) // Agrega el registro
-		return nil, fmt.Errorf("error al obtener tweets: %w", err)
+// withUsernames agrega el username del autor, las menciones y las imágenes a cada tweet y embebe los tweets
+// retuiteados o citados, resolviendo todos los autores con una única búsqueda por lote.
+// Si un autor ya no existe en `user-service` se usa domain.UnknownUsername.
+func (repo *TweetRepository) withUsernames(tweets []domain.Tweet) ([]domain.TweetWithUser, error) {
+	referenced, err := repo.findReferencedTweets(tweets)
+	if err != nil {
+		return nil, err
 	}
 
-	var tweetsWithUser []domain.TweetWithUser
+	userIDs := make([]uint, 0, len(tweets)+len(referenced))
+	seen := make(map[uint]bool, len(tweets)+len(referenced))
+	addUser := func(tweet domain.Tweet) {
+		if !seen[tweet.UserID] {
+			seen[tweet.UserID] = true
+			userIDs = append(userIDs, tweet.UserID)
+		}
+	}
 	for _, tweet := range tweets {
-		user, err := repo.userRepo.FindUserByID(tweet.UserID)
-		if err != nil {
-			fmt.Printf("Error al obtener usuario para tweet %d: %v\n", tweet.ID, err) // Agrega el registro
-			return nil, fmt.Errorf("usuario no encontrado para tweet %d: %w", tweet.ID, err)
+		addUser(tweet)
+	}
+	for _, tweet := range referenced {
+		addUser(tweet)
+	}
+
+	users, err := repo.userRepo.FindUsersByIDs(userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	all := append(make([]domain.Tweet, 0, len(tweets)+len(referenced)), tweets...)
+	for _, tweet := range referenced {
+		all = append(all, tweet)
+	}
+	mentions, err := repo.findMentions(all)
+	if err != nil {
+		return nil, err
+	}
+	attached, err := repo.findMedia(all)
+	if err != nil {
+		return nil, err
+	}
+
+	// Los tweets eliminados solo llegan aquí como ancestros o respuestas de un hilo o como citas, y se
+	// muestran como tombstone
+	withUser := func(tweet domain.Tweet) domain.TweetWithUser {
+		if tweet.IsDeleted() {
+			return domain.TweetWithUser{Tweet: tweet}.Tombstone()
 		}
-		tweetsWithUser = append(tweetsWithUser, domain.TweetWithUser{
-			Tweet:    tweet,
-			Username: user.Username,
-		})
+		username, private := domain.UnknownUsername, false
+		if user, ok := users[tweet.UserID]; ok {
+			username, private = user.Username, user.IsPrivate
+		}
+		return domain.TweetWithUser{Tweet: tweet, Username: username, AuthorPrivate: private, Mentions: mentions[tweet.ID], Media: attached[tweet.ID]}
+	}
+	embed := func(tweetID *uint) *domain.TweetWithUser {
+		if tweetID == nil {
+			return nil
+		}
+		original, ok := referenced[*tweetID]
+		if !ok {
+			return nil
+		}
+		embedded := withUser(original)
+		return &embedded
 	}
 
+	tweetsWithUser := make([]domain.TweetWithUser, 0, len(tweets))
+	for _, tweet := range tweets {
+		tweetWithUser := withUser(tweet)
+		tweetWithUser.Retweeted = embed(tweet.RetweetOfTweetID)
+		tweetWithUser.Quoted = embed(tweet.QuotedTweetID)
+		tweetsWithUser = append(tweetsWithUser, tweetWithUser)
+	}
 	return tweetsWithUser, nil
 }
 
+// findTweetsInOrder carga los tweets con una sola consulta y los devuelve en el orden de tweetIDs,
+// omitiendo los que ya no existen
+func (repo *TweetRepository) findTweetsInOrder(tweetIDs []uint) ([]domain.Tweet, error) {
+	tweets := make([]domain.Tweet, 0, len(tweetIDs))
+	if len(tweetIDs) == 0 {
+		return tweets, nil
+	}
+
+	var found []domain.Tweet
+	if err := repo.tweetDB.Where("id IN ?", tweetIDs).Find(&found).Error; err != nil {
+		return nil, err
+	}
+
+	byID := make(map[uint]domain.Tweet, len(found))
+	for _, tweet := range found {
+		byID[tweet.ID] = tweet
+	}
+	for _, tweetID := range tweetIDs {
+		if tweet, ok := byID[tweetID]; ok {
+			tweets = append(tweets, tweet)
+		}
+	}
+	return tweets, nil
+}
+
+// findReferencedTweets carga en una sola consulta los tweets retuiteados o citados por los tweets dados,
+// incluidos los eliminados para mostrar las citas de un tweet eliminado como tombstone
+func (repo *TweetRepository) findReferencedTweets(tweets []domain.Tweet) (map[uint]domain.Tweet, error) {
+	ids := make([]uint, 0)
+	for _, tweet := range tweets {
+		if tweet.RetweetOfTweetID != nil {
+			ids = append(ids, *tweet.RetweetOfTweetID)
+		}
+		if tweet.QuotedTweetID != nil {
+			ids = append(ids, *tweet.QuotedTweetID)
+		}
+	}
+
+	referenced := make(map[uint]domain.Tweet, len(ids))
+	if len(ids) == 0 {
+		return referenced, nil
+	}
+
+	var found []domain.Tweet
+	if err := repo.tweetDB.Unscoped().Where("id IN ?", ids).Find(&found).Error; err != nil {
+		return nil, err
+	}
+	for _, tweet := range found {
+		referenced[tweet.ID] = tweet
+	}
+	return referenced, nil
+}
+
+// findPage aplica la paginación por keyset (created_at, id) descendente.
+// Devuelve el cursor de la página siguiente, o nil si no hay más tweets.
+func (repo *TweetRepository) findPage(query *gorm.DB, cursor *domain.Cursor, limit int) ([]domain.Tweet, *domain.Cursor, error) {
+	if cursor != nil {
+		query = query.Where("(created_at, id) < (?, ?)", cursor.CreatedAt, cursor.ID)
+	}
+
+	var tweets []domain.Tweet
+	if err := query.Order("created_at DESC, id DESC").Limit(limit + 1).Find(&tweets).Error; err != nil {
+		return nil, nil, err
+	}
+
+	if len(tweets) <= limit {
+		return tweets, nil, nil
+	}
+	tweets = tweets[:limit]
+	next := tweets[limit-1].Cursor()
+	return tweets, &next, nil
+}
+
+// findNewestPage es findPage quitando en la consulta las apariciones repetidas de un mismo contenido:
+// de un tweet y sus retweets solo queda el más nuevo, entre todos los tweets del usuario (userID) o de
+// todos los usuarios (userID 0) y no solo los de la página. Así las páginas no quedan más cortas que limit
+// ni un tweet vuelve a aparecer en la página siguiente. El cursor y el límite se aplican a la consulta
+// principal, que recorre el índice por (created_at, id); cada fila solo consulta sus copias más nuevas
+// con idx_tweets_content_newest (ver MigrateContentIndex).
+func (repo *TweetRepository) findNewestPage(userID uint, cursor *domain.Cursor, limit int) ([]domain.Tweet, *domain.Cursor, error) {
+	newer := `SELECT 1 FROM tweets AS newer
+		WHERE COALESCE(newer.retweet_of_tweet_id, newer.id) = COALESCE(tweets.retweet_of_tweet_id, tweets.id)
+		AND (newer.created_at, newer.id) > (tweets.created_at, tweets.id)
+		AND newer.deleted_at IS NULL`
+	query := repo.tweetDB
+	if userID != 0 {
+		query = query.Where("user_id = ?", userID)
+		newer += " AND newer.user_id = tweets.user_id"
+	}
+	return repo.findPage(query.Where("NOT EXISTS ("+newer+")"), cursor, limit)
+}
+
+// MigrateContentIndex crea el índice con el que findNewestPage busca las copias más nuevas de un mismo
+// contenido. Se ejecuta después de AutoMigrate y es idempotente.
+func MigrateContentIndex(db *gorm.DB) error {
+	err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_tweets_content_newest
+		ON tweets ((COALESCE(retweet_of_tweet_id, id)), created_at, id) WHERE deleted_at IS NULL`).Error
+	if err != nil {
+		return fmt.Errorf("error al crear el índice de contenido: %w", err)
+	}
+	return nil
+}
+
 // Obtener un tweet por ID
 func (repo *TweetRepository) GetTweetByID(tweetID uint) (*domain.Tweet, error) {
 	var tweet domain.Tweet
 	if err := repo.tweetDB.First(&tweet, tweetID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTweetNotFound
+		}
 		return nil, err
 	}
 	return &tweet, nil
 }
 
-// Eliminar un tweet por ID
-func (repo *TweetRepository) DeleteTweetByID(tweetID uint) error {
-	if err := repo.tweetDB.Delete(&domain.Tweet{}, tweetID).Error; err != nil {
-		return errors.New("no se pudo eliminar el tweet")
+// Obtener el hilo de un tweet: sus ancestros desde la raíz y una página de todas sus respuestas
+// (directas e indirectas) ordenadas por profundidad y luego de la más antigua a la más nueva.
+// Si el viewer no puede ver el tweet devuelve ErrTweetNotFound; los ancestros y respuestas ocultos se omiten,
+// igual que las respuestas de usuarios bloqueados o silenciados.
+func (repo *TweetRepository) GetThread(tweetID uint, viewer domain.Viewer, cursor *domain.ThreadCursor, limit int) (*domain.Thread, *domain.ThreadCursor, error) {
+	tweet, err := repo.GetTweetByID(tweetID)
+	if err != nil {
+		return nil, nil, err
 	}
-	return nil
+
+	ancestors, err := repo.findAncestors(*tweet)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error al obtener los ancestros del tweet: %w", err)
+	}
+
+	replies, next, err := repo.findReplies(*tweet, cursor, limit)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error al obtener las respuestas del tweet: %w", err)
+	}
+
+	// Resolver los autores de todo el hilo en una sola petición a `user-service`
+	tweets := make([]domain.Tweet, 0, 1+len(ancestors)+len(replies))
+	tweets = append(append(append(tweets, *tweet), ancestors...), replies...)
+	tweetsWithUser, err := repo.withUsernames(tweets)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error al obtener usuarios del hilo: %w", err)
+	}
+	followed, err := repo.followedPrivateAuthors(tweetsWithUser, viewer)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error al obtener usuarios del hilo: %w", err)
+	}
+	root := domain.FilterVisible(tweetsWithUser[:1], viewer, followed)
+	if len(root) == 0 {
+		return nil, nil, ErrTweetNotFound
+	}
+
+	// Las respuestas de usuarios bloqueados o silenciados no se muestran
+	hidden, err := repo.hiddenAuthors(viewer)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error al obtener los usuarios ocultos: %w", err)
+	}
+	visibleReplies := domain.FilterVisible(tweetsWithUser[1+len(ancestors):], viewer, followed)
+
+	return &domain.Thread{
+		Tweet:     root[0],
+		Ancestors: domain.FilterVisible(tweetsWithUser[1:1+len(ancestors)], viewer, followed),
+		Replies:   domain.HideAuthors(visibleReplies, hidden),
+	}, next, nil
+}
+
+// findAncestors recorre la cadena de tweets respondidos hasta la raíz, ordenada desde la raíz
+func (repo *TweetRepository) findAncestors(tweet domain.Tweet) ([]domain.Tweet, error) {
+	ancestors := make([]domain.Tweet, 0)
+	if tweet.InReplyToTweetID == nil {
+		return ancestors, nil
+	}
+
+	err := repo.tweetDB.Raw(`
+		WITH RECURSIVE ancestors AS (
+			SELECT * FROM tweets WHERE id = ?
+			UNION ALL
+			SELECT t.* FROM tweets t JOIN ancestors a ON t.id = a.in_reply_to_tweet_id
+		)
+		SELECT * FROM ancestors ORDER BY depth ASC, created_at ASC, id ASC`, *tweet.InReplyToTweetID).
+		Scan(&ancestors).Error
+	return ancestors, err
+}
+
+// findReplies aplica la paginación por keyset (depth, created_at, id) ascendente sobre el árbol de respuestas.
+// Devuelve el cursor de la página siguiente, o nil si no hay más respuestas.
+func (repo *TweetRepository) findReplies(tweet domain.Tweet, cursor *domain.ThreadCursor, limit int) ([]domain.Tweet, *domain.ThreadCursor, error) {
+	query := `
+		WITH RECURSIVE replies AS (
+			SELECT * FROM tweets WHERE in_reply_to_tweet_id = ?
+			UNION ALL
+			SELECT t.* FROM tweets t JOIN replies r ON t.in_reply_to_tweet_id = r.id
+		)
+		SELECT * FROM replies`
+	args := []interface{}{tweet.ID}
+	if cursor != nil {
+		query += ` WHERE (depth, created_at, id) > (?, ?, ?)`
+		args = append(args, cursor.Depth, cursor.CreatedAt, cursor.ID)
+	}
+	query += ` ORDER BY depth ASC, created_at ASC, id ASC LIMIT ?`
+	args = append(args, limit+1)
+
+	replies := make([]domain.Tweet, 0)
+	if err := repo.tweetDB.Raw(query, args...).Scan(&replies).Error; err != nil {
+		return nil, nil, err
+	}
+
+	if len(replies) <= limit {
+		return replies, nil, nil
+	}
+	replies = replies[:limit]
+	next := replies[limit-1].ThreadCursor()
+	return replies, &next, nil
+}
+
+// Eliminar un tweet por ID, devuelve ErrTweetNotFound si no existe o ya se eliminó. deletedBy es
+// quien lo elimina (el autor o un administrador), que decide quién puede restaurarlo.
+// El tweet y sus retweets se marcan como eliminados con el mismo DeletedAt para restaurarlos juntos
+// (ver RestoreTweet); sus likes, hashtags, menciones, versiones e imágenes se conservan hasta que el
+// Purger los borra definitivamente. Un retweet no tiene contenido propio y se borra directamente.
+// Los retweets se devuelven para poder quitarlos de los timelines.
+// Si el tweet era una respuesta o un retweet se descuenta del contador del tweet original.
+// En la misma transacción se guarda un evento TweetDeleted por el tweet y por cada retweet.
+func (repo *TweetRepository) DeleteTweetByID(tweetID, deletedBy uint) ([]domain.Tweet, error) {
+	var retweets []domain.Tweet
+	err := repo.tweetDB.Transaction(func(tx *gorm.DB) error {
+		var tweet domain.Tweet
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&tweet, tweetID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrTweetNotFound
+			}
+			return err
+		}
+
+		if tweet.IsRetweet() {
+			if err := tx.Unscoped().Delete(&tweet).Error; err != nil {
+				return err
+			}
+			if err := decrementCounter(tx, *tweet.RetweetOfTweetID, "retweet_count"); err != nil {
+				return err
+			}
+			return enqueueTweetsDeleted(tx, tweet)
+		}
+
+		if err := tx.Where("retweet_of_tweet_id = ?", tweet.ID).Find(&retweets).Error; err != nil {
+			return err
+		}
+		err := tx.Model(&domain.Tweet{}).Where("id = ? OR retweet_of_tweet_id = ?", tweet.ID, tweet.ID).
+			UpdateColumns(map[string]interface{}{"deleted_at": time.Now(), "deleted_by_id": deletedBy}).Error
+		if err != nil {
+			return errors.New("no se pudo eliminar el tweet")
+		}
+
+		if tweet.InReplyToTweetID != nil {
+			if err := decrementCounter(tx, *tweet.InReplyToTweetID, "reply_count"); err != nil {
+				return err
+			}
+		}
+		return enqueueTweetsDeleted(tx, append([]domain.Tweet{tweet}, retweets...)...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return retweets, nil
+}
+
+// decrementCounter descuenta uno de un contador desnormalizado sin bajar de cero. También se actualizan
+// los tweets eliminados, para que el contador sea correcto si se restauran.
+func decrementCounter(tx *gorm.DB, tweetID uint, column string) error {
+	return tx.Unscoped().Model(&domain.Tweet{}).Where("id = ? AND "+column+" > 0", tweetID).
+		UpdateColumn(column, gorm.Expr(column+" - 1")).Error
 }
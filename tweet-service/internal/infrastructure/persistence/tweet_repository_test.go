@@ -0,0 +1,234 @@
+package persistence
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DevOpslp/microblogging-platform/auth"
+	"github.com/DevOpslp/microblogging-platform/tweet-service/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeUserRepository cuenta las búsquedas por lote para verificar que no haya consultas N+1
+type fakeUserRepository struct {
+	users      map[uint]*domain.User
+	follows    map[uint]map[uint]bool
+	blocks     map[uint]map[uint]bool // bloqueador -> bloqueados
+	hidden     map[string]map[uint]bool
+	batchCalls int
+}
+
+func (f *fakeUserRepository) FindUserByUsername(username string) (*domain.User, error) {
+	for _, user := range f.users {
+		if user.Username == username {
+			return user, nil
+		}
+	}
+	return nil, assert.AnError
+}
+
+func (f *fakeUserRepository) FindUserByID(userID uint) (*domain.User, error) {
+	if user, ok := f.users[userID]; ok {
+		return user, nil
+	}
+	return nil, assert.AnError
+}
+
+func (f *fakeUserRepository) FindUsersByIDs(userIDs []uint) (map[uint]*domain.User, error) {
+	f.batchCalls++
+	users := make(map[uint]*domain.User)
+	for _, userID := range userIDs {
+		if user, ok := f.users[userID]; ok {
+			users[userID] = user
+		}
+	}
+	return users, nil
+}
+
+func (f *fakeUserRepository) FindUsersByUsernames(usernames []string) (map[string]*domain.User, error) {
+	f.batchCalls++
+	users := make(map[string]*domain.User)
+	for _, username := range usernames {
+		if user, err := f.FindUserByUsername(username); err == nil {
+			users[username] = user
+		}
+	}
+	return users, nil
+}
+
+func (f *fakeUserRepository) FindFollowedIDs(followerID uint, userIDs []uint) (map[uint]bool, error) {
+	followed := make(map[uint]bool)
+	for _, userID := range userIDs {
+		followed[userID] = f.follows[followerID][userID]
+	}
+	return followed, nil
+}
+
+func (f *fakeUserRepository) FindBlockerIDs(blockedID uint, userIDs []uint) (map[uint]bool, error) {
+	blockers := make(map[uint]bool)
+	for _, userID := range userIDs {
+		blockers[userID] = f.blocks[userID][blockedID]
+	}
+	return blockers, nil
+}
+
+func (f *fakeUserRepository) FindHiddenUserIDs(username string) (map[uint]bool, error) {
+	if hidden, ok := f.hidden[username]; ok {
+		return hidden, nil
+	}
+	return map[uint]bool{}, nil
+}
+
+func TestWithUsernamesSingleLookup(t *testing.T) {
+	userRepo := &fakeUserRepository{users: map[uint]*domain.User{
+		1: {ID: 1, Username: "user1"},
+		2: {ID: 2, Username: "user2"},
+	}}
+	repo := NewTweetRepository(nil, userRepo, nil)
+
+	tweets := []domain.Tweet{
+		{ID: 10, UserID: 1},
+		{ID: 11, UserID: 2},
+		{ID: 12, UserID: 1},
+		{ID: 13, UserID: 3}, // Autor eliminado de user-service
+	}
+
+	tweetsWithUser, err := repo.withUsernames(tweets)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, userRepo.batchCalls, "Debe resolver todos los autores con una sola petición")
+
+	usernames := make([]string, 0)
+	for _, tweet := range tweetsWithUser {
+		usernames = append(usernames, tweet.Username)
+	}
+	assert.Equal(t, []string{"user1", "user2", "user1", domain.UnknownUsername}, usernames)
+}
+
+func TestResolveMentionsIgnoresUnknownUsers(t *testing.T) {
+	userRepo := &fakeUserRepository{users: map[uint]*domain.User{
+		1: {ID: 1, Username: "user1"},
+		2: {ID: 2, Username: "user2"},
+	}}
+	repo := NewTweetRepository(nil, userRepo, nil)
+
+	mentions, err := repo.resolveMentions("@user2 @desconocido @user1 @user2 user1@example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, userRepo.batchCalls, "Debe resolver todas las menciones con una sola petición")
+	assert.Equal(t, []domain.TweetMention{
+		{UserID: 2, Username: "user2"},
+		{UserID: 1, Username: "user1"},
+	}, mentions)
+
+	// Sin menciones no se consulta a user-service
+	mentions, err = repo.resolveMentions("sin menciones")
+	assert.NoError(t, err)
+	assert.Empty(t, mentions)
+	assert.Equal(t, 1, userRepo.batchCalls)
+}
+
+func TestVisibleWithUsernamesHidesPrivateAuthors(t *testing.T) {
+	userRepo := &fakeUserRepository{
+		users: map[uint]*domain.User{
+			1: {ID: 1, Username: "user1", IsPrivate: true},
+			2: {ID: 2, Username: "user2"},
+		},
+		follows: map[uint]map[uint]bool{3: {1: true}},
+	}
+	repo := NewTweetRepository(nil, userRepo, nil)
+
+	tweets := []domain.Tweet{{ID: 10, UserID: 1}, {ID: 11, UserID: 2}}
+	ids := func(viewer domain.Viewer) []uint {
+		visible, err := repo.visibleWithUsernames(tweets, viewer)
+		assert.NoError(t, err)
+		result := make([]uint, 0)
+		for _, tweet := range visible {
+			result = append(result, tweet.ID)
+		}
+		return result
+	}
+
+	assert.Equal(t, []uint{11}, ids(domain.Viewer{}))
+	assert.Equal(t, []uint{11}, ids(domain.Viewer{UserID: 2}))
+	assert.Equal(t, []uint{10, 11}, ids(domain.Viewer{UserID: 3}))
+	assert.Equal(t, []uint{10, 11}, ids(domain.Viewer{UserID: 1}))
+	assert.Equal(t, []uint{10, 11}, ids(domain.Viewer{All: true}))
+
+	// Una cuenta privada solo la ven ella misma y sus seguidores
+	assert.ErrorIs(t, repo.checkCanViewUser(userRepo.users[1], domain.Viewer{}), ErrPrivateAccount)
+	assert.ErrorIs(t, repo.checkCanViewUser(userRepo.users[1], domain.Viewer{UserID: 2}), ErrPrivateAccount)
+	assert.NoError(t, repo.checkCanViewUser(userRepo.users[1], domain.Viewer{UserID: 3}))
+	assert.NoError(t, repo.checkCanViewUser(userRepo.users[2], domain.Viewer{}))
+}
+
+func TestCheckNotBlockedRefusesBlockedMentions(t *testing.T) {
+	userRepo := &fakeUserRepository{
+		users: map[uint]*domain.User{
+			1: {ID: 1, Username: "user1"},
+			2: {ID: 2, Username: "user2"},
+			3: {ID: 3, Username: "user3"},
+		},
+		blocks: map[uint]map[uint]bool{2: {1: true}},
+	}
+	repo := NewTweetRepository(nil, userRepo, nil)
+
+	mentions, err := repo.resolveMentions("@user2 @user3")
+	assert.NoError(t, err)
+	assert.ErrorIs(t, repo.checkNotBlocked(1, nil, mentions), ErrMentionBlocked)
+
+	// El bloqueo es en una sola dirección: user2 sí puede mencionar a user1
+	mentions, err = repo.resolveMentions("@user1 @user3")
+	assert.NoError(t, err)
+	assert.NoError(t, repo.checkNotBlocked(2, nil, mentions))
+}
+
+func TestHiddenAuthors(t *testing.T) {
+	userRepo := &fakeUserRepository{hidden: map[string]map[uint]bool{"user1": {2: true}}}
+	repo := NewTweetRepository(nil, userRepo, nil)
+
+	hidden, err := repo.hiddenAuthors(domain.Viewer{UserID: 1, Username: "user1"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[uint]bool{2: true}, hidden)
+
+	// Los lectores anónimos y los servicios no ocultan a nadie
+	for _, viewer := range []domain.Viewer{{}, {UserID: 1, Username: "user1", All: true}} {
+		hidden, err := repo.hiddenAuthors(viewer)
+		assert.NoError(t, err)
+		assert.Empty(t, hidden)
+	}
+}
+
+func TestVisibleWithUsernamesUsesPrivacyFromUserService(t *testing.T) {
+	// Respuestas de user-service para una cuenta privada (user1) y una pública (user2); user3 sigue a user1
+	userService := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/users/batch":
+			w.Write([]byte(`{"users": [{"user_id": 1, "username": "user1", "is_private": true}, {"user_id": 2, "username": "user2", "is_private": false}]}`))
+		case "/users/follows":
+			if r.URL.Query().Get("follower_id") == "3" {
+				w.Write([]byte(`{"followed_ids": [1]}`))
+				return
+			}
+			w.Write([]byte(`{"followed_ids": []}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer userService.Close()
+
+	repo := NewTweetRepository(nil, NewHTTPUserRepository(userService.URL, auth.NewTokenManager("secreto-de-prueba")), nil)
+	tweets := []domain.Tweet{{ID: 10, UserID: 1}, {ID: 11, UserID: 2}}
+	ids := func(viewer domain.Viewer) []uint {
+		visible, err := repo.visibleWithUsernames(tweets, viewer)
+		assert.NoError(t, err)
+		result := make([]uint, 0)
+		for _, tweet := range visible {
+			result = append(result, tweet.ID)
+		}
+		return result
+	}
+
+	assert.Equal(t, []uint{11}, ids(domain.Viewer{}))
+	assert.Equal(t, []uint{11}, ids(domain.Viewer{UserID: 2}))
+	assert.Equal(t, []uint{10, 11}, ids(domain.Viewer{UserID: 3}))
+}
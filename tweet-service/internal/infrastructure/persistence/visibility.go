@@ -0,0 +1,72 @@
+package persistence
+
+import (
+	"errors"
+
+	"github.com/DevOpslp/microblogging-platform/tweet-service/internal/domain"
+)
+
+// ErrPrivateAccount se devuelve al listar los tweets o likes de una cuenta privada que el viewer no sigue
+var ErrPrivateAccount = errors.New("la cuenta es privada")
+
+// GetVisibleTweet carga un tweet con sus usuarios y tweets embebidos. Un tweet que el viewer no puede
+// ver responde ErrTweetNotFound, igual que uno inexistente.
+func (repo *TweetRepository) GetVisibleTweet(tweetID uint, viewer domain.Viewer) (*domain.TweetWithUser, error) {
+	tweet, err := repo.GetTweetByID(tweetID)
+	if err != nil {
+		return nil, err
+	}
+
+	visible, err := repo.visibleWithUsernames([]domain.Tweet{*tweet}, viewer)
+	if err != nil {
+		return nil, err
+	}
+	if len(visible) == 0 {
+		return nil, ErrTweetNotFound
+	}
+	return &visible[0], nil
+}
+
+// visibleWithUsernames aplica withUsernames y quita los tweets que el viewer no puede ver
+func (repo *TweetRepository) visibleWithUsernames(tweets []domain.Tweet, viewer domain.Viewer) ([]domain.TweetWithUser, error) {
+	tweetsWithUser, err := repo.withUsernames(tweets)
+	if err != nil {
+		return nil, err
+	}
+
+	followed, err := repo.followedPrivateAuthors(tweetsWithUser, viewer)
+	if err != nil {
+		return nil, err
+	}
+	return domain.FilterVisible(tweetsWithUser, viewer, followed), nil
+}
+
+// followedPrivateAuthors consulta con una sola petición a user-service cuáles de los autores privados
+// de los tweets sigue el viewer. Los lectores anónimos no siguen a nadie.
+func (repo *TweetRepository) followedPrivateAuthors(tweets []domain.TweetWithUser, viewer domain.Viewer) (map[uint]bool, error) {
+	authorIDs := domain.PrivateAuthorIDs(tweets, viewer)
+	if len(authorIDs) == 0 || viewer.UserID == 0 {
+		return map[uint]bool{}, nil
+	}
+	return repo.userRepo.FindFollowedIDs(viewer.UserID, authorIDs)
+}
+
+// checkCanViewUser devuelve ErrPrivateAccount si el usuario tiene la cuenta privada y el viewer no es
+// el propio usuario ni lo sigue
+func (repo *TweetRepository) checkCanViewUser(user *domain.User, viewer domain.Viewer) error {
+	if viewer.All || !user.IsPrivate || user.ID == viewer.UserID {
+		return nil
+	}
+	if viewer.UserID == 0 {
+		return ErrPrivateAccount
+	}
+
+	followed, err := repo.userRepo.FindFollowedIDs(viewer.UserID, []uint{user.ID})
+	if err != nil {
+		return err
+	}
+	if !followed[user.ID] {
+		return ErrPrivateAccount
+	}
+	return nil
+}
@@ -1,27 +1,62 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/DevOpslp/microblogging-platform/auth"
+	"github.com/DevOpslp/microblogging-platform/events"
 	"github.com/DevOpslp/microblogging-platform/user-service/internal/infrastructure/api"
 	"github.com/DevOpslp/microblogging-platform/user-service/internal/infrastructure/persistence"
 )
 
 func main() {
+	// Secreto compartido para firmar y verificar tokens; sin él el servicio no arranca
+	authSecret, err := auth.SecretFromEnv()
+	if err != nil {
+		log.Fatalf("Error de configuración: %v", err)
+	}
+
 	// Configuración de la base de datos
 	db := persistence.NewDB()
 
 	// Configuración del repositorio de usuarios
 	userRepository := persistence.NewUserRepository(db)
 
+	// Publicar los eventos de la outbox en el broker configurado por EVENT_BROKER
+	broker, err := events.NewBrokerFromEnv(context.Background())
+	if err != nil {
+		log.Fatalf("Error al conectar con el broker de eventos: %v", err)
+	}
+	defer broker.Close()
+	go events.NewRelay(events.NewGormOutbox(db), broker).Run(context.Background())
+
+	// Precalcular las recomendaciones de seguimiento de los usuarios activos (ej. RECOMMENDATIONS_INTERVAL=15m)
+	recommendationInterval := persistence.DefaultRecommendationInterval
+	if value := os.Getenv("RECOMMENDATIONS_INTERVAL"); value != "" {
+		recommendationInterval, err = time.ParseDuration(value)
+		if err != nil || recommendationInterval <= 0 {
+			log.Fatalf("RECOMMENDATIONS_INTERVAL inválido: %q", value)
+		}
+	}
+	recommender := persistence.NewRecommender(userRepository, recommendationInterval, persistence.DefaultRecommendationActiveWindow)
+	go recommender.Run(context.Background())
+
 	// Inicia el enrutador de Gin
 	router := gin.Default()
 
+	// Tokens de acceso firmados con el secreto compartido entre servicios
+	tokens := auth.NewTokenManager(authSecret)
+
+	// Cantidad de tweets de los perfiles, consultada a `tweet-service` (omitida si TWEET_SERVICE_URL está vacío)
+	tweetCounter := persistence.NewHTTPTweetCounter(os.Getenv("TWEET_SERVICE_URL"))
+
 	// Pasar userRepository a SetupRoutes
-	api.SetupRoutes(router, *userRepository)
+	api.SetupRoutes(router, *userRepository, tokens, tweetCounter, recommender)
 
 	// Obtiene el puerto desde las variables de entorno o usa 8080 por defecto
 	port := os.Getenv("PORT")
@@ -0,0 +1,20 @@
+package domain
+
+import "time"
+
+// Bloqueo de BlockerID a BlockedID: elimina los seguimientos entre ambos, impide crear nuevos y oculta
+// los tweets de cada uno en los timelines del otro.
+type Block struct {
+	BlockerID uint `gorm:"primaryKey"`
+	BlockedID uint `gorm:"primaryKey;index"`
+	CreatedAt time.Time
+	Blocked   User `gorm:"foreignKey:BlockedID"`
+}
+
+// Silencio de MuterID a MutedID: solo oculta los tweets de MutedID a MuterID, sin que MutedID lo sepa
+type Mute struct {
+	MuterID   uint `gorm:"primaryKey"`
+	MutedID   uint `gorm:"primaryKey"`
+	CreatedAt time.Time
+	Muted     User `gorm:"foreignKey:MutedID"`
+}
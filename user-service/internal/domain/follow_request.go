@@ -0,0 +1,13 @@
+package domain
+
+import "time"
+
+// Solicitud pendiente de RequesterID para seguir a la cuenta privada TargetID.
+// Se elimina al aprobarla (creando el seguimiento) o al rechazarla.
+type FollowRequest struct {
+	ID          uint      `gorm:"primaryKey"`
+	RequesterID uint      `gorm:"not null;uniqueIndex:idx_follow_requests_pair,priority:1"`
+	TargetID    uint      `gorm:"not null;uniqueIndex:idx_follow_requests_pair,priority:2;index:idx_follow_requests_target_created,priority:1"`
+	CreatedAt   time.Time `gorm:"index:idx_follow_requests_target_created,priority:2"`
+	Requester   User      `gorm:"foreignKey:RequesterID"`
+}
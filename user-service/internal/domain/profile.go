@@ -0,0 +1,123 @@
+package domain
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Largo máximo, en caracteres, de cada campo del perfil
+const (
+	MaxDisplayNameLength = 50
+	MaxBioLength         = 160
+	MaxLocationLength    = 30
+	MaxWebsiteLength     = 100
+	MaxAvatarURLLength   = 300
+)
+
+// ProfileUpdate son los campos del perfil que cambia PATCH /me/profile. Un campo nil no se modifica
+// y uno vacío se borra.
+type ProfileUpdate struct {
+	DisplayName *string `json:"display_name"`
+	Bio         *string `json:"bio"`
+	AvatarURL   *string `json:"avatar_url"`
+	Location    *string `json:"location"`
+	Website     *string `json:"website"`
+}
+
+// ProfileErrors indica, por campo, por qué el perfil no es válido
+type ProfileErrors map[string]string
+
+func (e ProfileErrors) Error() string {
+	fields := make([]string, 0, len(e))
+	for field := range e {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	messages := make([]string, 0, len(fields))
+	for _, field := range fields {
+		messages = append(messages, fmt.Sprintf("%s: %s", field, e[field]))
+	}
+	return "perfil inválido: " + strings.Join(messages, "; ")
+}
+
+// Normalize normaliza los campos a Unicode NFC sin espacios al principio ni al final y los valida.
+// Solo la bio admite saltos de línea; las URLs deben ser http o https.
+func (p *ProfileUpdate) Normalize() error {
+	errs := ProfileErrors{}
+	normalizeText(errs, "display_name", p.DisplayName, MaxDisplayNameLength, false)
+	normalizeText(errs, "bio", p.Bio, MaxBioLength, true)
+	normalizeText(errs, "location", p.Location, MaxLocationLength, false)
+	normalizeURL(errs, "avatar_url", p.AvatarURL, MaxAvatarURLLength)
+	normalizeURL(errs, "website", p.Website, MaxWebsiteLength)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// Apply copia al usuario los campos presentes en la actualización
+func (p ProfileUpdate) Apply(user *User) {
+	if p.DisplayName != nil {
+		user.DisplayName = *p.DisplayName
+	}
+	if p.Bio != nil {
+		user.Bio = *p.Bio
+	}
+	if p.AvatarURL != nil {
+		user.AvatarURL = *p.AvatarURL
+	}
+	if p.Location != nil {
+		user.Location = *p.Location
+	}
+	if p.Website != nil {
+		user.Website = *p.Website
+	}
+}
+
+func normalizeText(errs ProfileErrors, field string, value *string, maxLength int, multiline bool) {
+	if value == nil {
+		return
+	}
+	if !utf8.ValidString(*value) {
+		errs[field] = "no es texto UTF-8 válido"
+		return
+	}
+
+	*value = strings.TrimSpace(norm.NFC.String(*value))
+	if utf8.RuneCountInString(*value) > maxLength {
+		errs[field] = fmt.Sprintf("debe tener como máximo %d caracteres", maxLength)
+		return
+	}
+	for _, r := range *value {
+		if r == '\n' && multiline {
+			continue
+		}
+		if unicode.IsControl(r) {
+			errs[field] = "contiene caracteres no permitidos"
+			return
+		}
+	}
+}
+
+func normalizeURL(errs ProfileErrors, field string, value *string, maxLength int) {
+	normalizeText(errs, field, value, maxLength, false)
+	if value == nil || *value == "" || errs[field] != "" {
+		return
+	}
+
+	parsed, err := url.Parse(*value)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		errs[field] = "debe ser una URL http o https"
+		return
+	}
+	if parsed.User != nil {
+		errs[field] = "no puede incluir usuario ni contraseña"
+	}
+}
@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// FollowRecommendation es un usuario sugerido a UserID por amigos de amigos: Mutuals es la cantidad de
+// usuarios seguidos por UserID que siguen a CandidateID, y SampleMutualID uno de ellos con cuenta pública
+// para la explicación (nil si todos son privados). Se precalculan en segundo plano y se reemplazan todas
+// las de un usuario en cada cálculo.
+type FollowRecommendation struct {
+	UserID         uint `gorm:"primaryKey"`
+	CandidateID    uint `gorm:"primaryKey"`
+	Mutuals        int  `gorm:"not null"`
+	SampleMutualID *uint
+	ComputedAt     time.Time `gorm:"not null"`
+	Candidate      User      `gorm:"foreignKey:CandidateID"`
+	SampleMutual   User      `gorm:"foreignKey:SampleMutualID"`
+}
+
+// Explanation describe por qué se recomienda al usuario, ej. "Lo siguen user2 y 3 más". Una cuenta
+// que se hizo privada después del cálculo no se nombra.
+func (r FollowRecommendation) Explanation() string {
+	if r.SampleMutualID == nil || r.SampleMutual.IsPrivate {
+		return FollowExplanation("", r.Mutuals)
+	}
+	return FollowExplanation(r.SampleMutual.Username, r.Mutuals)
+}
+
+// FollowExplanation arma la explicación de una recomendación a partir de uno de los seguidos en
+// común y de la cantidad total; sin sample (todos son cuentas privadas) no se nombra a ninguno
+func FollowExplanation(sample string, mutuals int) string {
+	switch {
+	case sample == "" && mutuals <= 1:
+		return "Lo sigue alguien que sigues"
+	case sample == "":
+		return fmt.Sprintf("Lo siguen %d usuarios que sigues", mutuals)
+	case mutuals <= 1:
+		return "Lo sigue " + sample
+	}
+	return fmt.Sprintf("Lo siguen %s y %d más", sample, mutuals-1)
+}
@@ -0,0 +1,24 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFollowExplanation(t *testing.T) {
+	assert.Equal(t, "Lo sigue user2", FollowExplanation("user2", 1))
+	assert.Equal(t, "Lo siguen user2 y 1 más", FollowExplanation("user2", 2))
+	assert.Equal(t, "Lo siguen user2 y 3 más", FollowExplanation("user2", 4))
+
+	assert.Equal(t, "Lo sigue alguien que sigues", FollowExplanation("", 1))
+	assert.Equal(t, "Lo siguen 4 usuarios que sigues", FollowExplanation("", 4))
+
+	sampleID := uint(3)
+	recommendation := FollowRecommendation{Mutuals: 3, SampleMutualID: &sampleID, SampleMutual: User{Username: "user3"}}
+	assert.Equal(t, "Lo siguen user3 y 2 más", recommendation.Explanation())
+
+	// Una cuenta que se hizo privada después del cálculo no se nombra
+	recommendation.SampleMutual.IsPrivate = true
+	assert.Equal(t, "Lo siguen 3 usuarios que sigues", recommendation.Explanation())
+}
@@ -0,0 +1,13 @@
+package domain
+
+import "time"
+
+// Token de refresco emitido en el login. Solo se guarda el hash SHA-256 del token entregado al cliente.
+type RefreshToken struct {
+	ID        uint      `gorm:"primaryKey"`
+	UserID    uint      `gorm:"not null;index"`
+	TokenHash string    `gorm:"uniqueIndex;not null"`
+	ExpiresAt time.Time `gorm:"not null"`
+	RevokedAt *time.Time
+	CreatedAt time.Time
+}
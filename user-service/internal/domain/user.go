@@ -4,12 +4,25 @@ import (
 	"time"
 )
 
+// Los tweets de una cuenta privada (IsPrivate) solo los ven sus seguidores, y para seguirla
+// hay que enviar una FollowRequest que el dueño aprueba.
+// Con DMFollowersOnly solo sus seguidores pueden iniciar conversaciones de mensajes directos con él.
+// Los campos del perfil (DisplayName a Website) se cambian con ProfileUpdate.
 type User struct {
-	ID        uint    `gorm:"primaryKey"`
-	Username  string  `gorm:"uniqueIndex;not null"`
-	Email     string  `gorm:"uniqueIndex;not null"`
-	Following []*User `gorm:"many2many:user_followers;joinForeignKey:UserID;joinReferences:FollowerID"`
-	Followers []*User `gorm:"many2many:user_followers;joinForeignKey:FollowerID;joinReferences:UserID"`
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	ID              uint    `gorm:"primaryKey"`
+	Username        string  `gorm:"uniqueIndex;not null"`
+	Email           string  `gorm:"uniqueIndex;not null" json:"-"`
+	PasswordHash    string  `gorm:"not null;default:''" json:"-"`
+	Role            string  `gorm:"not null;default:'user'" json:"-"`
+	IsPrivate       bool    `gorm:"not null;default:false" json:"is_private"`
+	DMFollowersOnly bool    `gorm:"not null;default:false" json:"dm_followers_only"`
+	DisplayName     string  `gorm:"not null;default:''" json:"display_name"`
+	Bio             string  `gorm:"not null;default:''" json:"bio"`
+	AvatarURL       string  `gorm:"not null;default:''" json:"avatar_url"`
+	Location        string  `gorm:"not null;default:''" json:"location"`
+	Website         string  `gorm:"not null;default:''" json:"website"`
+	Following       []*User `gorm:"many2many:user_followers;joinForeignKey:UserID;joinReferences:FollowerID"`
+	Followers       []*User `gorm:"many2many:user_followers;joinForeignKey:FollowerID;joinReferences:UserID"`
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
 }
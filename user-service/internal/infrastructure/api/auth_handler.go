@@ -0,0 +1,97 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/DevOpslp/microblogging-platform/auth"
+	"github.com/DevOpslp/microblogging-platform/user-service/internal/domain"
+	"github.com/DevOpslp/microblogging-platform/user-service/internal/infrastructure/persistence"
+	"github.com/gin-gonic/gin"
+)
+
+func (h *UserHandler) Login(c *gin.Context) {
+	var body struct {
+		Username string `json:"username" binding:"required"`
+		Password string `json:"password" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos de login inválidos"})
+		return
+	}
+
+	user, err := h.userRepo.Authenticate(body.Username, body.Password)
+	if err != nil {
+		if errors.Is(err, persistence.ErrInvalidCredentials) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario o contraseña incorrectos"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo iniciar sesión"})
+		}
+		return
+	}
+
+	refreshToken, err := h.userRepo.CreateRefreshToken(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo iniciar sesión"})
+		return
+	}
+
+	h.respondTokens(c, user, refreshToken)
+}
+
+func (h *UserHandler) RefreshToken(c *gin.Context) {
+	var body struct {
+		RefreshToken string `json:"refresh_token" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "refresh_token no válido"})
+		return
+	}
+
+	user, refreshToken, err := h.userRepo.RotateRefreshToken(body.RefreshToken)
+	if err != nil {
+		if errors.Is(err, persistence.ErrInvalidRefreshToken) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token de refresco inválido o expirado"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo renovar la sesión"})
+		}
+		return
+	}
+
+	h.respondTokens(c, user, refreshToken)
+}
+
+func (h *UserHandler) Logout(c *gin.Context) {
+	var body struct {
+		RefreshToken string `json:"refresh_token" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "refresh_token no válido"})
+		return
+	}
+
+	if err := h.userRepo.RevokeRefreshToken(body.RefreshToken); err != nil && !errors.Is(err, persistence.ErrInvalidRefreshToken) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo cerrar la sesión"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Sesión cerrada exitosamente"})
+}
+
+func (h *UserHandler) respondTokens(c *gin.Context, user *domain.User, refreshToken string) {
+	accessToken, err := h.tokens.IssueAccessToken(user.ID, user.Username, user.Role)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo emitir el token de acceso"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"access_token":  accessToken,
+		"refresh_token": refreshToken,
+		"token_type":    "Bearer",
+		"expires_in":    int(auth.AccessTokenTTL.Seconds()),
+	})
+}
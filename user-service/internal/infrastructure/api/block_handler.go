@@ -0,0 +1,194 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DevOpslp/microblogging-platform/auth"
+	"github.com/DevOpslp/microblogging-platform/user-service/internal/domain"
+	"github.com/gin-gonic/gin"
+)
+
+// BlockUser bloquea al usuario {"username"}; los seguimientos entre ambos se eliminan
+func (h *UserHandler) BlockUser(c *gin.Context) {
+	target, ok := h.bindRelationTarget(c)
+	if !ok {
+		return
+	}
+
+	if err := h.userRepo.BlockUser(auth.UserID(c), target.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo bloquear al usuario"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Usuario bloqueado exitosamente"})
+}
+
+// UnblockUser quita el bloqueo del usuario :username
+func (h *UserHandler) UnblockUser(c *gin.Context) {
+	target, ok := h.findRelationTarget(c, c.Param("username"))
+	if !ok {
+		return
+	}
+
+	if err := h.userRepo.UnblockUser(auth.UserID(c), target.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo desbloquear al usuario"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Usuario desbloqueado exitosamente"})
+}
+
+// GetBlocks lista los usuarios bloqueados por el usuario autenticado
+func (h *UserHandler) GetBlocks(c *gin.Context) {
+	blocks, err := h.userRepo.GetBlocks(auth.UserID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo obtener la lista de bloqueados"})
+		return
+	}
+
+	response := make([]gin.H, 0, len(blocks))
+	for _, block := range blocks {
+		response = append(response, relationResponse(block.Blocked, block.CreatedAt))
+	}
+	c.JSON(http.StatusOK, gin.H{"blocks": response})
+}
+
+// MuteUser silencia al usuario {"username"}; sus tweets dejan de aparecer en los timelines
+func (h *UserHandler) MuteUser(c *gin.Context) {
+	target, ok := h.bindRelationTarget(c)
+	if !ok {
+		return
+	}
+
+	if err := h.userRepo.MuteUser(auth.UserID(c), target.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo silenciar al usuario"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Usuario silenciado exitosamente"})
+}
+
+// UnmuteUser quita el silencio del usuario :username
+func (h *UserHandler) UnmuteUser(c *gin.Context) {
+	target, ok := h.findRelationTarget(c, c.Param("username"))
+	if !ok {
+		return
+	}
+
+	if err := h.userRepo.UnmuteUser(auth.UserID(c), target.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo quitar el silencio al usuario"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Usuario ya no silenciado"})
+}
+
+// GetMutes lista los usuarios silenciados por el usuario autenticado
+func (h *UserHandler) GetMutes(c *gin.Context) {
+	mutes, err := h.userRepo.GetMutes(auth.UserID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo obtener la lista de silenciados"})
+		return
+	}
+
+	response := make([]gin.H, 0, len(mutes))
+	for _, mute := range mutes {
+		response = append(response, relationResponse(mute.Muted, mute.CreatedAt))
+	}
+	c.JSON(http.StatusOK, gin.H{"mutes": response})
+}
+
+func relationResponse(user domain.User, createdAt time.Time) gin.H {
+	return gin.H{"user_id": user.ID, "username": user.Username, "created_at": createdAt.Format(time.RFC3339)}
+}
+
+// bindRelationTarget lee {"username"} del cuerpo y busca al usuario
+func (h *UserHandler) bindRelationTarget(c *gin.Context) (*domain.User, bool) {
+	var body struct {
+		Username string `json:"username"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil || body.Username == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Username no válido"})
+		return nil, false
+	}
+	return h.findRelationTarget(c, body.Username)
+}
+
+// findRelationTarget busca al usuario a bloquear o silenciar; nadie puede bloquearse ni silenciarse a sí mismo
+func (h *UserHandler) findRelationTarget(c *gin.Context, username string) (*domain.User, bool) {
+	target, err := h.userRepo.FindUserByUsername(username)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Usuario no encontrado"})
+		return nil, false
+	}
+	if target.ID == auth.UserID(c) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No puedes aplicarte esta acción a ti mismo"})
+		return nil, false
+	}
+	return target, true
+}
+
+// GetHiddenUsers devuelve los usuarios cuyos tweets no se le muestran a :username (bloqueados en
+// cualquier dirección o silenciados). Solo lo consultan otros servicios, porque revela a quién bloqueó.
+func (h *UserHandler) GetHiddenUsers(c *gin.Context) {
+	user, err := h.userRepo.FindUserByUsername(c.Param("username"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Usuario no encontrado"})
+		return
+	}
+
+	hidden, err := h.userRepo.GetHiddenUsers(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo obtener la lista de usuarios ocultos"})
+		return
+	}
+
+	response := make([]gin.H, 0, len(hidden))
+	for _, hiddenUser := range hidden {
+		response = append(response, gin.H{"user_id": hiddenUser.ID, "username": hiddenUser.Username})
+	}
+	c.JSON(http.StatusOK, gin.H{"hidden": response})
+}
+
+// GetBlockerIDs indica cuáles de los usuarios ?ids=1,2 bloquearon a ?user_id. Lo usa tweet-service
+// para rechazar respuestas y menciones de usuarios bloqueados.
+func (h *UserHandler) GetBlockerIDs(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Query("user_id"), 10, 64)
+	if err != nil || userID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user_id inválido"})
+		return
+	}
+
+	userIDs, ok := parseIDList(c, c.Query("ids"))
+	if !ok {
+		return
+	}
+
+	blockers, err := h.userRepo.FindBlockerIDs(uint(userID), userIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudieron obtener los bloqueos"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"blocker_ids": blockers})
+}
+
+// parseIDList interpreta una lista de IDs separados por comas, de hasta maxBatchUsers elementos.
+// Si no es válida responde 400 y devuelve false.
+func parseIDList(c *gin.Context, value string) ([]uint, bool) {
+	parts := strings.Split(value, ",")
+	if len(parts) > maxBatchUsers {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Se aceptan como máximo %d IDs", maxBatchUsers)})
+		return nil, false
+	}
+
+	ids := make([]uint, 0, len(parts))
+	for _, part := range parts {
+		id, err := strconv.ParseUint(strings.TrimSpace(part), 10, 64)
+		if err != nil || id == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "ID de usuario inválido"})
+			return nil, false
+		}
+		ids = append(ids, uint(id))
+	}
+	return ids, true
+}
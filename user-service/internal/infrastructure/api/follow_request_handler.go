@@ -0,0 +1,125 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/DevOpslp/microblogging-platform/auth"
+	"github.com/DevOpslp/microblogging-platform/user-service/internal/infrastructure/persistence"
+	"github.com/gin-gonic/gin"
+)
+
+// GetFollowRequests lista las solicitudes pendientes para seguir al usuario autenticado
+func (h *UserHandler) GetFollowRequests(c *gin.Context) {
+	requests, err := h.userRepo.GetFollowRequests(auth.UserID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudieron obtener las solicitudes de seguimiento"})
+		return
+	}
+
+	response := make([]gin.H, 0, len(requests))
+	for _, request := range requests {
+		response = append(response, gin.H{
+			"id":         request.ID,
+			"user_id":    request.RequesterID,
+			"username":   request.Requester.Username,
+			"created_at": request.CreatedAt.Format(time.RFC3339),
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"follow_requests": response})
+}
+
+// ApproveFollowRequest acepta una solicitud dirigida al usuario autenticado; quien la envió pasa a seguirlo
+func (h *UserHandler) ApproveFollowRequest(c *gin.Context) {
+	requestID, ok := parseFollowRequestID(c)
+	if !ok {
+		return
+	}
+
+	request, err := h.userRepo.ApproveFollowRequest(auth.UserID(c), requestID)
+	if err != nil {
+		respondFollowRequestError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Solicitud de seguimiento aprobada", "username": request.Requester.Username})
+}
+
+// DenyFollowRequest rechaza una solicitud dirigida al usuario autenticado
+func (h *UserHandler) DenyFollowRequest(c *gin.Context) {
+	requestID, ok := parseFollowRequestID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.userRepo.DenyFollowRequest(auth.UserID(c), requestID); err != nil {
+		respondFollowRequestError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Solicitud de seguimiento rechazada"})
+}
+
+func parseFollowRequestID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de solicitud inválido"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// Las solicitudes de otros usuarios responden 404 igual que las inexistentes
+func respondFollowRequestError(c *gin.Context, err error) {
+	if errors.Is(err, persistence.ErrFollowRequestNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Solicitud de seguimiento no encontrada"})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo procesar la solicitud de seguimiento"})
+}
+
+// UpdatePrivacy cambia la privacidad de la cuenta del usuario autenticado.
+// Al volverla pública se aprueban las solicitudes pendientes.
+func (h *UserHandler) UpdatePrivacy(c *gin.Context) {
+	var body struct {
+		IsPrivate *bool `json:"is_private" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "is_private es obligatorio"})
+		return
+	}
+
+	approved, err := h.userRepo.SetPrivate(auth.UserID(c), *body.IsPrivate)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo actualizar la privacidad de la cuenta"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"is_private": *body.IsPrivate, "approved_requests": approved})
+}
+
+// GetFollowedIDs indica cuáles de los usuarios ?ids=1,2 sigue ?follower_id. Lo usa tweet-service
+// para mostrar los tweets de cuentas privadas solo a sus seguidores.
+func (h *UserHandler) GetFollowedIDs(c *gin.Context) {
+	followerID, err := strconv.ParseUint(c.Query("follower_id"), 10, 64)
+	if err != nil || followerID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "follower_id inválido"})
+		return
+	}
+
+	userIDs, ok := parseIDList(c, c.Query("ids"))
+	if !ok {
+		return
+	}
+
+	followed, err := h.userRepo.FindFollowedIDs(uint(followerID), userIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudieron obtener los seguimientos"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"followed_ids": followed})
+}
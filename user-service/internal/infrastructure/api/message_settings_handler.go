@@ -0,0 +1,51 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/DevOpslp/microblogging-platform/auth"
+	"github.com/gin-gonic/gin"
+)
+
+// UpdateMessageSettings cambia quién puede iniciar conversaciones de mensajes directos con el usuario
+// autenticado: con dm_followers_only solo sus seguidores
+func (h *UserHandler) UpdateMessageSettings(c *gin.Context) {
+	var body struct {
+		DMFollowersOnly *bool `json:"dm_followers_only" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "dm_followers_only es obligatorio"})
+		return
+	}
+
+	if err := h.userRepo.SetDMFollowersOnly(auth.UserID(c), *body.DMFollowersOnly); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo actualizar la configuración de mensajes"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"dm_followers_only": *body.DMFollowersOnly})
+}
+
+// GetMessageRestrictions indica a cuáles de los usuarios ?ids=1,2 no puede escribirles ?sender_id: los
+// bloqueados en cualquier dirección y los que solo aceptan mensajes de sus seguidores. Lo usa
+// message-service antes de crear conversaciones y enviar mensajes.
+func (h *UserHandler) GetMessageRestrictions(c *gin.Context) {
+	senderID, err := strconv.ParseUint(c.Query("sender_id"), 10, 64)
+	if err != nil || senderID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sender_id inválido"})
+		return
+	}
+
+	recipientIDs, ok := parseIDList(c, c.Query("ids"))
+	if !ok {
+		return
+	}
+
+	blocked, followersOnly, err := h.userRepo.FindMessageRestrictions(uint(senderID), recipientIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudieron obtener las restricciones de mensajes"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"blocked_ids": blocked, "followers_only_ids": followersOnly})
+}
@@ -0,0 +1,102 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/DevOpslp/microblogging-platform/auth"
+	"github.com/DevOpslp/microblogging-platform/user-service/internal/domain"
+	"github.com/DevOpslp/microblogging-platform/user-service/internal/infrastructure/persistence"
+	"github.com/gin-gonic/gin"
+)
+
+// GetProfile devuelve el perfil público de un usuario con sus contadores. Nunca incluye el email.
+// Si tweet-service no responde, tweet_count es null.
+func (h *UserHandler) GetProfile(c *gin.Context) {
+	user, err := h.userRepo.FindUserByUsername(c.Param("username"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Usuario no encontrado"})
+		return
+	}
+	h.respondProfile(c, user, profileResponse(user))
+}
+
+// GetOwnProfile devuelve el perfil del usuario autenticado con sus contadores. Es la única respuesta que
+// incluye el email.
+func (h *UserHandler) GetOwnProfile(c *gin.Context) {
+	user, err := h.userRepo.FindUserByID(auth.UserID(c))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Usuario no encontrado"})
+		return
+	}
+	h.respondProfile(c, user, ownProfileResponse(user))
+}
+
+// respondProfile agrega los contadores de seguidores, seguidos y tweets al perfil y lo responde
+func (h *UserHandler) respondProfile(c *gin.Context, user *domain.User, profile gin.H) {
+	followers, following, err := h.userRepo.CountFollows(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo obtener el perfil"})
+		return
+	}
+
+	var tweetCount *int64
+	if count, err := h.tweets.CountTweets(user.ID); err == nil {
+		tweetCount = &count
+	} else if !errors.Is(err, persistence.ErrTweetCountUnavailable) {
+		log.Printf("No se pudo obtener la cantidad de tweets de tweet-service: %v", err)
+	}
+
+	profile["followers_count"] = followers
+	profile["following_count"] = following
+	profile["tweet_count"] = tweetCount
+	c.JSON(http.StatusOK, profile)
+}
+
+// UpdateProfile cambia los campos enviados del perfil del usuario autenticado. Los errores de
+// validación se informan por campo en "fields".
+func (h *UserHandler) UpdateProfile(c *gin.Context) {
+	var update domain.ProfileUpdate
+	if err := c.ShouldBindJSON(&update); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos de perfil inválidos"})
+		return
+	}
+
+	if err := update.Normalize(); err != nil {
+		var fields domain.ProfileErrors
+		errors.As(err, &fields)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos de perfil inválidos", "fields": fields})
+		return
+	}
+
+	user, err := h.userRepo.UpdateProfile(auth.UserID(c), update)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo actualizar el perfil"})
+		return
+	}
+	c.JSON(http.StatusOK, ownProfileResponse(user))
+}
+
+// profileResponse arma los campos públicos del perfil
+func profileResponse(user *domain.User) gin.H {
+	return gin.H{
+		"user_id":           user.ID,
+		"username":          user.Username,
+		"display_name":      user.DisplayName,
+		"bio":               user.Bio,
+		"avatar_url":        user.AvatarURL,
+		"location":          user.Location,
+		"website":           user.Website,
+		"is_private":        user.IsPrivate,
+		"dm_followers_only": user.DMFollowersOnly,
+		"created_at":        user.CreatedAt,
+	}
+}
+
+// ownProfileResponse es el perfil que ve su dueño: los campos públicos más el email
+func ownProfileResponse(user *domain.User) gin.H {
+	profile := profileResponse(user)
+	profile["email"] = user.Email
+	return profile
+}
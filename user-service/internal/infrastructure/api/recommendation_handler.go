@@ -0,0 +1,52 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/DevOpslp/microblogging-platform/auth"
+	"github.com/DevOpslp/microblogging-platform/user-service/internal/infrastructure/persistence"
+	"github.com/gin-gonic/gin"
+)
+
+const DefaultRecommendationsLimit = 10
+
+// GetFollowRecommendations sugiere usuarios para seguir al usuario autenticado a partir de a quiénes
+// siguen los usuarios que sigue, con la explicación de cada sugerencia. Si todavía no tiene
+// recomendaciones calculadas responde una lista vacía y pide calcularlas en segundo plano.
+func (h *UserHandler) GetFollowRecommendations(c *gin.Context) {
+	limit := DefaultRecommendationsLimit
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > persistence.MaxFollowRecommendations {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limit debe ser un número entre 1 y %d", persistence.MaxFollowRecommendations)})
+			return
+		}
+		limit = parsed
+	}
+
+	userID := auth.UserID(c)
+	recommendations, err := h.userRepo.GetFollowRecommendations(userID, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudieron obtener las recomendaciones"})
+		return
+	}
+	if len(recommendations) == 0 {
+		h.recommender.Enqueue(userID)
+	}
+
+	response := make([]gin.H, 0, len(recommendations))
+	for _, recommendation := range recommendations {
+		response = append(response, gin.H{
+			"user_id":      recommendation.Candidate.ID,
+			"username":     recommendation.Candidate.Username,
+			"display_name": recommendation.Candidate.DisplayName,
+			"avatar_url":   recommendation.Candidate.AvatarURL,
+			"is_private":   recommendation.Candidate.IsPrivate,
+			"mutuals":      recommendation.Mutuals,
+			"explanation":  recommendation.Explanation(),
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"recommendations": response})
+}
@@ -1,19 +1,58 @@
 package api
 
 import (
+	"github.com/DevOpslp/microblogging-platform/auth"
 	"github.com/DevOpslp/microblogging-platform/user-service/internal/infrastructure/persistence"
 	"github.com/gin-gonic/gin"
 )
 
-func SetupRoutes(router *gin.Engine, userRepo persistence.UserRepository) {
-	handler := NewUserHandler(userRepo)
+func SetupRoutes(router *gin.Engine, userRepo persistence.UserRepository, tokens *auth.TokenManager, tweets persistence.TweetCounter, recommender *persistence.Recommender) {
+	handler := NewUserHandler(userRepo, tokens, tweets, recommender)
 
-	router.POST("/follow", handler.FollowUser)
-	router.POST("/unfollow", handler.UnfollowUser)
+	// Registro y sesión
 	router.POST("/register", handler.RegisterUser)
-	router.GET("/followers", handler.GetFollowers)
-	router.GET("/following", handler.GetFollowing)
+	router.POST("/login", handler.Login)
+	router.POST("/refresh", handler.RefreshToken)
+	router.POST("/logout", handler.Logout)
+
+	// Consultas públicas
 	router.GET("/users", handler.GetAllUsers)
+	router.GET("/users/batch", handler.GetUsersBatch)
+	router.GET("/users/:username", handler.GetProfile)
 	router.GET("/user/:username", handler.GetUserByUsername)
 	router.GET("/user-by-id/:id", handler.GetUserByID)
+
+	// Seguidores y seguidos: los de cuentas privadas solo los ven su dueño y sus seguidores
+	optional := router.Group("/", auth.OptionalMiddleware(tokens))
+	optional.GET("/users/:username/followers", handler.GetFollowersByUsername)
+	optional.GET("/users/:username/following", handler.GetFollowingByUsername)
+
+	// Acciones del usuario autenticado
+	authenticated := router.Group("/", auth.Middleware(tokens))
+	authenticated.POST("/follow", handler.FollowUser)
+	authenticated.POST("/unfollow", handler.UnfollowUser)
+	authenticated.GET("/followers", handler.GetFollowers)
+	authenticated.GET("/following", handler.GetFollowing)
+	authenticated.GET("/me", handler.GetOwnProfile)
+	authenticated.PATCH("/me/privacy", handler.UpdatePrivacy)
+	authenticated.PATCH("/me/profile", handler.UpdateProfile)
+	authenticated.PATCH("/me/messages", handler.UpdateMessageSettings)
+	authenticated.GET("/follow-requests", handler.GetFollowRequests)
+	authenticated.POST("/follow-requests/:id/approve", handler.ApproveFollowRequest)
+	authenticated.POST("/follow-requests/:id/deny", handler.DenyFollowRequest)
+	authenticated.GET("/blocks", handler.GetBlocks)
+	authenticated.POST("/blocks", handler.BlockUser)
+	authenticated.DELETE("/blocks/:username", handler.UnblockUser)
+	authenticated.GET("/mutes", handler.GetMutes)
+	authenticated.POST("/mutes", handler.MuteUser)
+	authenticated.DELETE("/mutes/:username", handler.UnmuteUser)
+	authenticated.GET("/recommendations/follow", handler.GetFollowRecommendations)
+
+	// Consultas de otros servicios que revelan seguimientos, bloqueos y silencios
+	internal := router.Group("/", auth.ServiceMiddleware(tokens))
+	internal.GET("/users/follows", handler.GetFollowedIDs)
+	internal.GET("/users/following", handler.GetFollowingBatch)
+	internal.GET("/users/:username/hidden", handler.GetHiddenUsers)
+	internal.GET("/users/blockers", handler.GetBlockerIDs)
+	internal.GET("/users/message-restrictions", handler.GetMessageRestrictions)
 }
@@ -1,25 +1,34 @@
 package api
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
+	"github.com/DevOpslp/microblogging-platform/auth"
+	"github.com/DevOpslp/microblogging-platform/user-service/internal/domain"
 	"github.com/DevOpslp/microblogging-platform/user-service/internal/infrastructure/persistence"
 	"github.com/gin-gonic/gin"
 )
 
 type UserHandler struct {
-	userRepo persistence.UserRepository
+	userRepo    persistence.UserRepository
+	tokens      *auth.TokenManager
+	tweets      persistence.TweetCounter
+	recommender *persistence.Recommender
 }
 
-func NewUserHandler(userRepo persistence.UserRepository) *UserHandler {
-	return &UserHandler{userRepo: userRepo}
+func NewUserHandler(userRepo persistence.UserRepository, tokens *auth.TokenManager, tweets persistence.TweetCounter, recommender *persistence.Recommender) *UserHandler {
+	return &UserHandler{userRepo: userRepo, tokens: tokens, tweets: tweets, recommender: recommender}
 }
 
 func (h *UserHandler) RegisterUser(c *gin.Context) {
 	var body struct {
 		Username string `json:"username" binding:"required"`
 		Email    string `json:"email" binding:"required,email"`
+		Password string `json:"password" binding:"required,min=8,max=72"`
 	}
 
 	if err := c.ShouldBindJSON(&body); err != nil {
@@ -27,7 +36,7 @@ func (h *UserHandler) RegisterUser(c *gin.Context) {
 		return
 	}
 
-	user, err := h.userRepo.RegisterUser(body.Username, body.Email)
+	user, err := h.userRepo.RegisterUser(body.Username, body.Email, body.Password)
 	if err != nil {
 		if err == persistence.ErrUserAlreadyExists {
 			c.JSON(http.StatusConflict, gin.H{"error": "Usuario ya registrado"})
@@ -59,21 +68,97 @@ func (h *UserHandler) GetUserByID(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"user_id": user.ID, "username": user.Username})
+	c.JSON(http.StatusOK, gin.H{"user_id": user.ID, "username": user.Username, "is_private": user.IsPrivate})
 }
 
-// Obtener User-ID a partir del username en el header
-func (h *UserHandler) getUserIDFromUsernameHeader(c *gin.Context) (uint, error) {
-	username := c.GetHeader("Username")
-	if username == "" {
-		return 0, http.ErrNoCookie
+// Cantidad máxima de IDs o usernames aceptados por GET /users/batch
+const maxBatchUsers = 100
+
+// GetUsersBatch busca varios usuarios en una sola petición, por ID (?ids=1,2) o por username (?usernames=a,b).
+// Los que no existen simplemente no aparecen en el resultado.
+func (h *UserHandler) GetUsersBatch(c *gin.Context) {
+	idsParam, usernamesParam := c.Query("ids"), c.Query("usernames")
+	if idsParam != "" && usernamesParam != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Use ids o usernames, no ambos"})
+		return
+	}
+	if usernamesParam != "" {
+		h.getUsersBatchByUsername(c, usernamesParam)
+		return
+	}
+	if idsParam == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Parámetro ids requerido"})
+		return
 	}
 
-	user, err := h.userRepo.FindUserByUsername(username)
+	parts := strings.Split(idsParam, ",")
+	if len(parts) > maxBatchUsers {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Se aceptan como máximo %d IDs", maxBatchUsers)})
+		return
+	}
+
+	userIDs := make([]uint, 0, len(parts))
+	for _, part := range parts {
+		userID, err := strconv.ParseUint(strings.TrimSpace(part), 10, 64)
+		if err != nil || userID == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "ID de usuario inválido"})
+			return
+		}
+		userIDs = append(userIDs, uint(userID))
+	}
+
+	users, err := h.userRepo.FindUsersByIDs(userIDs)
+	respondUsersBatch(c, users, err)
+}
+
+// getUsersBatchByUsername resuelve usernames exactos, usado por tweet-service para las menciones
+func (h *UserHandler) getUsersBatchByUsername(c *gin.Context, usernamesParam string) {
+	usernames, ok := parseUsernameList(c, usernamesParam)
+	if !ok {
+		return
+	}
+
+	users, err := h.userRepo.FindUsersByUsernames(usernames)
+	respondUsersBatch(c, users, err)
+}
+
+// parseUsernameList interpreta una lista de usernames separados por comas, de hasta maxBatchUsers
+// elementos. Si no es válida responde 400 y devuelve false.
+func parseUsernameList(c *gin.Context, value string) ([]string, bool) {
+	parts := strings.Split(value, ",")
+	if len(parts) > maxBatchUsers {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Se aceptan como máximo %d usernames", maxBatchUsers)})
+		return nil, false
+	}
+
+	usernames := make([]string, 0, len(parts))
+	for _, part := range parts {
+		username := strings.TrimSpace(part)
+		if username == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Username inválido"})
+			return nil, false
+		}
+		usernames = append(usernames, username)
+	}
+	return usernames, true
+}
+
+func respondUsersBatch(c *gin.Context, users []*domain.User, err error) {
 	if err != nil {
-		return 0, err
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudieron obtener los usuarios"})
+		return
+	}
+
+	usersResponse := make([]gin.H, 0, len(users))
+	for _, user := range users {
+		usersResponse = append(usersResponse, gin.H{
+			"user_id":    user.ID,
+			"username":   user.Username,
+			"is_private": user.IsPrivate,
+		})
 	}
-	return user.ID, nil
+
+	c.JSON(http.StatusOK, gin.H{"users": usersResponse})
 }
 
 func (h *UserHandler) GetUserByUsername(c *gin.Context) {
@@ -83,7 +168,7 @@ func (h *UserHandler) GetUserByUsername(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Usuario no encontrado"})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"user_id": user.ID, "username": user.Username})
+	c.JSON(http.StatusOK, gin.H{"user_id": user.ID, "username": user.Username, "is_private": user.IsPrivate})
 }
 
 func (h *UserHandler) GetAllUsers(c *gin.Context) {
@@ -106,11 +191,8 @@ func (h *UserHandler) GetAllUsers(c *gin.Context) {
 }
 
 func (h *UserHandler) FollowUser(c *gin.Context) {
-	userID, err := h.getUserIDFromUsernameHeader(c)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Username no válido o no encontrado"})
-		return
-	}
+	// Usuario autenticado por auth.Middleware
+	userID := auth.UserID(c)
 
 	var body struct {
 		FollowUsername string `json:"follow_username"`
@@ -129,19 +211,28 @@ func (h *UserHandler) FollowUser(c *gin.Context) {
 	}
 
 	// Ahora pasamos los IDs
-	if err := h.userRepo.FollowUser(userID, followUser.ID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo seguir al usuario"})
+	pending, err := h.userRepo.FollowUser(userID, followUser.ID)
+	if err != nil {
+		if errors.Is(err, persistence.ErrBlocked) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "No puedes seguir a este usuario"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo seguir al usuario"})
+		}
 		return
 	}
+
+	// Las cuentas privadas deben aprobar la solicitud antes de que empiece el seguimiento
+	if pending {
+		c.JSON(http.StatusAccepted, gin.H{"message": "Solicitud de seguimiento enviada", "status": "pending"})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{"message": "Usuario seguido exitosamente"})
 }
 
 func (h *UserHandler) UnfollowUser(c *gin.Context) {
-	userID, err := h.getUserIDFromUsernameHeader(c)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Username no válido o no encontrado"})
-		return
-	}
+	// Usuario autenticado por auth.Middleware
+	userID := auth.UserID(c)
 
 	var body struct {
 		UnfollowUsername string `json:"unfollow_username"`
@@ -166,12 +257,78 @@ func (h *UserHandler) UnfollowUser(c *gin.Context) {
 }
 
 func (h *UserHandler) GetFollowers(c *gin.Context) {
-	userID, err := h.getUserIDFromUsernameHeader(c)
+	h.respondFollowers(c, auth.UserID(c))
+}
+
+func (h *UserHandler) GetFollowing(c *gin.Context) {
+	h.respondFollowing(c, auth.UserID(c))
+}
+
+// Seguidores de un usuario, también usado por otros servicios (ej. fan-out de timeline-service)
+func (h *UserHandler) GetFollowersByUsername(c *gin.Context) {
+	user := h.findVisibleFollows(c)
+	if user == nil {
+		return
+	}
+	h.respondFollowers(c, user.ID)
+}
+
+// Usuarios seguidos por un usuario, también usado por otros servicios (ej. timeline-service)
+func (h *UserHandler) GetFollowingByUsername(c *gin.Context) {
+	user := h.findVisibleFollows(c)
+	if user == nil {
+		return
+	}
+	h.respondFollowing(c, user.ID)
+}
+
+// GetFollowingBatch devuelve los usernames seguidos por cada uno de los usuarios de ?usernames=a,b en
+// una sola petición, usado por timeline-service para buscar autores de segundo grado. Solo para
+// servicios internos: incluye los seguidos de cuentas privadas.
+func (h *UserHandler) GetFollowingBatch(c *gin.Context) {
+	usernames, ok := parseUsernameList(c, c.Query("usernames"))
+	if !ok {
+		return
+	}
+
+	following, err := h.userRepo.GetFollowingByUsernames(usernames)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Username no válido o no encontrado"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo obtener la lista de seguidos"})
 		return
 	}
+	c.JSON(http.StatusOK, gin.H{"following": following})
+}
+
+// findVisibleFollows carga el usuario de la ruta si quien consulta puede ver sus seguidores y seguidos,
+// con la misma regla que sus tweets: si la cuenta es privada solo su dueño, sus seguidores aprobados y
+// los servicios internos. Si no existe responde 404, si no puede verlos 403, y devuelve nil.
+func (h *UserHandler) findVisibleFollows(c *gin.Context) *domain.User {
+	user, err := h.userRepo.FindUserByUsername(c.Param("username"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Usuario no encontrado"})
+		return nil
+	}
+
+	viewerID := auth.UserID(c)
+	role := auth.Role(c)
+	if !user.IsPrivate || viewerID == user.ID || role == auth.RoleAdmin || role == auth.RoleService {
+		return user
+	}
+	if viewerID != 0 {
+		followed, err := h.userRepo.FindFollowedIDs(viewerID, []uint{user.ID})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudieron obtener los seguimientos"})
+			return nil
+		}
+		if len(followed) > 0 {
+			return user
+		}
+	}
+	c.JSON(http.StatusForbidden, gin.H{"error": "Esta cuenta es privada"})
+	return nil
+}
 
+func (h *UserHandler) respondFollowers(c *gin.Context, userID uint) {
 	followers, err := h.userRepo.GetFollowers(userID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo obtener la lista de seguidores"})
@@ -186,13 +343,7 @@ func (h *UserHandler) GetFollowers(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"followers": followersResponse})
 }
 
-func (h *UserHandler) GetFollowing(c *gin.Context) {
-	userID, err := h.getUserIDFromUsernameHeader(c)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Username no válido o no encontrado"})
-		return
-	}
-
+func (h *UserHandler) respondFollowing(c *gin.Context, userID uint) {
 	following, err := h.userRepo.GetFollowing(userID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "No se pudo obtener la lista de usuarios seguidos"})
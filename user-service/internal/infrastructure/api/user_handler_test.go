@@ -1,6 +1,7 @@
 package api
 
 import (
+	"encoding/json"
 	"fmt"
 	"math/rand"
 	"net/http"
@@ -9,6 +10,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/DevOpslp/microblogging-platform/auth"
+	"github.com/DevOpslp/microblogging-platform/events"
 	"github.com/DevOpslp/microblogging-platform/user-service/internal/domain"
 	"github.com/DevOpslp/microblogging-platform/user-service/internal/infrastructure/persistence"
 	"github.com/gin-gonic/gin"
@@ -19,6 +22,8 @@ import (
 
 var db *gorm.DB
 var userRepo *persistence.UserRepository
+var recommender *persistence.Recommender
+var tokens = auth.NewTokenManager("secreto-de-prueba")
 
 // setupTestDB configura la base de datos de prueba y migra el esquema necesario
 func setupTestDB() {
@@ -30,10 +35,11 @@ func setupTestDB() {
 	}
 
 	// Migrar el esquema y crear el repositorio
-	if err := db.AutoMigrate(&domain.User{}); err != nil {
+	if err := db.AutoMigrate(&domain.User{}, &domain.RefreshToken{}, &domain.FollowRequest{}, &domain.Block{}, &domain.Mute{}, &domain.FollowRecommendation{}, &events.OutboxEvent{}); err != nil {
 		panic("No se pudo migrar el esquema de User")
 	}
 	userRepo = persistence.NewUserRepository(db)
+	recommender = persistence.NewRecommender(userRepo, time.Hour, time.Hour)
 }
 
 // cleanDatabase elimina usuarios específicos y sus relaciones para mantener la base de datos limpia después de las pruebas
@@ -41,9 +47,14 @@ func cleanDatabase(userIDs ...uint) {
 	// Eliminar relaciones de seguidores/seguidores
 	for _, id := range userIDs {
 		db.Exec("DELETE FROM user_followers WHERE follower_id = ? OR user_id = ?", id, id)
+		db.Exec("DELETE FROM follow_requests WHERE requester_id = ? OR target_id = ?", id, id)
+		db.Exec("DELETE FROM blocks WHERE blocker_id = ? OR blocked_id = ?", id, id)
+		db.Exec("DELETE FROM mutes WHERE muter_id = ? OR muted_id = ?", id, id)
+		db.Exec("DELETE FROM follow_recommendations WHERE user_id = ? OR candidate_id = ? OR sample_mutual_id = ?", id, id, id)
 	}
-	// Eliminar usuarios
+	// Eliminar tokens de refresco y usuarios
 	for _, id := range userIDs {
+		db.Exec("DELETE FROM refresh_tokens WHERE user_id = ?", id)
 		db.Exec("DELETE FROM users WHERE id = ?", id)
 	}
 }
@@ -52,10 +63,19 @@ func cleanDatabase(userIDs ...uint) {
 func setupTestRouter() *gin.Engine {
 	gin.SetMode(gin.TestMode)
 	router := gin.Default()
-	SetupRoutes(router, *userRepo)
+	SetupRoutes(router, *userRepo, tokens, persistence.NewHTTPTweetCounter(""), recommender)
 	return router
 }
 
+// bearerFor emite un token de acceso para el usuario y arma el header Authorization
+func bearerFor(user *domain.User) string {
+	token, err := tokens.IssueAccessToken(user.ID, user.Username, auth.RoleUser)
+	if err != nil {
+		panic("No se pudo emitir el token de prueba")
+	}
+	return auth.BearerHeader(token)
+}
+
 // generateRandomUser crea un usuario con un nombre de usuario y email aleatorios para las pruebas
 func generateRandomUser() (*domain.User, error) {
 	username := fmt.Sprintf("test_user%d", rand.Intn(1000000))
@@ -95,7 +115,7 @@ func TestUserFollowUnfollowFlow(t *testing.T) {
 	t.Run("Usuario1 sigue a Usuario2", func(t *testing.T) {
 		followRequest := fmt.Sprintf(`{"follow_username": "%s"}`, user2.Username)
 		req, _ := http.NewRequest("POST", "/follow", strings.NewReader(followRequest))
-		req.Header.Set("Username", user1.Username) // Usar el Username en el header
+		req.Header.Set("Authorization", bearerFor(user1))
 		req.Header.Set("Content-Type", "application/json")
 		w := httptest.NewRecorder()
 		router.ServeHTTP(w, req)
@@ -107,7 +127,7 @@ func TestUserFollowUnfollowFlow(t *testing.T) {
 	// Paso 2: Verificar que Usuario 1 está siguiendo a Usuario 2
 	t.Run("Verificar que Usuario1 está siguiendo a Usuario2", func(t *testing.T) {
 		req, _ := http.NewRequest("GET", "/following", nil)
-		req.Header.Set("Username", user1.Username)
+		req.Header.Set("Authorization", bearerFor(user1))
 		w := httptest.NewRecorder()
 		router.ServeHTTP(w, req)
 
@@ -119,7 +139,7 @@ func TestUserFollowUnfollowFlow(t *testing.T) {
 	// Paso 3: Verificar que Usuario 2 tiene a Usuario 1 como seguidor
 	t.Run("Verificar que Usuario2 tiene a Usuario1 como seguidor", func(t *testing.T) {
 		req, _ := http.NewRequest("GET", "/followers", nil)
-		req.Header.Set("Username", user2.Username)
+		req.Header.Set("Authorization", bearerFor(user2))
 		w := httptest.NewRecorder()
 		router.ServeHTTP(w, req)
 
@@ -132,7 +152,7 @@ func TestUserFollowUnfollowFlow(t *testing.T) {
 	t.Run("Usuario1 deja de seguir a Usuario2", func(t *testing.T) {
 		unfollowRequest := fmt.Sprintf(`{"unfollow_username": "%s"}`, user2.Username)
 		req, _ := http.NewRequest("POST", "/unfollow", strings.NewReader(unfollowRequest))
-		req.Header.Set("Username", user1.Username) // Usar el Username en el header
+		req.Header.Set("Authorization", bearerFor(user1))
 		req.Header.Set("Content-Type", "application/json")
 		w := httptest.NewRecorder()
 		router.ServeHTTP(w, req)
@@ -144,7 +164,7 @@ func TestUserFollowUnfollowFlow(t *testing.T) {
 	// Paso 5: Confirmar que la lista de "following" de Usuario 1 está vacía
 	t.Run("Verificar que la lista de 'following' de Usuario1 está vacía", func(t *testing.T) {
 		req, _ := http.NewRequest("GET", "/following", nil)
-		req.Header.Set("Username", user1.Username)
+		req.Header.Set("Authorization", bearerFor(user1))
 		w := httptest.NewRecorder()
 		router.ServeHTTP(w, req)
 
@@ -156,7 +176,7 @@ func TestUserFollowUnfollowFlow(t *testing.T) {
 	// Paso 6: Confirmar que la lista de "followers" de Usuario 2 está vacía
 	t.Run("Verificar que la lista de 'followers' de Usuario2 está vacía", func(t *testing.T) {
 		req, _ := http.NewRequest("GET", "/followers", nil)
-		req.Header.Set("Username", user2.Username)
+		req.Header.Set("Authorization", bearerFor(user2))
 		w := httptest.NewRecorder()
 		router.ServeHTTP(w, req)
 
@@ -164,4 +184,738 @@ func TestUserFollowUnfollowFlow(t *testing.T) {
 		expectedEmptyFollowersResponse := `{"followers": []}`
 		assert.JSONEq(t, expectedEmptyFollowersResponse, w.Body.String())
 	})
+
+	// Paso 7: Cada cambio guardó un solo evento en la outbox, aunque seguir se repita
+	t.Run("Verificar los eventos de la outbox", func(t *testing.T) {
+		followRequest := fmt.Sprintf(`{"follow_username": "%s"}`, user2.Username)
+		for i := 0; i < 2; i++ {
+			req, _ := http.NewRequest("POST", "/follow", strings.NewReader(followRequest))
+			req.Header.Set("Authorization", bearerFor(user1))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(httptest.NewRecorder(), req)
+		}
+
+		countEvents := func(eventType string) int64 {
+			var count int64
+			db.Model(&events.OutboxEvent{}).
+				Where("type = ? AND (payload->>'follower_id')::bigint = ? AND (payload->>'followed_id')::bigint = ?", eventType, user1.ID, user2.ID).
+				Count(&count)
+			return count
+		}
+		assert.Equal(t, int64(2), countEvents(events.TypeUserFollowed))
+		assert.Equal(t, int64(1), countEvents(events.TypeUserUnfollowed))
+
+		// notification-service arma la notificación con el username que lleva el evento
+		var named int64
+		db.Model(&events.OutboxEvent{}).
+			Where("type = ? AND payload->>'follower_username' = ? AND payload->>'followed_username' = ?", events.TypeUserFollowed, user1.Username, user2.Username).
+			Count(&named)
+		assert.Equal(t, int64(2), named)
+	})
+}
+
+// TestGetUsersBatch prueba la búsqueda de varios usuarios por ID en una sola petición
+func TestGetUsersBatch(t *testing.T) {
+	setupTestDB()
+
+	user1, err := generateRandomUser()
+	assert.NoError(t, err, "No se pudo crear el usuario1 en userDB")
+	user2, err := generateRandomUser()
+	assert.NoError(t, err, "No se pudo crear el usuario2 en userDB")
+
+	defer cleanDatabase(user1.ID, user2.ID)
+
+	// user2 es una cuenta privada; el resultado debe indicarlo
+	assert.NoError(t, db.Model(user2).Update("is_private", true).Error)
+
+	router := setupTestRouter()
+
+	// Los IDs inexistentes se omiten del resultado
+	t.Run("Obtener usuarios existentes", func(t *testing.T) {
+		url := fmt.Sprintf("/users/batch?ids=%d,%d,999999999", user1.ID, user2.ID)
+		req, _ := http.NewRequest("GET", url, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		expected := fmt.Sprintf(`{"users": [{"user_id": %d, "username": "%s", "is_private": false}, {"user_id": %d, "username": "%s", "is_private": true}]}`,
+			user1.ID, user1.Username, user2.ID, user2.Username)
+		assert.JSONEq(t, expected, w.Body.String())
+	})
+
+	t.Run("Obtener usuarios por username", func(t *testing.T) {
+		url := fmt.Sprintf("/users/batch?usernames=%s,%s,usuario-inexistente", user2.Username, user1.Username)
+		req, _ := http.NewRequest("GET", url, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		expected := fmt.Sprintf(`{"users": [{"user_id": %d, "username": "%s", "is_private": false}, {"user_id": %d, "username": "%s", "is_private": true}]}`,
+			user1.ID, user1.Username, user2.ID, user2.Username)
+		assert.JSONEq(t, expected, w.Body.String())
+	})
+
+	t.Run("Rechazar IDs inválidos", func(t *testing.T) {
+		for _, query := range []string{"", "?ids=", "?ids=abc", "?ids=1,,2", "?ids=0", "?usernames=a,,b", "?ids=1&usernames=a"} {
+			req, _ := http.NewRequest("GET", "/users/batch"+query, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code, query)
+		}
+	})
+}
+
+// TestGetFollowingBatch prueba la consulta de los seguidos de varios usuarios en una sola petición
+func TestGetFollowingBatch(t *testing.T) {
+	setupTestDB()
+
+	users := make([]*domain.User, 0, 3)
+	for i := 0; i < 3; i++ {
+		user, err := generateRandomUser()
+		if !assert.NoError(t, err, "No se pudo crear el usuario en userDB") {
+			return
+		}
+		users = append(users, user)
+	}
+	user1, user2, user3 := users[0], users[1], users[2]
+	defer cleanDatabase(user1.ID, user2.ID, user3.ID)
+	for _, followed := range []*domain.User{user2, user3} {
+		_, err := userRepo.FollowUser(user1.ID, followed.ID)
+		assert.NoError(t, err)
+	}
+
+	router := setupTestRouter()
+	getFollowing := func(query, bearer string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/users/following"+query, nil)
+		if bearer != "" {
+			req.Header.Set("Authorization", bearer)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	serviceToken, _ := tokens.IssueServiceToken("timeline-service")
+
+	// Los usuarios sin seguidos aparecen con una lista vacía y los inexistentes se omiten
+	w := getFollowing(fmt.Sprintf("?usernames=%s,%s,usuario-inexistente", user1.Username, user2.Username), auth.BearerHeader(serviceToken))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Following map[string][]string `json:"following"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Len(t, response.Following, 2)
+	assert.ElementsMatch(t, []string{user2.Username, user3.Username}, response.Following[user1.Username])
+	assert.Empty(t, response.Following[user2.Username])
+
+	// Solo la usan los servicios internos
+	assert.Equal(t, http.StatusUnauthorized, getFollowing("?usernames="+user1.Username, "").Code)
+	assert.Equal(t, http.StatusUnauthorized, getFollowing("?usernames="+user1.Username, bearerFor(user1)).Code)
+	assert.Equal(t, http.StatusBadRequest, getFollowing("?usernames=a,,b", auth.BearerHeader(serviceToken)).Code)
+}
+
+// TestPrivateAccountFlow prueba las solicitudes de seguimiento de una cuenta privada
+func TestPrivateAccountFlow(t *testing.T) {
+	setupTestDB()
+
+	owner, err := generateRandomUser()
+	assert.NoError(t, err, "No se pudo crear el dueño de la cuenta en userDB")
+	requester1, err := generateRandomUser()
+	assert.NoError(t, err, "No se pudo crear el usuario1 en userDB")
+	requester2, err := generateRandomUser()
+	assert.NoError(t, err, "No se pudo crear el usuario2 en userDB")
+
+	defer cleanDatabase(owner.ID, requester1.ID, requester2.ID)
+
+	router := setupTestRouter()
+
+	send := func(method, path, body string, user *domain.User) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", bearerFor(user))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	follow := func(user *domain.User) *httptest.ResponseRecorder {
+		return send("POST", "/follow", fmt.Sprintf(`{"follow_username": "%s"}`, owner.Username), user)
+	}
+	followedIDs := func(follower *domain.User) string {
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/users/follows?follower_id=%d&ids=%d", follower.ID, owner.ID), nil)
+		serviceToken, _ := tokens.IssueServiceToken("tweet-service")
+		req.Header.Set("Authorization", auth.BearerHeader(serviceToken))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		return w.Body.String()
+	}
+
+	var requestIDs []uint
+
+	t.Run("Seguir una cuenta privada crea una solicitud", func(t *testing.T) {
+		w := send("PATCH", "/me/privacy", `{"is_private": true}`, owner)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"is_private": true, "approved_requests": 0}`, w.Body.String())
+
+		for _, requester := range []*domain.User{requester1, requester2} {
+			w = follow(requester)
+			assert.Equal(t, http.StatusAccepted, w.Code)
+			assert.JSONEq(t, `{"message": "Solicitud de seguimiento enviada", "status": "pending"}`, w.Body.String())
+		}
+		// Repetir la solicitud no crea otra
+		assert.Equal(t, http.StatusAccepted, follow(requester1).Code)
+
+		w = send("GET", "/follow-requests", "", owner)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response struct {
+			FollowRequests []struct {
+				ID       uint   `json:"id"`
+				UserID   uint   `json:"user_id"`
+				Username string `json:"username"`
+			} `json:"follow_requests"`
+		}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Len(t, response.FollowRequests, 2)
+		for _, request := range response.FollowRequests {
+			requestIDs = append(requestIDs, request.ID)
+		}
+		assert.Equal(t, requester2.Username, response.FollowRequests[0].Username)
+		assert.JSONEq(t, `{"followed_ids": []}`, followedIDs(requester1))
+	})
+
+	t.Run("Aprobar y rechazar solicitudes", func(t *testing.T) {
+		// Solo el destinatario puede responder la solicitud
+		w := send("POST", fmt.Sprintf("/follow-requests/%d/approve", requestIDs[1]), "", requester2)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		w = send("POST", fmt.Sprintf("/follow-requests/%d/approve", requestIDs[1]), "", owner)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, fmt.Sprintf(`{"message": "Solicitud de seguimiento aprobada", "username": "%s"}`, requester1.Username), w.Body.String())
+		assert.JSONEq(t, fmt.Sprintf(`{"followed_ids": [%d]}`, owner.ID), followedIDs(requester1))
+
+		w = send("POST", fmt.Sprintf("/follow-requests/%d/deny", requestIDs[0]), "", owner)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"followed_ids": []}`, followedIDs(requester2))
+
+		w = send("POST", fmt.Sprintf("/follow-requests/%d/deny", requestIDs[0]), "", owner)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Seguidores y seguidos de una cuenta privada", func(t *testing.T) {
+		// La consulta de seguimientos entre servicios exige un token de servicio
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/users/follows?follower_id=%d&ids=%d", requester1.ID, owner.ID), nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+		for _, list := range []string{"followers", "following"} {
+			path := fmt.Sprintf("/users/%s/%s", owner.Username, list)
+			assert.Equal(t, http.StatusOK, send("GET", path, "", owner).Code, path)
+			assert.Equal(t, http.StatusOK, send("GET", path, "", requester1).Code, path)
+			assert.Equal(t, http.StatusForbidden, send("GET", path, "", requester2).Code, path)
+
+			req, _ := http.NewRequest("GET", path, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusForbidden, w.Code, path)
+
+			req, _ = http.NewRequest("GET", path, nil)
+			serviceToken, _ := tokens.IssueServiceToken("timeline-service")
+			req.Header.Set("Authorization", auth.BearerHeader(serviceToken))
+			w = httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusOK, w.Code, path)
+		}
+
+		w = send("GET", fmt.Sprintf("/users/%s/followers", owner.Username), "", owner)
+		assert.JSONEq(t, fmt.Sprintf(`{"followers": [{"username": "%s"}]}`, requester1.Username), w.Body.String())
+		assert.Equal(t, http.StatusNotFound, send("GET", "/users/no_existe_nunca/followers", "", owner).Code)
+	})
+
+	t.Run("Volver pública la cuenta aprueba las pendientes", func(t *testing.T) {
+		assert.Equal(t, http.StatusAccepted, follow(requester2).Code)
+
+		w := send("PATCH", "/me/privacy", `{"is_private": false}`, owner)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"is_private": false, "approved_requests": 1}`, w.Body.String())
+		assert.JSONEq(t, fmt.Sprintf(`{"followed_ids": [%d]}`, owner.ID), followedIDs(requester2))
+
+		w = send("GET", "/follow-requests", "", owner)
+		assert.JSONEq(t, `{"follow_requests": []}`, w.Body.String())
+
+		// Los seguidores de una cuenta pública los ve cualquiera
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/users/%s/followers", owner.Username), nil)
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+}
+
+// TestBlockAndMuteFlow prueba que bloquear elimine los seguimientos e impida nuevos, y los usuarios ocultos
+func TestBlockAndMuteFlow(t *testing.T) {
+	setupTestDB()
+
+	user1, err := generateRandomUser()
+	assert.NoError(t, err, "No se pudo crear el usuario1 en userDB")
+	user2, err := generateRandomUser()
+	assert.NoError(t, err, "No se pudo crear el usuario2 en userDB")
+	user3, err := generateRandomUser()
+	assert.NoError(t, err, "No se pudo crear el usuario3 en userDB")
+
+	defer cleanDatabase(user1.ID, user2.ID, user3.ID)
+
+	router := setupTestRouter()
+
+	send := func(method, path, body string, user *domain.User) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", bearerFor(user))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	follow := func(user, target *domain.User) int {
+		return send("POST", "/follow", fmt.Sprintf(`{"follow_username": "%s"}`, target.Username), user).Code
+	}
+	serviceGet := func(path string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", path, nil)
+		serviceToken, _ := tokens.IssueServiceToken("tweet-service")
+		req.Header.Set("Authorization", auth.BearerHeader(serviceToken))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Bloquear elimina los seguimientos en ambas direcciones", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, follow(user1, user2))
+		assert.Equal(t, http.StatusOK, follow(user2, user1))
+
+		w := send("POST", "/blocks", fmt.Sprintf(`{"username": "%s"}`, user2.Username), user1)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, int64(0), db.Model(user1).Association("Following").Count())
+		assert.Equal(t, int64(0), db.Model(user2).Association("Following").Count())
+
+		// Ninguno de los dos puede volver a seguir al otro
+		assert.Equal(t, http.StatusForbidden, follow(user1, user2))
+		assert.Equal(t, http.StatusForbidden, follow(user2, user1))
+
+		w = send("GET", "/blocks", "", user1)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), user2.Username)
+
+		w = send("POST", "/blocks", fmt.Sprintf(`{"username": "%s"}`, user1.Username), user1)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		// Repetir el bloqueo no guarda otro evento; timeline-service lo usa para descartar los timelines
+		assert.Equal(t, http.StatusOK, send("POST", "/blocks", fmt.Sprintf(`{"username": "%s"}`, user2.Username), user1).Code)
+		var blockEvents int64
+		db.Model(&events.OutboxEvent{}).
+			Where("type = ? AND payload->>'blocker_username' = ? AND payload->>'blocked_username' = ?", events.TypeUserBlocked, user1.Username, user2.Username).
+			Count(&blockEvents)
+		assert.Equal(t, int64(1), blockEvents)
+	})
+
+	t.Run("Usuarios ocultos y bloqueadores", func(t *testing.T) {
+		w := send("POST", "/mutes", fmt.Sprintf(`{"username": "%s"}`, user3.Username), user1)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w = serviceGet(fmt.Sprintf("/users/%s/hidden", user1.Username))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, fmt.Sprintf(`{"hidden": [{"user_id": %d, "username": "%s"}, {"user_id": %d, "username": "%s"}]}`,
+			user2.ID, user2.Username, user3.ID, user3.Username), w.Body.String())
+
+		// El bloqueado tampoco ve a quien lo bloqueó; silenciar no es recíproco
+		w = serviceGet(fmt.Sprintf("/users/%s/hidden", user2.Username))
+		assert.JSONEq(t, fmt.Sprintf(`{"hidden": [{"user_id": %d, "username": "%s"}]}`, user1.ID, user1.Username), w.Body.String())
+		w = serviceGet(fmt.Sprintf("/users/%s/hidden", user3.Username))
+		assert.JSONEq(t, `{"hidden": []}`, w.Body.String())
+
+		w = serviceGet(fmt.Sprintf("/users/blockers?user_id=%d&ids=%d,%d", user2.ID, user1.ID, user3.ID))
+		assert.JSONEq(t, fmt.Sprintf(`{"blocker_ids": [%d]}`, user1.ID), w.Body.String())
+
+		// Los bloqueos solo los consultan otros servicios
+		w = send("GET", fmt.Sprintf("/users/%s/hidden", user1.Username), "", user1)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("Desbloquear y quitar el silencio", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, send("DELETE", "/blocks/"+user2.Username, "", user1).Code)
+		assert.Equal(t, http.StatusOK, send("DELETE", "/mutes/"+user3.Username, "", user1).Code)
+
+		w := send("GET", "/mutes", "", user1)
+		assert.JSONEq(t, `{"mutes": []}`, w.Body.String())
+		w = serviceGet(fmt.Sprintf("/users/%s/hidden", user1.Username))
+		assert.JSONEq(t, `{"hidden": []}`, w.Body.String())
+
+		// Los seguimientos eliminados no se restauran, pero se pueden volver a crear
+		assert.Equal(t, int64(0), db.Model(user1).Association("Following").Count())
+		assert.Equal(t, http.StatusOK, follow(user1, user2))
+	})
+}
+
+// TestMessageSettingsFlow prueba a quiénes no se les puede escribir por mensaje directo
+func TestMessageSettingsFlow(t *testing.T) {
+	setupTestDB()
+
+	sender, err := generateRandomUser()
+	assert.NoError(t, err, "No se pudo crear el remitente en userDB")
+	followed, err := generateRandomUser()
+	assert.NoError(t, err, "No se pudo crear el usuario seguido en userDB")
+	stranger, err := generateRandomUser()
+	assert.NoError(t, err, "No se pudo crear el usuario no seguido en userDB")
+	blocker, err := generateRandomUser()
+	assert.NoError(t, err, "No se pudo crear el usuario que bloquea en userDB")
+
+	defer cleanDatabase(sender.ID, followed.ID, stranger.ID, blocker.ID)
+
+	router := setupTestRouter()
+
+	send := func(method, path, body string, user *domain.User) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		if user != nil {
+			req.Header.Set("Authorization", bearerFor(user))
+		}
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	restrictions := func() string {
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/users/message-restrictions?sender_id=%d&ids=%d,%d,%d",
+			sender.ID, followed.ID, stranger.ID, blocker.ID), nil)
+		serviceToken, _ := tokens.IssueServiceToken("message-service")
+		req.Header.Set("Authorization", auth.BearerHeader(serviceToken))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		return w.Body.String()
+	}
+
+	t.Run("Sin restricciones", func(t *testing.T) {
+		assert.JSONEq(t, `{"blocked_ids": [], "followers_only_ids": []}`, restrictions())
+	})
+
+	t.Run("Solo seguidores y bloqueos", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, send("POST", "/follow", fmt.Sprintf(`{"follow_username": "%s"}`, followed.Username), sender).Code)
+		for _, user := range []*domain.User{followed, stranger} {
+			w := send("PATCH", "/me/messages", `{"dm_followers_only": true}`, user)
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.JSONEq(t, `{"dm_followers_only": true}`, w.Body.String())
+		}
+		assert.Equal(t, http.StatusOK, send("POST", "/blocks", fmt.Sprintf(`{"username": "%s"}`, sender.Username), blocker).Code)
+
+		// El remitente puede escribirle a quien sigue; el bloqueo aplica aunque lo haya hecho el destinatario
+		assert.JSONEq(t, fmt.Sprintf(`{"blocked_ids": [%d], "followers_only_ids": [%d]}`, blocker.ID, stranger.ID), restrictions())
+
+		w := send("GET", "/users/"+stranger.Username, "", nil)
+		assert.Contains(t, w.Body.String(), `"dm_followers_only":true`)
+	})
+
+	t.Run("Validación", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, send("PATCH", "/me/messages", `{}`, sender).Code)
+		// Las restricciones solo las consultan otros servicios
+		w := send("GET", fmt.Sprintf("/users/message-restrictions?sender_id=%d&ids=%d", sender.ID, blocker.ID), "", sender)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
+
+func TestProfileFlow(t *testing.T) {
+	setupTestDB()
+
+	user1, err := generateRandomUser()
+	assert.NoError(t, err, "No se pudo crear el usuario1 en userDB")
+	user2, err := generateRandomUser()
+	assert.NoError(t, err, "No se pudo crear el usuario2 en userDB")
+
+	defer cleanDatabase(user1.ID, user2.ID)
+
+	router := setupTestRouter()
+
+	send := func(method, path, body string, user *domain.User) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		if user != nil {
+			req.Header.Set("Authorization", bearerFor(user))
+		}
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Actualizar el perfil", func(t *testing.T) {
+		w := send("PATCH", "/me/profile", `{"display_name": " Usuario Uno ", "bio": "Hola", "website": "https://example.com"}`, user1)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var profile map[string]interface{}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &profile))
+		assert.Equal(t, "Usuario Uno", profile["display_name"])
+		assert.Equal(t, "Hola", profile["bio"])
+		assert.Equal(t, "https://example.com", profile["website"])
+
+		// Los campos que no se envían no cambian
+		w = send("PATCH", "/me/profile", `{"location": "Córdoba"}`, user1)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &profile))
+		assert.Equal(t, "Usuario Uno", profile["display_name"])
+		assert.Equal(t, "Córdoba", profile["location"])
+	})
+
+	t.Run("Validación por campo", func(t *testing.T) {
+		w := send("PATCH", "/me/profile", `{"website": "ftp://example.com", "bio": "`+strings.Repeat("a", 161)+`"}`, user1)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		var response struct {
+			Fields map[string]string `json:"fields"`
+		}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Contains(t, response.Fields, "website")
+		assert.Contains(t, response.Fields, "bio")
+
+		assert.Equal(t, http.StatusUnauthorized, send("PATCH", "/me/profile", `{"bio": "x"}`, nil).Code)
+	})
+
+	t.Run("Perfil público con contadores y sin email", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, send("POST", "/follow", fmt.Sprintf(`{"follow_username": "%s"}`, user1.Username), user2).Code)
+
+		w := send("GET", "/users/"+user1.Username, "", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NotContains(t, w.Body.String(), "email")
+		assert.NotContains(t, w.Body.String(), user1.Email)
+
+		var profile map[string]interface{}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &profile))
+		assert.Equal(t, user1.Username, profile["username"])
+		assert.Equal(t, "Usuario Uno", profile["display_name"])
+		assert.Equal(t, float64(1), profile["followers_count"])
+		assert.Equal(t, float64(0), profile["following_count"])
+		// Sin tweet-service configurado la cantidad de tweets no está disponible
+		assert.Nil(t, profile["tweet_count"])
+
+		assert.Equal(t, http.StatusNotFound, send("GET", "/users/no_existe_nunca", "", nil).Code)
+	})
+
+	t.Run("Perfil propio con email", func(t *testing.T) {
+		w := send("GET", "/me", "", user1)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var profile map[string]interface{}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &profile))
+		assert.Equal(t, user1.Username, profile["username"])
+		assert.Equal(t, user1.Email, profile["email"])
+		assert.Equal(t, float64(1), profile["followers_count"])
+
+		assert.Equal(t, http.StatusUnauthorized, send("GET", "/me", "", nil).Code)
+	})
+
+	t.Run("Los usuarios serializados no incluyen el email", func(t *testing.T) {
+		data, err := json.Marshal(user1)
+		assert.NoError(t, err)
+		assert.NotContains(t, string(data), user1.Email)
+	})
+}
+
+// TestRegisterLoginRefreshFlow prueba el registro con contraseña, el login y la renovación de tokens
+func TestRegisterLoginRefreshFlow(t *testing.T) {
+	setupTestDB()
+	router := setupTestRouter()
+
+	username := fmt.Sprintf("test_user%d", rand.Intn(1000000))
+	var registeredID uint
+	defer func() { cleanDatabase(registeredID) }()
+
+	postJSON := func(path, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	var tokensResponse struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+	}
+
+	t.Run("Registrar usuario con contraseña", func(t *testing.T) {
+		w := postJSON("/register", fmt.Sprintf(`{"username": "%s", "email": "%s@example.com", "password": "corta"}`, username, username))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = postJSON("/register", fmt.Sprintf(`{"username": "%s", "email": "%s@example.com", "password": "secreto123"}`, username, username))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NotContains(t, w.Body.String(), "secreto123")
+
+		user, err := userRepo.FindUserByUsername(username)
+		assert.NoError(t, err)
+		registeredID = user.ID
+		assert.NotEqual(t, "secreto123", user.PasswordHash)
+	})
+
+	t.Run("Login", func(t *testing.T) {
+		w := postJSON("/login", fmt.Sprintf(`{"username": "%s", "password": "incorrecta"}`, username))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+		w = postJSON("/login", fmt.Sprintf(`{"username": "%s", "password": "secreto123"}`, username))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &tokensResponse))
+
+		claims, err := tokens.Verify(tokensResponse.AccessToken)
+		assert.NoError(t, err)
+		assert.Equal(t, registeredID, claims.UserID)
+		assert.Equal(t, username, claims.Username)
+	})
+
+	t.Run("El token de acceso autentica las peticiones", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/following", nil)
+		req.Header.Set("Username", username)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+		req.Header.Set("Authorization", auth.BearerHeader(tokensResponse.AccessToken))
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("Renovar y revocar tokens", func(t *testing.T) {
+		oldRefreshToken := tokensResponse.RefreshToken
+
+		w := postJSON("/refresh", fmt.Sprintf(`{"refresh_token": "%s"}`, oldRefreshToken))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &tokensResponse))
+		assert.NotEqual(t, oldRefreshToken, tokensResponse.RefreshToken)
+
+		// Un token de refresco ya canjeado no se puede volver a usar
+		w = postJSON("/refresh", fmt.Sprintf(`{"refresh_token": "%s"}`, oldRefreshToken))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+		w = postJSON("/logout", fmt.Sprintf(`{"refresh_token": "%s"}`, tokensResponse.RefreshToken))
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w = postJSON("/refresh", fmt.Sprintf(`{"refresh_token": "%s"}`, tokensResponse.RefreshToken))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
+
+// TestFollowRecommendationsFlow prueba las recomendaciones por amigos de amigos y sus exclusiones
+func TestFollowRecommendationsFlow(t *testing.T) {
+	setupTestDB()
+
+	users := make([]*domain.User, 0, 6)
+	for i := 0; i < 6; i++ {
+		user, err := generateRandomUser()
+		if !assert.NoError(t, err, "No se pudo crear el usuario en userDB") {
+			return
+		}
+		users = append(users, user)
+	}
+	user1, user2, user3, user4, user5, user6 := users[0], users[1], users[2], users[3], users[4], users[5]
+	defer cleanDatabase(user1.ID, user2.ID, user3.ID, user4.ID, user5.ID, user6.ID)
+
+	router := setupTestRouter()
+
+	send := func(method, path, body string, user *domain.User) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", bearerFor(user))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	follow := func(user, target *domain.User) {
+		w := send("POST", "/follow", fmt.Sprintf(`{"follow_username": "%s"}`, target.Username), user)
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+	type recommendation struct {
+		UserID      uint   `json:"user_id"`
+		Username    string `json:"username"`
+		Mutuals     int    `json:"mutuals"`
+		Explanation string `json:"explanation"`
+	}
+	getRecommendations := func(query string) (int, []recommendation) {
+		w := send("GET", "/recommendations/follow"+query, "", user1)
+		var response struct {
+			Recommendations []recommendation `json:"recommendations"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return w.Code, response.Recommendations
+	}
+
+	// user1 sigue a user2 y user3; user4 lo siguen ambos, user5 y user6 solo user2, y user3 sigue a user1
+	follow(user1, user2)
+	follow(user1, user3)
+	follow(user2, user4)
+	follow(user3, user4)
+	follow(user2, user5)
+	follow(user2, user6)
+	follow(user3, user1)
+	assert.Equal(t, http.StatusOK, send("POST", "/blocks", fmt.Sprintf(`{"username": "%s"}`, user6.Username), user1).Code)
+
+	t.Run("Amigos de amigos ordenados por seguidos en común", func(t *testing.T) {
+		// Sin recomendaciones guardadas la consulta no las calcula: responde vacío y las encola
+		code, recommendations := getRecommendations("")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Empty(t, recommendations)
+
+		refreshed, err := recommender.RefreshQueued(time.Now())
+		assert.NoError(t, err)
+		assert.Equal(t, 1, refreshed)
+
+		code, recommendations = getRecommendations("")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, []recommendation{
+			{UserID: user4.ID, Username: user4.Username, Mutuals: 2, Explanation: fmt.Sprintf("Lo siguen %s y 1 más", user2.Username)},
+			{UserID: user5.ID, Username: user5.Username, Mutuals: 1, Explanation: "Lo sigue " + user2.Username},
+		}, recommendations, "No se recomienda al propio usuario ni a los bloqueados")
+
+		code, recommendations = getRecommendations("?limit=1")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Len(t, recommendations, 1)
+
+		code, _ = getRecommendations("?limit=0")
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+
+	t.Run("Las recomendaciones guardadas excluyen los nuevos seguimientos", func(t *testing.T) {
+		follow(user1, user5)
+
+		_, recommendations := getRecommendations("")
+		if assert.Len(t, recommendations, 1) {
+			assert.Equal(t, user4.ID, recommendations[0].UserID)
+		}
+	})
+
+	t.Run("Se precalculan para los usuarios activos", func(t *testing.T) {
+		_, err := userRepo.CreateRefreshToken(user1.ID)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		refreshed, err := persistence.NewRecommender(userRepo, time.Hour, time.Hour).RefreshActive(time.Now())
+		assert.NoError(t, err)
+		assert.GreaterOrEqual(t, refreshed, 1)
+
+		var stored []domain.FollowRecommendation
+		db.Where("user_id = ?", user1.ID).Find(&stored)
+		if assert.Len(t, stored, 1, "Al recalcular ya no se guarda el usuario seguido") {
+			assert.Equal(t, user4.ID, stored[0].CandidateID)
+		}
+	})
+
+	t.Run("Las cuentas privadas no se nombran en la explicación", func(t *testing.T) {
+		assert.NoError(t, db.Model(user2).Update("is_private", true).Error)
+		assert.NoError(t, userRepo.RefreshFollowRecommendations(user1.ID, time.Now()))
+
+		_, recommendations := getRecommendations("")
+		if assert.Len(t, recommendations, 1) {
+			assert.Equal(t, fmt.Sprintf("Lo siguen %s y 1 más", user3.Username), recommendations[0].Explanation)
+		}
+
+		// Si el seguido elegido se hace privado después del cálculo tampoco se nombra
+		assert.NoError(t, db.Model(user3).Update("is_private", true).Error)
+		_, recommendations = getRecommendations("")
+		if assert.Len(t, recommendations, 1) {
+			assert.Equal(t, "Lo siguen 2 usuarios que sigues", recommendations[0].Explanation)
+		}
+	})
 }
@@ -0,0 +1,127 @@
+package persistence
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/DevOpslp/microblogging-platform/user-service/internal/domain"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// Duración de los tokens de refresco emitidos en el login
+const RefreshTokenTTL = 30 * 24 * time.Hour
+
+var (
+	ErrInvalidCredentials  = errors.New("credenciales inválidas")
+	ErrInvalidRefreshToken = errors.New("token de refresco inválido")
+)
+
+func hashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// Método para validar username y contraseña
+func (repo *UserRepository) Authenticate(username, password string) (*domain.User, error) {
+	var user domain.User
+	if err := repo.db.Where("username = ?", username).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, err
+	}
+
+	// Los usuarios sin contraseña (creados antes de la autenticación) no pueden iniciar sesión
+	if user.PasswordHash == "" || bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) != nil {
+		return nil, ErrInvalidCredentials
+	}
+	return &user, nil
+}
+
+// Método para emitir un nuevo token de refresco para un usuario
+func (repo *UserRepository) CreateRefreshToken(userID uint) (string, error) {
+	return createRefreshToken(repo.db, userID)
+}
+
+// Método para canjear un token de refresco por uno nuevo; el token canjeado queda revocado
+func (repo *UserRepository) RotateRefreshToken(token string) (*domain.User, string, error) {
+	var user domain.User
+	var newToken string
+
+	err := repo.db.Transaction(func(tx *gorm.DB) error {
+		refreshToken, err := revokeRefreshToken(tx, token)
+		if err != nil {
+			return err
+		}
+		if err := tx.First(&user, refreshToken.UserID).Error; err != nil {
+			return ErrInvalidRefreshToken
+		}
+		newToken, err = createRefreshToken(tx, user.ID)
+		return err
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return &user, newToken, nil
+}
+
+// Método para revocar un token de refresco (logout)
+func (repo *UserRepository) RevokeRefreshToken(token string) error {
+	_, err := revokeRefreshToken(repo.db, token)
+	return err
+}
+
+func createRefreshToken(db *gorm.DB, userID uint) (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+
+	refreshToken := domain.RefreshToken{
+		UserID:    userID,
+		TokenHash: hashRefreshToken(token),
+		ExpiresAt: time.Now().Add(RefreshTokenTTL),
+	}
+	if err := db.Create(&refreshToken).Error; err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// revokeRefreshToken marca el token como revocado si sigue vigente; la condición del UPDATE evita
+// que dos peticiones concurrentes canjeen el mismo token
+func revokeRefreshToken(db *gorm.DB, token string) (*domain.RefreshToken, error) {
+	var refreshToken domain.RefreshToken
+	if err := db.Where("token_hash = ?", hashRefreshToken(token)).First(&refreshToken).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	result := db.Model(&domain.RefreshToken{}).
+		Where("id = ? AND revoked_at IS NULL AND expires_at > ?", refreshToken.ID, now).
+		Update("revoked_at", now)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrInvalidRefreshToken
+	}
+	return &refreshToken, nil
+}
+
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
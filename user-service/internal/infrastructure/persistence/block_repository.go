@@ -0,0 +1,120 @@
+package persistence
+
+import (
+	"errors"
+
+	"github.com/DevOpslp/microblogging-platform/events"
+	"github.com/DevOpslp/microblogging-platform/user-service/internal/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrBlocked se devuelve al seguir a un usuario cuando alguno de los dos bloqueó al otro
+var ErrBlocked = errors.New("existe un bloqueo entre los usuarios")
+
+// BlockUser bloquea a blockedID. En la misma transacción elimina los seguimientos en ambas direcciones
+// (con sus eventos UserUnfollowed) y las solicitudes de seguimiento pendientes entre ambos, y guarda el
+// evento UserBlocked. Bloquear a un usuario ya bloqueado no tiene efecto.
+func (repo *UserRepository) BlockUser(blockerID, blockedID uint) error {
+	return repo.db.Transaction(func(tx *gorm.DB) error {
+		var blocker, blocked domain.User
+		if err := tx.First(&blocker, blockerID).Error; err != nil {
+			return err
+		}
+		if err := tx.First(&blocked, blockedID).Error; err != nil {
+			return err
+		}
+
+		block := domain.Block{BlockerID: blockerID, BlockedID: blockedID}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&block)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		if err := removeFollow(tx, &blocker, &blocked); err != nil {
+			return err
+		}
+		if err := removeFollow(tx, &blocked, &blocker); err != nil {
+			return err
+		}
+		err := tx.Where("(requester_id = ? AND target_id = ?) OR (requester_id = ? AND target_id = ?)",
+			blockerID, blockedID, blockedID, blockerID).Delete(&domain.FollowRequest{}).Error
+		if err != nil {
+			return err
+		}
+		return events.Enqueue(tx, eventSource, events.UserBlocked{
+			BlockerID:       blocker.ID,
+			BlockerUsername: blocker.Username,
+			BlockedID:       blocked.ID,
+			BlockedUsername: blocked.Username,
+		})
+	})
+}
+
+// UnblockUser quita el bloqueo; no restaura los seguimientos eliminados
+func (repo *UserRepository) UnblockUser(blockerID, blockedID uint) error {
+	return repo.db.Where("blocker_id = ? AND blocked_id = ?", blockerID, blockedID).Delete(&domain.Block{}).Error
+}
+
+// Usuarios bloqueados por userID, del bloqueo más nuevo al más antiguo
+func (repo *UserRepository) GetBlocks(userID uint) ([]domain.Block, error) {
+	blocks := make([]domain.Block, 0)
+	err := repo.db.Preload("Blocked").Where("blocker_id = ?", userID).
+		Order("created_at DESC, blocked_id DESC").Find(&blocks).Error
+	return blocks, err
+}
+
+// MuteUser silencia a mutedID; silenciar a un usuario ya silenciado no tiene efecto
+func (repo *UserRepository) MuteUser(muterID, mutedID uint) error {
+	mute := domain.Mute{MuterID: muterID, MutedID: mutedID}
+	return repo.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&mute).Error
+}
+
+// UnmuteUser quita el silencio
+func (repo *UserRepository) UnmuteUser(muterID, mutedID uint) error {
+	return repo.db.Where("muter_id = ? AND muted_id = ?", muterID, mutedID).Delete(&domain.Mute{}).Error
+}
+
+// Usuarios silenciados por userID, del silencio más nuevo al más antiguo
+func (repo *UserRepository) GetMutes(userID uint) ([]domain.Mute, error) {
+	mutes := make([]domain.Mute, 0)
+	err := repo.db.Preload("Muted").Where("muter_id = ?", userID).
+		Order("created_at DESC, muted_id DESC").Find(&mutes).Error
+	return mutes, err
+}
+
+// isBlocked indica si alguno de los dos usuarios bloqueó al otro
+func isBlocked(tx *gorm.DB, userID, otherID uint) (bool, error) {
+	var count int64
+	err := tx.Model(&domain.Block{}).
+		Where("(blocker_id = ? AND blocked_id = ?) OR (blocker_id = ? AND blocked_id = ?)", userID, otherID, otherID, userID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// FindBlockerIDs devuelve cuáles de userIDs bloquearon a blockedID, usado por tweet-service para
+// rechazar respuestas y menciones de usuarios bloqueados
+func (repo *UserRepository) FindBlockerIDs(blockedID uint, userIDs []uint) ([]uint, error) {
+	ids := make([]uint, 0)
+	if len(userIDs) == 0 {
+		return ids, nil
+	}
+	err := repo.db.Model(&domain.Block{}).Where("blocked_id = ? AND blocker_id IN ?", blockedID, userIDs).
+		Order("blocker_id").Pluck("blocker_id", &ids).Error
+	return ids, err
+}
+
+// GetHiddenUsers devuelve los usuarios cuyos tweets no se le muestran a userID: los que bloqueó,
+// los que lo bloquearon y los que silenció
+func (repo *UserRepository) GetHiddenUsers(userID uint) ([]*domain.User, error) {
+	users := make([]*domain.User, 0)
+	err := repo.db.Select("id", "username").Where(`id IN (
+		SELECT blocked_id FROM blocks WHERE blocker_id = ?
+		UNION SELECT blocker_id FROM blocks WHERE blocked_id = ?
+		UNION SELECT muted_id FROM mutes WHERE muter_id = ?)`, userID, userID, userID).
+		Order("id").Find(&users).Error
+	return users, err
+}
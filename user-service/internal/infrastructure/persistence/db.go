@@ -6,6 +6,7 @@ import (
 	"os"
 	"time"
 
+	"github.com/DevOpslp/microblogging-platform/events"
 	"github.com/DevOpslp/microblogging-platform/user-service/internal/domain"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -26,9 +27,9 @@ func NewDB() *gorm.DB {
 		log.Fatalf("No se pudo conectar a la base de datos: %v", err)
 	}
 
-	// Migración automática del modelo User
-	if err := db.AutoMigrate(&domain.User{}); err != nil {
-		log.Fatalf("Error al migrar el modelo User: %v", err)
+	// Migración automática de los modelos de usuario y de la outbox de eventos
+	if err := db.AutoMigrate(&domain.User{}, &domain.RefreshToken{}, &domain.FollowRequest{}, &domain.Block{}, &domain.Mute{}, &domain.FollowRecommendation{}, &events.OutboxEvent{}); err != nil {
+		log.Fatalf("Error al migrar los modelos: %v", err)
 	}
 
 	// Verificar y crear datos iniciales
@@ -37,6 +38,9 @@ func NewDB() *gorm.DB {
 	return db
 }
 
+// Contraseña de los usuarios de ejemplo, solo para desarrollo local
+const seedPassword = "password123"
+
 func seedUsers(db *gorm.DB) {
 	var count int64
 	db.Model(&domain.User{}).Count(&count)
@@ -45,11 +49,17 @@ func seedUsers(db *gorm.DB) {
 	if count == 0 {
 		log.Println("Creando usuarios de ejemplo...")
 
+		// Todos los usuarios de ejemplo usan la contraseña `password123`
+		passwordHash, err := hashPassword(seedPassword)
+		if err != nil {
+			log.Fatalf("Error al generar la contraseña de los usuarios de ejemplo: %v", err)
+		}
+
 		users := []domain.User{
-			{Username: "user1", Email: "user1@example.com", CreatedAt: time.Now(), UpdatedAt: time.Now(), Following: []*domain.User{}, Followers: []*domain.User{}},
-			{Username: "user2", Email: "user2@example.com", CreatedAt: time.Now(), UpdatedAt: time.Now(), Following: []*domain.User{}, Followers: []*domain.User{}},
-			{Username: "user3", Email: "user3@example.com", CreatedAt: time.Now(), UpdatedAt: time.Now(), Following: []*domain.User{}, Followers: []*domain.User{}},
-			{Username: "user4", Email: "user4@example.com", CreatedAt: time.Now(), UpdatedAt: time.Now(), Following: []*domain.User{}, Followers: []*domain.User{}},
+			{Username: "user1", Email: "user1@example.com", PasswordHash: passwordHash, CreatedAt: time.Now(), UpdatedAt: time.Now(), Following: []*domain.User{}, Followers: []*domain.User{}},
+			{Username: "user2", Email: "user2@example.com", PasswordHash: passwordHash, CreatedAt: time.Now(), UpdatedAt: time.Now(), Following: []*domain.User{}, Followers: []*domain.User{}},
+			{Username: "user3", Email: "user3@example.com", PasswordHash: passwordHash, CreatedAt: time.Now(), UpdatedAt: time.Now(), Following: []*domain.User{}, Followers: []*domain.User{}},
+			{Username: "user4", Email: "user4@example.com", PasswordHash: passwordHash, CreatedAt: time.Now(), UpdatedAt: time.Now(), Following: []*domain.User{}, Followers: []*domain.User{}},
 		}
 
 		// Insertar los usuarios en la base de datos
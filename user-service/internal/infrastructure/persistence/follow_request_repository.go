@@ -0,0 +1,114 @@
+package persistence
+
+import (
+	"errors"
+
+	"github.com/DevOpslp/microblogging-platform/user-service/internal/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrFollowRequestNotFound se devuelve cuando la solicitud no existe o no está dirigida al usuario
+var ErrFollowRequestNotFound = errors.New("solicitud de seguimiento no encontrada")
+
+// Solicitudes pendientes para seguir a un usuario, de la más nueva a la más antigua
+func (repo *UserRepository) GetFollowRequests(targetID uint) ([]domain.FollowRequest, error) {
+	requests := make([]domain.FollowRequest, 0)
+	err := repo.db.Preload("Requester").Where("target_id = ?", targetID).
+		Order("created_at DESC, id DESC").Find(&requests).Error
+	return requests, err
+}
+
+// ApproveFollowRequest crea el seguimiento pedido y elimina la solicitud en una sola transacción,
+// junto con el evento UserFollowed. Solo el destinatario de la solicitud puede aprobarla.
+func (repo *UserRepository) ApproveFollowRequest(targetID, requestID uint) (*domain.FollowRequest, error) {
+	var request domain.FollowRequest
+	err := repo.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.Returning{}).Where("id = ? AND target_id = ?", requestID, targetID).Delete(&request)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrFollowRequestNotFound
+		}
+		return approve(tx, &request)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+// approve crea el seguimiento de una solicitud ya eliminada y carga a quien la envió
+func approve(tx *gorm.DB, request *domain.FollowRequest) error {
+	var target domain.User
+	if err := tx.First(&request.Requester, request.RequesterID).Error; err != nil {
+		return err
+	}
+	if err := tx.First(&target, request.TargetID).Error; err != nil {
+		return err
+	}
+	return addFollow(tx, &request.Requester, &target)
+}
+
+// DenyFollowRequest elimina la solicitud sin crear el seguimiento
+func (repo *UserRepository) DenyFollowRequest(targetID, requestID uint) error {
+	result := repo.db.Where("id = ? AND target_id = ?", requestID, targetID).Delete(&domain.FollowRequest{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrFollowRequestNotFound
+	}
+	return nil
+}
+
+// SetPrivate cambia la privacidad de la cuenta. Al volverla pública se aprueban todas las
+// solicitudes pendientes, y se devuelve cuántas se aprobaron.
+func (repo *UserRepository) SetPrivate(userID uint, private bool) (int, error) {
+	approved := 0
+	err := repo.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&domain.User{}).Where("id = ?", userID).Update("is_private", private)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		if private {
+			return nil
+		}
+
+		var requests []domain.FollowRequest
+		if err := tx.Clauses(clause.Returning{}).Where("target_id = ?", userID).Delete(&requests).Error; err != nil {
+			return err
+		}
+		for i := range requests {
+			if err := approve(tx, &requests[i]); err != nil {
+				return err
+			}
+		}
+		approved = len(requests)
+		return nil
+	})
+	return approved, err
+}
+
+// FindFollowedIDs devuelve cuáles de userIDs sigue followerID, usado por tweet-service para
+// decidir si puede mostrar los tweets de cuentas privadas
+func (repo *UserRepository) FindFollowedIDs(followerID uint, userIDs []uint) ([]uint, error) {
+	ids := make([]uint, 0)
+	if len(userIDs) == 0 {
+		return ids, nil
+	}
+
+	var followed []*domain.User
+	user := domain.User{ID: followerID}
+	if err := repo.db.Model(&user).Where("users.id IN ?", userIDs).Association("Following").Find(&followed); err != nil {
+		return nil, err
+	}
+	for _, followedUser := range followed {
+		ids = append(ids, followedUser.ID)
+	}
+	return ids, nil
+}
@@ -0,0 +1,52 @@
+package persistence
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ErrTweetCountUnavailable se devuelve cuando no hay tweet-service configurado
+var ErrTweetCountUnavailable = errors.New("cantidad de tweets no disponible")
+
+// TweetCounter consulta a tweet-service la cantidad de tweets publicados por un usuario
+type TweetCounter interface {
+	CountTweets(userID uint) (int64, error)
+}
+
+type HTTPTweetCounter struct {
+	baseURL string
+	client  *http.Client
+}
+
+// Con baseURL vacío CountTweets devuelve ErrTweetCountUnavailable
+func NewHTTPTweetCounter(baseURL string) *HTTPTweetCounter {
+	return &HTTPTweetCounter{baseURL: baseURL, client: &http.Client{Timeout: 2 * time.Second}}
+}
+
+// CountTweets usa el endpoint /tweets/count?user_id= de tweet-service
+func (c *HTTPTweetCounter) CountTweets(userID uint) (int64, error) {
+	if c.baseURL == "" {
+		return 0, ErrTweetCountUnavailable
+	}
+
+	resp, err := c.client.Get(fmt.Sprintf("%s/tweets/count?user_id=%d", c.baseURL, userID))
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("error: tweet-service devolvió estado %d", resp.StatusCode)
+	}
+
+	var result struct {
+		TweetCount int64 `json:"tweet_count"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return 0, err
+	}
+	return result.TweetCount, nil
+}
@@ -0,0 +1,50 @@
+package persistence
+
+import (
+	"github.com/DevOpslp/microblogging-platform/user-service/internal/domain"
+	"gorm.io/gorm"
+)
+
+// blockedWithSender filtra los usuarios que tienen un bloqueo con @sender en cualquier dirección
+const blockedWithSender = `EXISTS (SELECT 1 FROM blocks b WHERE (b.blocker_id = @sender AND b.blocked_id = users.id)
+	OR (b.blocker_id = users.id AND b.blocked_id = @sender))`
+
+// notFollowedBySender filtra los usuarios que @sender no sigue. En user_followers, user_id es quien
+// sigue y follower_id el usuario seguido.
+const notFollowedBySender = `NOT EXISTS (SELECT 1 FROM user_followers f WHERE f.user_id = @sender AND f.follower_id = users.id)`
+
+// SetDMFollowersOnly cambia quién puede iniciar conversaciones de mensajes directos con el usuario:
+// solo sus seguidores, o cualquiera
+func (repo *UserRepository) SetDMFollowersOnly(userID uint, followersOnly bool) error {
+	result := repo.db.Model(&domain.User{}).Where("id = ?", userID).Update("dm_followers_only", followersOnly)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// FindMessageRestrictions indica a cuáles de recipientIDs no puede escribirles senderID: blocked tiene
+// los que tienen un bloqueo con él en cualquier dirección, y followersOnly los que solo aceptan mensajes
+// de sus seguidores y no son seguidos por senderID. Lo usa message-service.
+func (repo *UserRepository) FindMessageRestrictions(senderID uint, recipientIDs []uint) ([]uint, []uint, error) {
+	blocked, followersOnly := make([]uint, 0), make([]uint, 0)
+	if len(recipientIDs) == 0 {
+		return blocked, followersOnly, nil
+	}
+
+	sender := map[string]interface{}{"sender": senderID}
+	err := repo.db.Model(&domain.User{}).Where("id IN ?", recipientIDs).Where(blockedWithSender, sender).
+		Order("id").Pluck("id", &blocked).Error
+	if err != nil {
+		return nil, nil, err
+	}
+	err = repo.db.Model(&domain.User{}).Where("id IN ? AND dm_followers_only", recipientIDs).Where(notFollowedBySender, sender).
+		Order("id").Pluck("id", &followersOnly).Error
+	if err != nil {
+		return nil, nil, err
+	}
+	return blocked, followersOnly, nil
+}
@@ -0,0 +1,33 @@
+package persistence
+
+import (
+	"github.com/DevOpslp/microblogging-platform/user-service/internal/domain"
+)
+
+// UpdateProfile guarda los campos presentes en update, que ya debe estar normalizado con
+// ProfileUpdate.Normalize, y devuelve el usuario actualizado
+func (repo *UserRepository) UpdateProfile(userID uint, update domain.ProfileUpdate) (*domain.User, error) {
+	user, err := repo.FindUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	update.Apply(user)
+	err = repo.db.Model(user).Select("display_name", "bio", "avatar_url", "location", "website").Updates(user).Error
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// CountFollows devuelve la cantidad de seguidores y de usuarios seguidos de un usuario.
+// En user_followers, user_id es quien sigue y follower_id el usuario seguido.
+func (repo *UserRepository) CountFollows(userID uint) (followers, following int64, err error) {
+	if err := repo.db.Table("user_followers").Where("follower_id = ?", userID).Count(&followers).Error; err != nil {
+		return 0, 0, err
+	}
+	if err := repo.db.Table("user_followers").Where("user_id = ?", userID).Count(&following).Error; err != nil {
+		return 0, 0, err
+	}
+	return followers, following, nil
+}
@@ -0,0 +1,88 @@
+package persistence
+
+import (
+	"time"
+
+	"github.com/DevOpslp/microblogging-platform/user-service/internal/domain"
+	"gorm.io/gorm"
+)
+
+// Recomendaciones guardadas por usuario
+const MaxFollowRecommendations = 50
+
+// excludedCandidate filtra los candidatos que no se recomiendan a @user: el propio usuario, los que ya
+// sigue o a los que envió una solicitud, los bloqueados en cualquier dirección y los silenciados.
+// En user_followers, user_id es quien sigue y follower_id el usuario seguido.
+const excludedCandidate = `candidate_id <> @user
+	AND NOT EXISTS (SELECT 1 FROM user_followers f WHERE f.user_id = @user AND f.follower_id = candidate_id)
+	AND NOT EXISTS (SELECT 1 FROM follow_requests r WHERE r.requester_id = @user AND r.target_id = candidate_id)
+	AND NOT EXISTS (SELECT 1 FROM blocks b WHERE (b.blocker_id = @user AND b.blocked_id = candidate_id)
+		OR (b.blocker_id = candidate_id AND b.blocked_id = @user))
+	AND NOT EXISTS (SELECT 1 FROM mutes m WHERE m.muter_id = @user AND m.muted_id = candidate_id)`
+
+// ComputeFollowRecommendations calcula los amigos de amigos de userID: los usuarios seguidos por los
+// que sigue, ordenados por cuántos de ellos los siguen (y luego por ID), sin los excluidos. Las
+// cuentas privadas cuentan como seguidos en común pero no se eligen para la explicación.
+func (repo *UserRepository) ComputeFollowRecommendations(userID uint, now time.Time) ([]domain.FollowRecommendation, error) {
+	candidates := repo.db.Table("user_followers AS followed").
+		Select("second.follower_id AS candidate_id, COUNT(*) AS mutuals, "+
+			"MIN(second.user_id) FILTER (WHERE NOT mutual.is_private) AS sample_mutual_id").
+		Joins("JOIN user_followers AS second ON second.user_id = followed.follower_id").
+		Joins("JOIN users AS mutual ON mutual.id = second.user_id").
+		Where("followed.user_id = ?", userID).
+		Group("second.follower_id")
+
+	recommendations := make([]domain.FollowRecommendation, 0)
+	err := repo.db.Table("(?) AS candidates", candidates).
+		Select("candidate_id, mutuals, sample_mutual_id").
+		Where(excludedCandidate, map[string]interface{}{"user": userID}).
+		Order("mutuals DESC, candidate_id").Limit(MaxFollowRecommendations).
+		Find(&recommendations).Error
+	if err != nil {
+		return nil, err
+	}
+	for i := range recommendations {
+		recommendations[i].UserID = userID
+		recommendations[i].ComputedAt = now
+	}
+	return recommendations, nil
+}
+
+// RefreshFollowRecommendations recalcula las recomendaciones de userID y reemplaza las guardadas
+func (repo *UserRepository) RefreshFollowRecommendations(userID uint, now time.Time) error {
+	recommendations, err := repo.ComputeFollowRecommendations(userID, now)
+	if err != nil {
+		return err
+	}
+	return repo.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&domain.FollowRecommendation{}).Error; err != nil {
+			return err
+		}
+		if len(recommendations) == 0 {
+			return nil
+		}
+		return tx.Omit("Candidate", "SampleMutual").Create(&recommendations).Error
+	})
+}
+
+// GetFollowRecommendations devuelve hasta limit recomendaciones guardadas de userID, con el candidato
+// y el seguido en común de la explicación. Se vuelven a aplicar las exclusiones para no recomendar a
+// quien el usuario siguió o bloqueó después del cálculo. Nunca se calculan en la consulta: si no hay
+// guardadas se devuelve una lista vacía.
+func (repo *UserRepository) GetFollowRecommendations(userID uint, limit int) ([]domain.FollowRecommendation, error) {
+	recommendations := make([]domain.FollowRecommendation, 0)
+	err := repo.db.Preload("Candidate").Preload("SampleMutual").
+		Where("user_id = ?", userID).
+		Where(excludedCandidate, map[string]interface{}{"user": userID}).
+		Order("mutuals DESC, candidate_id").Limit(limit).
+		Find(&recommendations).Error
+	return recommendations, err
+}
+
+// FindActiveUserIDs devuelve los usuarios que iniciaron sesión o renovaron su token desde since
+func (repo *UserRepository) FindActiveUserIDs(since time.Time) ([]uint, error) {
+	ids := make([]uint, 0)
+	err := repo.db.Model(&domain.RefreshToken{}).Where("created_at >= ?", since).
+		Distinct("user_id").Order("user_id").Pluck("user_id", &ids).Error
+	return ids, err
+}
@@ -0,0 +1,130 @@
+package persistence
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultRecommendationInterval     = 15 * time.Minute
+	DefaultRecommendationActiveWindow = 7 * 24 * time.Hour
+
+	// Usuarios que pueden esperar un cálculo pedido con Enqueue
+	recommendationQueueSize = 1000
+)
+
+// Recommender precalcula periódicamente las recomendaciones de seguimiento de los usuarios activos,
+// los que iniciaron sesión o renovaron su token dentro de activeWindow, y las de los usuarios que las
+// pidieron sin tenerlas guardadas
+type Recommender struct {
+	repo         *UserRepository
+	interval     time.Duration
+	activeWindow time.Duration
+	pending      chan uint
+
+	mu     sync.Mutex
+	queued map[uint]bool
+}
+
+func NewRecommender(repo *UserRepository, interval, activeWindow time.Duration) *Recommender {
+	return &Recommender{
+		repo:         repo,
+		interval:     interval,
+		activeWindow: activeWindow,
+		pending:      make(chan uint, recommendationQueueSize),
+		queued:       make(map[uint]bool),
+	}
+}
+
+// Enqueue pide calcular en segundo plano las recomendaciones de userID. Un usuario ya encolado no se
+// vuelve a encolar; con la cola llena el pedido se descarta y se calcula en la siguiente pasada si el
+// usuario está activo.
+func (r *Recommender) Enqueue(userID uint) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.queued[userID] {
+		return
+	}
+	select {
+	case r.pending <- userID:
+		r.queued[userID] = true
+	default:
+	}
+}
+
+// RefreshQueued calcula las recomendaciones de los usuarios encolados hasta vaciar la cola. Devuelve
+// cuántos se actualizaron y el último error.
+func (r *Recommender) RefreshQueued(now time.Time) (int, error) {
+	refreshed := 0
+	var lastErr error
+	for {
+		select {
+		case userID := <-r.pending:
+			if err := r.refreshQueued(userID, now); err != nil {
+				lastErr = err
+				continue
+			}
+			refreshed++
+		default:
+			return refreshed, lastErr
+		}
+	}
+}
+
+func (r *Recommender) refreshQueued(userID uint, now time.Time) error {
+	r.mu.Lock()
+	delete(r.queued, userID)
+	r.mu.Unlock()
+	return r.repo.RefreshFollowRecommendations(userID, now)
+}
+
+// RefreshActive recalcula las recomendaciones de los usuarios activos a la hora now. Un error en un
+// usuario no detiene a los demás; devuelve cuántos se actualizaron y el último error.
+func (r *Recommender) RefreshActive(now time.Time) (int, error) {
+	userIDs, err := r.repo.FindActiveUserIDs(now.Add(-r.activeWindow))
+	if err != nil {
+		return 0, err
+	}
+
+	refreshed := 0
+	var lastErr error
+	for _, userID := range userIDs {
+		if err := r.repo.RefreshFollowRecommendations(userID, now); err != nil {
+			lastErr = err
+			continue
+		}
+		refreshed++
+	}
+	return refreshed, lastErr
+}
+
+// Run recalcula las recomendaciones cada interval, y las encoladas en cuanto se piden, hasta que ctx se
+// cancela. Los errores solo se registran: las recomendaciones guardadas se siguen sirviendo hasta la
+// siguiente pasada.
+func (r *Recommender) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		if refreshed, err := r.RefreshActive(time.Now()); err != nil {
+			log.Printf("Error al calcular las recomendaciones de seguimiento: %v", err)
+		} else if refreshed > 0 {
+			log.Printf("Se calcularon las recomendaciones de %d usuarios activos", refreshed)
+		}
+
+		for waiting := true; waiting; {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				waiting = false
+			case userID := <-r.pending:
+				if err := r.refreshQueued(userID, time.Now()); err != nil {
+					log.Printf("Error al calcular las recomendaciones de seguimiento del usuario %d: %v", userID, err)
+				}
+			}
+		}
+	}
+}
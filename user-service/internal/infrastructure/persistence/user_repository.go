@@ -5,10 +5,15 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/DevOpslp/microblogging-platform/events"
 	"github.com/DevOpslp/microblogging-platform/user-service/internal/domain"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// Origen de los eventos que user-service guarda en la outbox
+const eventSource = "user-service"
+
 type UserRepository struct {
 	db *gorm.DB
 }
@@ -41,6 +46,66 @@ func (repo *UserRepository) FindUserByID(userID uint) (*domain.User, error) {
 	return &user, nil
 }
 
+// Método para encontrar varios usuarios por ID en una sola consulta, con solo ID, Username e IsPrivate.
+// Los IDs que no existen simplemente no aparecen en el resultado.
+func (repo *UserRepository) FindUsersByIDs(userIDs []uint) ([]*domain.User, error) {
+	users := make([]*domain.User, 0, len(userIDs))
+	if len(userIDs) == 0 {
+		return users, nil
+	}
+	if err := repo.db.Select("id", "username", "is_private").Where("id IN ?", userIDs).Order("id").Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// Método para encontrar varios usuarios por username exacto en una sola consulta, con solo ID, Username e IsPrivate.
+// Los usernames que no existen simplemente no aparecen en el resultado.
+func (repo *UserRepository) FindUsersByUsernames(usernames []string) ([]*domain.User, error) {
+	users := make([]*domain.User, 0, len(usernames))
+	if len(usernames) == 0 {
+		return users, nil
+	}
+	if err := repo.db.Select("id", "username", "is_private").Where("username IN ?", usernames).Order("id").Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// GetFollowingByUsernames devuelve los usernames seguidos por cada uno de los usernames indicados en una
+// sola consulta. Todos los usernames existentes aparecen en el resultado, aunque no sigan a nadie.
+// En user_followers, user_id es quien sigue y follower_id el usuario seguido.
+func (repo *UserRepository) GetFollowingByUsernames(usernames []string) (map[string][]string, error) {
+	following := make(map[string][]string)
+	if len(usernames) == 0 {
+		return following, nil
+	}
+
+	var rows []struct {
+		Source   string
+		Followed *string
+	}
+	err := repo.db.Table("users AS source").
+		Select("source.username AS source, followed.username AS followed").
+		Joins("LEFT JOIN user_followers AS f ON f.user_id = source.id").
+		Joins("LEFT JOIN users AS followed ON followed.id = f.follower_id").
+		Where("source.username IN ?", usernames).
+		Order("source.username, followed.username").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		if _, ok := following[row.Source]; !ok {
+			following[row.Source] = make([]string, 0)
+		}
+		if row.Followed != nil {
+			following[row.Source] = append(following[row.Source], *row.Followed)
+		}
+	}
+	return following, nil
+}
+
 // Método para obtener todos los usuarios con solo ID y Username
 func (repo *UserRepository) GetAllUsers() ([]*domain.User, error) {
 	var users []*domain.User
@@ -51,40 +116,103 @@ func (repo *UserRepository) GetAllUsers() ([]*domain.User, error) {
 }
 
 // Métodos que usan user IDs
-func (repo *UserRepository) FollowUser(userID, followID uint) error {
-	// Buscar el usuario y el usuario a seguir usando los IDs
-	var user, followUser domain.User
-	if err := repo.db.First(&user, userID).Error; err != nil {
-		return fmt.Errorf("no se encontró el usuario con ID %d: %w", userID, err)
+
+// FollowUser registra el seguimiento y guarda el evento UserFollowed en la misma transacción.
+// Si la cuenta a seguir es privada en cambio crea una solicitud pendiente y devuelve pending en true.
+// Si alguno de los dos bloqueó al otro devuelve ErrBlocked.
+// Seguir a un usuario que ya se sigue, o repetir una solicitud, no tiene efecto ni genera otro evento.
+func (repo *UserRepository) FollowUser(userID, followID uint) (pending bool, err error) {
+	err = repo.db.Transaction(func(tx *gorm.DB) error {
+		// Buscar el usuario y el usuario a seguir usando los IDs
+		var user, followUser domain.User
+		if err := tx.First(&user, userID).Error; err != nil {
+			return fmt.Errorf("no se encontró el usuario con ID %d: %w", userID, err)
+		}
+		if err := tx.First(&followUser, followID).Error; err != nil {
+			return fmt.Errorf("no se encontró el usuario a seguir con ID %d: %w", followID, err)
+		}
+
+		if tx.Model(&user).Where("users.id = ?", followID).Association("Following").Count() > 0 {
+			return nil
+		}
+
+		blocked, err := isBlocked(tx, userID, followID)
+		if err != nil {
+			return err
+		}
+		if blocked {
+			return ErrBlocked
+		}
+
+		if followUser.IsPrivate {
+			pending = true
+			request := domain.FollowRequest{RequesterID: userID, TargetID: followID}
+			return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&request).Error
+		}
+		return addFollow(tx, &user, &followUser)
+	})
+	return pending, err
+}
+
+// addFollow crea el seguimiento y guarda el evento UserFollowed en la transacción tx. Si otra
+// petición ya creó el mismo seguimiento no se inserta ninguna fila y no se guarda otro evento.
+func addFollow(tx *gorm.DB, user, followUser *domain.User) error {
+	result := tx.Table("user_followers").Clauses(clause.OnConflict{DoNothing: true}).
+		Create(map[string]interface{}{"user_id": user.ID, "follower_id": followUser.ID})
+	if result.Error != nil {
+		return fmt.Errorf("no se pudo seguir al usuario con ID %d: %w", followUser.ID, result.Error)
 	}
-	if err := repo.db.First(&followUser, followID).Error; err != nil {
-		return fmt.Errorf("no se encontró el usuario a seguir con ID %d: %w", followID, err)
+	if result.RowsAffected == 0 {
+		return nil
 	}
+	return events.Enqueue(tx, eventSource, events.UserFollowed{
+		FollowerID:       user.ID,
+		FollowerUsername: user.Username,
+		FollowedID:       followUser.ID,
+		FollowedUsername: followUser.Username,
+	})
+}
 
-	// Intentar agregar la relación de seguimiento
-	if err := repo.db.Model(&user).Association("Following").Append(&followUser); err != nil {
-		return fmt.Errorf("no se pudo seguir al usuario con ID %d: %w", followID, err)
-	}
+// UnfollowUser elimina el seguimiento y guarda el evento UserUnfollowed en la misma transacción.
+// Dejar de seguir a un usuario que no se sigue no tiene efecto ni genera eventos, salvo cancelar
+// la solicitud pendiente si la había.
+func (repo *UserRepository) UnfollowUser(userID, unfollowID uint) error {
+	return repo.db.Transaction(func(tx *gorm.DB) error {
+		// Buscar el usuario y el usuario a dejar de seguir usando los IDs
+		var user, unfollowUser domain.User
+		if err := tx.First(&user, userID).Error; err != nil {
+			return fmt.Errorf("no se encontró el usuario con ID %d: %w", userID, err)
+		}
+		if err := tx.First(&unfollowUser, unfollowID).Error; err != nil {
+			return fmt.Errorf("no se encontró el usuario a dejar de seguir con ID %d: %w", unfollowID, err)
+		}
 
-	return nil
+		// Dejar de seguir también cancela una solicitud pendiente
+		if err := tx.Where("requester_id = ? AND target_id = ?", userID, unfollowID).Delete(&domain.FollowRequest{}).Error; err != nil {
+			return err
+		}
+
+		return removeFollow(tx, &user, &unfollowUser)
+	})
 }
 
-func (repo *UserRepository) UnfollowUser(userID, unfollowID uint) error {
-	// Buscar el usuario y el usuario a dejar de seguir usando los IDs
-	var user, unfollowUser domain.User
-	if err := repo.db.First(&user, userID).Error; err != nil {
-		return fmt.Errorf("no se encontró el usuario con ID %d: %w", userID, err)
-	}
-	if err := repo.db.First(&unfollowUser, unfollowID).Error; err != nil {
-		return fmt.Errorf("no se encontró el usuario a dejar de seguir con ID %d: %w", unfollowID, err)
+// removeFollow elimina el seguimiento, si existe, y guarda el evento UserUnfollowed en la transacción tx
+func removeFollow(tx *gorm.DB, user, unfollowUser *domain.User) error {
+	if tx.Model(user).Where("users.id = ?", unfollowUser.ID).Association("Following").Count() == 0 {
+		return nil
 	}
 
 	// Intentar eliminar la relación de seguimiento
-	if err := repo.db.Model(&user).Association("Following").Delete(&unfollowUser); err != nil {
-		return fmt.Errorf("no se pudo dejar de seguir al usuario con ID %d: %w", unfollowID, err)
+	if err := tx.Model(user).Association("Following").Delete(unfollowUser); err != nil {
+		return fmt.Errorf("no se pudo dejar de seguir al usuario con ID %d: %w", unfollowUser.ID, err)
 	}
 
-	return nil
+	return events.Enqueue(tx, eventSource, events.UserUnfollowed{
+		FollowerID:       user.ID,
+		FollowerUsername: user.Username,
+		FollowedID:       unfollowUser.ID,
+		FollowedUsername: unfollowUser.Username,
+	})
 }
 
 func (repo *UserRepository) GetFollowers(userID uint) ([]*domain.User, error) {
@@ -133,24 +261,38 @@ func (repo *UserRepository) GetFollowing(userID uint) ([]*domain.User, error) {
 
 var ErrUserAlreadyExists = errors.New("usuario ya registrado")
 
-func (repo *UserRepository) RegisterUser(username, email string) (*domain.User, error) {
+func (repo *UserRepository) RegisterUser(username, email, password string) (*domain.User, error) {
 	// Verificar si el usuario ya existe
 	var existingUser domain.User
 	if err := repo.db.Where("username = ? OR email = ?", username, email).First(&existingUser).Error; err == nil {
 		return nil, ErrUserAlreadyExists
 	}
 
+	// Guardar solo el hash de la contraseña
+	passwordHash, err := hashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+
 	// Crear usuario con listas Following y Followers vacías
 	user := domain.User{
-		Username:  username,
-		Email:     email,
-		Following: []*domain.User{},
-		Followers: []*domain.User{},
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		Username:     username,
+		Email:        email,
+		PasswordHash: passwordHash,
+		Following:    []*domain.User{},
+		Followers:    []*domain.User{},
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
 	}
 
-	if err := repo.db.Create(&user).Error; err != nil {
+	// El evento UserRegistered se guarda en la misma transacción que el usuario
+	err = repo.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+		return events.Enqueue(tx, eventSource, events.UserRegistered{UserID: user.ID, Username: user.Username})
+	})
+	if err != nil {
 		return nil, err
 	}
 	return &user, nil